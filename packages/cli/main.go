@@ -38,6 +38,8 @@ const (
 	TypeSharedLibrary = "SHARED_LIBRARY"
 	TypeBook          = "BOOK"
 	TypeVideo         = "VIDEO"
+	TypeMusic         = "MUSIC"
+	TypeBoardGame     = "BOARDGAME"
 	TypeEvent         = "EVENT"
 	TypeCollection    = "COLLECTION"
 )
@@ -547,6 +549,7 @@ type dynamoRecord struct {
 	SharedFromId   string  `dynamodbav:"SharedFromId"`
 	TotalItems     int     `dynamodbav:"TotalItems"`
 	ItemCount      int     `dynamodbav:"ItemCount"`
+	DeletedAt      *string `dynamodbav:"DeletedAt"` // Set on trashed records
 	Name           string  `dynamodbav:"LibraryName"` // For LIBRARY entities
 	CollName       string  `dynamodbav:"CollectionName"` // For COLLECTION entities
 }
@@ -591,10 +594,14 @@ func checkConsistency(ctx context.Context, cognitoClient *cognitoidentityprovide
 			libId, collId := extractLibraryAndCollectionIdFromSK(r.SK)
 			ownerId := extractOwnerIdFromPK(r.PK)
 			collections[ownerId+"#"+libId+"#"+collId] = r
-		case TypeBook, TypeVideo:
+		case TypeBook, TypeVideo, TypeMusic, TypeBoardGame:
 			libId, itemId := extractLibraryAndItemIdFromSK(r.SK)
 			ownerId := extractOwnerIdFromPK(r.PK)
 			items[ownerId+"#"+libId+"#"+itemId] = r
+			// Trashed items are not counted in their library and collection
+			if r.DeletedAt != nil {
+				continue
+			}
 			itemsByLibrary[ownerId+"#"+libId] = append(itemsByLibrary[ownerId+"#"+libId], r)
 			if r.CollectionId != nil && *r.CollectionId != "" {
 				itemsByCollection[ownerId+"#"+libId+"#"+*r.CollectionId] = append(itemsByCollection[ownerId+"#"+libId+"#"+*r.CollectionId], r)
@@ -919,7 +926,7 @@ func printConsistencyResults(r consistencyResult) {
 	// Orphaned items
 	if len(r.OrphanedItems) > 0 {
 		fmt.Printf("ORPHANED ITEMS (%d):\n", len(r.OrphanedItems))
-		fmt.Println("  (BOOK/VIDEO records in non-existent LIBRARY)")
+		fmt.Println("  (BOOK/VIDEO/MUSIC/BOARDGAME records in non-existent LIBRARY)")
 		for _, item := range r.OrphanedItems {
 			fmt.Printf("  - Owner: %s, Library: %s, Item: %s (%s)\n", item.OwnerId, item.LibraryId, item.RecordId, item.RecordType)
 		}
@@ -1102,7 +1109,7 @@ func fixThumbnails(ctx context.Context, dynamoClient *dynamodb.Client, s3Client
 	return nil
 }
 
-// scanItemsWithPictureUrl scans DynamoDB for all BOOK and VIDEO items that have PictureUrl set
+// scanItemsWithPictureUrl scans DynamoDB for all BOOK, VIDEO, MUSIC and BOARDGAME items that have PictureUrl set
 func scanItemsWithPictureUrl(ctx context.Context, client *dynamodb.Client, tableName string) ([]itemRecord, error) {
 	var items []itemRecord
 	var lastKey map[string]dynamodbtypes.AttributeValue
@@ -1110,10 +1117,12 @@ func scanItemsWithPictureUrl(ctx context.Context, client *dynamodb.Client, table
 	for {
 		input := &dynamodb.ScanInput{
 			TableName:         aws.String(tableName),
-			FilterExpression:  aws.String("(EntityType = :book OR EntityType = :video) AND attribute_exists(PictureUrl)"),
+			FilterExpression:  aws.String("(EntityType = :book OR EntityType = :video OR EntityType = :music OR EntityType = :boardgame) AND attribute_exists(PictureUrl)"),
 			ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
-				":book":  &dynamodbtypes.AttributeValueMemberS{Value: TypeBook},
-				":video": &dynamodbtypes.AttributeValueMemberS{Value: TypeVideo},
+				":book":      &dynamodbtypes.AttributeValueMemberS{Value: TypeBook},
+				":video":     &dynamodbtypes.AttributeValueMemberS{Value: TypeVideo},
+				":music":     &dynamodbtypes.AttributeValueMemberS{Value: TypeMusic},
+				":boardgame": &dynamodbtypes.AttributeValueMemberS{Value: TypeBoardGame},
 			},
 			ExclusiveStartKey: lastKey,
 		}
@@ -42,24 +42,79 @@ func main() {
 	g.GET("/libraries", h.ListLibraries)
 	g.PUT("/libraries/:libraryId", h.UpdateLibrary)
 	g.DELETE("/libraries/:libraryId", h.DeleteLibrary)
+	g.POST("/libraries/:libraryId/restore", h.RestoreLibrary)
+	g.GET("/libraries/:libraryId/changes", h.GetLibraryChanges)
 	g.GET("/libraries/:libraryId/items", h.ListLibraryItems)
 	g.POST("/libraries/:libraryId/books", h.CreateBook)
 	g.PUT("/libraries/:libraryId/books/:bookId", h.UpdateBook)
 	g.POST("/libraries/:libraryId/videos", h.CreateVideo)
 	g.PUT("/libraries/:libraryId/videos/:videoId", h.UpdateVideo)
+	g.POST("/libraries/:libraryId/music", h.CreateMusic)
+	g.PUT("/libraries/:libraryId/music/:musicId", h.UpdateMusic)
+	g.POST("/libraries/:libraryId/boardgames", h.CreateBoardGame)
+	g.PUT("/libraries/:libraryId/boardgames/:boardGameId", h.UpdateBoardGame)
 	g.DELETE("/libraries/:libraryId/items/:itemId", h.DeleteItem)
+	g.POST("/libraries/:libraryId/items/:itemId/restore", h.RestoreItem)
 	g.POST("/libraries/:libraryId/share", h.ShareLibrary)
 	g.POST("/libraries/:libraryId/unshare", h.UnshareLibrary)
 	g.POST("/libraries/:libraryId/items/:itemId/events", h.CreateItemHistoryEvent)
 	g.GET("/libraries/:libraryId/items/:itemId/events", h.GetItemHistoryEvents)
 	g.DELETE("/libraries/:libraryId/items/:itemId/events", h.DeleteItemHistoryEvents)
+	g.GET("/libraries/:libraryId/items/:itemId/changes", h.GetItemChanges)
+	g.PUT("/libraries/:libraryId/items/:itemId/status", h.UpdateItemStatus)
+	g.GET("/libraries/:libraryId/items/:itemId/review", h.GetItemReview)
+	g.PUT("/libraries/:libraryId/items/:itemId/review", h.UpdateItemReview)
+	g.DELETE("/libraries/:libraryId/items/:itemId/review", h.DeleteItemReview)
+	g.POST("/libraries/:libraryId/items/:itemId/acquire", h.AcquireWishlistItem)
+	g.POST("/libraries/:libraryId/items/:itemId/move", h.MoveItem)
+	g.POST("/libraries/:libraryId/items/batch", h.BatchItems)
+	g.POST("/libraries/:libraryId/items/:itemId/borrow-requests", h.RequestBorrow)
 	// Collection routes
 	g.GET("/libraries/:libraryId/collections", h.ListCollections)
 	g.POST("/libraries/:libraryId/collections", h.CreateCollection)
 	g.GET("/libraries/:libraryId/collections/:collectionId", h.GetCollection)
 	g.PUT("/libraries/:libraryId/collections/:collectionId", h.UpdateCollection)
 	g.DELETE("/libraries/:libraryId/collections/:collectionId", h.DeleteCollection)
+	g.GET("/libraries/:libraryId/collections/:collectionId/volumes", h.GetSeriesVolumes)
+	g.GET("/libraries/:libraryId/collections/:collectionId/changes", h.GetCollectionChanges)
+	g.POST("/libraries/:libraryId/collections/:collectionId/lend", h.LendCollection)
+	g.POST("/libraries/:libraryId/collections/:collectionId/return", h.ReturnCollection)
+	// Location routes
+	g.GET("/libraries/:libraryId/locations", h.ListLocations)
+	g.POST("/libraries/:libraryId/locations", h.CreateLocation)
+	g.PUT("/libraries/:libraryId/locations/:locationId", h.RenameLocation)
+	g.DELETE("/libraries/:libraryId/locations/:locationId", h.DeleteLocation)
+	g.GET("/libraries/:libraryId/locations/:locationId/items", h.ListLocationItems)
+	g.PUT("/libraries/:libraryId/items/location", h.MoveItemsToLocation)
+	// Tag routes
+	g.GET("/libraries/:libraryId/tags", h.ListTags)
+	g.PUT("/libraries/:libraryId/tags/:tagId", h.RenameTag)
+	g.DELETE("/libraries/:libraryId/tags/:tagId", h.DeleteTag)
+	// Contact routes
+	g.GET("/contacts", h.ListContacts)
+	g.POST("/contacts", h.CreateContact)
+	g.GET("/contacts/:contactId", h.GetContact)
+	g.PUT("/contacts/:contactId", h.UpdateContact)
+	g.DELETE("/contacts/:contactId", h.DeleteContact)
+	g.GET("/contacts/:contactId/loans", h.ListContactLoans)
+	// Borrow request routes
+	g.GET("/borrow-requests/received", h.ListReceivedBorrowRequests)
+	g.GET("/borrow-requests/sent", h.ListSentBorrowRequests)
+	g.POST("/borrow-requests/:requestId/accept", h.AcceptBorrowRequest)
+	g.POST("/borrow-requests/:requestId/decline", h.DeclineBorrowRequest)
+	g.DELETE("/borrow-requests/:requestId", h.CancelBorrowRequest)
 	g.POST("/search", h.Search)
+	g.GET("/valuation", h.GetValuation)
+	g.GET("/trash", h.ListTrash)
+	g.GET("/loans/overdue", h.ListOverdueLoans)
+	g.GET("/loans/borrowed", h.ListBorrowedLoans)
+	g.GET("/calendar-feed", h.GetCalendarFeed)
+	g.POST("/calendar-feed", h.CreateCalendarFeed)
+	g.DELETE("/calendar-feed", h.DeleteCalendarFeed)
+
+	// Calendar feeds are read by calendar applications, without Cognito token: they are served
+	// by a dedicated HTTP API without authorizer and check the token of their address
+	router.GET("/feeds/:userId/loans.ics", h.GetLoansCalendar)
 
 	// LWA forwards requests to the port set by env (default 8080).
 	// Locally (no LWA) the same default lets `go run ./api/cmd` work out of the box.
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// A batch is processed within a single request, its size is bounded to stay within the API timeout
+const maxBatchOperations = 500
+
+// Batch request/response models
+
+type BatchItemOperationRequest struct {
+	Action       domain.ItemAction `json:"action"`                 // CREATE, UPDATE, DELETE or MOVE_TO_COLLECTION
+	Type         domain.ItemType   `json:"type"`                   // Item type of CREATE and UPDATE
+	ItemId       string            `json:"itemId,omitempty"`       // Item of UPDATE, DELETE and MOVE_TO_COLLECTION
+	Item         json.RawMessage   `json:"item,omitempty"`         // Create or update request of the item type
+	CollectionId *string           `json:"collectionId,omitempty"` // Target of MOVE_TO_COLLECTION, null removes the item from its collection
+}
+
+type BatchItemsRequest struct {
+	Operations []BatchItemOperationRequest `json:"operations"`
+}
+
+type BatchItemResultResponse struct {
+	Status       int        `json:"status"`
+	Id           string     `json:"id,omitempty"`
+	CollectionId *string    `json:"collectionId,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+	Message      string     `json:"message,omitempty"`
+}
+
+type BatchItemsResponse struct {
+	Results []BatchItemResultResponse `json:"results"` // In the order of the operations
+}
+
+// BatchItems applies create, update, delete and move to collection operations to the items of a library.
+// Each operation has its own result, a failed operation does not prevent the others.
+func (h *HTTPHandler) BatchItems(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request BatchItemsRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if len(request.Operations) == 0 || len(request.Operations) > maxBatchOperations {
+		log.Error().Msgf("Invalid request: operations must have 1-%d entries", maxBatchOperations)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - operations must have 1 to 500 entries",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	results := make([]BatchItemResultResponse, len(request.Operations))
+	ops := []domain.ItemOperation{}
+	indexes := []int{} // Index in the request of each operation sent
+
+	for idx, o := range request.Operations {
+		op, err := h.mapBatchItemOperation(&o, libraryId, t)
+		if err != nil {
+			log.Error().Int("index", idx).Msg(err.Error())
+			results[idx] = BatchItemResultResponse{
+				Status:  http.StatusBadRequest,
+				Id:      o.ItemId,
+				Message: err.Error(),
+			}
+			continue
+		}
+		ops = append(ops, *op)
+		indexes = append(indexes, idx)
+	}
+
+	if len(ops) > 0 {
+		applied, err := h.s.ApplyItemOperations(t.userId, libraryId, ops, t.actor())
+		if err != nil {
+			if handleShareRoleError(c, err) {
+				return
+			}
+			if strings.Contains(err.Error(), "unknown library") {
+				c.JSON(http.StatusNotFound, gin.H{
+					"message": err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to apply operations",
+			})
+			return
+		}
+
+		for n, r := range applied {
+			results[indexes[n]] = buildBatchItemResult(&ops[n], &r)
+		}
+	}
+
+	c.JSON(http.StatusOK, BatchItemsResponse{Results: results})
+}
+
+// mapBatchItemOperation validates an operation, and maps its item payload as the handler of the item type does
+func (h *HTTPHandler) mapBatchItemOperation(o *BatchItemOperationRequest, libraryId string, t *tokenInfo) (*domain.ItemOperation, error) {
+	op := domain.ItemOperation{Action: o.Action}
+
+	if o.Action != domain.CreateItemAction && len(o.ItemId) == 0 {
+		return nil, errors.New("invalid request - item id is mandatory")
+	}
+
+	switch o.Action {
+	case domain.CreateItemAction, domain.UpdateItemAction:
+		if len(o.Item) == 0 {
+			return nil, errors.New("invalid request - item is mandatory")
+		}
+		item, fetchPicture, err := h.mapBatchItem(o, libraryId, t)
+		if err != nil {
+			return nil, err
+		}
+		op.Item = item
+		op.FetchPicture = fetchPicture
+	case domain.DeleteItemAction:
+		op.Item = &domain.LibraryItem{OwnerId: t.userId, LibraryId: libraryId, Id: o.ItemId}
+	case domain.MoveToCollectionAction:
+		op.Item = &domain.LibraryItem{OwnerId: t.userId, LibraryId: libraryId, Id: o.ItemId}
+		op.CollectionId = trimOptional(o.CollectionId)
+	default:
+		return nil, errors.New("invalid request - unknown action (must be CREATE, UPDATE, DELETE or MOVE_TO_COLLECTION)")
+	}
+
+	return &op, nil
+}
+
+// mapBatchItem decodes the create or update request of the item type. Returns whether the picture is fetched again.
+func (h *HTTPHandler) mapBatchItem(o *BatchItemOperationRequest, libraryId string, t *tokenInfo) (*domain.LibraryItem, bool, error) {
+	invalid := errors.New("invalid request - invalid item")
+	create := o.Action == domain.CreateItemAction
+
+	switch o.Type {
+	case domain.ItemBook:
+		if create {
+			var request CreateBookRequest
+			if err := json.Unmarshal(o.Item, &request); err != nil {
+				return nil, false, invalid
+			}
+			item, err := h.mapCreateBookRequest(&request, libraryId, t)
+			return item, false, err
+		}
+		var request UpdateBookRequest
+		if err := json.Unmarshal(o.Item, &request); err != nil {
+			return nil, false, invalid
+		}
+		item, err := h.mapUpdateBookRequest(&request, libraryId, o.ItemId, t)
+		return item, request.UpdatePicture != nil && *request.UpdatePicture, err
+	case domain.ItemVideo:
+		if create {
+			var request CreateVideoRequest
+			if err := json.Unmarshal(o.Item, &request); err != nil {
+				return nil, false, invalid
+			}
+			item, err := h.mapCreateVideoRequest(&request, libraryId, t)
+			return item, false, err
+		}
+		var request UpdateVideoRequest
+		if err := json.Unmarshal(o.Item, &request); err != nil {
+			return nil, false, invalid
+		}
+		item, err := h.mapUpdateVideoRequest(&request, libraryId, o.ItemId, t)
+		return item, request.UpdatePicture != nil && *request.UpdatePicture, err
+	case domain.ItemMusic:
+		if create {
+			var request CreateMusicRequest
+			if err := json.Unmarshal(o.Item, &request); err != nil {
+				return nil, false, invalid
+			}
+			item, err := h.mapCreateMusicRequest(&request, libraryId, t)
+			return item, false, err
+		}
+		var request UpdateMusicRequest
+		if err := json.Unmarshal(o.Item, &request); err != nil {
+			return nil, false, invalid
+		}
+		item, err := h.mapUpdateMusicRequest(&request, libraryId, o.ItemId, t)
+		return item, request.UpdatePicture != nil && *request.UpdatePicture, err
+	case domain.ItemBoardGame:
+		if create {
+			var request CreateBoardGameRequest
+			if err := json.Unmarshal(o.Item, &request); err != nil {
+				return nil, false, invalid
+			}
+			item, err := h.mapCreateBoardGameRequest(&request, libraryId, t)
+			return item, false, err
+		}
+		var request UpdateBoardGameRequest
+		if err := json.Unmarshal(o.Item, &request); err != nil {
+			return nil, false, invalid
+		}
+		item, err := h.mapUpdateBoardGameRequest(&request, libraryId, o.ItemId, t)
+		return item, request.UpdatePicture != nil && *request.UpdatePicture, err
+	default:
+		return nil, false, errors.New("invalid request - unsupported item type")
+	}
+}
+
+// buildBatchItemResult maps the outcome of an operation to the status the single item endpoints would return
+func buildBatchItemResult(op *domain.ItemOperation, r *domain.ItemOperationResult) BatchItemResultResponse {
+	if r.Err != nil {
+		result := BatchItemResultResponse{Id: op.Item.Id}
+		if op.Action == domain.CreateItemAction {
+			result.Id = ""
+		}
+
+		msg := r.Err.Error()
+		switch {
+		case strings.Contains(msg, "unknown item"):
+			result.Status = http.StatusNotFound
+			result.Message = msg
+		case strings.Contains(msg, "not returned"):
+			result.Status = http.StatusConflict
+			result.Message = msg
+		case strings.Contains(msg, "not found") || strings.Contains(msg, "lent") || strings.Contains(msg, "already in batch") ||
+			strings.Contains(msg, "custom field"):
+			result.Status = http.StatusBadRequest
+			result.Message = msg
+		default:
+			result.Status = http.StatusInternalServerError
+			result.Message = "Failed to apply operation"
+		}
+		return result
+	}
+
+	if op.Action == domain.DeleteItemAction {
+		return BatchItemResultResponse{Status: http.StatusOK, Id: op.Item.Id}
+	}
+
+	status := http.StatusOK
+	if op.Action == domain.CreateItemAction {
+		status = http.StatusCreated
+	}
+
+	return BatchItemResultResponse{
+		Status:       status,
+		Id:           r.Item.Id,
+		CollectionId: r.Item.CollectionId,
+		UpdatedAt:    r.Item.UpdatedAt,
+	}
+}
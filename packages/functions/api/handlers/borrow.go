@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Borrow request models
+
+type CreateBorrowRequestRequest struct {
+	CopyId  string  `json:"copyId,omitempty"`  // Copy requested, for items with several copies
+	Message string  `json:"message,omitempty"` // Note to the owner
+	DueDate *string `json:"dueDate,omitempty"` // YYYY-MM-DD, proposed return date
+}
+
+type CreateBorrowRequestResponse struct {
+	Id string `json:"id"`
+}
+
+type AcceptBorrowRequestRequest struct {
+	CopyId  string  `json:"copyId,omitempty"`  // Copy lent, replaces the requested one
+	DueDate *string `json:"dueDate,omitempty"` // YYYY-MM-DD, replaces the proposed return date
+}
+
+type BorrowRequestResponse struct {
+	Id            string                     `json:"id"`
+	OwnerId       string                     `json:"ownerId"`
+	OwnerName     string                     `json:"ownerName"`
+	LibraryId     string                     `json:"libraryId"`
+	LibraryName   string                     `json:"libraryName"`
+	ItemId        string                     `json:"itemId"`
+	Type          domain.ItemType            `json:"type"`
+	Title         string                     `json:"title"`
+	CopyId        *string                    `json:"copyId,omitempty"`
+	RequesterId   string                     `json:"requesterId"`
+	RequesterName string                     `json:"requesterName"`
+	Message       *string                    `json:"message,omitempty"`
+	DueDate       *time.Time                 `json:"dueDate,omitempty"`
+	Status        domain.BorrowRequestStatus `json:"status"`
+	CreatedAt     *time.Time                 `json:"createdAt"`
+	UpdatedAt     *time.Time                 `json:"updatedAt"`
+}
+
+type GetBorrowRequestsResponse struct {
+	Requests []BorrowRequestResponse `json:"requests"`
+}
+
+// RequestBorrow asks the owner of a library shared to the user to lend an item
+func (h *HTTPHandler) RequestBorrow(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+
+	var request CreateBorrowRequestRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	message := strings.TrimSpace(request.Message)
+	if len(message) > 200 {
+		log.Error().Msgf("Message too long")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - message too long (max. 200 chars)",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+	actor := t.actor()
+	borrowRequest := domain.BorrowRequest{
+		LibraryId:     libraryId,
+		ItemId:        itemId,
+		RequesterId:   actor.UserId,
+		RequesterName: actor.UserName,
+	}
+	if request.CopyId != "" {
+		borrowRequest.CopyId = &request.CopyId
+	}
+	if message != "" {
+		borrowRequest.Message = &message
+	}
+	if request.DueDate != nil {
+		borrowRequest.DueDate, err = parseDueDate(domain.Lent, *request.DueDate)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	result, err := h.s.RequestBorrow(&borrowRequest)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") || strings.Contains(err.Error(), "not shared") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Item not found",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "copy") || strings.Contains(err.Error(), "wishlist") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to request borrow",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateBorrowRequestResponse{
+		Id: result.Id,
+	})
+}
+
+// ListReceivedBorrowRequests returns the borrow requests on the items of the user, most recent first.
+// The status query parameter restricts them to the requests with this status.
+func (h *HTTPHandler) ListReceivedBorrowRequests(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	requests, err := h.s.ListReceivedBorrowRequests(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list borrow requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildBorrowRequestsResponse(requests, c.Query("status")))
+}
+
+// ListSentBorrowRequests returns the borrow requests sent by the user, most recent first.
+// The status query parameter restricts them to the requests with this status.
+func (h *HTTPHandler) ListSentBorrowRequests(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	requests, err := h.s.ListSentBorrowRequests(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list borrow requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildBorrowRequestsResponse(requests, c.Query("status")))
+}
+
+// AcceptBorrowRequest lends the requested item to the requester
+func (h *HTTPHandler) AcceptBorrowRequest(c *gin.Context) {
+	requestId := c.Param("requestId")
+
+	// The body is optional
+	var request AcceptBorrowRequestRequest
+	if c.Request.ContentLength > 0 {
+		err := c.BindJSON(&request)
+		if err != nil {
+			log.Error().Msgf("Invalid request: %s", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request.",
+			})
+			return
+		}
+	}
+
+	var dueDate *time.Time
+	if request.DueDate != nil {
+		var err error
+		dueDate, err = parseDueDate(domain.Lent, *request.DueDate)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	t := h.getTokenInfo(c)
+	err := h.s.AcceptBorrowRequest(t.userId, requestId, request.CopyId, dueDate)
+	if err != nil {
+		handleBorrowRequestAnswerError(c, err, "Failed to accept borrow request")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeclineBorrowRequest refuses a pending borrow request
+func (h *HTTPHandler) DeclineBorrowRequest(c *gin.Context) {
+	requestId := c.Param("requestId")
+	t := h.getTokenInfo(c)
+
+	err := h.s.DeclineBorrowRequest(t.userId, requestId)
+	if err != nil {
+		handleBorrowRequestAnswerError(c, err, "Failed to decline borrow request")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// CancelBorrowRequest removes a borrow request sent by the user, withdrawing it while it is pending
+func (h *HTTPHandler) CancelBorrowRequest(c *gin.Context) {
+	requestId := c.Param("requestId")
+	t := h.getTokenInfo(c)
+
+	err := h.s.CancelBorrowRequest(t.userId, requestId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to cancel borrow request",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func handleBorrowRequestAnswerError(c *gin.Context, err error, fallback string) {
+	var status int
+	switch {
+	case strings.Contains(err.Error(), "copy"):
+		status = http.StatusBadRequest
+	case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unknown item"):
+		status = http.StatusNotFound
+	case strings.Contains(err.Error(), "already"):
+		// Request already answered, item already lent or contact name taken
+		status = http.StatusConflict
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": fallback,
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message": err.Error(),
+	})
+}
+
+func buildBorrowRequestsResponse(requests []*domain.BorrowRequest, status string) GetBorrowRequestsResponse {
+	response := GetBorrowRequestsResponse{Requests: []BorrowRequestResponse{}}
+	for _, r := range requests {
+		if status != "" && string(r.Status) != status {
+			continue
+		}
+
+		response.Requests = append(response.Requests, BorrowRequestResponse{
+			Id:            r.Id,
+			OwnerId:       r.OwnerId,
+			OwnerName:     r.OwnerName,
+			LibraryId:     r.LibraryId,
+			LibraryName:   r.LibraryName,
+			ItemId:        r.ItemId,
+			Type:          r.ItemType,
+			Title:         r.Title,
+			CopyId:        r.CopyId,
+			RequesterId:   r.RequesterId,
+			RequesterName: r.RequesterName,
+			Message:       r.Message,
+			DueDate:       r.DueDate,
+			Status:        r.Status,
+			CreatedAt:     r.CreatedAt,
+			UpdatedAt:     r.UpdatedAt,
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Public address of the feeds, served by CloudFront outside the authenticated API
+const calendarFeedBaseURL = "https://alexandria.isnan.eu/feeds"
+
+// Calendar feed models
+
+type CalendarFeedResponse struct {
+	Url       string     `json:"url,omitempty"` // Only returned on creation, the token is not stored
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// CreateCalendarFeed generates the address of the loans calendar of the user, revoking the previous one
+func (h *HTTPHandler) CreateCalendarFeed(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	token, err := h.s.CreateCalendarFeed(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create calendar feed",
+		})
+		return
+	}
+
+	current := time.Now().UTC()
+	c.JSON(http.StatusCreated, CalendarFeedResponse{
+		Url:       fmt.Sprintf("%s/%s/loans.ics?token=%s", calendarFeedBaseURL, url.PathEscape(t.userId), token),
+		CreatedAt: &current,
+	})
+}
+
+// GetCalendarFeed tells whether the user has a calendar feed, without its address
+func (h *HTTPHandler) GetCalendarFeed(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	feed, err := h.s.GetCalendarFeed(t.userId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to get calendar feed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CalendarFeedResponse{
+		CreatedAt: feed.CreatedAt,
+	})
+}
+
+// DeleteCalendarFeed revokes the calendar feed of the user
+func (h *HTTPHandler) DeleteCalendarFeed(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	err := h.s.DeleteCalendarFeed(t.userId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to delete calendar feed",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetLoansCalendar renders the current loans of a user as an iCalendar feed.
+// This route is public: calendar applications authenticate with the token of the feed address.
+func (h *HTTPHandler) GetLoansCalendar(c *gin.Context) {
+	userId := c.Param("userId")
+	token := c.Query("token")
+
+	loans, err := h.s.ListCalendarLoans(userId, token)
+	if err != nil {
+		// An unknown user and a wrong token are not told apart
+		if strings.Contains(err.Error(), "invalid calendar token") {
+			c.String(http.StatusNotFound, "Calendar not found")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Failed to get calendar")
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(renderLoansCalendar(loans, time.Now().UTC())))
+}
+
+// renderLoansCalendar builds an iCalendar (RFC 5545) document with one all-day event per loan:
+// on its due date, or on its lending date for loans without due date
+func renderLoansCalendar(loans []domain.CalendarLoan, now time.Time) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldCalendarLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Alexandria//Loans//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:Alexandria loans")
+
+	stamp := now.Format("20060102T150405Z")
+	for _, l := range loans {
+		var date time.Time
+		var summary string
+		switch {
+		case l.DueDate != nil:
+			date = *l.DueDate
+			summary = fmt.Sprintf("Due back: %s (%s)", l.Item.Title, l.LentTo)
+		case l.LentAt != nil:
+			date = *l.LentAt
+			summary = fmt.Sprintf("Lent: %s to %s", l.Item.Title, l.LentTo)
+		default:
+			// Neither due nor dated by the item history
+			continue
+		}
+
+		description := []string{
+			fmt.Sprintf("Library: %s", l.Item.LibraryName),
+			fmt.Sprintf("Lent to: %s", l.LentTo),
+		}
+		if l.LentAt != nil {
+			description = append(description, fmt.Sprintf("Lent on: %s", l.LentAt.Format("2006-01-02")))
+		}
+		if l.DueDate != nil {
+			description = append(description, fmt.Sprintf("Due on: %s", l.DueDate.Format("2006-01-02")))
+		}
+
+		// The uid identifies the loan, so that calendar applications update it when its due date changes
+		uid := l.Item.Id
+		if l.CopyId != nil {
+			uid = fmt.Sprintf("%s-%s", uid, *l.CopyId)
+		}
+		if l.LentAt != nil {
+			uid = fmt.Sprintf("%s-%s", uid, l.LentAt.UTC().Format("20060102T150405Z"))
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:%s@alexandria.isnan.eu", uid))
+		writeLine(fmt.Sprintf("DTSTAMP:%s", stamp))
+		writeLine(fmt.Sprintf("DTSTART;VALUE=DATE:%s", date.Format("20060102")))
+		writeLine(fmt.Sprintf("DTEND;VALUE=DATE:%s", date.AddDate(0, 0, 1).Format("20060102")))
+		writeLine(fmt.Sprintf("SUMMARY:%s", escapeCalendarText(summary)))
+		writeLine(fmt.Sprintf("DESCRIPTION:%s", escapeCalendarText(strings.Join(description, "\n"))))
+		writeLine("TRANSP:TRANSPARENT")
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+var calendarTextEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	";", "\\;",
+	",", "\\,",
+	"\r\n", "\\n",
+	"\n", "\\n",
+)
+
+func escapeCalendarText(text string) string {
+	return calendarTextEscaper.Replace(text)
+}
+
+// foldCalendarLine splits lines longer than 75 octets, continuation lines start with a space.
+// Multi-byte characters are never split.
+func foldCalendarLine(line string) string {
+	const maxOctets = 75
+
+	var b strings.Builder
+	size := 0
+	for len(line) > 0 {
+		_, width := utf8.DecodeRuneInString(line)
+		if size+width > maxOctets {
+			b.WriteString("\r\n ")
+			size = 1
+		}
+		b.WriteString(line[:width])
+		size += width
+		line = line[width:]
+	}
+	return b.String()
+}
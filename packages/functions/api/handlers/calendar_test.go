@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+func TestEscapeCalendarText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "plain text", text: "Dune", expected: "Dune"},
+		{name: "comma and semicolon", text: "Asterix, Obelix; Idefix", expected: "Asterix\\, Obelix\\; Idefix"},
+		{name: "backslash", text: "AC\\DC", expected: "AC\\\\DC"},
+		{name: "newlines", text: "Library: Books\nLent to: Alice\r\nDue on: 2024-03-15", expected: "Library: Books\\nLent to: Alice\\nDue on: 2024-03-15"},
+		{name: "escaped once", text: "\\,", expected: "\\\\\\,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if escaped := escapeCalendarText(tt.text); escaped != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, escaped)
+			}
+		})
+	}
+}
+
+func TestFoldCalendarLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{name: "empty line", line: "", expected: ""},
+		{name: "short line", line: "SUMMARY:Dune", expected: "SUMMARY:Dune"},
+		{name: "75 octets", line: strings.Repeat("a", 75), expected: strings.Repeat("a", 75)},
+		{name: "76 octets", line: strings.Repeat("a", 76), expected: strings.Repeat("a", 75) + "\r\n a"},
+		{
+			name:     "several continuation lines of 74 octets",
+			line:     strings.Repeat("a", 75+74+2),
+			expected: strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n " + "aa",
+		},
+		{
+			// "é" is 2 octets, the 38th would end at the 76th octet
+			name:     "multi-byte characters not split",
+			line:     strings.Repeat("é", 38),
+			expected: strings.Repeat("é", 37) + "\r\n é",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldCalendarLine(tt.line)
+			if folded != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, folded)
+			}
+			for _, l := range strings.Split(folded, "\r\n") {
+				if len(l) > 75 {
+					t.Errorf("line of %d octets: %q", len(l), l)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderLoansCalendar(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	lentAt := time.Date(2024, 2, 10, 14, 0, 0, 0, time.UTC)
+	dueDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	copyId := "copy"
+	item := &domain.LibraryItem{Id: "item", Title: "Dune", LibraryName: "Books"}
+
+	header := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Alexandria//Loans//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Alexandria loans",
+	}
+
+	tests := []struct {
+		name     string
+		loans    []domain.CalendarLoan
+		expected []string
+	}{
+		{
+			name:     "no loans",
+			loans:    []domain.CalendarLoan{},
+			expected: []string{},
+		},
+		{
+			name: "loan due back",
+			loans: []domain.CalendarLoan{
+				{Loan: domain.Loan{Item: item, LentTo: "Alice", DueDate: &dueDate}, LentAt: &lentAt},
+			},
+			expected: []string{
+				"BEGIN:VEVENT",
+				"UID:item-20240210T140000Z@alexandria.isnan.eu",
+				"DTSTAMP:20240301T083000Z",
+				"DTSTART;VALUE=DATE:20240315",
+				"DTEND;VALUE=DATE:20240316",
+				"SUMMARY:Due back: Dune (Alice)",
+				"DESCRIPTION:Library: Books\\nLent to: Alice\\nLent on: 2024-02-10\\nDue on: 20",
+				" 24-03-15",
+				"TRANSP:TRANSPARENT",
+				"END:VEVENT",
+			},
+		},
+		{
+			name: "copy lent without due date",
+			loans: []domain.CalendarLoan{
+				{Loan: domain.Loan{Item: item, CopyId: &copyId, LentTo: "Bob, Jr."}, LentAt: &lentAt},
+			},
+			expected: []string{
+				"BEGIN:VEVENT",
+				"UID:item-copy-20240210T140000Z@alexandria.isnan.eu",
+				"DTSTAMP:20240301T083000Z",
+				"DTSTART;VALUE=DATE:20240210",
+				"DTEND;VALUE=DATE:20240211",
+				"SUMMARY:Lent: Dune to Bob\\, Jr.",
+				"DESCRIPTION:Library: Books\\nLent to: Bob\\, Jr.\\nLent on: 2024-02-10",
+				"TRANSP:TRANSPARENT",
+				"END:VEVENT",
+			},
+		},
+		{
+			name: "loan predating the history, with a due date",
+			loans: []domain.CalendarLoan{
+				{Loan: domain.Loan{Item: item, LentTo: "Alice", DueDate: &dueDate}},
+			},
+			expected: []string{
+				"BEGIN:VEVENT",
+				"UID:item@alexandria.isnan.eu",
+				"DTSTAMP:20240301T083000Z",
+				"DTSTART;VALUE=DATE:20240315",
+				"DTEND;VALUE=DATE:20240316",
+				"SUMMARY:Due back: Dune (Alice)",
+				"DESCRIPTION:Library: Books\\nLent to: Alice\\nDue on: 2024-03-15",
+				"TRANSP:TRANSPARENT",
+				"END:VEVENT",
+			},
+		},
+		{
+			name: "loan neither due nor dated skipped",
+			loans: []domain.CalendarLoan{
+				{Loan: domain.Loan{Item: item, LentTo: "Alice"}},
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := renderLoansCalendar(tt.loans, now)
+
+			if !strings.HasSuffix(calendar, "END:VCALENDAR\r\n") {
+				t.Fatalf("calendar not ended by a CRLF terminated END:VCALENDAR: %q", calendar)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n")
+			expected := append(append(append([]string{}, header...), tt.expected...), "END:VCALENDAR")
+			if len(lines) != len(expected) {
+				t.Fatalf("expected %d lines, got %d: %q", len(expected), len(lines), lines)
+			}
+			for idx := range expected {
+				if lines[idx] != expected[idx] {
+					t.Errorf("line %d: expected %q, got %q", idx, expected[idx], lines[idx])
+				}
+			}
+		})
+	}
+}
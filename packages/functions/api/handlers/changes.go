@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Change log response models
+
+type FieldChangeResponse struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+type ChangeEntryResponse struct {
+	Date     *time.Time            `json:"date"`
+	UserId   string                `json:"userId"`
+	UserName string                `json:"userName"`
+	Changes  []FieldChangeResponse `json:"changes"`
+}
+
+type ChangeLogResponse struct {
+	Entries           []ChangeEntryResponse `json:"changes"`
+	ContinuationToken string                `json:"nextToken"`
+}
+
+// GetItemChanges returns the field-level changes of an item, most recent first
+func (h *HTTPHandler) GetItemChanges(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+	continuationToken, pageSize := changeLogPage(c)
+	t := h.getTokenInfo(c)
+
+	changeLog, err := h.s.GetItemChanges(t.userId, libraryId, itemId, continuationToken, pageSize)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to query item changes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildChangeLogResponse(changeLog))
+}
+
+// GetCollectionChanges returns the field-level changes of a collection, most recent first
+func (h *HTTPHandler) GetCollectionChanges(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	collectionId := c.Param("collectionId")
+	continuationToken, pageSize := changeLogPage(c)
+	t := h.getTokenInfo(c)
+
+	changeLog, err := h.s.GetCollectionChanges(t.userId, libraryId, collectionId, continuationToken, pageSize)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to query collection changes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildChangeLogResponse(changeLog))
+}
+
+// GetLibraryChanges returns the field-level changes of a library, most recent first
+func (h *HTTPHandler) GetLibraryChanges(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	continuationToken, pageSize := changeLogPage(c)
+	t := h.getTokenInfo(c)
+
+	changeLog, err := h.s.GetLibraryChanges(t.userId, libraryId, continuationToken, pageSize)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown library") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to query library changes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildChangeLogResponse(changeLog))
+}
+
+// changeLogPage reads the pagination parameters, with the same defaults and limit as the item history
+func changeLogPage(c *gin.Context) (string, int) {
+	continuationToken := c.Query("nextToken")
+	limit := c.DefaultQuery("limit", "10")
+	pageSize, err := strconv.Atoi(limit)
+	if err != nil {
+		pageSize = 10
+	}
+
+	if pageSize > 50 {
+		pageSize = 50
+	}
+	return continuationToken, pageSize
+}
+
+func buildChangeLogResponse(changeLog *domain.ChangeLog) ChangeLogResponse {
+	entries := []ChangeEntryResponse{}
+	for _, e := range changeLog.Entries {
+		changes := []FieldChangeResponse{}
+		for _, f := range e.Changes {
+			changes = append(changes, FieldChangeResponse{
+				Field: f.Field,
+				From:  f.From,
+				To:    f.To,
+			})
+		}
+		entries = append(entries, ChangeEntryResponse{
+			Date:     e.Date,
+			UserId:   e.UserId,
+			UserName: e.UserName,
+			Changes:  changes,
+		})
+	}
+
+	return ChangeLogResponse{
+		Entries:           entries,
+		ContinuationToken: changeLog.ContinuationToken,
+	}
+}
@@ -14,8 +14,10 @@ import (
 // Collection request/response models
 
 type CreateCollectionRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsSeries     bool   `json:"isSeries"`
+	TotalVolumes *int   `json:"totalVolumes,omitempty"`
 }
 
 type CreateCollectionResponse struct {
@@ -23,15 +25,26 @@ type CreateCollectionResponse struct {
 }
 
 type UpdateCollectionRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsSeries     *bool  `json:"isSeries,omitempty"` // Keeps the series flag, and total volumes unless set, when not set
+	TotalVolumes *int   `json:"totalVolumes,omitempty"`
 }
 
 type GetCollectionResponse struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	ItemCount   int    `json:"itemCount"`
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	ItemCount    int    `json:"itemCount"`
+	IsSeries     bool   `json:"isSeries"`
+	TotalVolumes *int   `json:"totalVolumes,omitempty"`
+}
+
+type GetSeriesVolumesResponse struct {
+	CollectionId   string `json:"collectionId"`
+	TotalVolumes   *int   `json:"totalVolumes,omitempty"`
+	OwnedVolumes   []int  `json:"ownedVolumes"`
+	MissingVolumes []int  `json:"missingVolumes"`
 }
 
 type GetCollectionsResponse struct {
@@ -51,6 +64,15 @@ func (h *HTTPHandler) validateCollectionPayload(c *domain.Collection) error {
 		return errors.New("invalid request - description too long (max. 500 chars)")
 	}
 
+	if c.TotalVolumes != nil {
+		if !c.IsSeries {
+			return errors.New("invalid request - total volumes can only be set on a series")
+		}
+		if *c.TotalVolumes < 1 || *c.TotalVolumes > 1000 {
+			return errors.New("invalid request - invalid total volumes (must be between 1 and 1000)")
+		}
+	}
+
 	return nil
 }
 
@@ -70,10 +92,12 @@ func (h *HTTPHandler) ListCollections(c *gin.Context) {
 	list := []GetCollectionResponse{}
 	for _, col := range collections {
 		list = append(list, GetCollectionResponse{
-			Id:          col.Id,
-			Name:        col.Name,
-			Description: col.Description,
-			ItemCount:   col.ItemCount,
+			Id:           col.Id,
+			Name:         col.Name,
+			Description:  col.Description,
+			ItemCount:    col.ItemCount,
+			IsSeries:     col.IsSeries,
+			TotalVolumes: col.TotalVolumes,
 		})
 	}
 
@@ -99,10 +123,12 @@ func (h *HTTPHandler) CreateCollection(c *gin.Context) {
 	t := h.getTokenInfo(c)
 
 	collection := domain.Collection{
-		Name:        strings.TrimSpace(request.Name),
-		Description: strings.TrimSpace(request.Description),
-		OwnerId:     t.userId,
-		LibraryId:   libraryId,
+		Name:         strings.TrimSpace(request.Name),
+		Description:  strings.TrimSpace(request.Description),
+		OwnerId:      t.userId,
+		LibraryId:    libraryId,
+		IsSeries:     request.IsSeries,
+		TotalVolumes: request.TotalVolumes,
 	}
 
 	err = h.validateCollectionPayload(&collection)
@@ -116,6 +142,9 @@ func (h *HTTPHandler) CreateCollection(c *gin.Context) {
 
 	result, err := h.s.CreateCollection(&collection)
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
 		// Check if it's a duplicate name error
 		if strings.Contains(err.Error(), "already exists") {
 			c.JSON(http.StatusConflict, gin.H{
@@ -156,10 +185,12 @@ func (h *HTTPHandler) GetCollection(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, GetCollectionResponse{
-		Id:          collection.Id,
-		Name:        collection.Name,
-		Description: collection.Description,
-		ItemCount:   collection.ItemCount,
+		Id:           collection.Id,
+		Name:         collection.Name,
+		Description:  collection.Description,
+		ItemCount:    collection.ItemCount,
+		IsSeries:     collection.IsSeries,
+		TotalVolumes: collection.TotalVolumes,
 	})
 }
 
@@ -181,14 +212,20 @@ func (h *HTTPHandler) UpdateCollection(c *gin.Context) {
 	t := h.getTokenInfo(c)
 
 	collection := domain.Collection{
-		Id:          collectionId,
-		Name:        strings.TrimSpace(request.Name),
-		Description: strings.TrimSpace(request.Description),
-		OwnerId:     t.userId,
-		LibraryId:   libraryId,
+		Id:           collectionId,
+		Name:         strings.TrimSpace(request.Name),
+		Description:  strings.TrimSpace(request.Description),
+		OwnerId:      t.userId,
+		LibraryId:    libraryId,
+		IsSeries:     request.IsSeries != nil && *request.IsSeries,
+		TotalVolumes: request.TotalVolumes,
 	}
 
-	err = h.validateCollectionPayload(&collection)
+	// Without isSeries, total volumes are checked against the stored series flag by the service
+	keepSeries := request.IsSeries == nil
+	validated := collection
+	validated.IsSeries = collection.IsSeries || keepSeries
+	err = h.validateCollectionPayload(&validated)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -197,8 +234,17 @@ func (h *HTTPHandler) UpdateCollection(c *gin.Context) {
 		return
 	}
 
-	err = h.s.UpdateCollection(&collection)
+	err = h.s.UpdateCollection(&collection, keepSeries, t.actor())
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "can only be set on a series") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		// Check if it's a duplicate name error
 		if strings.Contains(err.Error(), "already exists") {
 			c.JSON(http.StatusConflict, gin.H{
@@ -235,6 +281,9 @@ func (h *HTTPHandler) DeleteCollection(c *gin.Context) {
 
 	err := h.s.DeleteCollection(&collection)
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to delete collection",
 		})
@@ -243,3 +292,37 @@ func (h *HTTPHandler) DeleteCollection(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// GetSeriesVolumes returns the owned and missing volumes of a series collection
+func (h *HTTPHandler) GetSeriesVolumes(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	collectionId := c.Param("collectionId")
+	t := h.getTokenInfo(c)
+
+	volumes, err := h.s.GetSeriesVolumes(t.userId, libraryId, collectionId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not a series") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to get series volumes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetSeriesVolumesResponse{
+		CollectionId:   volumes.CollectionId,
+		TotalVolumes:   volumes.TotalVolumes,
+		OwnedVolumes:   volumes.OwnedVolumes,
+		MissingVolumes: volumes.MissingVolumes,
+	})
+}
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Contact request/response models
+
+type ContactRequest struct {
+	Name  string  `json:"name"`
+	Email *string `json:"email,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+}
+
+type CreateContactResponse struct {
+	Id string `json:"id"`
+}
+
+type LendingStatsResponse struct {
+	TotalLoans      int      `json:"totalLoans"`
+	CurrentLoans    int      `json:"currentLoans"`
+	OverdueLoans    int      `json:"overdueLoans"`
+	ReturnedOnTime  int      `json:"returnedOnTime"`
+	ReturnedLate    int      `json:"returnedLate"`
+	AverageDaysLate float64  `json:"averageDaysLate"`           // Over the late returns
+	PunctualityRate *float64 `json:"punctualityRate,omitempty"` // 0 to 1, share of the loans with a due date returned on time
+	DamagedReturns  int      `json:"damagedReturns"`            // Loans returned in DAMAGED condition
+}
+
+type GetContactResponse struct {
+	Id        string               `json:"id"`
+	Name      string               `json:"name"`
+	Email     *string              `json:"email,omitempty"`
+	Phone     *string              `json:"phone,omitempty"`
+	UserId    *string              `json:"userId,omitempty"` // App user the contact is, linked by borrow requests
+	UpdatedAt *time.Time           `json:"updatedAt"`
+	Stats     LendingStatsResponse `json:"stats"`
+}
+
+type GetContactsResponse struct {
+	Contacts []GetContactResponse `json:"contacts"`
+}
+
+type ContactLoanResponse struct {
+	LibraryId   string          `json:"libraryId"`
+	LibraryName string          `json:"libraryName"` // Library of the item when it was lent
+	ItemId      string          `json:"itemId"`
+	Type        domain.ItemType `json:"type"`
+	Title       string          `json:"title"`
+	CopyId      *string         `json:"copyId,omitempty"`
+	LentAt      *time.Time      `json:"lentAt"`
+	DueDate     *time.Time      `json:"dueDate,omitempty"`
+	ReturnedAt  *time.Time      `json:"returnedAt,omitempty"` // Not set while the item is lent
+	DaysLate    int             `json:"daysLate"`
+	// Condition assessed on return, if any
+	ReturnCondition *domain.ItemCondition `json:"returnCondition,omitempty"`
+	ReturnNote      *string               `json:"returnNote,omitempty"`
+}
+
+type GetContactLoansResponse struct {
+	Loans []ContactLoanResponse `json:"loans"`
+}
+
+func (h *HTTPHandler) validateContactPayload(c *domain.Contact) error {
+	if len(c.Name) == 0 {
+		return errors.New("invalid request - contact name is mandatory")
+	}
+
+	if len(c.Name) > 50 {
+		return errors.New("invalid request - name too long (max. 50 chars)")
+	}
+
+	if c.Email != nil {
+		if _, err := mail.ParseAddress(*c.Email); err != nil || len(*c.Email) > 100 {
+			return errors.New("invalid request - invalid email")
+		}
+	}
+
+	if c.Phone != nil && len(*c.Phone) > 30 {
+		return errors.New("invalid request - phone too long (max. 30 chars)")
+	}
+
+	return nil
+}
+
+// mapContactRequest builds a contact from a request, empty details are unset
+func mapContactRequest(request *ContactRequest, ownerId string) domain.Contact {
+	contact := domain.Contact{
+		Name:    strings.TrimSpace(request.Name),
+		OwnerId: ownerId,
+	}
+	if request.Email != nil && strings.TrimSpace(*request.Email) != "" {
+		email := strings.TrimSpace(*request.Email)
+		contact.Email = &email
+	}
+	if request.Phone != nil && strings.TrimSpace(*request.Phone) != "" {
+		phone := strings.TrimSpace(*request.Phone)
+		contact.Phone = &phone
+	}
+	return contact
+}
+
+// ListContacts returns the contacts of the user with their lending statistics
+func (h *HTTPHandler) ListContacts(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	contacts, err := h.s.ListContacts(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list contacts",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	response := GetContactsResponse{Contacts: []GetContactResponse{}}
+	for _, contact := range contacts {
+		response.Contacts = append(response.Contacts, buildContactResponse(contact, now))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetContact returns a contact with its lending statistics
+func (h *HTTPHandler) GetContact(c *gin.Context) {
+	contactId := c.Param("contactId")
+	t := h.getTokenInfo(c)
+
+	contact, err := h.s.GetContact(t.userId, contactId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to get contact",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildContactResponse(contact, time.Now().UTC()))
+}
+
+// ListContactLoans returns the loans of a contact, most recent first.
+// The current query parameter restricts them to the current (true) or returned (false) loans,
+// the condition query parameter to the loans returned in this condition (e.g. DAMAGED for the damage history).
+func (h *HTTPHandler) ListContactLoans(c *gin.Context) {
+	contactId := c.Param("contactId")
+	current := c.Query("current")
+	condition := c.Query("condition")
+	t := h.getTokenInfo(c)
+
+	contact, err := h.s.GetContact(t.userId, contactId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list contact loans",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	response := GetContactLoansResponse{Loans: []ContactLoanResponse{}}
+	for _, l := range contact.Loans {
+		if (current == "true" && l.ReturnedAt != nil) || (current == "false" && l.ReturnedAt == nil) {
+			continue
+		}
+		if condition != "" && (l.ReturnCondition == nil || string(*l.ReturnCondition) != condition) {
+			continue
+		}
+
+		response.Loans = append(response.Loans, ContactLoanResponse{
+			LibraryId:   l.LibraryId,
+			LibraryName: l.LibraryName,
+			ItemId:      l.ItemId,
+			Type:        l.ItemType,
+			Title:       l.Title,
+			CopyId:      l.CopyId,
+			LentAt:      l.LentAt,
+			DueDate:     l.DueDate,
+			ReturnedAt:  l.ReturnedAt,
+			DaysLate:    l.DaysLate(now),
+			// Condition assessed on return
+			ReturnCondition: l.ReturnCondition,
+			ReturnNote:      l.ReturnNote,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateContact creates a contact items can be lent to
+func (h *HTTPHandler) CreateContact(c *gin.Context) {
+	var request ContactRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+	contact := mapContactRequest(&request, t.userId)
+
+	err = h.validateContactPayload(&contact)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.s.CreateContact(&contact)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create contact",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateContactResponse{
+		Id: result.Id,
+	})
+}
+
+// UpdateContact renames a contact and updates its details, the items lent to it are updated asynchronously
+func (h *HTTPHandler) UpdateContact(c *gin.Context) {
+	contactId := c.Param("contactId")
+
+	var request ContactRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+	contact := mapContactRequest(&request, t.userId)
+	contact.Id = contactId
+
+	err = h.validateContactPayload(&contact)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = h.s.UpdateContact(&contact)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update contact",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteContact removes a contact and its lending history, once the items lent to it are returned
+func (h *HTTPHandler) DeleteContact(c *gin.Context) {
+	contactId := c.Param("contactId")
+	t := h.getTokenInfo(c)
+
+	err := h.s.DeleteContact(t.userId, contactId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not returned") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to delete contact",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func buildContactResponse(contact *domain.Contact, now time.Time) GetContactResponse {
+	stats := contact.Stats(now)
+	return GetContactResponse{
+		Id:        contact.Id,
+		Name:      contact.Name,
+		Email:     contact.Email,
+		Phone:     contact.Phone,
+		UserId:    contact.UserId,
+		UpdatedAt: contact.UpdatedAt,
+		Stats: LendingStatsResponse{
+			TotalLoans:      stats.TotalLoans,
+			CurrentLoans:    stats.CurrentLoans,
+			OverdueLoans:    stats.OverdueLoans,
+			ReturnedOnTime:  stats.ReturnedOnTime,
+			ReturnedLate:    stats.ReturnedLate,
+			AverageDaysLate: stats.AverageDaysLate,
+			PunctualityRate: stats.PunctualityRate,
+			DamagedReturns:  stats.DamagedReturns,
+		},
+	}
+}
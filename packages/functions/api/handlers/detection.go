@@ -26,7 +26,21 @@ payload:
 	{
 		type: 1,
 		image: <base64 encoded image>, // optional: for OCR detection
-		title: <string>                // optional: for manual title search
+		title: <string>,               // optional: for manual title search
+		videoKind: <MOVIE|TV_SERIES>   // optional: restrict search (both when omitted)
+	}
+
+	For music:
+	{
+		type: 3,
+		code: <EAN-13 or UPC-A barcode>
+	}
+
+	For board games:
+	{
+		type: 4,
+		code: <EAN-13 or UPC-A barcode>, // optional: for barcode lookup
+		title: <string>                  // optional: for title search
 	}
 */
 func (h *HTTPHandler) RequestDetection(c *gin.Context) {
@@ -41,7 +55,7 @@ func (h *HTTPHandler) RequestDetection(c *gin.Context) {
 		return
 	}
 
-	validTypes := []domain.ItemType{domain.ItemBook, domain.ItemVideo}
+	validTypes := []domain.ItemType{domain.ItemBook, domain.ItemVideo, domain.ItemMusic, domain.ItemBoardGame}
 
 	if !slices.Contains(validTypes, domain.ItemType(request.Type)) {
 		msg := fmt.Sprintf("Invalid request - Incorrect detection type : %d", request.Type)
@@ -63,6 +77,18 @@ func (h *HTTPHandler) RequestDetection(c *gin.Context) {
 		h.handleVideoDetection(c, request)
 		return
 	}
+
+	// Handle music detection (barcode-based)
+	if domain.ItemType(request.Type) == domain.ItemMusic {
+		h.handleMusicDetection(c, request)
+		return
+	}
+
+	// Handle board game detection (barcode or title)
+	if domain.ItemType(request.Type) == domain.ItemBoardGame {
+		h.handleBoardGameDetection(c, request)
+		return
+	}
 }
 
 // handleBookDetection handles book detection via ISBN
@@ -103,6 +129,10 @@ func (h *HTTPHandler) handleBookDetection(c *gin.Context, request DetectRequest)
 
 	resolvedBooks := h.s.ResolveBook(request.Code)
 
+	// All the resolved books share the scanned ISBN
+	t := h.getTokenInfo(c)
+	duplicates := h.findDuplicates(t.userId, []*domain.LibraryItem{{Type: domain.ItemBook, Isbn: request.Code}})[0]
+
 	detectedBooks := make([]DetectedBookResponse, 0)
 	for _, r := range resolvedBooks {
 		detectedBooks = append(detectedBooks, DetectedBookResponse{
@@ -112,8 +142,11 @@ func (h *HTTPHandler) handleBookDetection(c *gin.Context, request DetectRequest)
 			Summary:    r.Summary,
 			PictureUrl: r.PictureUrl,
 			Isbn:       request.Code,
+			SeriesName: r.SeriesName,
+			Volume:     r.Volume,
 			Source:     r.Source,
 			Error:      r.Error,
+			Duplicates: duplicates,
 		})
 	}
 
@@ -124,6 +157,15 @@ func (h *HTTPHandler) handleBookDetection(c *gin.Context, request DetectRequest)
 
 // handleVideoDetection handles video detection via OCR or manual title search
 func (h *HTTPHandler) handleVideoDetection(c *gin.Context, request DetectRequest) {
+	if request.VideoKind != nil && !isValidVideoKind(*request.VideoKind) {
+		msg := fmt.Sprintf("Invalid request - Incorrect video kind : %s", *request.VideoKind)
+		log.Error().Msg(msg)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": msg,
+		})
+		return
+	}
+
 	var searchTitle string
 	var extractedTitle *string
 
@@ -159,22 +201,43 @@ func (h *HTTPHandler) handleVideoDetection(c *gin.Context, request DetectRequest
 	}
 
 	// Search for videos using the title
-	resolvedVideos := h.s.ResolveVideo(searchTitle)
+	resolvedVideos := h.s.ResolveVideo(searchTitle, request.VideoKind)
 
-	detectedVideos := make([]DetectedVideoResponse, 0)
+	candidates := []*domain.LibraryItem{}
 	for _, v := range resolvedVideos {
+		tmdbId, kind := v.TmdbId, v.Kind
+		candidates = append(candidates, &domain.LibraryItem{Type: domain.ItemVideo, TmdbId: &tmdbId, VideoKind: &kind})
+	}
+	t := h.getTokenInfo(c)
+	duplicates := h.findDuplicates(t.userId, candidates)
+
+	detectedVideos := make([]DetectedVideoResponse, 0)
+	for idx, v := range resolvedVideos {
+		var seasons []DetectedSeasonResponse
+		for _, s := range v.Seasons {
+			seasons = append(seasons, DetectedSeasonResponse{
+				Number:       s.Number,
+				Name:         s.Name,
+				EpisodeCount: s.EpisodeCount,
+			})
+		}
 		detectedVideos = append(detectedVideos, DetectedVideoResponse{
-			Id:          v.Id,
-			Title:       v.Title,
-			Summary:     v.Summary,
-			PictureUrl:  v.PictureUrl,
-			Directors:   v.Directors,
-			Cast:        v.Cast,
-			ReleaseYear: v.ReleaseYear,
-			Duration:    v.Duration,
-			TmdbId:      v.TmdbId,
-			Source:      v.Source,
-			Error:       v.Error,
+			Id:           v.Id,
+			Kind:         v.Kind,
+			Title:        v.Title,
+			Summary:      v.Summary,
+			PictureUrl:   v.PictureUrl,
+			Directors:    v.Directors,
+			Cast:         v.Cast,
+			ReleaseYear:  v.ReleaseYear,
+			Duration:     v.Duration,
+			TmdbId:       v.TmdbId,
+			SeasonCount:  v.SeasonCount,
+			EpisodeCount: v.EpisodeCount,
+			Seasons:      seasons,
+			Source:       v.Source,
+			Error:        v.Error,
+			Duplicates:   duplicates[idx],
 		})
 	}
 
@@ -183,3 +246,122 @@ func (h *HTTPHandler) handleVideoDetection(c *gin.Context, request DetectRequest
 		ExtractedTitle: extractedTitle,
 	})
 }
+
+// handleMusicDetection handles music album detection via EAN/UPC barcode
+func (h *HTTPHandler) handleMusicDetection(c *gin.Context, request DetectRequest) {
+	code := strings.TrimSpace(request.Code)
+	if !isValidBarcode(code) {
+		msg := fmt.Sprintf("Invalid request - Incorrect barcode : %s - Expected EAN-13 or UPC-A", request.Code)
+		log.Error().Msg(msg)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": msg,
+		})
+		return
+	}
+
+	resolvedMusic := h.s.ResolveMusic(code)
+
+	detectedMusic := make([]DetectedMusicResponse, 0)
+	for _, m := range resolvedMusic {
+		detectedMusic = append(detectedMusic, DetectedMusicResponse{
+			Id:          m.Id,
+			Title:       m.Title,
+			Artists:     m.Artists,
+			Tracklist:   m.Tracklist,
+			Label:       m.Label,
+			ReleaseYear: m.ReleaseYear,
+			PictureUrl:  m.PictureUrl,
+			Barcode:     code,
+			Source:      m.Source,
+			Error:       m.Error,
+		})
+	}
+
+	c.JSON(http.StatusOK, DetectResponse{
+		DetectedMusic: detectedMusic,
+	})
+}
+
+// handleBoardGameDetection handles board game detection via EAN/UPC barcode or title search
+func (h *HTTPHandler) handleBoardGameDetection(c *gin.Context, request DetectRequest) {
+	var resolvedGames []domain.ResolvedBoardGame
+	var barcode *string
+
+	// Priority: barcode > title
+	if code := strings.TrimSpace(request.Code); code != "" {
+		if !isValidBarcode(code) {
+			msg := fmt.Sprintf("Invalid request - Incorrect barcode : %s - Expected EAN-13 or UPC-A", request.Code)
+			log.Error().Msg(msg)
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": msg,
+			})
+			return
+		}
+		resolvedGames = h.s.ResolveBoardGame(code, true)
+		barcode = &code
+	} else if request.Title != nil && strings.TrimSpace(*request.Title) != "" {
+		resolvedGames = h.s.ResolveBoardGame(strings.TrimSpace(*request.Title), false)
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Board game detection requires either 'code' (barcode) or 'title' field",
+		})
+		return
+	}
+
+	detectedGames := make([]DetectedBoardGameResponse, 0)
+	for _, g := range resolvedGames {
+		detectedGames = append(detectedGames, DetectedBoardGameResponse{
+			Id:          g.Id,
+			Title:       g.Title,
+			Summary:     g.Summary,
+			PictureUrl:  g.PictureUrl,
+			Designers:   g.Designers,
+			Publisher:   g.Publisher,
+			MinPlayers:  g.MinPlayers,
+			MaxPlayers:  g.MaxPlayers,
+			PlayTime:    g.PlayTime,
+			ReleaseYear: g.ReleaseYear,
+			BggId:       g.BggId,
+			Barcode:     barcode,
+			Source:      g.Source,
+			Error:       g.Error,
+		})
+	}
+
+	c.JSON(http.StatusOK, DetectResponse{
+		DetectedBoardGames: detectedGames,
+	})
+}
+
+// isValidVideoKind checks the video kind is a movie or a TV series
+func isValidVideoKind(kind domain.VideoKind) bool {
+	return kind == domain.Movie || kind == domain.TvSeries
+}
+
+// isValidBarcode checks an EAN-13 or UPC-A (12 digits) barcode, including its check digit
+func isValidBarcode(code string) bool {
+	if len(code) != 12 && len(code) != 13 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(code)-1; i++ {
+		digit := code[i]
+		if digit < '0' || digit > '9' {
+			return false
+		}
+		// Weights alternate 3/1 starting from the digit next to the check digit
+		weight := 1
+		if (len(code)-1-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(digit-'0') * weight
+	}
+
+	check := code[len(code)-1]
+	if check < '0' || check > '9' {
+		return false
+	}
+
+	return (10-sum%10)%10 == int(check-'0')
+}
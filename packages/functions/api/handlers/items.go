@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"alexandria.isnan.eu/functions/internal/domain"
 	"alexandria.isnan.eu/functions/internal/slices"
@@ -40,6 +44,74 @@ func (h *HTTPHandler) validateItemPayload(item *domain.LibraryItem) error {
 		}
 	}
 
+	// Volume doubles as the order within a series, so shares its range
+	if item.Volume != nil && (*item.Volume < 1 || *item.Volume > 1000) {
+		return errors.New("invalid request - invalid volume (must be between 1 and 1000)")
+	}
+
+	if item.SeriesName != nil && len(*item.SeriesName) > 100 {
+		return errors.New("invalid request - series name too long (max. 100 chars)")
+	}
+
+	if len(item.Tags) > 20 {
+		return errors.New("invalid request - too many tags (max. 20)")
+	}
+
+	for _, t := range item.Tags {
+		if len(t) > 30 {
+			return errors.New("invalid request - tag too long (max. 30 chars)")
+		}
+	}
+
+	// Values are checked against the library custom fields by the service layer
+	if len(item.CustomValues) > 20 {
+		return errors.New("invalid request - too many custom values (max. 20)")
+	}
+
+	for name, v := range item.CustomValues {
+		if len(name) == 0 || len(name) > 30 {
+			return errors.New("invalid request - invalid custom field name (1 to 30 chars)")
+		}
+		if len(v) > 200 {
+			return errors.New("invalid request - custom value too long (max. 200 chars)")
+		}
+	}
+
+	if item.Format != nil && !item.Format.IsValidFor(item.Type) {
+		return errors.New("invalid request - format not supported for this item type")
+	}
+
+	if len(item.Copies) > 20 {
+		return errors.New("invalid request - too many copies (max. 20)")
+	}
+
+	for _, c := range item.Copies {
+		if c.Format != nil && !c.Format.IsValidFor(item.Type) {
+			return errors.New("invalid request - copy format not supported for this item type")
+		}
+		if c.Condition != nil && !isValidItemCondition(*c.Condition) {
+			return errors.New("invalid request - invalid copy condition (must be NEW, GOOD, WORN or DAMAGED)")
+		}
+	}
+
+	if item.Acquisition != nil {
+		if item.Acquisition.Price != nil && (*item.Acquisition.Price < 0 || *item.Acquisition.Price > 1000000) {
+			return errors.New("invalid request - invalid purchase price (must be between 0 and 1000000)")
+		}
+
+		if item.Acquisition.Price != nil && !isValidCurrency(item.Acquisition.Currency) {
+			return errors.New("invalid request - invalid currency (expected ISO 4217 code)")
+		}
+
+		if len(item.Acquisition.Place) > 100 {
+			return errors.New("invalid request - purchase place too long (max. 100 chars)")
+		}
+
+		if item.Acquisition.Date != nil && item.Acquisition.Date.After(time.Now().UTC()) {
+			return errors.New("invalid request - purchase date in the future")
+		}
+	}
+
 	// Video-specific validation
 	if item.Type == domain.ItemVideo {
 		for _, d := range item.Directors {
@@ -55,6 +127,96 @@ func (h *HTTPHandler) validateItemPayload(item *domain.LibraryItem) error {
 		if item.Duration != nil && (*item.Duration < 0 || *item.Duration > 1000) {
 			return errors.New("invalid request - invalid duration (must be between 0 and 1000 minutes)")
 		}
+
+		if item.VideoKind != nil && !isValidVideoKind(*item.VideoKind) {
+			return errors.New("invalid request - invalid video kind (must be MOVIE or TV_SERIES)")
+		}
+
+		isTvSeries := item.VideoKind != nil && *item.VideoKind == domain.TvSeries
+		if !isTvSeries && (len(item.Seasons) > 0 || item.SeasonCount != nil) {
+			return errors.New("invalid request - seasons can only be set on TV series")
+		}
+
+		if item.SeasonCount != nil && (*item.SeasonCount < 1 || *item.SeasonCount > 100) {
+			return errors.New("invalid request - invalid season count (must be between 1 and 100)")
+		}
+
+		for _, s := range item.Seasons {
+			if s < 1 || s > 100 {
+				return errors.New("invalid request - invalid season number (must be between 1 and 100)")
+			}
+			if item.SeasonCount != nil && s > *item.SeasonCount {
+				return errors.New("invalid request - season number greater than season count")
+			}
+		}
+	}
+
+	// Music-specific validation
+	if item.Type == domain.ItemMusic {
+		for _, a := range item.Artists {
+			if len(a) > 100 {
+				return errors.New("invalid request - artist name too long (max. 100 chars)")
+			}
+		}
+
+		if len(item.Tracklist) > 200 {
+			return errors.New("invalid request - too many tracks (max. 200)")
+		}
+
+		for _, t := range item.Tracklist {
+			if len(t) > 200 {
+				return errors.New("invalid request - track title too long (max. 200 chars)")
+			}
+		}
+
+		if item.Label != nil && len(*item.Label) > 100 {
+			return errors.New("invalid request - label too long (max. 100 chars)")
+		}
+
+		if item.ReleaseYear != nil && (*item.ReleaseYear < 1800 || *item.ReleaseYear > 2100) {
+			return errors.New("invalid request - invalid release year")
+		}
+
+		if item.Barcode != nil && *item.Barcode != "" && !isValidBarcode(*item.Barcode) {
+			return errors.New("invalid request - invalid barcode (expected EAN-13 or UPC-A)")
+		}
+	}
+
+	// Board game-specific validation
+	if item.Type == domain.ItemBoardGame {
+		for _, d := range item.Designers {
+			if len(d) > 100 {
+				return errors.New("invalid request - designer name too long (max. 100 chars)")
+			}
+		}
+
+		if item.Publisher != nil && len(*item.Publisher) > 100 {
+			return errors.New("invalid request - publisher too long (max. 100 chars)")
+		}
+
+		if item.MinPlayers != nil && (*item.MinPlayers < 1 || *item.MinPlayers > 100) {
+			return errors.New("invalid request - invalid min. players (must be between 1 and 100)")
+		}
+
+		if item.MaxPlayers != nil && (*item.MaxPlayers < 1 || *item.MaxPlayers > 100) {
+			return errors.New("invalid request - invalid max. players (must be between 1 and 100)")
+		}
+
+		if item.MinPlayers != nil && item.MaxPlayers != nil && *item.MinPlayers > *item.MaxPlayers {
+			return errors.New("invalid request - min. players greater than max. players")
+		}
+
+		if item.PlayTime != nil && (*item.PlayTime < 0 || *item.PlayTime > 10000) {
+			return errors.New("invalid request - invalid play time (must be between 0 and 10000 minutes)")
+		}
+
+		if item.ReleaseYear != nil && (*item.ReleaseYear < 1800 || *item.ReleaseYear > 2100) {
+			return errors.New("invalid request - invalid release year")
+		}
+
+		if item.Barcode != nil && *item.Barcode != "" && !isValidBarcode(*item.Barcode) {
+			return errors.New("invalid request - invalid barcode (expected EAN-13 or UPC-A)")
+		}
 	}
 
 	return nil
@@ -74,7 +236,10 @@ func (h *HTTPHandler) CreateItemHistoryEvent(c *gin.Context) {
 		return
 	}
 
-	if len(request.Event) > 50 {
+	// Lending to a contact or an app user does not need a name
+	lendToContact := request.Type == domain.Lent && (request.ContactId != "" || request.UserName != "")
+
+	if len(request.Event) > 50 && !lendToContact {
 		log.Error().Msgf("Name too long")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "Name too long.",
@@ -82,7 +247,7 @@ func (h *HTTPHandler) CreateItemHistoryEvent(c *gin.Context) {
 		return
 	}
 
-	if len(request.Event) == 0 {
+	if len(request.Event) == 0 && !lendToContact {
 		log.Error().Msgf("Name missing")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "Name missing.",
@@ -90,18 +255,58 @@ func (h *HTTPHandler) CreateItemHistoryEvent(c *gin.Context) {
 		return
 	}
 
+	var dueDate *time.Time
+	if request.DueDate != nil {
+		dueDate, err = parseDueDate(request.Type, *request.DueDate)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	assessment, err := parseReturnAssessment(&request)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
 	t := h.getTokenInfo(c)
 
 	if request.Type == domain.Lent {
-
-		err = h.s.LendItem(t.userId, libraryId, itemId, strings.TrimSpace(request.Event))
+		// Lenders of a shared library lend to the contacts of its owner
+		var ownerId, contactId string
+		ownerId, err = h.s.ResolveLibraryOwner(t.userId, libraryId, domain.ShareLender)
+		if err == nil {
+			contactId, err = h.lendingContactId(ownerId, request.ContactId, request.UserName, request.Event)
+		}
+		if err == nil {
+			err = h.s.LendItem(t.userId, libraryId, itemId, request.CopyId, contactId, dueDate)
+		}
 	}
 
 	if request.Type == domain.Returned {
-		err = h.s.ReturnItem(t.userId, libraryId, itemId, strings.TrimSpace(request.Event))
+		err = h.s.ReturnItem(t.userId, libraryId, itemId, request.CopyId, strings.TrimSpace(request.Event), assessment)
+	}
+
+	if request.Type == domain.StatusChanged {
+		status := domain.ItemStatus(strings.TrimSpace(request.Event))
+		if !isValidItemStatus(status) {
+			err = errors.New("invalid request - unknown status")
+		} else {
+			err = h.s.SetItemStatus(t.userId, libraryId, itemId, status, nil, t.actor())
+		}
 	}
 
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
 		log.Error().Msg(err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": err.Error(),
@@ -113,6 +318,148 @@ func (h *HTTPHandler) CreateItemHistoryEvent(c *gin.Context) {
 
 }
 
+// lendingContactId returns the contact a loan is made to: the given contact, the contact linked to an app user,
+// or the contact with the given name. Lending by name reuses the contact with the same name, or creates it.
+func (h *HTTPHandler) lendingContactId(ownerId string, contactId string, userName string, name string) (string, error) {
+	var contact *domain.Contact
+	var err error
+	if userName != "" {
+		contact, err = h.s.ResolveUserContact(ownerId, strings.TrimSpace(userName))
+	} else if contactId == "" {
+		contact, err = h.s.ResolveContact(ownerId, strings.TrimSpace(name))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if contact != nil {
+		contactId = contact.Id
+	}
+	return contactId, nil
+}
+
+// Photos are sent base64 encoded in the request, which must fit in the Lambda payload limit
+const maxConditionPictureSize = 3 * 1024 * 1024
+
+// parseReturnAssessment validates the condition of a returned item, nil when the return does not assess it.
+// The note and the photo describe the condition, they cannot be given without it.
+func parseReturnAssessment(request *ItemHistoryEntryRequest) (*domain.ReturnAssessment, error) {
+	note := strings.TrimSpace(request.ConditionNote)
+	if request.Condition == nil {
+		if note != "" || request.ConditionPicture != "" {
+			return nil, errors.New("invalid request - condition missing")
+		}
+		return nil, nil
+	}
+
+	if request.Type != domain.Returned {
+		return nil, errors.New("invalid request - condition can only be set when returning")
+	}
+
+	if !isValidItemCondition(*request.Condition) {
+		return nil, errors.New("invalid request - unknown condition")
+	}
+
+	if len(note) > 500 {
+		return nil, errors.New("invalid request - condition note too long (max. 500 chars)")
+	}
+
+	assessment := domain.ReturnAssessment{Condition: *request.Condition}
+	if note != "" {
+		assessment.Note = &note
+	}
+
+	if request.ConditionPicture != "" {
+		picture, err := base64.StdEncoding.DecodeString(request.ConditionPicture)
+		if err != nil || !strings.HasPrefix(http.DetectContentType(picture), "image/") {
+			return nil, errors.New("invalid request - invalid condition picture")
+		}
+		if len(picture) > maxConditionPictureSize {
+			return nil, errors.New("invalid request - condition picture too large (max. 3MB)")
+		}
+		assessment.Picture = picture
+	}
+
+	return &assessment, nil
+}
+
+// parseDueDate parses the due date of a loan, which cannot be in the past
+func parseDueDate(evtType domain.ItemEventType, date string) (*time.Time, error) {
+	if evtType != domain.Lent {
+		return nil, errors.New("invalid request - due date can only be set when lending")
+	}
+
+	dueDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, errors.New("invalid request - invalid due date (expected YYYY-MM-DD)")
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if dueDate.Before(today) {
+		return nil, errors.New("invalid request - due date in the past")
+	}
+
+	return &dueDate, nil
+}
+
+// UpdateItemStatus records a reading/watching status change of the requester in the item history
+func (h *HTTPHandler) UpdateItemStatus(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+
+	var request UpdateItemStatusRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if !isValidItemStatus(request.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request - unknown status.",
+		})
+		return
+	}
+
+	var date *time.Time
+	if request.Date != nil {
+		day, err := time.Parse("2006-01-02", *request.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request - invalid date (expected YYYY-MM-DD).",
+			})
+			return
+		}
+		// Keep the current time of day so that several changes on the same day do not share the same event key
+		now := time.Now().UTC()
+		d := time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+		if d.After(now) && d.YearDay() == now.YearDay() && d.Year() == now.Year() {
+			d = now
+		}
+		date = &d
+	}
+
+	t := h.getTokenInfo(c)
+
+	err = h.s.SetItemStatus(t.userId, libraryId, itemId, request.Status, date, t.actor())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+func isValidItemStatus(status domain.ItemStatus) bool {
+	return status.IsValidFor(domain.ItemBook) || status.IsValidFor(domain.ItemVideo)
+}
+
 func (h *HTTPHandler) DeleteItemHistoryEvents(c *gin.Context) {
 	libraryId := c.Param("libraryId")
 	itemId := c.Param("itemId")
@@ -156,11 +503,24 @@ func (h *HTTPHandler) GetItemHistoryEvents(c *gin.Context) {
 	entries := []ItemHistoryEntry{}
 
 	for _, e := range history.Entries {
-		entries = append(entries, ItemHistoryEntry{
-			Date:  e.Date,
-			Type:  e.Type,
-			Event: e.Event,
-		})
+		entry := ItemHistoryEntry{
+			Date:          e.Date,
+			Type:          e.Type,
+			Event:         e.Event,
+			CopyId:        e.CopyId,
+			DueDate:       e.DueDate,
+			ContactId:     e.ContactId,
+			Condition:     e.Condition,
+			ConditionNote: e.ConditionNote,
+			UserId:        e.UserId,
+			UserName:      e.UserName,
+		}
+		if e.ConditionPicture && e.Date != nil {
+			url := fmt.Sprintf("https://alexandria.isnan.eu/thumbnails/user/%s/library/%s/item/%s/returns/%s",
+				t.userId, libraryId, itemId, domain.ReturnPictureId(*e.Date))
+			entry.ConditionPicture = &url
+		}
+		entries = append(entries, entry)
 	}
 
 	response := ItemHistoryEntryListResponse{
@@ -187,32 +547,7 @@ func (h *HTTPHandler) UpdateBook(c *gin.Context) {
 
 	t := h.getTokenInfo(c)
 
-	// Order = 0 means no order has been set
-	if request.Order != nil && *request.Order == 0 {
-		request.Order = nil
-	}
-
-	// Empty collectionId means no collection has been set
-	if request.CollectionId != nil && *request.CollectionId == "" {
-		request.CollectionId = nil
-	}
-
-	item := domain.LibraryItem{
-		Id:           bookId,
-		Title:        strings.TrimSpace(request.Title),
-		LibraryId:    libraryId,
-		OwnerId:      t.userId,
-		OwnerName:    t.userName,
-		Summary:      strings.TrimSpace(request.Summary),
-		Isbn:         strings.TrimSpace(request.Isbn),
-		Authors:      slices.Map(request.Authors, func(a string) string { return strings.TrimSpace(a) }),
-		Type:         domain.ItemBook,
-		PictureUrl:   request.PictureUrl,
-		CollectionId: request.CollectionId,
-		Order:        request.Order,
-	}
-
-	err = h.validateItemPayload(&item)
+	item, err := h.mapUpdateBookRequest(&request, libraryId, bookId, t)
 	if err != nil {
 		log.Error().Msg(err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -223,8 +558,17 @@ func (h *HTTPHandler) UpdateBook(c *gin.Context) {
 
 	fetchPicture := request.UpdatePicture != nil && *request.UpdatePicture
 
-	err = h.s.UpdateItem(&item, fetchPicture)
+	err = h.s.UpdateItem(item, fetchPicture, t.actor())
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "lent") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to update item",
 		})
@@ -247,30 +591,619 @@ func (h *HTTPHandler) DeleteItem(c *gin.Context) {
 
 	err := h.s.DeleteItem(&item)
 	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not returned") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to delete item",
 		})
 		return
 	}
 
-	c.Status(http.StatusOK)
-}
-
-func (h *HTTPHandler) CreateBook(c *gin.Context) {
-	libraryId := c.Param("libraryId")
-
-	var request CreateBookRequest
-	err := c.BindJSON(&request)
+	c.Status(http.StatusOK)
+}
+
+// MoveItem moves an item to another library of the user, keeping its history
+func (h *HTTPHandler) MoveItem(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+
+	var request MoveItemRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if len(request.LibraryId) == 0 {
+		log.Error().Msg("Target library missing")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - target library is mandatory",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.s.MoveItem(t.userId, libraryId, itemId, request.LibraryId, trimOptional(request.CollectionId))
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "unknown item") || strings.Contains(err.Error(), "unknown library") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not returned") || strings.Contains(err.Error(), "pending borrow requests") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "target library") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to move item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, MoveItemResponse{
+		Id:           item.Id,
+		LibraryId:    item.LibraryId,
+		CollectionId: item.CollectionId,
+		UpdatedAt:    item.UpdatedAt,
+	})
+}
+
+func (h *HTTPHandler) CreateBook(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request CreateBookRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapCreateBookRequest(&request, libraryId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Looked up before saving, so the new item is not reported
+	duplicates := h.findDuplicates(t.userId, []*domain.LibraryItem{item})[0]
+
+	result, err := h.s.CreateItem(item)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, &CreateBookResponse{
+		Id:           result.Id,
+		UpdatedAt:    result.UpdatedAt,
+		CollectionId: result.CollectionId,
+		Duplicates:   duplicates,
+	})
+}
+
+// CreateVideo handles video creation
+func (h *HTTPHandler) CreateVideo(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request CreateVideoRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapCreateVideoRequest(&request, libraryId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Looked up before saving, so the new item is not reported
+	duplicates := h.findDuplicates(t.userId, []*domain.LibraryItem{item})[0]
+
+	result, err := h.s.CreateItem(item)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create video",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, &CreateVideoResponse{
+		Id:         result.Id,
+		UpdatedAt:  result.UpdatedAt,
+		Duplicates: duplicates,
+	})
+}
+
+// UpdateVideo handles video updates
+func (h *HTTPHandler) UpdateVideo(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	videoId := c.Param("videoId")
+
+	var request UpdateVideoRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapUpdateVideoRequest(&request, libraryId, videoId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fetchPicture := request.UpdatePicture != nil && *request.UpdatePicture
+
+	err = h.s.UpdateItem(item, fetchPicture, t.actor())
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "lent") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update video",
+		})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// CreateMusic handles music album creation
+func (h *HTTPHandler) CreateMusic(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request CreateMusicRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapCreateMusicRequest(&request, libraryId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.s.CreateItem(item)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create music",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, &CreateMusicResponse{
+		Id:        result.Id,
+		UpdatedAt: result.UpdatedAt,
+	})
+}
+
+// UpdateMusic handles music album updates
+func (h *HTTPHandler) UpdateMusic(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	musicId := c.Param("musicId")
+
+	var request UpdateMusicRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapUpdateMusicRequest(&request, libraryId, musicId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fetchPicture := request.UpdatePicture != nil && *request.UpdatePicture
+
+	err = h.s.UpdateItem(item, fetchPicture, t.actor())
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "lent") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update music",
+		})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// CreateBoardGame handles board game creation
+func (h *HTTPHandler) CreateBoardGame(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request CreateBoardGameRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapCreateBoardGameRequest(&request, libraryId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.s.CreateItem(item)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create board game",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, &CreateBoardGameResponse{
+		Id:        result.Id,
+		UpdatedAt: result.UpdatedAt,
+	})
+}
+
+// UpdateBoardGame handles board game updates
+func (h *HTTPHandler) UpdateBoardGame(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	boardGameId := c.Param("boardGameId")
+
+	var request UpdateBoardGameRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.mapUpdateBoardGameRequest(&request, libraryId, boardGameId, t)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	fetchPicture := request.UpdatePicture != nil && *request.UpdatePicture
+
+	err = h.s.UpdateItem(item, fetchPicture, t.actor())
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "lent") || strings.Contains(err.Error(), "custom field") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to update board game",
+		})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// normalizeSeasons sorts season numbers and drops duplicates
+// mapCreateBookRequest builds and validates the book of a creation request
+func (h *HTTPHandler) mapCreateBookRequest(request *CreateBookRequest, libraryId string, t *tokenInfo) (*domain.LibraryItem, error) {
+	// Order = 0 means no order has been set
+	if request.Order != nil && *request.Order == 0 {
+		request.Order = nil
+	}
+
+	// Empty collectionId means no collection has been set
+	if request.CollectionId != nil && *request.CollectionId == "" {
+		request.CollectionId = nil
+	}
+
+	item := domain.LibraryItem{
+		Title:        strings.TrimSpace(request.Title),
+		Summary:      strings.TrimSpace(request.Summary),
+		Isbn:         strings.TrimSpace(request.Isbn),
+		Authors:      slices.Map(request.Authors, func(a string) string { return strings.TrimSpace(a) }),
+		LibraryId:    libraryId,
+		OwnerId:      t.userId,
+		OwnerName:    t.displayName,
+		Type:         domain.ItemBook,
+		PictureUrl:   request.PictureUrl,
+		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptional(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
+		Order:        request.Order,
+		Volume:       request.Volume,
+		SeriesName:   trimOptional(request.SeriesName),
+	}
+
+	var err error
+	item.Acquisition, err = mapAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// mapUpdateBookRequest builds and validates the book of an update request
+func (h *HTTPHandler) mapUpdateBookRequest(request *UpdateBookRequest, libraryId string, itemId string, t *tokenInfo) (*domain.LibraryItem, error) {
+	// Order = 0 means no order has been set
+	if request.Order != nil && *request.Order == 0 {
+		request.Order = nil
+	}
+
+	// Empty collectionId means no collection has been set
+	if request.CollectionId != nil && *request.CollectionId == "" {
+		request.CollectionId = nil
+	}
+
+	item := domain.LibraryItem{
+		Id:           itemId,
+		Title:        strings.TrimSpace(request.Title),
+		LibraryId:    libraryId,
+		OwnerId:      t.userId,
+		OwnerName:    t.userName,
+		Summary:      strings.TrimSpace(request.Summary),
+		Isbn:         strings.TrimSpace(request.Isbn),
+		Authors:      slices.Map(request.Authors, func(a string) string { return strings.TrimSpace(a) }),
+		Type:         domain.ItemBook,
+		PictureUrl:   request.PictureUrl,
+		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptionalUpdate(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
+		Order:        request.Order,
+		Volume:       request.Volume,
+	}
+
+	var err error
+	item.Acquisition, err = mapUpdatedAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// mapCreateVideoRequest builds and validates the video of a creation request
+func (h *HTTPHandler) mapCreateVideoRequest(request *CreateVideoRequest, libraryId string, t *tokenInfo) (*domain.LibraryItem, error) {
+	// Order = 0 means no order has been set
+	if request.Order != nil && *request.Order == 0 {
+		request.Order = nil
+	}
+
+	// Empty collectionId means no collection has been set
+	if request.CollectionId != nil && *request.CollectionId == "" {
+		request.CollectionId = nil
+	}
+
+	item := domain.LibraryItem{
+		Title:        strings.TrimSpace(request.Title),
+		Summary:      strings.TrimSpace(request.Summary),
+		LibraryId:    libraryId,
+		OwnerId:      t.userId,
+		OwnerName:    t.displayName,
+		Type:         domain.ItemVideo,
+		PictureUrl:   request.PictureUrl,
+		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptional(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
+		Order:        request.Order,
+		Directors:    slices.Map(request.Directors, func(d string) string { return strings.TrimSpace(d) }),
+		Cast:         slices.Map(request.Cast, func(c string) string { return strings.TrimSpace(c) }),
+		ReleaseYear:  request.ReleaseYear,
+		Duration:     request.Duration,
+		TmdbId:       request.TmdbId,
+		VideoKind:    request.Kind,
+		Seasons:      normalizeSeasons(request.Seasons),
+		SeasonCount:  request.SeasonCount,
+	}
+
+	var err error
+	item.Acquisition, err = mapAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// mapUpdateVideoRequest builds and validates the video of an update request
+func (h *HTTPHandler) mapUpdateVideoRequest(request *UpdateVideoRequest, libraryId string, itemId string, t *tokenInfo) (*domain.LibraryItem, error) {
+	// Order = 0 means no order has been set
+	if request.Order != nil && *request.Order == 0 {
+		request.Order = nil
+	}
+
+	// Empty collectionId means no collection has been set
+	if request.CollectionId != nil && *request.CollectionId == "" {
+		request.CollectionId = nil
+	}
+
+	item := domain.LibraryItem{
+		Id:           itemId,
+		Title:        strings.TrimSpace(request.Title),
+		LibraryId:    libraryId,
+		OwnerId:      t.userId,
+		OwnerName:    t.userName,
+		Summary:      strings.TrimSpace(request.Summary),
+		Type:         domain.ItemVideo,
+		PictureUrl:   request.PictureUrl,
+		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptionalUpdate(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
+		Order:        request.Order,
+		Directors:    slices.Map(request.Directors, func(d string) string { return strings.TrimSpace(d) }),
+		Cast:         slices.Map(request.Cast, func(c string) string { return strings.TrimSpace(c) }),
+		ReleaseYear:  request.ReleaseYear,
+		Duration:     request.Duration,
+		TmdbId:       request.TmdbId,
+		VideoKind:    request.Kind,
+		Seasons:      normalizeSeasons(request.Seasons),
+		SeasonCount:  request.SeasonCount,
+	}
+
+	var err error
+	item.Acquisition, err = mapUpdatedAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
 	if err != nil {
-		log.Error().Msgf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid request.",
-		})
-		return
+		return nil, err
 	}
 
-	t := h.getTokenInfo(c)
+	return &item, nil
+}
 
+// mapCreateMusicRequest builds and validates the music album of a creation request
+func (h *HTTPHandler) mapCreateMusicRequest(request *CreateMusicRequest, libraryId string, t *tokenInfo) (*domain.LibraryItem, error) {
 	// Order = 0 means no order has been set
 	if request.Order != nil && *request.Order == 0 {
 		request.Order = nil
@@ -284,62 +1217,86 @@ func (h *HTTPHandler) CreateBook(c *gin.Context) {
 	item := domain.LibraryItem{
 		Title:        strings.TrimSpace(request.Title),
 		Summary:      strings.TrimSpace(request.Summary),
-		Isbn:         strings.TrimSpace(request.Isbn),
-		Authors:      slices.Map(request.Authors, func(a string) string { return strings.TrimSpace(a) }),
 		LibraryId:    libraryId,
 		OwnerId:      t.userId,
 		OwnerName:    t.displayName,
-		Type:         domain.ItemBook,
+		Type:         domain.ItemMusic,
 		PictureUrl:   request.PictureUrl,
 		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptional(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
 		Order:        request.Order,
+		Artists:      slices.Map(request.Artists, func(a string) string { return strings.TrimSpace(a) }),
+		Tracklist:    slices.Map(request.Tracklist, func(t string) string { return strings.TrimSpace(t) }),
+		Label:        trimOptional(request.Label),
+		ReleaseYear:  request.ReleaseYear,
+		Barcode:      trimOptional(request.Barcode),
 	}
 
-	err = h.validateItemPayload(&item)
-	if err != nil {
-		log.Error().Msg(err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-		})
-		return
+	var err error
+	item.Acquisition, err = mapAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
 	}
-
-	result, err := h.s.CreateItem(&item)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to create item",
-		})
-		return
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, &CreateBookResponse{
-		Id:        result.Id,
-		UpdatedAt: result.UpdatedAt,
-	})
+	return &item, nil
 }
 
-// CreateVideo handles video creation
-func (h *HTTPHandler) CreateVideo(c *gin.Context) {
-	libraryId := c.Param("libraryId")
+// mapUpdateMusicRequest builds and validates the music album of an update request
+func (h *HTTPHandler) mapUpdateMusicRequest(request *UpdateMusicRequest, libraryId string, itemId string, t *tokenInfo) (*domain.LibraryItem, error) {
+	// Order = 0 means no order has been set
+	if request.Order != nil && *request.Order == 0 {
+		request.Order = nil
+	}
 
-	var request CreateVideoRequest
-	err := c.BindJSON(&request)
+	// Empty collectionId means no collection has been set
+	if request.CollectionId != nil && *request.CollectionId == "" {
+		request.CollectionId = nil
+	}
+
+	item := domain.LibraryItem{
+		Id:           itemId,
+		Title:        strings.TrimSpace(request.Title),
+		LibraryId:    libraryId,
+		OwnerId:      t.userId,
+		OwnerName:    t.userName,
+		Summary:      strings.TrimSpace(request.Summary),
+		Type:         domain.ItemMusic,
+		PictureUrl:   request.PictureUrl,
+		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptionalUpdate(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
+		Order:        request.Order,
+		Artists:      slices.Map(request.Artists, func(a string) string { return strings.TrimSpace(a) }),
+		Tracklist:    slices.Map(request.Tracklist, func(t string) string { return strings.TrimSpace(t) }),
+		Label:        trimOptional(request.Label),
+		ReleaseYear:  request.ReleaseYear,
+		Barcode:      trimOptional(request.Barcode),
+	}
+
+	var err error
+	item.Acquisition, err = mapUpdatedAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
 	if err != nil {
-		log.Error().Msgf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid request.",
-		})
-		return
+		return nil, err
 	}
 
-	t := h.getTokenInfo(c)
+	return &item, nil
+}
 
+// mapCreateBoardGameRequest builds and validates the board game of a creation request
+func (h *HTTPHandler) mapCreateBoardGameRequest(request *CreateBoardGameRequest, libraryId string, t *tokenInfo) (*domain.LibraryItem, error) {
 	// Order = 0 means no order has been set
 	if request.Order != nil && *request.Order == 0 {
 		request.Order = nil
@@ -356,63 +1313,39 @@ func (h *HTTPHandler) CreateVideo(c *gin.Context) {
 		LibraryId:    libraryId,
 		OwnerId:      t.userId,
 		OwnerName:    t.displayName,
-		Type:         domain.ItemVideo,
+		Type:         domain.ItemBoardGame,
 		PictureUrl:   request.PictureUrl,
 		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptional(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
 		Order:        request.Order,
-		Directors:    slices.Map(request.Directors, func(d string) string { return strings.TrimSpace(d) }),
-		Cast:         slices.Map(request.Cast, func(c string) string { return strings.TrimSpace(c) }),
+		Designers:    slices.Map(request.Designers, func(d string) string { return strings.TrimSpace(d) }),
+		Publisher:    trimOptional(request.Publisher),
+		MinPlayers:   request.MinPlayers,
+		MaxPlayers:   request.MaxPlayers,
+		PlayTime:     request.PlayTime,
 		ReleaseYear:  request.ReleaseYear,
-		Duration:     request.Duration,
-		TmdbId:       request.TmdbId,
+		BggId:        trimOptional(request.BggId),
+		Barcode:      trimOptional(request.Barcode),
 	}
 
-	err = h.validateItemPayload(&item)
-	if err != nil {
-		log.Error().Msg(err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
-		})
-		return
+	var err error
+	item.Acquisition, err = mapAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
 	}
-
-	result, err := h.s.CreateItem(&item)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to create video",
-		})
-		return
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, &CreateVideoResponse{
-		Id:        result.Id,
-		UpdatedAt: result.UpdatedAt,
-	})
+	return &item, nil
 }
 
-// UpdateVideo handles video updates
-func (h *HTTPHandler) UpdateVideo(c *gin.Context) {
-	libraryId := c.Param("libraryId")
-	videoId := c.Param("videoId")
-
-	var request UpdateVideoRequest
-	err := c.BindJSON(&request)
-	if err != nil {
-		log.Error().Msgf("Invalid request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid request.",
-		})
-		return
-	}
-
-	t := h.getTokenInfo(c)
-
+// mapUpdateBoardGameRequest builds and validates the board game of an update request
+func (h *HTTPHandler) mapUpdateBoardGameRequest(request *UpdateBoardGameRequest, libraryId string, itemId string, t *tokenInfo) (*domain.LibraryItem, error) {
 	// Order = 0 means no order has been set
 	if request.Order != nil && *request.Order == 0 {
 		request.Order = nil
@@ -424,48 +1357,275 @@ func (h *HTTPHandler) UpdateVideo(c *gin.Context) {
 	}
 
 	item := domain.LibraryItem{
-		Id:           videoId,
+		Id:           itemId,
 		Title:        strings.TrimSpace(request.Title),
+		Summary:      strings.TrimSpace(request.Summary),
 		LibraryId:    libraryId,
 		OwnerId:      t.userId,
 		OwnerName:    t.userName,
-		Summary:      strings.TrimSpace(request.Summary),
-		Type:         domain.ItemVideo,
+		Type:         domain.ItemBoardGame,
 		PictureUrl:   request.PictureUrl,
 		CollectionId: request.CollectionId,
+		Tags:         normalizeTags(request.Tags),
+		LocationId:   trimOptionalUpdate(request.LocationId),
+		Format:       request.Format,
+		Copies:       mapItemCopies(request.Copies),
+		CustomValues: normalizeCustomValues(request.CustomValues),
 		Order:        request.Order,
-		Directors:    slices.Map(request.Directors, func(d string) string { return strings.TrimSpace(d) }),
-		Cast:         slices.Map(request.Cast, func(c string) string { return strings.TrimSpace(c) }),
+		Designers:    slices.Map(request.Designers, func(d string) string { return strings.TrimSpace(d) }),
+		Publisher:    trimOptional(request.Publisher),
+		MinPlayers:   request.MinPlayers,
+		MaxPlayers:   request.MaxPlayers,
+		PlayTime:     request.PlayTime,
 		ReleaseYear:  request.ReleaseYear,
-		Duration:     request.Duration,
-		TmdbId:       request.TmdbId,
+		BggId:        trimOptional(request.BggId),
+		Barcode:      trimOptional(request.Barcode),
 	}
 
-	err = h.validateItemPayload(&item)
+	var err error
+	item.Acquisition, err = mapUpdatedAcquisition(request.Acquisition)
+	if err == nil {
+		err = h.validateItemPayload(&item)
+	}
 	if err != nil {
-		log.Error().Msg(err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": err.Error(),
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func normalizeSeasons(seasons []int) []int {
+	if len(seasons) == 0 {
+		return nil
+	}
+	sorted := append([]int{}, seasons...)
+	sort.Ints(sorted)
+
+	normalized := []int{}
+	for i, season := range sorted {
+		if i == 0 || season != sorted[i-1] {
+			normalized = append(normalized, season)
+		}
+	}
+	return normalized
+}
+
+// normalizeTags trims tag names and drops empty and duplicate (case-insensitive) ones, keeping the order.
+// An omitted list stays nil, so that updates keep the current tags.
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, t)
+	}
+	return normalized
+}
+
+// normalizeCustomValues trims custom values and drops the empty ones, which unset the field.
+// Omitted values stay nil, so that updates keep the current values.
+func normalizeCustomValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+
+	normalized := map[string]string{}
+	for name, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		normalized[strings.TrimSpace(name)] = v
+	}
+	return normalized
+}
+
+// formatSeasonsLabel renders sorted season numbers as ranges, e.g. "Season 1–3, 5"
+func formatSeasonsLabel(seasons []int) *string {
+	if len(seasons) == 0 {
+		return nil
+	}
+
+	var ranges []string
+	for i := 0; i < len(seasons); {
+		j := i
+		for j+1 < len(seasons) && seasons[j+1] == seasons[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(seasons[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d–%d", seasons[i], seasons[j]))
+		}
+		i = j + 1
+	}
+
+	label := fmt.Sprintf("Season %s", strings.Join(ranges, ", "))
+	return &label
+}
+
+// trimOptional trims an optional string, mapping blank values to nil
+func isValidItemCondition(condition domain.ItemCondition) bool {
+	return condition == domain.ConditionNew || condition == domain.ConditionGood ||
+		condition == domain.ConditionWorn || condition == domain.ConditionDamaged
+}
+
+// mapItemCopies converts the requested copies, the lending state is kept by the service layer.
+// An omitted list stays nil, so that updates keep the current copies.
+func mapItemCopies(copies []ItemCopyRequest) []domain.ItemCopy {
+	if copies == nil {
+		return nil
+	}
+
+	result := []domain.ItemCopy{}
+	for _, c := range copies {
+		result = append(result, domain.ItemCopy{
+			Id:        strings.TrimSpace(c.Id),
+			Format:    c.Format,
+			Condition: c.Condition,
 		})
-		return
+	}
+	return result
+}
+
+func buildItemCopiesResponse(copies []domain.ItemCopy) []ItemCopyResponse {
+	if len(copies) == 0 {
+		return nil
 	}
 
-	fetchPicture := request.UpdatePicture != nil && *request.UpdatePicture
+	result := []ItemCopyResponse{}
+	for _, c := range copies {
+		result = append(result, ItemCopyResponse{
+			Id:        c.Id,
+			Format:    c.Format,
+			Condition: c.Condition,
+			LentTo:    c.LentTo,
+			LentToId:  c.LentToId,
+			DueDate:   c.DueDate,
+		})
+	}
+	return result
+}
+
+// mapAcquisition parses the requested acquisition, nil when nothing is set
+func mapAcquisition(request *AcquisitionRequest) (*domain.Acquisition, error) {
+	if request == nil {
+		return nil, nil
+	}
+
+	a := domain.Acquisition{
+		Price:    request.Price,
+		Currency: strings.ToUpper(strings.TrimSpace(request.Currency)),
+		Place:    strings.TrimSpace(request.Place),
+	}
+
+	if request.Date != nil && *request.Date != "" {
+		day, err := time.Parse("2006-01-02", *request.Date)
+		if err != nil {
+			return nil, errors.New("invalid request - invalid purchase date (expected YYYY-MM-DD)")
+		}
+		a.Date = &day
+	}
+
+	if a.Date == nil && a.Price == nil && a.Currency == "" && a.Place == "" {
+		return nil, nil
+	}
+
+	return &a, nil
+}
+
+// mapUpdatedAcquisition converts the acquisition of an update. An empty one removes the purchase details,
+// unlike an omitted one (nil) which keeps them.
+func mapUpdatedAcquisition(request *AcquisitionRequest) (*domain.Acquisition, error) {
+	a, err := mapAcquisition(request)
+	if err == nil && a == nil && request != nil {
+		return &domain.Acquisition{}, nil
+	}
+	return a, err
+}
+
+func buildAcquisitionResponse(a *domain.Acquisition) *AcquisitionResponse {
+	if a == nil {
+		return nil
+	}
+
+	return &AcquisitionResponse{
+		Date:     a.Date,
+		Price:    a.Price,
+		Currency: a.Currency,
+		Place:    a.Place,
+	}
+}
+
+// isValidCurrency checks the ISO 4217 format (three uppercase letters)
+func isValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
 
-	err = h.s.UpdateItem(&item, fetchPicture)
+// findDuplicates returns the existing items matching each candidate. The check is informative only:
+// on failure it is logged and no duplicates are reported.
+func (h *HTTPHandler) findDuplicates(userId string, candidates []*domain.LibraryItem) [][]DuplicateItemResponse {
+	result := make([][]DuplicateItemResponse, len(candidates))
+
+	matches, err := h.s.FindDuplicates(userId, candidates)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"message": err.Error(),
+		log.Warn().Str("userId", userId).Msgf("Failed to check duplicates: %s", err.Error())
+		return result
+	}
+
+	for idx, m := range matches {
+		for _, i := range m {
+			result[idx] = append(result[idx], DuplicateItemResponse{
+				Id:          i.Id,
+				Title:       i.Title,
+				LibraryId:   i.LibraryId,
+				LibraryName: i.LibraryName,
+				OwnerId:     i.OwnerId,
+				OwnerName:   i.OwnerName,
+				Wanted:      i.Wanted,
 			})
-			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to update video",
-		})
-		return
 	}
-	c.Status(http.StatusOK)
+
+	return result
+}
+
+func trimOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// trimOptionalUpdate trims an optional value of an update. An empty value unsets the field,
+// unlike an omitted one (nil) which keeps the current value.
+func trimOptionalUpdate(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
 }
 
 func (h *HTTPHandler) ListLibraryItems(c *gin.Context) {
@@ -484,6 +1644,16 @@ func (h *HTTPHandler) ListLibraryItems(c *gin.Context) {
 
 	t := h.getTokenInfo(c)
 
+	// Filters return a flat list, collections are not relevant there
+	if c.Query("status") != "" || c.Query("tag") != "" || c.Query("location") != "" {
+		filter := domain.ItemFilter{}
+		if location := c.Query("location"); location != "" {
+			filter.LocationIds = []string{location}
+		}
+		h.listFilteredLibraryItems(c, t.userId, libraryId, filter, continuationToken, pageSize)
+		return
+	}
+
 	// Use grouped query for server-side collection grouping
 	content, err := h.s.ListItemsByLibraryGrouped(t.userId, libraryId, continuationToken, pageSize)
 	if err != nil {
@@ -516,9 +1686,12 @@ func (h *HTTPHandler) ListLibraryItems(c *gin.Context) {
 				Items:       nestedItems,
 				ItemCount:   item.ItemCount,
 				Partial:     item.Partial,
+				// Series info
+				IsSeries:     item.IsSeries,
+				TotalVolumes: item.TotalVolumes,
 			})
 		} else {
-			// Standalone book or video
+			// Standalone book, video, music or board game
 			itemsResponse = append(itemsResponse, h.buildItemResponse(item))
 		}
 	}
@@ -531,6 +1704,41 @@ func (h *HTTPHandler) ListLibraryItems(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// listFilteredLibraryItems lists the items matching the filter, completed with the status and tag query parameters
+func (h *HTTPHandler) listFilteredLibraryItems(c *gin.Context, userId string, libraryId string, filter domain.ItemFilter, continuationToken string, pageSize int) {
+	if status := domain.ItemStatus(c.Query("status")); status != "" {
+		if !isValidItemStatus(status) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request - unknown status.",
+			})
+			return
+		}
+		filter.Status = &status
+	}
+
+	if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+		filter.Tag = &tag
+	}
+
+	content, err := h.s.ListFilteredItems(userId, libraryId, &filter, continuationToken, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to query library items ",
+		})
+		return
+	}
+
+	itemsResponse := []GetItemResponse{}
+	for _, item := range content.Items {
+		itemsResponse = append(itemsResponse, h.buildItemResponse(item))
+	}
+
+	c.JSON(http.StatusOK, GetLibrariesContentResponse{
+		GetItemsResponse:  itemsResponse,
+		ContinuationToken: content.ContinuationToken,
+	})
+}
+
 // buildItemResponse converts a domain.LibraryItem to the appropriate GetItemResponse
 // Picture field contains CloudFront URL (if item has a thumbnail in S3)
 func (h *HTTPHandler) buildItemResponse(i *domain.LibraryItem) GetItemResponse {
@@ -551,14 +1759,36 @@ func (h *HTTPHandler) buildItemResponse(i *domain.LibraryItem) GetItemResponse {
 		LibraryId:      &i.LibraryId,
 		LibraryName:    &i.LibraryName,
 		LentTo:         i.LentTo,
+		LentToId:       i.LentToId,
+		DueDate:        i.DueDate,
 		OwnerId:        i.OwnerId,
 		CollectionId:   i.CollectionId,
 		CollectionName: i.CollectionName,
 		Order:          i.Order,
+		Volume:         i.Volume,
+		Tags:           i.Tags,
+		Wanted:         i.Wanted,
+		LocationId:     i.LocationId,
+		LocationPath:   i.LocationPath,
+		Format:         i.Format,
+		Condition:      i.Condition,
+		Copies:         buildItemCopiesResponse(i.Copies),
+		Acquisition:    buildAcquisitionResponse(i.Acquisition),
+		CustomValues:   i.CustomValues,
+		Status:         i.Status,
+		StatusDate:     i.StatusDate,
+		MyRating:       i.MyRating,
+		RatingCount:    i.RatingCount,
 		PictureUrl:     i.PictureUrl,
 		UpdatedAt:      i.UpdatedAt,
 	}
 
+	if i.RatingCount > 0 {
+		// Rounded to one decimal
+		average := math.Round(float64(i.RatingTotal)/float64(i.RatingCount)*10) / 10
+		baseResponse.AverageRating = &average
+	}
+
 	switch i.Type {
 	case domain.ItemBook:
 		return GetBookResponse{
@@ -568,6 +1798,11 @@ func (h *HTTPHandler) buildItemResponse(i *domain.LibraryItem) GetItemResponse {
 			Isbn:                i.Isbn,
 		}
 	case domain.ItemVideo:
+		// Videos created before TV series support are movies
+		videoKind := domain.Movie
+		if i.VideoKind != nil {
+			videoKind = *i.VideoKind
+		}
 		return GetVideoResponse{
 			GetItemResponseBase: baseResponse,
 			Directors:           i.Directors,
@@ -576,6 +1811,33 @@ func (h *HTTPHandler) buildItemResponse(i *domain.LibraryItem) GetItemResponse {
 			ReleaseYear:         i.ReleaseYear,
 			Duration:            i.Duration,
 			TmdbId:              i.TmdbId,
+			Kind:                videoKind,
+			Seasons:             i.Seasons,
+			SeasonCount:         i.SeasonCount,
+			SeasonsLabel:        formatSeasonsLabel(i.Seasons),
+		}
+	case domain.ItemMusic:
+		return GetMusicResponse{
+			GetItemResponseBase: baseResponse,
+			Artists:             i.Artists,
+			Tracklist:           i.Tracklist,
+			Summary:             i.Summary,
+			Label:               i.Label,
+			ReleaseYear:         i.ReleaseYear,
+			Barcode:             i.Barcode,
+		}
+	case domain.ItemBoardGame:
+		return GetBoardGameResponse{
+			GetItemResponseBase: baseResponse,
+			Summary:             i.Summary,
+			Designers:           i.Designers,
+			Publisher:           i.Publisher,
+			MinPlayers:          i.MinPlayers,
+			MaxPlayers:          i.MaxPlayers,
+			PlayTime:            i.PlayTime,
+			ReleaseYear:         i.ReleaseYear,
+			BggId:               i.BggId,
+			Barcode:             i.Barcode,
 		}
 	default:
 		return GetCollectionItemResponse{
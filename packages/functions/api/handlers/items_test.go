@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// itemsServices records the item updates, the other services are not expected to be called
+type itemsServices struct {
+	ports.Services
+	updated *domain.LibraryItem
+}
+
+func (s *itemsServices) UpdateItem(i *domain.LibraryItem, fetchPicture bool, actor domain.Actor) error {
+	s.updated = i
+	return nil
+}
+
+// updateBook sends the body to the book update route, and returns the item passed to the services
+func updateBook(t *testing.T, body string) *domain.LibraryItem {
+	gin.SetMode(gin.TestMode)
+	s := &itemsServices{}
+	h := NewHTTPHandler(s)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("tokenInfo", &tokenInfo{userId: "owner", userName: "owner"})
+	})
+	r.PUT("/libraries/:libraryId/books/:bookId", h.UpdateBook)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/libraries/library/books/book", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if s.updated == nil {
+		t.Fatalf("expected the item to be updated")
+	}
+	return s.updated
+}
+
+func TestUpdateBookOmittedFields(t *testing.T) {
+	// Clients unaware of the fields do not send them, the services keep the current values
+	item := updateBook(t, `{"title": "Dune"}`)
+	if item.Tags != nil {
+		t.Errorf("expected omitted tags to be nil, got %v", item.Tags)
+	}
+	if item.LocationId != nil {
+		t.Errorf("expected omitted location to be nil, got %q", *item.LocationId)
+	}
+	if item.Copies != nil {
+		t.Errorf("expected omitted copies to be nil, got %v", item.Copies)
+	}
+	if item.Acquisition != nil {
+		t.Errorf("expected omitted acquisition to be nil, got %v", item.Acquisition)
+	}
+	if item.CustomValues != nil {
+		t.Errorf("expected omitted custom values to be nil, got %v", item.CustomValues)
+	}
+
+	// An empty list or value removes the values
+	item = updateBook(t, `{"title": "Dune", "tags": [], "locationId": " ", "copies": [], "acquisition": {}, "customValues": {"Signed": " "}}`)
+	if item.Tags == nil || len(item.Tags) != 0 {
+		t.Errorf("expected no tags, got %#v", item.Tags)
+	}
+	if item.Copies == nil || len(item.Copies) != 0 {
+		t.Errorf("expected no copies, got %#v", item.Copies)
+	}
+	if item.Acquisition == nil || *item.Acquisition != (domain.Acquisition{}) {
+		t.Errorf("expected an empty acquisition, got %v", item.Acquisition)
+	}
+	if item.CustomValues == nil || len(item.CustomValues) != 0 {
+		t.Errorf("expected no custom values, got %#v", item.CustomValues)
+	}
+	if item.LocationId == nil || *item.LocationId != "" {
+		t.Errorf("expected an empty location, got %v", item.LocationId)
+	}
+
+	item = updateBook(t, `{"title": "Dune", "tags": [" Sci-fi ", "sci-fi", "", "Classic"], "locationId": "shelf "}`)
+	if expected := []string{"Sci-fi", "Classic"}; !reflect.DeepEqual(item.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, item.Tags)
+	}
+	if item.LocationId == nil || *item.LocationId != "shelf" {
+		t.Errorf("expected location shelf, got %v", item.LocationId)
+	}
+}
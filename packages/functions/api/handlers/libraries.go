@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/slices"
 	"github.com/gin-gonic/gin"
 
 	"github.com/rs/zerolog/log"
@@ -25,9 +26,77 @@ func (h *HTTPHandler) validateLibraryPayload(library *domain.Library) error {
 	if len(library.Description) > 100 {
 		return errors.New("invalid request - description too long (max. 100 chars)")
 	}
+
+	if library.Kind != "" && library.Kind != domain.OwnedLibrary && library.Kind != domain.WishlistLibrary {
+		return errors.New("invalid request - invalid library kind (must be OWNED or WISHLIST)")
+	}
+
+	if len(library.CustomFields) > 20 {
+		return errors.New("invalid request - too many custom fields (max. 20)")
+	}
+
+	names := map[string]bool{}
+	for _, f := range library.CustomFields {
+		if len(f.Name) == 0 || len(f.Name) > 30 {
+			return errors.New("invalid request - invalid custom field name (1 to 30 chars)")
+		}
+
+		key := strings.ToLower(f.Name)
+		if names[key] {
+			return errors.New("invalid request - duplicate custom field name")
+		}
+		names[key] = true
+
+		if !f.Type.IsValid() {
+			return errors.New("invalid request - invalid custom field type (must be TEXT, NUMBER, DATE or ENUM)")
+		}
+
+		if f.Type != domain.CustomEnum && len(f.Options) > 0 {
+			return errors.New("invalid request - options can only be set on ENUM custom fields")
+		}
+
+		if f.Type == domain.CustomEnum && (len(f.Options) == 0 || len(f.Options) > 50) {
+			return errors.New("invalid request - invalid custom field options (1 to 50 options)")
+		}
+
+		for _, o := range f.Options {
+			if len(o) == 0 || len(o) > 50 {
+				return errors.New("invalid request - invalid custom field option (1 to 50 chars)")
+			}
+		}
+	}
 	return nil
 }
 
+// mapCustomFields trims the names and options of the requested custom fields, keeping nil for an omitted list
+func mapCustomFields(fields []CustomFieldRequest) []domain.CustomField {
+	if fields == nil {
+		return nil
+	}
+
+	mapped := []domain.CustomField{}
+	for _, f := range fields {
+		mapped = append(mapped, domain.CustomField{
+			Name:    strings.TrimSpace(f.Name),
+			Type:    f.Type,
+			Options: slices.Map(f.Options, func(o string) string { return strings.TrimSpace(o) }),
+		})
+	}
+	return mapped
+}
+
+func buildCustomFieldsResponse(fields []domain.CustomField) []CustomFieldResponse {
+	response := []CustomFieldResponse{}
+	for _, f := range fields {
+		response = append(response, CustomFieldResponse{
+			Name:    f.Name,
+			Type:    f.Type,
+			Options: f.Options,
+		})
+	}
+	return response
+}
+
 func (h *HTTPHandler) DeleteLibrary(c *gin.Context) {
 	libraryId := c.Param("libraryId")
 
@@ -40,6 +109,12 @@ func (h *HTTPHandler) DeleteLibrary(c *gin.Context) {
 
 	err := h.s.DeleteLibrary(&library)
 	if err != nil {
+		if strings.Contains(err.Error(), "not returned") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to delete library",
 		})
@@ -69,6 +144,8 @@ func (h *HTTPHandler) UpdateLibrary(c *gin.Context) {
 		Name:        strings.TrimSpace(request.Name),
 		Description: strings.TrimSpace(request.Description),
 		OwnerId:     t.userId,
+		// nil keeps the current custom fields
+		CustomFields: mapCustomFields(request.CustomFields),
 	}
 
 	err = h.validateLibraryPayload(&library)
@@ -81,7 +158,7 @@ func (h *HTTPHandler) UpdateLibrary(c *gin.Context) {
 		return
 	}
 
-	err = h.s.UpdateLibrary(&library)
+	err = h.s.UpdateLibrary(&library, t.actor())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to update library",
@@ -104,21 +181,30 @@ func (h *HTTPHandler) ListLibraries(c *gin.Context) {
 	}
 
 	list := []GetLibraryResponse{}
+	ownedItems := 0
 
 	for _, l := range libraries {
+		if l.SharedFrom == nil && l.Kind != domain.WishlistLibrary {
+			ownedItems += l.TotalItems
+		}
 		list = append(list, GetLibraryResponse{
-			Id:          l.Id,
-			Name:        l.Name,
-			Description: l.Description,
-			TotalItems:  l.TotalItems,
-			SharedTo:    l.SharedTo,
-			SharedFrom:  l.SharedFrom,
-			UpdatedAt:   l.UpdatedAt,
+			Id:           l.Id,
+			Name:         l.Name,
+			Description:  l.Description,
+			Kind:         l.Kind,
+			TotalItems:   l.TotalItems,
+			SharedTo:     l.SharedTo,
+			SharedFrom:   l.SharedFrom,
+			UpdatedAt:    l.UpdatedAt,
+			CustomFields: buildCustomFieldsResponse(l.CustomFields),
+			ShareRoles:   l.ShareRoles,
+			Role:         l.ShareRole,
 		})
 	}
 
 	response := GetLibrariesResponse{
-		Libraries: list,
+		Libraries:  list,
+		OwnedItems: ownedItems,
 	}
 	c.JSON(http.StatusOK, response)
 }
@@ -199,7 +285,10 @@ payload:
 
 	{
 		email: <user email>,
+		role: <VIEWER, LENDER or EDITOR>,
 	}
+
+Sharing again with another role changes the role of the user.
 */
 
 func (h *HTTPHandler) ShareLibrary(c *gin.Context) {
@@ -235,16 +324,35 @@ func (h *HTTPHandler) ShareLibrary(c *gin.Context) {
 		return
 	}
 
+	role := domain.ShareViewer
+	if request.Role != nil {
+		role = *request.Role
+	}
+	if !role.IsValid() {
+		log.Error().Msgf("Invalid share role: %s", role)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - invalid share role (must be VIEWER, LENDER or EDITOR)",
+		})
+		return
+	}
+
 	sh := domain.ShareLibrary{
 		SharedFromUserName: t.userName,
 		SharedFromUserId:   t.userId,
 		SharedToUserName:   request.Email,
 		LibraryId:          libraryId,
+		Role:               role,
 	}
 
 	err = h.s.ShareLibrary(&sh)
 
 	if err != nil {
+		if strings.Contains(err.Error(), "already shared") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to share library",
 		})
@@ -254,6 +362,18 @@ func (h *HTTPHandler) ShareLibrary(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// handleShareRoleError answers 403 when the role of the requester on a shared library does not allow the action
+func handleShareRoleError(c *gin.Context, err error) bool {
+	if !strings.Contains(err.Error(), "share role") {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"message": err.Error(),
+	})
+	return true
+}
+
 /*
 payload:
 
@@ -275,10 +395,16 @@ func (h *HTTPHandler) CreateLibrary(c *gin.Context) {
 	t := h.getTokenInfo(c)
 
 	library := domain.Library{
-		Name:        strings.TrimSpace(request.Name),
-		Description: strings.TrimSpace(request.Description),
-		OwnerName:   t.displayName,
-		OwnerId:     t.userId,
+		Name:         strings.TrimSpace(request.Name),
+		Description:  strings.TrimSpace(request.Description),
+		Kind:         domain.OwnedLibrary,
+		OwnerName:    t.displayName,
+		OwnerId:      t.userId,
+		CustomFields: mapCustomFields(request.CustomFields),
+	}
+
+	if request.Kind != nil {
+		library.Kind = *request.Kind
 	}
 
 	err = h.validateLibraryPayload(&library)
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+func TestValidateLibraryPayloadCustomFields(t *testing.T) {
+	tooMany := []domain.CustomField{}
+	for i := 0; i < 21; i++ {
+		tooMany = append(tooMany, domain.CustomField{Name: fmt.Sprintf("Field %d", i), Type: domain.CustomText})
+	}
+	tooManyOptions := []string{}
+	for i := 0; i < 51; i++ {
+		tooManyOptions = append(tooManyOptions, fmt.Sprintf("Option %d", i))
+	}
+
+	tests := []struct {
+		name   string
+		fields []domain.CustomField
+		err    string
+	}{
+		{
+			name:   "no fields",
+			fields: nil,
+		},
+		{
+			name: "valid fields",
+			fields: []domain.CustomField{
+				{Name: "Signed", Type: domain.CustomText},
+				{Name: "Pages", Type: domain.CustomNumber},
+				{Name: "Read on", Type: domain.CustomDate},
+				{Name: "Region", Type: domain.CustomEnum, Options: []string{"EU", "US"}},
+			},
+		},
+		{
+			name:   "too many fields",
+			fields: tooMany,
+			err:    "invalid request - too many custom fields (max. 20)",
+		},
+		{
+			name:   "empty name",
+			fields: []domain.CustomField{{Name: "", Type: domain.CustomText}},
+			err:    "invalid request - invalid custom field name (1 to 30 chars)",
+		},
+		{
+			name:   "name too long",
+			fields: []domain.CustomField{{Name: "A custom field name far too long", Type: domain.CustomText}},
+			err:    "invalid request - invalid custom field name (1 to 30 chars)",
+		},
+		{
+			name: "duplicate name, whatever the case",
+			fields: []domain.CustomField{
+				{Name: "Signed", Type: domain.CustomText},
+				{Name: "signed", Type: domain.CustomNumber},
+			},
+			err: "invalid request - duplicate custom field name",
+		},
+		{
+			name:   "unknown type",
+			fields: []domain.CustomField{{Name: "Signed", Type: "BOOLEAN"}},
+			err:    "invalid request - invalid custom field type (must be TEXT, NUMBER, DATE or ENUM)",
+		},
+		{
+			name:   "options of a non ENUM field",
+			fields: []domain.CustomField{{Name: "Signed", Type: domain.CustomText, Options: []string{"yes"}}},
+			err:    "invalid request - options can only be set on ENUM custom fields",
+		},
+		{
+			name:   "ENUM field without options",
+			fields: []domain.CustomField{{Name: "Region", Type: domain.CustomEnum}},
+			err:    "invalid request - invalid custom field options (1 to 50 options)",
+		},
+		{
+			name:   "ENUM field with too many options",
+			fields: []domain.CustomField{{Name: "Region", Type: domain.CustomEnum, Options: tooManyOptions}},
+			err:    "invalid request - invalid custom field options (1 to 50 options)",
+		},
+		{
+			name:   "empty option",
+			fields: []domain.CustomField{{Name: "Region", Type: domain.CustomEnum, Options: []string{"EU", ""}}},
+			err:    "invalid request - invalid custom field option (1 to 50 chars)",
+		},
+	}
+
+	h := &HTTPHandler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.validateLibraryPayload(&domain.Library{Name: "Books", CustomFields: tt.fields})
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err.Error())
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Loan response models
+
+type OverdueLoanResponse struct {
+	LibraryId   string          `json:"libraryId"`
+	LibraryName string          `json:"libraryName"`
+	ItemId      string          `json:"itemId"`
+	Type        domain.ItemType `json:"type"`
+	Title       string          `json:"title"`
+	Picture     *string         `json:"picture,omitempty"`
+	CopyId      *string         `json:"copyId,omitempty"` // Copy lent, for items with several copies
+	LentTo      string          `json:"lentTo"`
+	DueDate     *time.Time      `json:"dueDate"`
+	DaysOverdue int             `json:"daysOverdue"`
+}
+
+type GetOverdueLoansResponse struct {
+	Loans []OverdueLoanResponse `json:"loans"`
+}
+
+type BorrowedLoanResponse struct {
+	OwnerId     string          `json:"ownerId"`
+	OwnerName   string          `json:"ownerName"`
+	LibraryId   string          `json:"libraryId"`
+	LibraryName string          `json:"libraryName"`
+	ItemId      string          `json:"itemId"`
+	Type        domain.ItemType `json:"type"`
+	Title       string          `json:"title"`
+	CopyId      *string         `json:"copyId,omitempty"` // Copy lent, for items with several copies
+	LentAt      *time.Time      `json:"lentAt"`
+	DueDate     *time.Time      `json:"dueDate,omitempty"`
+	DaysOverdue int             `json:"daysOverdue"`
+}
+
+type GetBorrowedLoansResponse struct {
+	Loans []BorrowedLoanResponse `json:"loans"`
+}
+
+type LendCollectionRequest struct {
+	Event     string  `json:"event,omitempty"`     // Name of the person, when lending by name
+	ContactId string  `json:"contactId,omitempty"` // Contact the items are lent to
+	UserName  string  `json:"userName,omitempty"`  // App user (email) the items are lent to
+	DueDate   *string `json:"dueDate,omitempty"`   // YYYY-MM-DD
+}
+
+type ReturnCollectionRequest struct {
+	ContactId string `json:"contactId,omitempty"` // Only return the items lent to this contact
+}
+
+type CollectionLoanResponse struct {
+	ItemId  string     `json:"itemId"`
+	Title   string     `json:"title"`
+	Volume  *int       `json:"volume,omitempty"`
+	CopyId  *string    `json:"copyId,omitempty"` // Copy lent or returned, for items with several copies
+	LentTo  string     `json:"lentTo,omitempty"` // Empty for the items not lent
+	DueDate *time.Time `json:"dueDate,omitempty"`
+}
+
+type CollectionLendingResponse struct {
+	Loans   []CollectionLoanResponse `json:"loans"`   // Items lent or returned
+	Skipped []CollectionLoanResponse `json:"skipped"` // Items already lent, or not returned
+}
+
+// ListOverdueLoans returns the loans past their due date across all the libraries of the user, oldest due date first
+func (h *HTTPHandler) ListOverdueLoans(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	loans, err := h.s.ListOverdueLoans(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list overdue loans",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	response := GetOverdueLoansResponse{
+		Loans: []OverdueLoanResponse{},
+	}
+	for _, l := range loans {
+		i := l.Item
+		var picture *string
+		if i.PictureUrl != nil && *i.PictureUrl != "" {
+			url := fmt.Sprintf("https://alexandria.isnan.eu/thumbnails/user/%s/library/%s/item/%s",
+				i.OwnerId, i.LibraryId, i.Id)
+			picture = &url
+		}
+
+		response.Loans = append(response.Loans, OverdueLoanResponse{
+			LibraryId:   i.LibraryId,
+			LibraryName: i.LibraryName,
+			ItemId:      i.Id,
+			Type:        i.Type,
+			Title:       i.Title,
+			Picture:     picture,
+			CopyId:      l.CopyId,
+			LentTo:      l.LentTo,
+			DueDate:     l.DueDate,
+			DaysOverdue: int(now.Sub(*l.DueDate).Hours() / 24),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListBorrowedLoans returns the items currently lent to the user by all the owners, most recent first
+func (h *HTTPHandler) ListBorrowedLoans(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	loans, err := h.s.ListBorrowedLoans(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list borrowed items",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	response := GetBorrowedLoansResponse{
+		Loans: []BorrowedLoanResponse{},
+	}
+	for _, l := range loans {
+		response.Loans = append(response.Loans, BorrowedLoanResponse{
+			OwnerId:     l.OwnerId,
+			OwnerName:   l.OwnerName,
+			LibraryId:   l.LibraryId,
+			LibraryName: l.LibraryName,
+			ItemId:      l.ItemId,
+			Type:        l.ItemType,
+			Title:       l.Title,
+			CopyId:      l.CopyId,
+			LentAt:      l.LentAt,
+			DueDate:     l.DueDate,
+			DaysOverdue: l.DaysLate(now),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// LendCollection lends all the items of a collection to the same person at once.
+// Items already lent are skipped and reported, the other ones are lent together or not at all.
+func (h *HTTPHandler) LendCollection(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	collectionId := c.Param("collectionId")
+
+	var request LendCollectionRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if request.ContactId == "" && request.UserName == "" {
+		name := strings.TrimSpace(request.Event)
+		if len(name) == 0 || len(name) > 50 {
+			log.Error().Msgf("Invalid name")
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid request - name missing or too long (max. 50 chars)",
+			})
+			return
+		}
+	}
+
+	var dueDate *time.Time
+	if request.DueDate != nil {
+		dueDate, err = parseDueDate(domain.Lent, *request.DueDate)
+		if err != nil {
+			log.Error().Msg(err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	t := h.getTokenInfo(c)
+	// Lenders of a shared library lend to the contacts of its owner
+	ownerId, err := h.s.ResolveLibraryOwner(t.userId, libraryId, domain.ShareLender)
+	if err != nil {
+		handleCollectionLendingError(c, err, "Failed to lend collection")
+		return
+	}
+
+	contactId, err := h.lendingContactId(ownerId, request.ContactId, request.UserName, request.Event)
+	if err != nil {
+		handleCollectionLendingError(c, err, "Failed to lend collection")
+		return
+	}
+
+	result, err := h.s.LendCollection(t.userId, libraryId, collectionId, contactId, dueDate)
+	if err != nil {
+		handleCollectionLendingError(c, err, "Failed to lend collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildCollectionLendingResponse(result))
+}
+
+// ReturnCollection returns all the lent items of a collection at once, or the ones lent to a contact
+func (h *HTTPHandler) ReturnCollection(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	collectionId := c.Param("collectionId")
+
+	// The body is optional
+	var request ReturnCollectionRequest
+	if c.Request.ContentLength > 0 {
+		err := c.BindJSON(&request)
+		if err != nil {
+			log.Error().Msgf("Invalid request: %s", err.Error())
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request.",
+			})
+			return
+		}
+	}
+
+	t := h.getTokenInfo(c)
+	result, err := h.s.ReturnCollection(t.userId, libraryId, collectionId, request.ContactId)
+	if err != nil {
+		handleCollectionLendingError(c, err, "Failed to return collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildCollectionLendingResponse(result))
+}
+
+func handleCollectionLendingError(c *gin.Context, err error, fallback string) {
+	if handleShareRoleError(c, err) {
+		return
+	}
+
+	var status int
+	switch {
+	case strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "No user"):
+		status = http.StatusNotFound
+	case strings.Contains(err.Error(), "already") || strings.Contains(err.Error(), "no lent item"):
+		// Nothing to lend or return, or contact name taken by another user
+		status = http.StatusConflict
+	case strings.Contains(err.Error(), "too many") || strings.Contains(err.Error(), "wishlist") || strings.Contains(err.Error(), "owner"):
+		status = http.StatusBadRequest
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": fallback,
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message": err.Error(),
+	})
+}
+
+func buildCollectionLendingResponse(result *domain.CollectionLending) CollectionLendingResponse {
+	toResponse := func(loans []domain.Loan) []CollectionLoanResponse {
+		response := []CollectionLoanResponse{}
+		for _, l := range loans {
+			response = append(response, CollectionLoanResponse{
+				ItemId:  l.Item.Id,
+				Title:   l.Item.Title,
+				Volume:  l.Item.Volume,
+				CopyId:  l.CopyId,
+				LentTo:  l.LentTo,
+				DueDate: l.DueDate,
+			})
+		}
+		return response
+	}
+
+	return CollectionLendingResponse{
+		Loans:   toResponse(result.Loans),
+		Skipped: toResponse(result.Skipped),
+	}
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Location request/response models
+
+type CreateLocationRequest struct {
+	Name     string              `json:"name"`
+	Kind     domain.LocationKind `json:"kind"`               // ROOM, BOOKCASE or SHELF
+	ParentId *string             `json:"parentId,omitempty"` // Room of a bookcase, bookcase of a shelf
+}
+
+type CreateLocationResponse struct {
+	Id   string `json:"id"`
+	Path string `json:"path"`
+}
+
+type UpdateLocationRequest struct {
+	Name string `json:"name"`
+}
+
+type GetLocationResponse struct {
+	Id       string              `json:"id"`
+	Name     string              `json:"name"`
+	Kind     domain.LocationKind `json:"kind"`
+	ParentId *string             `json:"parentId,omitempty"`
+	Path     string              `json:"path"` // e.g. "Living room / Bookcase A / Shelf 2"
+}
+
+type GetLocationsResponse struct {
+	Locations []GetLocationResponse `json:"locations"`
+}
+
+type MoveItemsToLocationRequest struct {
+	ItemIds    []string `json:"itemIds"`
+	LocationId *string  `json:"locationId"` // null removes the items from their location
+}
+
+func (h *HTTPHandler) validateLocationPayload(l *domain.Location) error {
+	if len(l.Name) == 0 {
+		return errors.New("invalid request - location name is mandatory")
+	}
+
+	if len(l.Name) > 50 {
+		return errors.New("invalid request - name too long (max. 50 chars)")
+	}
+
+	if l.Kind != domain.Room && l.Kind != domain.Bookcase && l.Kind != domain.Shelf {
+		return errors.New("invalid request - invalid location kind (must be ROOM, BOOKCASE or SHELF)")
+	}
+
+	return nil
+}
+
+// ListLocations returns the locations of a library
+func (h *HTTPHandler) ListLocations(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	t := h.getTokenInfo(c)
+
+	locations, err := h.s.ListLocations(t.userId, libraryId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list locations",
+		})
+		return
+	}
+
+	response := GetLocationsResponse{Locations: []GetLocationResponse{}}
+	for _, l := range locations {
+		response.Locations = append(response.Locations, GetLocationResponse{
+			Id:       l.Id,
+			Name:     l.Name,
+			Kind:     l.Kind,
+			ParentId: l.ParentId,
+			Path:     l.Path,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateLocation creates a room, a bookcase or a shelf
+func (h *HTTPHandler) CreateLocation(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request CreateLocationRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	location := domain.Location{
+		Name:      strings.TrimSpace(request.Name),
+		Kind:      request.Kind,
+		ParentId:  request.ParentId,
+		OwnerId:   t.userId,
+		LibraryId: libraryId,
+	}
+
+	err = h.validateLocationPayload(&location)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.s.CreateLocation(&location)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "location") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to create location",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateLocationResponse{
+		Id:   result.Id,
+		Path: result.Path,
+	})
+}
+
+// RenameLocation renames a location, the stored items are updated asynchronously
+func (h *HTTPHandler) RenameLocation(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	locationId := c.Param("locationId")
+
+	var request UpdateLocationRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if len(name) == 0 || len(name) > 50 {
+		log.Error().Msg("Invalid location name")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - location name is mandatory (max. 50 chars)",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	location := domain.Location{
+		Id:        locationId,
+		Name:      name,
+		OwnerId:   t.userId,
+		LibraryId: libraryId,
+	}
+
+	err = h.s.RenameLocation(&location)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to rename location",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteLocation removes a location without nested locations, its items are no longer located
+func (h *HTTPHandler) DeleteLocation(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	locationId := c.Param("locationId")
+	t := h.getTokenInfo(c)
+
+	location := domain.Location{
+		Id:        locationId,
+		OwnerId:   t.userId,
+		LibraryId: libraryId,
+	}
+
+	err := h.s.DeleteLocation(&location)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "nested locations") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to delete location",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListLocationItems returns the items stored in a location, including its nested locations
+func (h *HTTPHandler) ListLocationItems(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	locationId := c.Param("locationId")
+	continuationToken := c.Query("nextToken")
+	limit := c.DefaultQuery("limit", "10")
+	pageSize, err := strconv.Atoi(limit)
+	if err != nil {
+		pageSize = 10
+	}
+
+	if pageSize > 50 {
+		pageSize = 50
+	}
+
+	t := h.getTokenInfo(c)
+
+	filter := domain.ItemFilter{LocationIds: []string{locationId}}
+	h.listFilteredLibraryItems(c, t.userId, libraryId, filter, continuationToken, pageSize)
+}
+
+// MoveItemsToLocation stores items into a location in bulk
+func (h *HTTPHandler) MoveItemsToLocation(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+
+	var request MoveItemsToLocationRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if len(request.ItemIds) == 0 || len(request.ItemIds) > 100 {
+		log.Error().Msgf("Invalid request: itemIds must have 1-100 entries")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - itemIds must have 1 to 100 entries",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	err = h.s.MoveItemsToLocation(t.userId, libraryId, request.ItemIds, trimOptional(request.LocationId))
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to move items",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"alexandria.isnan.eu/functions/internal/domain"
 	"github.com/gin-gonic/gin"
 	"github.com/lestrrat-go/jwx/jwt"
 
@@ -21,6 +22,15 @@ type tokenInfo struct {
 	approved    bool
 }
 
+// actor returns the user of the token as recorded in the change logs, named by the display name when set
+func (t *tokenInfo) actor() domain.Actor {
+	name := t.displayName
+	if name == "" {
+		name = t.userName
+	}
+	return domain.Actor{UserId: t.userId, UserName: name}
+}
+
 func TokenParser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.Request.Header.Get("Authorization")
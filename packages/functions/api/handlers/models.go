@@ -8,9 +8,11 @@ import (
 
 type DetectRequest struct {
 	Type  int     `json:"type"`
-	Code  string  `json:"code"`            // ISBN for books
+	Code  string  `json:"code"`            // ISBN for books, EAN/UPC barcode for music and board games
 	Image *string `json:"image,omitempty"` // Base64 image for video OCR
-	Title *string `json:"title,omitempty"` // Manual title input for video search
+	Title *string `json:"title,omitempty"` // Manual title input for video and board game search
+	// VideoKind restricts video search to movies or TV series (both when omitted)
+	VideoKind *domain.VideoKind `json:"videoKind,omitempty"`
 }
 
 type DetectedBookResponse struct {
@@ -20,34 +22,111 @@ type DetectedBookResponse struct {
 	Summary    string   `json:"summary"`
 	PictureUrl *string  `json:"pictureUrl,omitempty"`
 	Isbn       string   `json:"isbn"`
+	SeriesName *string  `json:"seriesName,omitempty"`
+	Volume     *int     `json:"volume,omitempty"`
 	Source     string   `json:"source"`
 	Error      *string  `json:"error,omitempty"`
+	// Items already in the owned and shared libraries with the same ISBN
+	Duplicates []DuplicateItemResponse `json:"duplicates,omitempty"`
 }
 
 // DetectedVideoResponse represents a detected video from TMDB
 type DetectedVideoResponse struct {
+	Id          string           `json:"id"`
+	Kind        domain.VideoKind `json:"kind,omitempty"`
+	Title       string           `json:"title"`
+	Summary     string           `json:"summary"`
+	PictureUrl  *string          `json:"pictureUrl,omitempty"`
+	Directors   []string         `json:"directors"`
+	Cast        []string         `json:"cast"`
+	ReleaseYear int              `json:"releaseYear"`
+	Duration    int              `json:"duration"`
+	TmdbId      string           `json:"tmdbId"`
+	// TV series-specific fields
+	SeasonCount  int                      `json:"seasonCount,omitempty"`
+	EpisodeCount int                      `json:"episodeCount,omitempty"`
+	Seasons      []DetectedSeasonResponse `json:"seasons,omitempty"`
+	Source       string                   `json:"source"`
+	Error        *string                  `json:"error,omitempty"`
+	// Items already in the owned and shared libraries with the same TMDB id
+	Duplicates []DuplicateItemResponse `json:"duplicates,omitempty"`
+}
+
+// DuplicateItemResponse references an existing item matching a detected or created one
+type DuplicateItemResponse struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	LibraryId   string `json:"libraryId"`
+	LibraryName string `json:"libraryName"`
+	OwnerId     string `json:"ownerId"`
+	OwnerName   string `json:"ownerName,omitempty"`
+	Wanted      bool   `json:"wanted,omitempty"` // In a wishlist
+}
+
+// DetectedSeasonResponse represents one season of a detected TV series
+type DetectedSeasonResponse struct {
+	Number       int    `json:"number"`
+	Name         string `json:"name"`
+	EpisodeCount int    `json:"episodeCount"`
+}
+
+// DetectedMusicResponse represents a detected music album from its barcode
+type DetectedMusicResponse struct {
+	Id          string   `json:"id"`
+	Title       string   `json:"title"`
+	Artists     []string `json:"artists"`
+	Tracklist   []string `json:"tracklist"`
+	Label       string   `json:"label"`
+	ReleaseYear int      `json:"releaseYear"`
+	PictureUrl  *string  `json:"pictureUrl,omitempty"`
+	Barcode     string   `json:"barcode"`
+	Source      string   `json:"source"`
+	Error       *string  `json:"error,omitempty"`
+}
+
+// DetectedBoardGameResponse represents a detected board game from BoardGameGeek
+type DetectedBoardGameResponse struct {
 	Id          string   `json:"id"`
 	Title       string   `json:"title"`
 	Summary     string   `json:"summary"`
 	PictureUrl  *string  `json:"pictureUrl,omitempty"`
-	Directors   []string `json:"directors"`
-	Cast        []string `json:"cast"`
+	Designers   []string `json:"designers"`
+	Publisher   string   `json:"publisher"`
+	MinPlayers  int      `json:"minPlayers"`
+	MaxPlayers  int      `json:"maxPlayers"`
+	PlayTime    int      `json:"playTime"` // Minutes
 	ReleaseYear int      `json:"releaseYear"`
-	Duration    int      `json:"duration"`
-	TmdbId      string   `json:"tmdbId"`
+	BggId       string   `json:"bggId"`
+	Barcode     *string  `json:"barcode,omitempty"` // Set when detected from a barcode
 	Source      string   `json:"source"`
 	Error       *string  `json:"error,omitempty"`
 }
 
 type DetectResponse struct {
-	DetectedBooks  []DetectedBookResponse  `json:"detectedBooks,omitempty"`
-	DetectedVideos []DetectedVideoResponse `json:"detectedVideos,omitempty"`
-	ExtractedTitle *string                 `json:"extractedTitle,omitempty"` // Title extracted via OCR
+	DetectedBooks      []DetectedBookResponse      `json:"detectedBooks,omitempty"`
+	DetectedVideos     []DetectedVideoResponse     `json:"detectedVideos,omitempty"`
+	DetectedMusic      []DetectedMusicResponse     `json:"detectedMusic,omitempty"`
+	DetectedBoardGames []DetectedBoardGameResponse `json:"detectedBoardGames,omitempty"`
+	ExtractedTitle     *string                     `json:"extractedTitle,omitempty"` // Title extracted via OCR
+}
+
+type CustomFieldRequest struct {
+	Name    string                 `json:"name"`
+	Type    domain.CustomFieldType `json:"type"`              // TEXT, NUMBER, DATE or ENUM
+	Options []string               `json:"options,omitempty"` // Allowed values of an ENUM field
+}
+
+type CustomFieldResponse struct {
+	Name    string                 `json:"name"`
+	Type    domain.CustomFieldType `json:"type"`
+	Options []string               `json:"options,omitempty"`
 }
 
 type CreateLibraryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	Kind         *domain.LibraryKind  `json:"kind,omitempty"` // OWNED (default) or WISHLIST
+	CustomFields []CustomFieldRequest `json:"customFields,omitempty"`
 }
 
 type CreateLibraryResponse struct {
@@ -57,22 +136,59 @@ type CreateLibraryResponse struct {
 }
 
 type GetLibraryResponse struct {
-	Id          string     `json:"id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	TotalItems  int        `json:"totalItems"`
-	UpdatedAt   *time.Time `json:"updatedAt"`
-	SharedTo    []string   `json:"sharedTo"`
-	SharedFrom  *string    `json:"sharedFrom,omitempty"`
+	Id           string                `json:"id"`
+	Name         string                `json:"name"`
+	Description  string                `json:"description"`
+	Kind         domain.LibraryKind    `json:"kind"`
+	TotalItems   int                   `json:"totalItems"`
+	UpdatedAt    *time.Time            `json:"updatedAt"`
+	SharedTo     []string              `json:"sharedTo"`
+	SharedFrom   *string               `json:"sharedFrom,omitempty"`
+	CustomFields []CustomFieldResponse `json:"customFields"`
+	// Role of each user the library is shared to, on the owner own libraries
+	ShareRoles map[string]domain.ShareRole `json:"shareRoles,omitempty"`
+	// Role of the requester, on the libraries shared to them
+	Role domain.ShareRole `json:"role,omitempty"`
 }
 
 type GetLibrariesResponse struct {
-	Libraries []GetLibraryResponse `json:"libraries"`
+	Libraries  []GetLibraryResponse `json:"libraries"`
+	OwnedItems int                  `json:"ownedItems"` // Items of the requester own libraries, wishlists excluded
 }
 
 type UpdateLibraryRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	CustomFields []CustomFieldRequest `json:"customFields"` // Omitted to keep the current schema, empty to remove all fields
+}
+
+type ItemCopyRequest struct {
+	Id        string                `json:"id,omitempty"` // Empty for a new copy
+	Format    *domain.ItemFormat    `json:"format,omitempty"`
+	Condition *domain.ItemCondition `json:"condition,omitempty"`
+}
+
+type ItemCopyResponse struct {
+	Id        string                `json:"id"`
+	Format    *domain.ItemFormat    `json:"format,omitempty"`
+	Condition *domain.ItemCondition `json:"condition,omitempty"`
+	LentTo    *string               `json:"lentTo,omitempty"`
+	LentToId  *string               `json:"lentToId,omitempty"` // Contact the copy is lent to
+	DueDate   *time.Time            `json:"dueDate,omitempty"`  // Return date of the current loan
+}
+
+type AcquisitionRequest struct {
+	Date     *string  `json:"date,omitempty"` // YYYY-MM-DD
+	Price    *float64 `json:"price,omitempty"`
+	Currency string   `json:"currency,omitempty"` // ISO 4217 code, e.g. EUR
+	Place    string   `json:"place,omitempty"`    // Where the item was bought
+}
+
+type AcquisitionResponse struct {
+	Date     *time.Time `json:"date,omitempty"`
+	Price    *float64   `json:"price,omitempty"`
+	Currency string     `json:"currency,omitempty"`
+	Place    string     `json:"place,omitempty"`
 }
 
 // Marker interface
@@ -81,19 +197,36 @@ type GetItemResponse interface {
 }
 
 type GetItemResponseBase struct {
-	Id             string          `json:"id"`
-	Type           domain.ItemType `json:"type"`
-	Title          string          `json:"title"`
-	Picture        *string         `json:"picture,omitempty"`
-	PictureUrl     *string         `json:"pictureUrl,omitempty"`
-	LibraryId      *string         `json:"libraryId,omitempty"`
-	LibraryName    *string         `json:"libraryName,omitempty"`
-	OwnerId        string          `json:"ownerId"`
-	LentTo         *string         `json:"lentTo,omitempty"`
-	CollectionId   *string         `json:"collectionId,omitempty"`
-	CollectionName *string         `json:"collectionName,omitempty"`
-	Order          *int            `json:"order,omitempty"`
-	UpdatedAt      *time.Time      `json:"updatedAt,omitempty"`
+	Id             string                `json:"id"`
+	Type           domain.ItemType       `json:"type"`
+	Title          string                `json:"title"`
+	Picture        *string               `json:"picture,omitempty"`
+	PictureUrl     *string               `json:"pictureUrl,omitempty"`
+	LibraryId      *string               `json:"libraryId,omitempty"`
+	LibraryName    *string               `json:"libraryName,omitempty"`
+	OwnerId        string                `json:"ownerId"`
+	LentTo         *string               `json:"lentTo,omitempty"`
+	LentToId       *string               `json:"lentToId,omitempty"` // Contact the item is lent to
+	DueDate        *time.Time            `json:"dueDate,omitempty"`  // Return date of the current loan
+	CollectionId   *string               `json:"collectionId,omitempty"`
+	CollectionName *string               `json:"collectionName,omitempty"`
+	Order          *int                  `json:"order,omitempty"`
+	Volume         *int                  `json:"volume,omitempty"`
+	Tags           []string              `json:"tags,omitempty"`
+	Wanted         bool                  `json:"wanted,omitempty"` // Wishlist item
+	LocationId     *string               `json:"locationId,omitempty"`
+	LocationPath   *string               `json:"locationPath,omitempty"` // e.g. "Living room / Bookcase A / Shelf 2"
+	Format         *domain.ItemFormat    `json:"format,omitempty"`
+	Condition      *domain.ItemCondition `json:"condition,omitempty"` // Assessed on the last return, copies have their own
+	Copies         []ItemCopyResponse    `json:"copies,omitempty"`
+	Acquisition    *AcquisitionResponse  `json:"acquisition,omitempty"`
+	Status         *domain.ItemStatus    `json:"status,omitempty"`
+	StatusDate     *time.Time            `json:"statusDate,omitempty"`
+	MyRating       *int                  `json:"myRating,omitempty"`      // Requester own rating
+	AverageRating  *float64              `json:"averageRating,omitempty"` // Average of the owner and sharers ratings
+	RatingCount    int                   `json:"ratingCount,omitempty"`
+	CustomValues   map[string]string     `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	UpdatedAt      *time.Time            `json:"updatedAt,omitempty"`
 }
 
 func (g GetItemResponseBase) getType() string { return "" }
@@ -113,62 +246,100 @@ type GetLibrariesContentResponse struct {
 }
 
 type CreateBookRequest struct {
-	Title        string   `json:"title"`
-	Summary      string   `json:"summary"`
-	Authors      []string `json:"authors"`
-	Isbn         string   `json:"isbn"`
-	PictureUrl   *string  `json:"pictureUrl,omitempty"`
-	CollectionId *string  `json:"collectionId,omitempty"`
-	Order        *int     `json:"order,omitempty"`
+	Title        string              `json:"title"`
+	Summary      string              `json:"summary"`
+	Authors      []string            `json:"authors"`
+	Isbn         string              `json:"isbn"`
+	PictureUrl   *string             `json:"pictureUrl,omitempty"`
+	CollectionId *string             `json:"collectionId,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	LocationId   *string             `json:"locationId,omitempty"`
+	Format       *domain.ItemFormat  `json:"format,omitempty"`
+	Copies       []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition  *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order        *int                `json:"order,omitempty"`
+	Volume       *int                `json:"volume,omitempty"`
+	SeriesName   *string             `json:"seriesName,omitempty"` // From detection, files the book into the matching series collection
 }
 
 type CreateBookResponse struct {
-	Id        string     `json:"id"`
-	UpdatedAt *time.Time `json:"updatedAt"`
+	Id           string                  `json:"id"`
+	UpdatedAt    *time.Time              `json:"updatedAt"`
+	CollectionId *string                 `json:"collectionId,omitempty"` // Set when filed into a collection, including a matched series
+	Duplicates   []DuplicateItemResponse `json:"duplicates,omitempty"`
 }
 
 type UpdateBookRequest struct {
-	Title         string   `json:"title"`
-	Summary       string   `json:"summary"`
-	Authors       []string `json:"authors"`
-	Isbn          string   `json:"isbn"`
-	PictureUrl    *string  `json:"pictureUrl,omitempty"`
-	CollectionId  *string  `json:"collectionId,omitempty"`
-	Order         *int     `json:"order,omitempty"`
-	UpdatePicture *bool    `json:"updatePicture,omitempty"`
+	Title         string              `json:"title"`
+	Summary       string              `json:"summary"`
+	Authors       []string            `json:"authors"`
+	Isbn          string              `json:"isbn"`
+	PictureUrl    *string             `json:"pictureUrl,omitempty"`
+	CollectionId  *string             `json:"collectionId,omitempty"`
+	Tags          []string            `json:"tags,omitempty"`
+	LocationId    *string             `json:"locationId,omitempty"`
+	Format        *domain.ItemFormat  `json:"format,omitempty"`
+	Copies        []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition   *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues  map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order         *int                `json:"order,omitempty"`
+	Volume        *int                `json:"volume,omitempty"`
+	UpdatePicture *bool               `json:"updatePicture,omitempty"`
 }
 
 // Video request/response models
 type CreateVideoRequest struct {
-	Title        string   `json:"title"`
-	Summary      string   `json:"summary"`
-	Directors    []string `json:"directors"`
-	Cast         []string `json:"cast"`
-	ReleaseYear  *int     `json:"releaseYear,omitempty"`
-	Duration     *int     `json:"duration,omitempty"`
-	TmdbId       *string  `json:"tmdbId,omitempty"`
-	PictureUrl   *string  `json:"pictureUrl,omitempty"`
-	CollectionId *string  `json:"collectionId,omitempty"`
-	Order        *int     `json:"order,omitempty"`
+	Title        string              `json:"title"`
+	Summary      string              `json:"summary"`
+	Directors    []string            `json:"directors"`
+	Cast         []string            `json:"cast"`
+	ReleaseYear  *int                `json:"releaseYear,omitempty"`
+	Duration     *int                `json:"duration,omitempty"`
+	TmdbId       *string             `json:"tmdbId,omitempty"`
+	PictureUrl   *string             `json:"pictureUrl,omitempty"`
+	CollectionId *string             `json:"collectionId,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	LocationId   *string             `json:"locationId,omitempty"`
+	Format       *domain.ItemFormat  `json:"format,omitempty"`
+	Copies       []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition  *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order        *int                `json:"order,omitempty"`
+	// TV series-specific fields
+	Kind        *domain.VideoKind `json:"kind,omitempty"`    // MOVIE (default) or TV_SERIES
+	Seasons     []int             `json:"seasons,omitempty"` // Seasons physically contained
+	SeasonCount *int              `json:"seasonCount,omitempty"`
 }
 
 type CreateVideoResponse struct {
-	Id        string     `json:"id"`
-	UpdatedAt *time.Time `json:"updatedAt"`
+	Id         string                  `json:"id"`
+	UpdatedAt  *time.Time              `json:"updatedAt"`
+	Duplicates []DuplicateItemResponse `json:"duplicates,omitempty"`
 }
 
 type UpdateVideoRequest struct {
-	Title         string   `json:"title"`
-	Summary       string   `json:"summary"`
-	Directors     []string `json:"directors"`
-	Cast          []string `json:"cast"`
-	ReleaseYear   *int     `json:"releaseYear,omitempty"`
-	Duration      *int     `json:"duration,omitempty"`
-	TmdbId        *string  `json:"tmdbId,omitempty"`
-	PictureUrl    *string  `json:"pictureUrl,omitempty"`
-	CollectionId  *string  `json:"collectionId,omitempty"`
-	Order         *int     `json:"order,omitempty"`
-	UpdatePicture *bool    `json:"updatePicture,omitempty"`
+	Title         string              `json:"title"`
+	Summary       string              `json:"summary"`
+	Directors     []string            `json:"directors"`
+	Cast          []string            `json:"cast"`
+	ReleaseYear   *int                `json:"releaseYear,omitempty"`
+	Duration      *int                `json:"duration,omitempty"`
+	TmdbId        *string             `json:"tmdbId,omitempty"`
+	PictureUrl    *string             `json:"pictureUrl,omitempty"`
+	CollectionId  *string             `json:"collectionId,omitempty"`
+	Tags          []string            `json:"tags,omitempty"`
+	LocationId    *string             `json:"locationId,omitempty"`
+	Format        *domain.ItemFormat  `json:"format,omitempty"`
+	Copies        []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition   *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues  map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order         *int                `json:"order,omitempty"`
+	UpdatePicture *bool               `json:"updatePicture,omitempty"`
+	// TV series-specific fields
+	Kind        *domain.VideoKind `json:"kind,omitempty"`    // MOVIE (default) or TV_SERIES
+	Seasons     []int             `json:"seasons,omitempty"` // Seasons physically contained
+	SeasonCount *int              `json:"seasonCount,omitempty"`
 }
 
 // GetVideoResponse for video items
@@ -180,10 +351,140 @@ type GetVideoResponse struct {
 	ReleaseYear *int     `json:"releaseYear,omitempty"`
 	Duration    *int     `json:"duration,omitempty"`
 	TmdbId      *string  `json:"tmdbId,omitempty"`
+	// TV series-specific fields
+	Kind         domain.VideoKind `json:"kind"`
+	Seasons      []int            `json:"seasons,omitempty"`
+	SeasonCount  *int             `json:"seasonCount,omitempty"`
+	SeasonsLabel *string          `json:"seasonsLabel,omitempty"` // e.g. "Season 1–3, 5"
 }
 
 func (g GetVideoResponse) getType() string { return domain.ItemVideo.String() }
 
+// Music request/response models
+type CreateMusicRequest struct {
+	Title        string              `json:"title"`
+	Summary      string              `json:"summary"`
+	Artists      []string            `json:"artists"`
+	Tracklist    []string            `json:"tracklist"`
+	Label        *string             `json:"label,omitempty"`
+	ReleaseYear  *int                `json:"releaseYear,omitempty"`
+	Barcode      *string             `json:"barcode,omitempty"`
+	PictureUrl   *string             `json:"pictureUrl,omitempty"`
+	CollectionId *string             `json:"collectionId,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	LocationId   *string             `json:"locationId,omitempty"`
+	Format       *domain.ItemFormat  `json:"format,omitempty"`
+	Copies       []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition  *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order        *int                `json:"order,omitempty"`
+}
+
+type CreateMusicResponse struct {
+	Id        string     `json:"id"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type UpdateMusicRequest struct {
+	Title         string              `json:"title"`
+	Summary       string              `json:"summary"`
+	Artists       []string            `json:"artists"`
+	Tracklist     []string            `json:"tracklist"`
+	Label         *string             `json:"label,omitempty"`
+	ReleaseYear   *int                `json:"releaseYear,omitempty"`
+	Barcode       *string             `json:"barcode,omitempty"`
+	PictureUrl    *string             `json:"pictureUrl,omitempty"`
+	CollectionId  *string             `json:"collectionId,omitempty"`
+	Tags          []string            `json:"tags,omitempty"`
+	LocationId    *string             `json:"locationId,omitempty"`
+	Format        *domain.ItemFormat  `json:"format,omitempty"`
+	Copies        []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition   *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues  map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order         *int                `json:"order,omitempty"`
+	UpdatePicture *bool               `json:"updatePicture,omitempty"`
+}
+
+// GetMusicResponse for music items
+type GetMusicResponse struct {
+	GetItemResponseBase
+	Artists     []string `json:"artists"`
+	Tracklist   []string `json:"tracklist"`
+	Summary     string   `json:"summary"`
+	Label       *string  `json:"label,omitempty"`
+	ReleaseYear *int     `json:"releaseYear,omitempty"`
+	Barcode     *string  `json:"barcode,omitempty"`
+}
+
+func (g GetMusicResponse) getType() string { return domain.ItemMusic.String() }
+
+// Board game request/response models
+type CreateBoardGameRequest struct {
+	Title        string              `json:"title"`
+	Summary      string              `json:"summary"`
+	Designers    []string            `json:"designers"`
+	Publisher    *string             `json:"publisher,omitempty"`
+	MinPlayers   *int                `json:"minPlayers,omitempty"`
+	MaxPlayers   *int                `json:"maxPlayers,omitempty"`
+	PlayTime     *int                `json:"playTime,omitempty"` // Minutes
+	ReleaseYear  *int                `json:"releaseYear,omitempty"`
+	BggId        *string             `json:"bggId,omitempty"`
+	Barcode      *string             `json:"barcode,omitempty"`
+	PictureUrl   *string             `json:"pictureUrl,omitempty"`
+	CollectionId *string             `json:"collectionId,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	LocationId   *string             `json:"locationId,omitempty"`
+	Format       *domain.ItemFormat  `json:"format,omitempty"`
+	Copies       []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition  *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order        *int                `json:"order,omitempty"`
+}
+
+type CreateBoardGameResponse struct {
+	Id        string     `json:"id"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type UpdateBoardGameRequest struct {
+	Title         string              `json:"title"`
+	Summary       string              `json:"summary"`
+	Designers     []string            `json:"designers"`
+	Publisher     *string             `json:"publisher,omitempty"`
+	MinPlayers    *int                `json:"minPlayers,omitempty"`
+	MaxPlayers    *int                `json:"maxPlayers,omitempty"`
+	PlayTime      *int                `json:"playTime,omitempty"` // Minutes
+	ReleaseYear   *int                `json:"releaseYear,omitempty"`
+	BggId         *string             `json:"bggId,omitempty"`
+	Barcode       *string             `json:"barcode,omitempty"`
+	PictureUrl    *string             `json:"pictureUrl,omitempty"`
+	CollectionId  *string             `json:"collectionId,omitempty"`
+	Tags          []string            `json:"tags,omitempty"`
+	LocationId    *string             `json:"locationId,omitempty"`
+	Format        *domain.ItemFormat  `json:"format,omitempty"`
+	Copies        []ItemCopyRequest   `json:"copies,omitempty"` // Physical copies, existing ones are referenced by id
+	Acquisition   *AcquisitionRequest `json:"acquisition,omitempty"`
+	CustomValues  map[string]string   `json:"customValues,omitempty"` // Keyed by custom field name of the library
+	Order         *int                `json:"order,omitempty"`
+	UpdatePicture *bool               `json:"updatePicture,omitempty"`
+}
+
+// GetBoardGameResponse for board game items
+type GetBoardGameResponse struct {
+	GetItemResponseBase
+	Summary     string   `json:"summary"`
+	Designers   []string `json:"designers"`
+	Publisher   *string  `json:"publisher,omitempty"`
+	MinPlayers  *int     `json:"minPlayers,omitempty"`
+	MaxPlayers  *int     `json:"maxPlayers,omitempty"`
+	PlayTime    *int     `json:"playTime,omitempty"`
+	ReleaseYear *int     `json:"releaseYear,omitempty"`
+	BggId       *string  `json:"bggId,omitempty"`
+	Barcode     *string  `json:"barcode,omitempty"`
+}
+
+func (g GetBoardGameResponse) getType() string { return domain.ItemBoardGame.String() }
+
 // GetCollectionItemResponse for collection items (type = 2)
 // Collections are returned as items in the items list for unified sorting
 type GetCollectionItemResponse struct {
@@ -201,12 +502,28 @@ type GetCollectionWithItemsResponse struct {
 	Items       []GetItemResponse `json:"items,omitempty"`   // Nested items within collection
 	ItemCount   int               `json:"itemCount"`         // Total items in collection (from DynamoDB)
 	Partial     bool              `json:"partial,omitempty"` // True if continues from previous page
+	// Series info
+	IsSeries     bool `json:"isSeries,omitempty"`
+	TotalVolumes *int `json:"totalVolumes,omitempty"`
 }
 
 func (g GetCollectionWithItemsResponse) getType() string { return domain.ItemCollection.String() }
 
+type MoveItemRequest struct {
+	LibraryId    string  `json:"libraryId"`              // Target library
+	CollectionId *string `json:"collectionId,omitempty"` // Collection of the target library, defaults to the one with the same name
+}
+
+type MoveItemResponse struct {
+	Id           string     `json:"id"`
+	LibraryId    string     `json:"libraryId"`
+	CollectionId *string    `json:"collectionId,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt"`
+}
+
 type ShareRequest struct {
-	Email string `json:"email"`
+	Email string            `json:"email"`
+	Role  *domain.ShareRole `json:"role,omitempty"` // VIEWER when not set
 }
 
 type UnshareRequest struct {
@@ -214,7 +531,9 @@ type UnshareRequest struct {
 }
 
 type SearchRequest struct {
-	Terms []string `json:"terms"`
+	Terms        []string          `json:"terms"`
+	Tags         []string          `json:"tags,omitempty"`         // Only return items carrying all these tags
+	CustomValues map[string]string `json:"customValues,omitempty"` // Only return items having all these custom values, keyed by field name
 }
 
 type SearchResponse struct {
@@ -222,14 +541,37 @@ type SearchResponse struct {
 }
 
 type ItemHistoryEntryRequest struct {
-	Type  domain.ItemEventType `json:"type"`
-	Event string               `json:"event"`
+	Type      domain.ItemEventType `json:"type"`
+	Event     string               `json:"event"`
+	CopyId    string               `json:"copyId,omitempty"`    // Copy lent or returned, for items with several copies
+	DueDate   *string              `json:"dueDate,omitempty"`   // YYYY-MM-DD, optional return date when lending
+	ContactId string               `json:"contactId,omitempty"` // Contact to lend to, event is then ignored
+	UserName  string               `json:"userName,omitempty"`  // App user to lend to, event is then ignored
+	// Condition assessed when returning
+	Condition        *domain.ItemCondition `json:"condition,omitempty"`
+	ConditionNote    string                `json:"conditionNote,omitempty"`
+	ConditionPicture string                `json:"conditionPicture,omitempty"` // Base64 encoded photo of the returned item
+}
+
+type UpdateItemStatusRequest struct {
+	Status domain.ItemStatus `json:"status"`
+	Date   *string           `json:"date,omitempty"` // YYYY-MM-DD, defaults to today
 }
 
 type ItemHistoryEntry struct {
-	Date  *time.Time           `json:"date"`
-	Type  domain.ItemEventType `json:"type"`
-	Event string               `json:"event"`
+	Date      *time.Time           `json:"date"`
+	Type      domain.ItemEventType `json:"type"`
+	Event     string               `json:"event"`
+	CopyId    *string              `json:"copyId,omitempty"`
+	DueDate   *time.Time           `json:"dueDate,omitempty"`
+	ContactId *string              `json:"contactId,omitempty"` // Contact of a lend or return event
+	// Condition assessed on return
+	Condition        *domain.ItemCondition `json:"condition,omitempty"`
+	ConditionNote    *string               `json:"conditionNote,omitempty"`
+	ConditionPicture *string               `json:"conditionPicture,omitempty"` // Url of the photo of the returned item
+	// User who changed their status
+	UserId   string `json:"userId,omitempty"`
+	UserName string `json:"userName,omitempty"`
 }
 
 type ItemHistoryEntryListResponse struct {
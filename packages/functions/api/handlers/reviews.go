@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Review request/response models
+
+type UpdateItemReviewRequest struct {
+	Rating *int   `json:"rating,omitempty"`
+	Notes  string `json:"notes"`
+}
+
+type GetItemReviewResponse struct {
+	Rating    *int       `json:"rating,omitempty"`
+	Notes     string     `json:"notes"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (h *HTTPHandler) validateReviewPayload(r *domain.ItemReview) error {
+	if r.Rating == nil && len(r.Notes) == 0 {
+		return errors.New("invalid request - rating or notes is mandatory")
+	}
+
+	if r.Rating != nil && (*r.Rating < 1 || *r.Rating > 5) {
+		return errors.New("invalid request - invalid rating (must be between 1 and 5)")
+	}
+
+	if len(r.Notes) > 2000 {
+		return errors.New("invalid request - notes too long (max. 2000 chars)")
+	}
+
+	return nil
+}
+
+// GetItemReview returns the requester rating and notes on an item
+func (h *HTTPHandler) GetItemReview(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+	t := h.getTokenInfo(c)
+
+	review, err := h.s.GetItemReview(t.userId, libraryId, itemId)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Item not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to get review",
+		})
+		return
+	}
+
+	if review == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Review not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetItemReviewResponse{
+		Rating:    review.Rating,
+		Notes:     review.Notes,
+		UpdatedAt: review.UpdatedAt,
+	})
+}
+
+// UpdateItemReview creates or replaces the requester rating and notes on an item
+func (h *HTTPHandler) UpdateItemReview(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+
+	var request UpdateItemReviewRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	review := domain.ItemReview{
+		LibraryId: libraryId,
+		ItemId:    itemId,
+		UserId:    t.userId,
+		Rating:    request.Rating,
+		Notes:     strings.TrimSpace(request.Notes),
+	}
+
+	err = h.validateReviewPayload(&review)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = h.s.SaveItemReview(&review)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Item not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to save review",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteItemReview removes the requester rating and notes on an item
+func (h *HTTPHandler) DeleteItemReview(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+	t := h.getTokenInfo(c)
+
+	err := h.s.DeleteItemReview(t.userId, libraryId, itemId)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") || strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to delete review",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
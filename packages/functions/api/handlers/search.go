@@ -1,10 +1,8 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
-	"alexandria.isnan.eu/functions/internal/domain"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -22,7 +20,7 @@ func (h *HTTPHandler) Search(c *gin.Context) {
 
 	t := h.getTokenInfo(c)
 
-	items, err := h.s.SearchItems(t.userId, request.Terms)
+	items, err := h.s.SearchItems(t.userId, request.Terms, normalizeTags(request.Tags), normalizeCustomValues(request.CustomValues))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "Failed to search items",
@@ -33,49 +31,7 @@ func (h *HTTPHandler) Search(c *gin.Context) {
 	itemsResponse := []GetItemResponse{}
 
 	for _, i := range items {
-		// Construct CloudFront URL if item has a picture in S3
-		var pictureCloudFrontUrl *string
-		if i.PictureUrl != nil && *i.PictureUrl != "" {
-			url := fmt.Sprintf("https://alexandria.isnan.eu/thumbnails/user/%s/library/%s/item/%s",
-				i.OwnerId, i.LibraryId, i.Id)
-			pictureCloudFrontUrl = &url
-		}
-
-		baseResponse := GetItemResponseBase{
-			Id:             i.Id,
-			Type:           i.Type,
-			Title:          i.Title,
-			Picture:        pictureCloudFrontUrl,
-			LibraryId:      &i.LibraryId,
-			LibraryName:    &i.LibraryName,
-			LentTo:         i.LentTo,
-			OwnerId:        i.OwnerId,
-			PictureUrl:     i.PictureUrl,
-			CollectionId:   i.CollectionId,
-			CollectionName: i.CollectionName,
-			Order:          i.Order,
-			UpdatedAt:      i.UpdatedAt,
-		}
-
-		switch i.Type {
-		case domain.ItemBook:
-			itemsResponse = append(itemsResponse, GetBookResponse{
-				GetItemResponseBase: baseResponse,
-				Authors:             i.Authors,
-				Summary:             i.Summary,
-				Isbn:                i.Isbn,
-			})
-		case domain.ItemVideo:
-			itemsResponse = append(itemsResponse, GetVideoResponse{
-				GetItemResponseBase: baseResponse,
-				Directors:           i.Directors,
-				Cast:                i.Cast,
-				Summary:             i.Summary,
-				ReleaseYear:         i.ReleaseYear,
-				Duration:            i.Duration,
-				TmdbId:              i.TmdbId,
-			})
-		}
+		itemsResponse = append(itemsResponse, h.buildItemResponse(i))
 	}
 
 	response := SearchResponse{
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Tag request/response models
+
+type UpdateTagRequest struct {
+	Name string `json:"name"`
+}
+
+type GetTagResponse struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	ItemCount int    `json:"itemCount"`
+}
+
+type GetTagsResponse struct {
+	Tags []GetTagResponse `json:"tags"`
+}
+
+func (h *HTTPHandler) validateTagPayload(t *domain.Tag) error {
+	if len(t.Name) == 0 {
+		return errors.New("invalid request - tag name is mandatory")
+	}
+
+	if len(t.Name) > 30 {
+		return errors.New("invalid request - tag too long (max. 30 chars)")
+	}
+
+	return nil
+}
+
+// ListTags returns the tag index of a library
+func (h *HTTPHandler) ListTags(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	t := h.getTokenInfo(c)
+
+	tags, err := h.s.ListTags(t.userId, libraryId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list tags",
+		})
+		return
+	}
+
+	response := GetTagsResponse{Tags: []GetTagResponse{}}
+	for _, tag := range tags {
+		response.Tags = append(response.Tags, GetTagResponse{
+			Id:        tag.Id,
+			Name:      tag.Name,
+			ItemCount: tag.ItemCount,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RenameTag renames a tag on all the items of a library
+func (h *HTTPHandler) RenameTag(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	tagId := c.Param("tagId")
+
+	var request UpdateTagRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	tag := domain.Tag{
+		Id:        tagId,
+		Name:      strings.TrimSpace(request.Name),
+		OwnerId:   t.userId,
+		LibraryId: libraryId,
+	}
+
+	err = h.validateTagPayload(&tag)
+	if err != nil {
+		log.Error().Msg(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	err = h.s.RenameTag(&tag)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "already exists") {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to rename tag",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteTag removes a tag from the library index and from its items
+func (h *HTTPHandler) DeleteTag(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	tagId := c.Param("tagId")
+	t := h.getTokenInfo(c)
+
+	tag := domain.Tag{
+		Id:        tagId,
+		OwnerId:   t.userId,
+		LibraryId: libraryId,
+	}
+
+	err := h.s.DeleteTag(&tag)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to delete tag",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Trash response models
+
+type TrashedLibraryResponse struct {
+	Id         string             `json:"id"`
+	Name       string             `json:"name"`
+	Kind       domain.LibraryKind `json:"kind"`
+	TotalItems int                `json:"totalItems"`
+	DeletedAt  *time.Time         `json:"deletedAt"`
+	ExpiresAt  *time.Time         `json:"expiresAt"` // Purge date
+}
+
+type TrashedItemResponse struct {
+	Id          string          `json:"id"`
+	Type        domain.ItemType `json:"type"`
+	Title       string          `json:"title"`
+	Picture     *string         `json:"picture,omitempty"`
+	LibraryId   string          `json:"libraryId"`
+	LibraryName string          `json:"libraryName"`
+	DeletedAt   *time.Time      `json:"deletedAt"`
+	ExpiresAt   *time.Time      `json:"expiresAt"` // Purge date
+}
+
+type GetTrashResponse struct {
+	Libraries []TrashedLibraryResponse `json:"libraries"`
+	Items     []TrashedItemResponse    `json:"items"` // Items deleted on their own, not the items of the trashed libraries
+}
+
+// ListTrash returns the libraries and items deleted by the user, until they are purged
+func (h *HTTPHandler) ListTrash(c *gin.Context) {
+	t := h.getTokenInfo(c)
+
+	trash, err := h.s.ListTrash(t.userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to list trash",
+		})
+		return
+	}
+
+	response := GetTrashResponse{
+		Libraries: []TrashedLibraryResponse{},
+		Items:     []TrashedItemResponse{},
+	}
+	for _, l := range trash.Libraries {
+		response.Libraries = append(response.Libraries, TrashedLibraryResponse{
+			Id:         l.Id,
+			Name:       l.Name,
+			Kind:       l.Kind,
+			TotalItems: l.TotalItems,
+			DeletedAt:  l.DeletedAt,
+			ExpiresAt:  l.ExpiresAt,
+		})
+	}
+	for _, i := range trash.Items {
+		// The picture is kept until the item is purged
+		var picture *string
+		if i.PictureUrl != nil && *i.PictureUrl != "" {
+			url := fmt.Sprintf("https://alexandria.isnan.eu/thumbnails/user/%s/library/%s/item/%s",
+				i.OwnerId, i.LibraryId, i.Id)
+			picture = &url
+		}
+
+		response.Items = append(response.Items, TrashedItemResponse{
+			Id:          i.Id,
+			Type:        i.Type,
+			Title:       i.Title,
+			Picture:     picture,
+			LibraryId:   i.LibraryId,
+			LibraryName: i.LibraryName,
+			DeletedAt:   i.DeletedAt,
+			ExpiresAt:   i.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreLibrary moves a library back from the trash
+func (h *HTTPHandler) RestoreLibrary(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	t := h.getTokenInfo(c)
+
+	library, err := h.s.RestoreLibrary(t.userId, libraryId)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown library") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to restore library",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetLibraryResponse{
+		Id:           library.Id,
+		Name:         library.Name,
+		Description:  library.Description,
+		Kind:         library.Kind,
+		TotalItems:   library.TotalItems,
+		UpdatedAt:    library.UpdatedAt,
+		SharedTo:     library.SharedTo,
+		ShareRoles:   library.ShareRoles,
+		CustomFields: buildCustomFieldsResponse(library.CustomFields),
+	})
+}
+
+// RestoreItem moves an item back from the trash into its library, which must not be in the trash
+func (h *HTTPHandler) RestoreItem(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+	t := h.getTokenInfo(c)
+
+	item, err := h.s.RestoreItem(t.userId, libraryId, itemId)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown item") || strings.Contains(err.Error(), "unknown library") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to restore item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.buildItemResponse(item))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Valuation response models
+
+type ValuationEntryResponse struct {
+	Id              string             `json:"id"` // Library id, collection id or item type name
+	Name            string             `json:"name"`
+	LibraryId       string             `json:"libraryId,omitempty"` // Set for collections
+	ItemCount       int                `json:"itemCount"`
+	ValuedItemCount int                `json:"valuedItemCount"` // Items having a purchase price
+	Totals          map[string]float64 `json:"totals"`          // By currency, e.g. {"EUR": 1234.5}
+}
+
+type GetValuationResponse struct {
+	Libraries   []ValuationEntryResponse `json:"libraries"`
+	Collections []ValuationEntryResponse `json:"collections"`
+	Types       []ValuationEntryResponse `json:"types"`
+}
+
+// GetValuation returns the inventory value of the owned and shared libraries, or of a single library
+func (h *HTTPHandler) GetValuation(c *gin.Context) {
+	libraryId := c.Query("library")
+	t := h.getTokenInfo(c)
+
+	valuation, err := h.s.GetValuation(t.userId, libraryId)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to compute valuation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, GetValuationResponse{
+		Libraries:   buildValuationEntries(valuation.Libraries),
+		Collections: buildValuationEntries(valuation.Collections),
+		Types:       buildValuationEntries(valuation.Types),
+	})
+}
+
+func buildValuationEntries(entries []domain.ValuationEntry) []ValuationEntryResponse {
+	result := []ValuationEntryResponse{}
+	for _, e := range entries {
+		result = append(result, ValuationEntryResponse{
+			Id:              e.Id,
+			Name:            e.Name,
+			LibraryId:       e.LibraryId,
+			ItemCount:       e.ItemCount,
+			ValuedItemCount: e.ValuedItemCount,
+			Totals:          e.Totals,
+		})
+	}
+	return result
+}
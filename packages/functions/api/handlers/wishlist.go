@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Wishlist request/response models
+
+type AcquireWishlistItemRequest struct {
+	LibraryId string `json:"libraryId"` // Owned library receiving the item
+}
+
+type AcquireWishlistItemResponse struct {
+	Id        string     `json:"id"`
+	LibraryId string     `json:"libraryId"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+// AcquireWishlistItem converts a wishlist item into an owned item of another library
+func (h *HTTPHandler) AcquireWishlistItem(c *gin.Context) {
+	libraryId := c.Param("libraryId")
+	itemId := c.Param("itemId")
+
+	var request AcquireWishlistItemRequest
+	err := c.BindJSON(&request)
+	if err != nil {
+		log.Error().Msgf("Invalid request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request.",
+		})
+		return
+	}
+
+	if len(request.LibraryId) == 0 {
+		log.Error().Msg("Target library missing")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid request - target library is mandatory",
+		})
+		return
+	}
+
+	t := h.getTokenInfo(c)
+
+	item, err := h.s.AcquireWishlistItem(t.userId, libraryId, itemId, request.LibraryId)
+	if err != nil {
+		if handleShareRoleError(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "unknown item") || strings.Contains(err.Error(), "unknown library") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "wishlist") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to acquire item",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, AcquireWishlistItemResponse{
+		Id:        item.Id,
+		LibraryId: item.LibraryId,
+		UpdatedAt: item.UpdatedAt,
+	})
+}
@@ -9,22 +9,66 @@ import (
 type Database interface {
 	PutLibrary(l *domain.Library) error
 	UpdateLibrary(l *domain.Library) error
-	DeleteLibrary(l *domain.Library) error
+	TrashLibrary(l *domain.Library) error
 	GetLibrary(ownerId string, libraryId string) (*domain.Library, error)
 	QueryLibraries(ownerId string) ([]domain.Library, error)
 	QueryItemsByLibrary(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.LibraryContent, error)
 	QueryLibraryContentGrouped(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.GroupedLibraryContent, error)
 	PutLibraryItem(i *domain.LibraryItem) error
 	UpdateLibraryItem(i *domain.LibraryItem) error
+	TrashLibraryItem(i *domain.LibraryItem) error
+	MoveLibraryItem(from *domain.LibraryItem, to *domain.LibraryItem) error
+	// DeleteLibraryItem removes an item for good along with its history, reviews and statuses
 	DeleteLibraryItem(i *domain.LibraryItem) error
+	// Batch methods return the errors by item id of the items not written
+	GetLibraryItems(ownerId string, libraryId string, itemIds []string) (map[string]*domain.LibraryItem, error)
+	PutLibraryItems(ownerId string, libraryId string, items []*domain.LibraryItem) map[string]error
+	UpdateLibraryItems(items []*domain.LibraryItem) map[string]error
+	TrashLibraryItems(ownerId string, libraryId string, items []*domain.LibraryItem) map[string]error
 	ShareLibrary(s *domain.ShareLibrary) error
 	UnshareLibrary(s *domain.UnshareLibrary) error
+	UpdateLibraryShareRole(s *domain.ShareLibrary) error
 	GetLibraryItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error)
 	GetSharedLibrary(ownerId string, libraryId string) (string, error)
+	// GetLibraryShare returns the share of a library to a user with its role, nil if not shared to the user
+	GetLibraryShare(userId string, libraryId string) (*domain.LibraryShare, error)
 	GetMatchedItems([]domain.IndexItem) ([]*domain.LibraryItem, error)
-	PutItemEvent(i *domain.LibraryItem, evtType domain.ItemEventType, evt string, date *time.Time) error
+	QueryFilteredItems(ownerId string, libraryId string, filter *domain.ItemFilter, continuationToken string, pageSize int) (*domain.LibraryContent, error)
+	QueryItemsAcquisition(ownerId string, libraryId string) ([]*domain.LibraryItem, error)
+	QueryItemIdentifiers(ownerId string, libraryId string, itemType domain.ItemType) ([]*domain.LibraryItem, error)
+	// PutItemEvent and PutItemCopyEvent store the lending state of lend and return events from the item,
+	// which must hold the due dates of its loans after the event. The contact loan, if any, is stored along.
+	PutItemEvent(i *domain.LibraryItem, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan) error
+	PutItemCopyEvent(i *domain.LibraryItem, copyId string, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan) error
+	// PutLendingEvents records the events of several items in one transaction, one event per item
+	PutLendingEvents(events []domain.LendingEvent, date *time.Time) error
 	QueryItemEvents(i *domain.LibraryItem, continuationToken string, pageSize int) (*domain.ItemHistory, error)
 	DeleteItemEvents(i *domain.LibraryItem) error
+	// QueryOverdueItems returns the items of an owner having a loan due before the given date
+	QueryOverdueItems(ownerId string, before time.Time) ([]*domain.LibraryItem, error)
+	// QueryLentItems returns the items of an owner with a current loan, and the items having copies
+	QueryLentItems(ownerId string) ([]*domain.LibraryItem, error)
+	// Change log methods - field-level changes of items (keyed by item id), collections and libraries, most recent first
+	PutItemChanges(ownerId string, libraryId string, changes map[string]*domain.ChangeEntry) error
+	PutCollectionChange(c *domain.Collection, e *domain.ChangeEntry) error
+	PutLibraryChange(l *domain.Library, e *domain.ChangeEntry) error
+	QueryItemChanges(ownerId string, libraryId string, itemId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	QueryCollectionChanges(ownerId string, libraryId string, collectionId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	QueryLibraryChanges(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	GetItemReview(ownerId string, libraryId string, itemId string, userId string) (*domain.ItemReview, error)
+	GetItemReviews(userId string, items []*domain.LibraryItem) (map[string]*domain.ItemReview, error)
+	PutItemReview(r *domain.ItemReview, previous *domain.ItemReview) error
+	DeleteItemReview(r *domain.ItemReview) error
+	// Item statuses are kept per user, PutItemStatus also records the change in the item history
+	GetItemStatus(ownerId string, libraryId string, itemId string, userId string) (*domain.UserItemStatus, error)
+	GetItemStatuses(userId string, items []*domain.LibraryItem) (map[string]*domain.UserItemStatus, error)
+	PutItemStatus(i *domain.LibraryItem, s *domain.UserItemStatus, actor domain.Actor) error
+	// Trash methods, trashed libraries and items are unknown to the other methods
+	QueryTrash(ownerId string) (*domain.Trash, error)
+	GetTrashedLibrary(ownerId string, libraryId string) (*domain.Library, error)
+	RestoreLibrary(l *domain.Library) error
+	GetTrashedLibraryItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error)
+	RestoreLibraryItem(i *domain.LibraryItem) error
 	// Collection methods
 	PutCollection(c *domain.Collection) error
 	UpdateCollection(c *domain.Collection) error
@@ -34,4 +78,42 @@ type Database interface {
 	QueryCollectionsByLibrary(ownerId string, libraryId string) ([]domain.Collection, error)
 	IncrementCollectionItemCount(ownerId string, libraryId string, collectionId string, delta int) error
 	GetMaxOrderInCollection(ownerId string, libraryId string, collectionId string) (int, error)
+	QueryCollectionVolumes(ownerId string, libraryId string, collectionId string) ([]int, error)
+	// QueryCollectionItems returns the items of a collection, trashed items excepted
+	QueryCollectionItems(ownerId string, libraryId string, collectionId string) ([]*domain.LibraryItem, error)
+	// Tag methods
+	PutTag(t *domain.Tag) error
+	UpdateTag(t *domain.Tag) error
+	DeleteTag(t *domain.Tag) error
+	GetTag(ownerId string, libraryId string, tagId string) (*domain.Tag, error)
+	QueryTagsByLibrary(ownerId string, libraryId string) ([]domain.Tag, error)
+	IncrementTagItemCount(ownerId string, libraryId string, tagId string, delta int) error
+	// Location methods
+	PutLocation(l *domain.Location) error
+	UpdateLocation(l *domain.Location) error
+	DeleteLocation(l *domain.Location) error
+	GetLocation(ownerId string, libraryId string, locationId string) (*domain.Location, error)
+	QueryLocationsByLibrary(ownerId string, libraryId string) ([]domain.Location, error)
+	UpdateItemsLocation(ownerId string, libraryId string, itemIds []string, l *domain.Location) error
+	// Contact methods - contacts are read with their loans
+	PutContact(c *domain.Contact) error
+	UpdateContact(c *domain.Contact) error
+	DeleteContact(c *domain.Contact) error
+	GetContact(ownerId string, contactId string) (*domain.Contact, error)
+	QueryContacts(ownerId string) ([]*domain.Contact, error)
+	PutContactLoans(loans []domain.ContactLoan) error
+	// QueryBorrowedLoans returns the current loans to an app user across all the owners
+	QueryBorrowedLoans(borrowerId string) ([]domain.ContactLoan, error)
+	// Borrow request methods - requests are stored with the library owner, Get methods return nil if not found
+	PutBorrowRequest(r *domain.BorrowRequest) error
+	UpdateBorrowRequestStatus(r *domain.BorrowRequest) error
+	DeleteBorrowRequest(r *domain.BorrowRequest) error
+	GetBorrowRequest(ownerId string, requestId string) (*domain.BorrowRequest, error)
+	GetSentBorrowRequest(requesterId string, requestId string) (*domain.BorrowRequest, error)
+	QueryReceivedBorrowRequests(ownerId string) ([]*domain.BorrowRequest, error)
+	QuerySentBorrowRequests(requesterId string) ([]*domain.BorrowRequest, error)
+	// Calendar feed methods - one feed per owner, GetCalendarFeed returns nil if none was created
+	PutCalendarFeed(f *domain.CalendarFeed) error
+	DeleteCalendarFeed(ownerId string) error
+	GetCalendarFeed(ownerId string) (*domain.CalendarFeed, error)
 }
@@ -1,6 +1,8 @@
 package ports
 
 import (
+	"time"
+
 	"alexandria.isnan.eu/functions/internal/domain"
 )
 
@@ -11,19 +13,41 @@ type BookResolver interface {
 
 // VideoResolver resolves video metadata from external sources
 type VideoResolver interface {
-	// ResolveByTitle searches for videos by title
+	// ResolveByTitle searches for movies by title
 	ResolveByTitle(title string, ch chan []domain.ResolvedVideo)
+	// ResolveTvSeriesByTitle searches for TV series by title, including season and episode counts
+	ResolveTvSeriesByTitle(title string, ch chan []domain.ResolvedVideo)
+	Name() string
+}
+
+// MusicResolver resolves music album metadata from EAN/UPC barcodes
+type MusicResolver interface {
+	Resolve(code string, ch chan []domain.ResolvedMusic)
+	Name() string
+}
+
+// BoardGameResolver resolves board game metadata from a title or an EAN/UPC barcode
+type BoardGameResolver interface {
+	ResolveByTitle(title string, ch chan []domain.ResolvedBoardGame)
+	ResolveByBarcode(code string, ch chan []domain.ResolvedBoardGame)
 	Name() string
 }
 
 type Services interface {
 	ResolveBook(code string) []domain.ResolvedBook
 	// ResolveVideo resolves video metadata from title (extracted via OCR or manual input)
-	ResolveVideo(title string) []domain.ResolvedVideo
+	// A nil kind searches both movies and TV series
+	ResolveVideo(title string, kind *domain.VideoKind) []domain.ResolvedVideo
+	// ResolveMusic resolves music album metadata from an EAN/UPC barcode
+	ResolveMusic(code string) []domain.ResolvedMusic
+	// ResolveBoardGame resolves board game metadata from a title, or from an EAN/UPC barcode when isBarcode is set
+	ResolveBoardGame(query string, isBarcode bool) []domain.ResolvedBoardGame
 	// ExtractTextFromImage extracts text from an image using OCR
 	ExtractTextFromImage(imageBase64 string) (string, error)
 	CreateLibrary(l *domain.Library) (*domain.Library, error)
-	UpdateLibrary(l *domain.Library) error
+	// UpdateLibrary, UpdateItem, UpdateCollection and the batch updates record the modified fields in the change log of the entity
+	UpdateLibrary(l *domain.Library, actor domain.Actor) error
+	// DeleteLibrary and DeleteItem move the library or item to the trash, where it is kept until the retention period ends
 	DeleteLibrary(l *domain.Library) error
 	ListLibraries(ownerId string) ([]domain.Library, error)
 	ListItemsByLibrary(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.LibraryContent, error)
@@ -31,18 +55,102 @@ type Services interface {
 	ListItemsByLibraryGrouped(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.GroupedLibraryContent, error)
 	CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error)
 	DeleteItem(i *domain.LibraryItem) error
-	UpdateItem(i *domain.LibraryItem, fetchPicture bool) error
+	// MoveItem moves an item to another library of its owner, keeping its history, reviews and picture
+	MoveItem(userId string, libraryId string, itemId string, targetLibraryId string, collectionId *string) (*domain.LibraryItem, error)
+	UpdateItem(i *domain.LibraryItem, fetchPicture bool, actor domain.Actor) error
+	// ApplyItemOperations applies a batch of operations to the items of an owned library, or of a library shared to the user as editor, results are in the operations order
+	ApplyItemOperations(userId string, libraryId string, ops []domain.ItemOperation, actor domain.Actor) ([]domain.ItemOperationResult, error)
+	// ShareLibrary shares a library to a user with a role, or changes the role of an existing share
 	ShareLibrary(sh *domain.ShareLibrary) error
 	UnshareLibrary(sh *domain.UnshareLibrary) error
-	SearchItems(ownerId string, terms []string) ([]*domain.LibraryItem, error)
-	LendItem(ownerId string, libraryId string, itemId string, lendTo string) error
-	ReturnItem(ownerId string, libraryId string, itemId string, from string) error
+	// ResolveLibraryOwner returns the owner of a library the user acts on with the role: the user for its own libraries,
+	// the owner of a library shared to the user with at least this role
+	ResolveLibraryOwner(userId string, libraryId string, role domain.ShareRole) (string, error)
+	// FindDuplicates returns, for each candidate, the items with the same ISBN (books) or TMDB id (videos)
+	// in the libraries owned by or shared to the user
+	FindDuplicates(userId string, candidates []*domain.LibraryItem) ([][]*domain.LibraryItem, error)
+	// SearchItems searches items matching all the terms, and carrying all the tags and custom values when set
+	SearchItems(ownerId string, terms []string, tags []string, customValues map[string]string) ([]*domain.LibraryItem, error)
+	// LendItem and ReturnItem apply to one copy (copyId) of the items having several copies, copyId is empty otherwise.
+	// Items are lent to a contact of the library owner, the due date of a loan is optional.
+	// Libraries shared to the user as lender or editor are accepted.
+	LendItem(userId string, libraryId string, itemId string, copyId string, contactId string, dueDate *time.Time) error
+	ReturnItem(userId string, libraryId string, itemId string, copyId string, from string, assessment *domain.ReturnAssessment) error
+	// ListOverdueLoans returns the loans of the items of all the libraries of the user not returned by their due date,
+	// oldest due date first
+	ListOverdueLoans(ownerId string) ([]domain.Loan, error)
+	// LendCollection and ReturnCollection apply to all the items of a collection at once, skipping and reporting
+	// the items already lent (lend) or not lent (return). ReturnCollection returns all the loans when contactId is empty.
+	LendCollection(userId string, libraryId string, collectionId string, contactId string, dueDate *time.Time) (*domain.CollectionLending, error)
+	ReturnCollection(userId string, libraryId string, collectionId string, contactId string) (*domain.CollectionLending, error)
+	// SetItemStatus records a reading/watching status change of the user, dated now when date is nil
+	SetItemStatus(userId string, libraryId string, itemId string, status domain.ItemStatus, date *time.Time, actor domain.Actor) error
+	// ListFilteredItems returns the library items matching the filter (reading/watching status, tag, location)
+	ListFilteredItems(ownerId string, libraryId string, filter *domain.ItemFilter, continuationToken string, pageSize int) (*domain.LibraryContent, error)
+	// GetValuation totals the purchase prices of the items of one library, or of all the libraries accessible to the user when libraryId is empty
+	GetValuation(userId string, libraryId string) (*domain.Valuation, error)
 	GetLibraryItemHistory(ownerId string, libraryId string, itemId string, continuationToken string, pageSize int) (*domain.ItemHistory, error)
 	DeleteLibraryItemHistory(ownerId string, libraryId string, itemId string) error
+	// Change log methods - who modified which fields, and when
+	GetItemChanges(ownerId string, libraryId string, itemId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	GetCollectionChanges(ownerId string, libraryId string, collectionId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	GetLibraryChanges(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.ChangeLog, error)
+	// Review methods - personal rating and notes, available on owned and shared libraries
+	GetItemReview(userId string, libraryId string, itemId string) (*domain.ItemReview, error)
+	SaveItemReview(r *domain.ItemReview) error
+	DeleteItemReview(userId string, libraryId string, itemId string) error
+	// Tag methods - tags are created on the fly when tagging items
+	ListTags(userId string, libraryId string) ([]domain.Tag, error)
+	RenameTag(t *domain.Tag) error
+	DeleteTag(t *domain.Tag) error
+	// Location methods - rooms, bookcases and shelves where items are stored
+	ListLocations(userId string, libraryId string) ([]domain.Location, error)
+	CreateLocation(l *domain.Location) (*domain.Location, error)
+	RenameLocation(l *domain.Location) error
+	DeleteLocation(l *domain.Location) error
+	// MoveItemsToLocation stores items into a location, or removes them from any location when locationId is nil
+	MoveItemsToLocation(userId string, libraryId string, itemIds []string, locationId *string) error
+	// Contact methods - people items are lent to, with their loans and lending statistics
+	ListContacts(ownerId string) ([]*domain.Contact, error)
+	GetContact(ownerId string, contactId string) (*domain.Contact, error)
+	CreateContact(c *domain.Contact) (*domain.Contact, error)
+	UpdateContact(c *domain.Contact) error
+	DeleteContact(ownerId string, contactId string) error
+	// ResolveContact returns the contact with the given name (case-insensitive), created if none exists
+	ResolveContact(ownerId string, name string) (*domain.Contact, error)
+	// ResolveUserContact returns the contact linked to the app user with the given user name, linked or created if none is
+	ResolveUserContact(ownerId string, userName string) (*domain.Contact, error)
+	// ListBorrowedLoans returns the items currently lent to the user by all the owners, most recent first
+	ListBorrowedLoans(userId string) ([]domain.ContactLoan, error)
+	// Borrow request methods - users of a shared library ask its owner to lend them items, listings are most recent first
+	RequestBorrow(r *domain.BorrowRequest) (*domain.BorrowRequest, error)
+	ListReceivedBorrowRequests(ownerId string) ([]*domain.BorrowRequest, error)
+	ListSentBorrowRequests(requesterId string) ([]*domain.BorrowRequest, error)
+	// AcceptBorrowRequest lends the item to the requester, copyId and dueDate replace the requested ones when set
+	AcceptBorrowRequest(ownerId string, requestId string, copyId string, dueDate *time.Time) error
+	DeclineBorrowRequest(ownerId string, requestId string) error
+	// CancelBorrowRequest removes a request sent by the requester, pending or answered
+	CancelBorrowRequest(requesterId string, requestId string) error
+	// Calendar feed methods - the loans calendar is read with a token instead of a user session
+	// CreateCalendarFeed returns the new token of the feed, replacing the previous one
+	CreateCalendarFeed(ownerId string) (string, error)
+	GetCalendarFeed(ownerId string) (*domain.CalendarFeed, error)
+	DeleteCalendarFeed(ownerId string) error
+	// ListCalendarLoans returns the current loans of the owner when the token matches their feed
+	ListCalendarLoans(ownerId string, token string) ([]domain.CalendarLoan, error)
+	// AcquireWishlistItem moves a wishlist item into an owned library, keeping its metadata and picture
+	AcquireWishlistItem(userId string, libraryId string, itemId string, targetLibraryId string) (*domain.LibraryItem, error)
+	// Trash methods - trashed libraries and items are restored with their content
+	ListTrash(ownerId string) (*domain.Trash, error)
+	RestoreLibrary(ownerId string, libraryId string) (*domain.Library, error)
+	RestoreItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error)
 	// Collection methods
 	CreateCollection(c *domain.Collection) (*domain.Collection, error)
-	UpdateCollection(c *domain.Collection) error
+	// UpdateCollection keeps the series flag of the collection, and its total volumes unless set, when keepSeries is set
+	UpdateCollection(c *domain.Collection, keepSeries bool, actor domain.Actor) error
 	DeleteCollection(c *domain.Collection) error
 	GetCollection(ownerId string, libraryId string, collectionId string) (*domain.Collection, error)
 	ListCollectionsByLibrary(ownerId string, libraryId string) ([]domain.Collection, error)
+	// GetSeriesVolumes reports owned and missing volumes of a series collection
+	GetSeriesVolumes(ownerId string, libraryId string, collectionId string) (*domain.SeriesVolumes, error)
 }
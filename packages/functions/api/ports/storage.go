@@ -11,6 +11,10 @@ type Storage interface {
 	GetPicture(ownerId string, libraryId string, itemId string) ([]byte, error)
 	DeletePicture(ownerId string, libraryId string, itemId string) error
 	DeletePictures(ownerId string, libraryId string) error
+	// PutReturnPicture stores the photo taken when an item was returned, next to the item picture
+	PutReturnPicture(ownerId string, libraryId string, itemId string, pictureId string, picture []byte) error
+	// MoveReturnPictures moves the return photos of an item to another library
+	MoveReturnPictures(ownerId string, fromLibraryId string, toLibraryId string, itemId string) error
 	// GetBlugeIndex downloads and extracts the Bluge index, returns path to index directory
 	GetBlugeIndex() (string, func(), error)
 	// GetSharedLibraries returns the shared libraries map (sharedToId -> []SharedLibraryEntry)
@@ -0,0 +1,250 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// GetLibraryItems returns the items of a library by id, unknown and trashed items are absent from the result
+func (d *dynamo) GetLibraryItems(ownerId string, libraryId string, itemIds []string) (map[string]*domain.LibraryItem, error) {
+	result := map[string]*domain.LibraryItem{}
+
+	// BatchGetItem accepts up to 100 keys
+	for _, chunk := range slices.ChunkBy(itemIds, 100) {
+		if len(chunk) == 0 {
+			continue
+		}
+
+		keys := []map[string]types.AttributeValue{}
+		for _, id := range chunk {
+			keys = append(keys, map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(ownerId)},
+				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(libraryId, id)},
+			})
+		}
+
+		requestItems := map[string]types.KeysAndAttributes{tableName: {Keys: keys}}
+		for attempt := 0; len(requestItems) > 0; attempt++ {
+			if attempt == 5 {
+				msg := "unable to get items"
+				log.Error().Str("libraryId", libraryId).Msg(msg)
+				return nil, errors.New(msg)
+			}
+
+			res, err := d.client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				log.Error().Str("libraryId", libraryId).Msgf("Failed to batch get items: %s", err.Error())
+				return nil, err
+			}
+
+			for _, item := range res.Responses[tableName] {
+				record := persistence.LibraryItem{}
+				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+					log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+					continue
+				}
+				if record.DeletedAt != nil {
+					continue
+				}
+				result[record.Id] = mapRecordToLibraryItem(&record)
+			}
+
+			requestItems = res.UnprocessedKeys
+		}
+	}
+
+	return result, nil
+}
+
+// PutLibraryItems inserts new items of a library, and adds them to the library total once.
+// Returns the errors by item id of the items not written.
+func (d *dynamo) PutLibraryItems(ownerId string, libraryId string, items []*domain.LibraryItem) map[string]error {
+	failed := map[string]error{}
+	requests := []types.WriteRequest{}
+	ids := map[string]string{}
+
+	for _, i := range items {
+		item, err := attributevalue.MarshalMap(libraryItemToRecord(i))
+		if err != nil {
+			log.Error().Str("title", i.Title).Msgf("Failed to marshal item: %s", err.Error())
+			failed[i.Id] = err
+			continue
+		}
+
+		ids[persistence.MakeLibraryItemSK(i.LibraryId, i.Id)] = i.Id
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	for id, err := range d.writeItemRequests(requests, ids) {
+		failed[id] = err
+	}
+
+	d.updateLibraryTotalItems(ownerId, libraryId, len(items)-len(failed))
+
+	return failed
+}
+
+// UpdateLibraryItems updates items by transactions of 100 items. Items of a failed transaction are updated one by one,
+// so that an item removed in the meantime does not fail the others.
+// Returns the errors by item id of the items not updated.
+func (d *dynamo) UpdateLibraryItems(items []*domain.LibraryItem) map[string]error {
+	failed := map[string]error{}
+
+	for _, chunk := range slices.ChunkBy(items, 100) {
+		if len(chunk) == 0 {
+			continue
+		}
+
+		transactItems := []types.TransactWriteItem{}
+		for _, i := range chunk {
+			expr, _ := expression.NewBuilder().WithUpdate(libraryItemUpdate(i)).Build()
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+					},
+					ConditionExpression:       aws.String("attribute_exists(PK) and attribute_exists(SK) and attribute_not_exists(DeletedAt)"),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+					UpdateExpression:          expr.Update(),
+				},
+			})
+		}
+
+		_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		})
+		if err == nil {
+			continue
+		}
+
+		log.Warn().Msgf("Failed to update items by transaction, updating one by one: %s", err.Error())
+		for _, i := range chunk {
+			if err := d.UpdateLibraryItem(i); err != nil {
+				log.Error().Str("id", i.Id).Msgf("Failed to update item: %s", err.Error())
+				failed[i.Id] = err
+			}
+		}
+	}
+
+	return failed
+}
+
+// TrashLibraryItems moves items of a library to the trash, by transactions of 99 items also updating the library total.
+// Items of a failed transaction are trashed one by one, so that an item removed in the meantime does not fail the others.
+// Returns the errors by item id of the items not trashed.
+func (d *dynamo) TrashLibraryItems(ownerId string, libraryId string, items []*domain.LibraryItem) map[string]error {
+	failed := map[string]error{}
+
+	for _, chunk := range slices.ChunkBy(items, 99) {
+		if len(chunk) == 0 {
+			continue
+		}
+
+		transactItems := []types.TransactWriteItem{libraryTotalItemsUpdate(ownerId, libraryId, -len(chunk))}
+		for _, i := range chunk {
+			transactItems = append(transactItems, libraryItemTrashUpdate(i))
+		}
+
+		_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		})
+		if err == nil {
+			continue
+		}
+
+		log.Warn().Msgf("Failed to trash items by transaction, trashing one by one: %s", err.Error())
+		for _, i := range chunk {
+			if err := d.TrashLibraryItem(i); err != nil {
+				failed[i.Id] = err
+			}
+		}
+	}
+
+	return failed
+}
+
+// writeItemRequests sends item write requests by chunks of 25.
+// Returns the errors by item id (ids maps item SKs to item ids), unprocessed requests are failures.
+func (d *dynamo) writeItemRequests(requests []types.WriteRequest, ids map[string]string) map[string]error {
+	failed := map[string]error{}
+
+	requestId := func(r types.WriteRequest) string {
+		key := map[string]types.AttributeValue{}
+		if r.PutRequest != nil {
+			key = r.PutRequest.Item
+		} else if r.DeleteRequest != nil {
+			key = r.DeleteRequest.Key
+		}
+		if sk, ok := key["SK"].(*types.AttributeValueMemberS); ok {
+			return ids[sk.Value]
+		}
+		return ""
+	}
+
+	for _, c := range slices.ChunkBy(requests, 25) {
+		if len(c) == 0 {
+			continue
+		}
+		res, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				tableName: c,
+			},
+		})
+		if err != nil {
+			log.Error().Msgf("Failed to batch write items: %s", err.Error())
+			for _, r := range c {
+				failed[requestId(r)] = err
+			}
+			continue
+		}
+
+		for _, r := range res.UnprocessedItems[tableName] {
+			failed[requestId(r)] = errors.New("item not processed, retry later")
+		}
+	}
+
+	return failed
+}
+
+// updateLibraryTotalItems adds delta to the TotalItems attribute of a library
+func (d *dynamo) updateLibraryTotalItems(ownerId string, libraryId string, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(libraryId)},
+		},
+		UpdateExpression: aws.String("ADD TotalItems :delta"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":delta": &types.AttributeValueMemberN{
+				Value: fmt.Sprintf("%d", delta),
+			},
+		},
+	})
+
+	if err != nil {
+		log.Warn().Str("libraryId", libraryId).Msgf("Failed to update library total items: %s", err.Error())
+	}
+}
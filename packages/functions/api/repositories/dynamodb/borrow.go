@@ -0,0 +1,232 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// PutBorrowRequest creates a new borrow request
+func (d *dynamo) PutBorrowRequest(r *domain.BorrowRequest) error {
+	record := persistence.BorrowRequest{
+		PK:            persistence.MakeBorrowRequestPK(r.OwnerId),
+		SK:            persistence.MakeBorrowRequestSK(r.Id),
+		GSI1PK:        persistence.MakeBorrowRequestGSI1PK(r.RequesterId),
+		GSI1SK:        persistence.MakeBorrowRequestGSI1SK(r.Id),
+		Id:            r.Id,
+		OwnerId:       r.OwnerId,
+		OwnerName:     r.OwnerName,
+		LibraryId:     r.LibraryId,
+		LibraryName:   r.LibraryName,
+		ItemId:        r.ItemId,
+		Type:          int(r.ItemType),
+		Title:         r.Title,
+		CopyId:        r.CopyId,
+		RequesterId:   r.RequesterId,
+		RequesterName: r.RequesterName,
+		Message:       r.Message,
+		DueDate:       r.DueDate,
+		Status:        string(r.Status),
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+		EntityType:    persistence.TypeBorrowRequest,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("itemId", r.ItemId).Msgf("Failed to marshal borrow request: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		log.Error().Str("itemId", r.ItemId).Msgf("Failed to put borrow request: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateBorrowRequestStatus records the answer of the owner to a borrow request
+func (d *dynamo) UpdateBorrowRequestStatus(r *domain.BorrowRequest) error {
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestPK(r.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestSK(r.Id)},
+		},
+		UpdateExpression:    aws.String("SET #status = :status, UpdatedAt = :updatedAt"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "Status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status":    &types.AttributeValueMemberS{Value: string(r.Status)},
+			":updatedAt": &types.AttributeValueMemberS{Value: r.UpdatedAt.Format(time.RFC3339Nano)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("requestId", r.Id).Msgf("Failed to update borrow request: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (d *dynamo) DeleteBorrowRequest(r *domain.BorrowRequest) error {
+	_, err := d.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestPK(r.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestSK(r.Id)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("requestId", r.Id).Msgf("Failed to delete borrow request: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetBorrowRequest retrieves a borrow request received by the owner, nil if it does not exist
+func (d *dynamo) GetBorrowRequest(ownerId string, requestId string) (*domain.BorrowRequest, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestSK(requestId)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("requestId", requestId).Msgf("Unable to get borrow request: %s", err.Error())
+		return nil, errors.New("unable to get borrow request")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.BorrowRequest{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal borrow request: %s", err.Error())
+		return nil, err
+	}
+
+	return mapRecordToBorrowRequest(&record), nil
+}
+
+// GetSentBorrowRequest retrieves a borrow request sent by the requester, nil if it does not exist
+func (d *dynamo) GetSentBorrowRequest(requesterId string, requestId string) (*domain.BorrowRequest, error) {
+	requests, err := d.queryBorrowRequests(dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk AND #GSI1SK = :gsi1sk"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestGSI1PK(requesterId)},
+			":gsi1sk": &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestGSI1SK(requestId)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return requests[0], nil
+}
+
+// QueryReceivedBorrowRequests returns the borrow requests on the items of the owner
+func (d *dynamo) QueryReceivedBorrowRequests(ownerId string) ([]*domain.BorrowRequest, error) {
+	return d.queryBorrowRequests(dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk AND begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestPK(ownerId)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "borrow-request#"},
+		},
+	})
+}
+
+// QuerySentBorrowRequests returns the borrow requests sent by the requester, whatever the owner
+func (d *dynamo) QuerySentBorrowRequests(requesterId string) ([]*domain.BorrowRequest, error) {
+	return d.queryBorrowRequests(dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk AND begins_with(#GSI1SK, :gsi1sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk":        &types.AttributeValueMemberS{Value: persistence.MakeBorrowRequestGSI1PK(requesterId)},
+			":gsi1sk_prefix": &types.AttributeValueMemberS{Value: "borrow-request#"},
+		},
+	})
+}
+
+func (d *dynamo) queryBorrowRequests(query dynamodb.QueryInput) ([]*domain.BorrowRequest, error) {
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	requests := []*domain.BorrowRequest{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Msgf("Failed to query borrow requests: %s", err.Error())
+			return nil, errors.New("unable to query borrow requests")
+		}
+
+		for _, item := range result.Items {
+			record := persistence.BorrowRequest{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal borrow request: %s", err.Error())
+				continue
+			}
+			requests = append(requests, mapRecordToBorrowRequest(&record))
+		}
+	}
+
+	return requests, nil
+}
+
+func mapRecordToBorrowRequest(record *persistence.BorrowRequest) *domain.BorrowRequest {
+	return &domain.BorrowRequest{
+		Id:            record.Id,
+		OwnerId:       record.OwnerId,
+		OwnerName:     record.OwnerName,
+		LibraryId:     record.LibraryId,
+		LibraryName:   record.LibraryName,
+		ItemId:        record.ItemId,
+		ItemType:      domain.ItemType(record.Type),
+		Title:         record.Title,
+		CopyId:        record.CopyId,
+		RequesterId:   record.RequesterId,
+		RequesterName: record.RequesterName,
+		Message:       record.Message,
+		DueDate:       record.DueDate,
+		Status:        domain.BorrowRequestStatus(record.Status),
+		CreatedAt:     record.CreatedAt,
+		UpdatedAt:     record.UpdatedAt,
+	}
+}
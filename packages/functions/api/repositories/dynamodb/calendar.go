@@ -0,0 +1,90 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// PutCalendarFeed stores the calendar feed of the owner, replacing the previous token
+func (d *dynamo) PutCalendarFeed(f *domain.CalendarFeed) error {
+	record := persistence.CalendarFeed{
+		PK:         persistence.MakeCalendarFeedPK(f.OwnerId),
+		SK:         persistence.MakeCalendarFeedSK(),
+		OwnerId:    f.OwnerId,
+		TokenHash:  f.TokenHash,
+		CreatedAt:  f.CreatedAt,
+		EntityType: persistence.TypeCalendarFeed,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("ownerId", f.OwnerId).Msgf("Failed to marshal calendar feed: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	if err != nil {
+		log.Error().Str("ownerId", f.OwnerId).Msgf("Failed to put calendar feed: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (d *dynamo) DeleteCalendarFeed(ownerId string) error {
+	_, err := d.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeCalendarFeedPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeCalendarFeedSK()},
+		},
+	})
+	if err != nil {
+		log.Error().Str("ownerId", ownerId).Msgf("Failed to delete calendar feed: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetCalendarFeed retrieves the calendar feed of the owner, nil if none was created
+func (d *dynamo) GetCalendarFeed(ownerId string) (*domain.CalendarFeed, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeCalendarFeedPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeCalendarFeedSK()},
+		},
+	})
+	if err != nil {
+		log.Error().Str("ownerId", ownerId).Msgf("Unable to get calendar feed: %s", err.Error())
+		return nil, errors.New("unable to get calendar feed")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.CalendarFeed{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal calendar feed: %s", err.Error())
+		return nil, err
+	}
+
+	return &domain.CalendarFeed{
+		OwnerId:   record.OwnerId,
+		TokenHash: record.TokenHash,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// PutItemChanges records the changes of items of a library, keyed by item id
+func (d *dynamo) PutItemChanges(ownerId string, libraryId string, changes map[string]*domain.ChangeEntry) error {
+	records := []persistence.ChangeEntry{}
+	for itemId, e := range changes {
+		records = append(records, changeEntryToRecord(ownerId, persistence.MakeItemChangeSK(libraryId, itemId, *e.Date), e))
+	}
+
+	return d.putChangeRecords(records)
+}
+
+func (d *dynamo) PutCollectionChange(c *domain.Collection, e *domain.ChangeEntry) error {
+	return d.putChangeRecords([]persistence.ChangeEntry{
+		changeEntryToRecord(c.OwnerId, persistence.MakeCollectionChangeSK(c.LibraryId, c.Id, *e.Date), e),
+	})
+}
+
+func (d *dynamo) PutLibraryChange(l *domain.Library, e *domain.ChangeEntry) error {
+	return d.putChangeRecords([]persistence.ChangeEntry{
+		changeEntryToRecord(l.OwnerId, persistence.MakeLibraryChangeSK(l.Id, *e.Date), e),
+	})
+}
+
+func (d *dynamo) QueryItemChanges(ownerId string, libraryId string, itemId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	return d.queryChanges(ownerId, fmt.Sprintf("library#%s#item#%s#change#", libraryId, itemId), continuationToken, pageSize)
+}
+
+func (d *dynamo) QueryCollectionChanges(ownerId string, libraryId string, collectionId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	return d.queryChanges(ownerId, fmt.Sprintf("library#%s#change#collection#%s#", libraryId, collectionId), continuationToken, pageSize)
+}
+
+func (d *dynamo) QueryLibraryChanges(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	return d.queryChanges(ownerId, fmt.Sprintf("library#%s#change#library#", libraryId), continuationToken, pageSize)
+}
+
+func (d *dynamo) putChangeRecords(records []persistence.ChangeEntry) error {
+	requests := []types.WriteRequest{}
+	for _, r := range records {
+		item, err := attributevalue.MarshalMap(r)
+		if err != nil {
+			log.Error().Str("sk", r.SK).Msgf("Failed to marshal change: %s", err.Error())
+			return err
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	for _, c := range slices.ChunkBy(requests, 25) {
+		if len(c) == 0 {
+			continue
+		}
+		_, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				tableName: c,
+			},
+		})
+		if err != nil {
+			log.Error().Msgf("Failed to batch put changes: %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryChanges returns the changes stored under the sort key prefix, most recent first
+func (d *dynamo) queryChanges(ownerId string, prefix string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk and begins_with(#SK,:change_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeChangePK(ownerId),
+			},
+			":change_prefix": &types.AttributeValueMemberS{
+				Value: prefix,
+			},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(int32(pageSize)),
+	}
+
+	if continuationToken != "" {
+		lek, err := deserializeLek(continuationToken)
+		if err != nil {
+			log.Error().Str("prefix", prefix).Msg("Unable to deserialize continuation token")
+			return nil, errors.New("unable to deserialize continuation token")
+		}
+
+		query.ExclusiveStartKey = lek
+	}
+
+	result, err := d.client.Query(context.TODO(), &query)
+	if err != nil {
+		log.Error().Str("prefix", prefix).Msgf("Failed to query changes: %s", err.Error())
+		return nil, err
+	}
+
+	entries := []domain.ChangeEntry{}
+	for _, item := range result.Items {
+		record := persistence.ChangeEntry{}
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			log.Warn().Str("prefix", prefix).Msgf("Failed to unmarshal change: %s", err.Error())
+			continue
+		}
+
+		entries = append(entries, domain.ChangeEntry{
+			Date:     record.UpdatedAt,
+			UserId:   record.UserId,
+			UserName: record.UserName,
+			Changes: slices.Map(record.Changes, func(c persistence.FieldChange) domain.FieldChange {
+				return domain.FieldChange{Field: c.Field, From: c.From, To: c.To}
+			}),
+		})
+	}
+
+	changeLog := &domain.ChangeLog{
+		Entries: entries,
+	}
+
+	if result.LastEvaluatedKey != nil {
+		nextToken, err := serializeLek(result.LastEvaluatedKey)
+		if err != nil {
+			log.Error().Str("prefix", prefix).Msg("Unable to serialize continuation token")
+			return nil, errors.New("unable to serialize continuation token")
+		}
+		changeLog.ContinuationToken = *nextToken
+	}
+
+	return changeLog, nil
+}
+
+func changeEntryToRecord(ownerId string, sk string, e *domain.ChangeEntry) persistence.ChangeEntry {
+	return persistence.ChangeEntry{
+		PK:       persistence.MakeChangePK(ownerId),
+		SK:       sk,
+		UserId:   e.UserId,
+		UserName: e.UserName,
+		Changes: slices.Map(e.Changes, func(c domain.FieldChange) persistence.FieldChange {
+			return persistence.FieldChange{Field: c.Field, From: c.From, To: c.To}
+		}),
+		UpdatedAt:  e.Date,
+		EntityType: persistence.TypeChange,
+	}
+}
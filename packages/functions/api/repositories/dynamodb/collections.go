@@ -42,16 +42,7 @@ func (d *dynamo) GetCollection(ownerId string, libraryId string, collectionId st
 		return nil, err
 	}
 
-	return &domain.Collection{
-		Id:          record.Id,
-		Name:        record.Name,
-		Description: record.Description,
-		ItemCount:   record.ItemCount,
-		OwnerId:     record.OwnerId,
-		LibraryId:   record.LibraryId,
-		CreatedAt:   record.CreatedAt,
-		UpdatedAt:   record.UpdatedAt,
-	}, nil
+	return mapRecordToCollection(&record), nil
 }
 
 // GetCollectionByName retrieves a collection by name within a library (for uniqueness check)
@@ -97,16 +88,7 @@ func (d *dynamo) GetCollectionByName(ownerId string, libraryId string, name stri
 		return nil, err
 	}
 
-	return &domain.Collection{
-		Id:          record.Id,
-		Name:        record.Name,
-		Description: record.Description,
-		ItemCount:   record.ItemCount,
-		OwnerId:     record.OwnerId,
-		LibraryId:   record.LibraryId,
-		CreatedAt:   record.CreatedAt,
-		UpdatedAt:   record.UpdatedAt,
-	}, nil
+	return mapRecordToCollection(&record), nil
 }
 
 // QueryCollectionsByLibrary returns all collections in a library.
@@ -148,16 +130,7 @@ func (d *dynamo) QueryCollectionsByLibrary(ownerId string, libraryId string) ([]
 				continue
 			}
 
-			collections = append(collections, domain.Collection{
-				Id:          record.Id,
-				Name:        record.Name,
-				Description: record.Description,
-				ItemCount:   record.ItemCount,
-				OwnerId:     record.OwnerId,
-				LibraryId:   record.LibraryId,
-				CreatedAt:   record.CreatedAt,
-				UpdatedAt:   record.UpdatedAt,
-			})
+			collections = append(collections, *mapRecordToCollection(&record))
 		}
 	}
 
@@ -180,6 +153,9 @@ func (d *dynamo) PutCollection(c *domain.Collection) error {
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 		EntityType:  persistence.TypeCollection,
+		// Series-specific fields
+		IsSeries:     c.IsSeries,
+		TotalVolumes: c.TotalVolumes,
 	}
 
 	item, err := attributevalue.MarshalMap(record)
@@ -203,20 +179,32 @@ func (d *dynamo) PutCollection(c *domain.Collection) error {
 
 // UpdateCollection updates an existing collection
 func (d *dynamo) UpdateCollection(c *domain.Collection) error {
+	updateExpression := "SET CollectionName = :name, Description = :description, UpdatedAt = :updatedAt, GSI1SK = :gsi1sk, IsSeries = :isSeries"
+	values := map[string]types.AttributeValue{
+		":name":        &types.AttributeValueMemberS{Value: c.Name},
+		":description": &types.AttributeValueMemberS{Value: c.Description},
+		":updatedAt":   &types.AttributeValueMemberS{Value: c.UpdatedAt.Format("2006-01-02T15:04:05.999999999Z07:00")},
+		":gsi1sk":      &types.AttributeValueMemberS{Value: persistence.MakeCollectionGSI1SK(c.Name)},
+		":isSeries":    &types.AttributeValueMemberBOOL{Value: c.IsSeries},
+	}
+
+	// Total volumes is optional, clear it when unset
+	if c.TotalVolumes != nil {
+		updateExpression += ", TotalVolumes = :totalVolumes"
+		values[":totalVolumes"] = &types.AttributeValueMemberN{Value: strconv.Itoa(*c.TotalVolumes)}
+	} else {
+		updateExpression += " REMOVE TotalVolumes"
+	}
+
 	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 		TableName: aws.String(tableName),
 		Key: map[string]types.AttributeValue{
 			"PK": &types.AttributeValueMemberS{Value: persistence.MakeCollectionPK(c.OwnerId)},
 			"SK": &types.AttributeValueMemberS{Value: persistence.MakeCollectionSK(c.LibraryId, c.Id)},
 		},
-		UpdateExpression:    aws.String("SET CollectionName = :name, Description = :description, UpdatedAt = :updatedAt, GSI1SK = :gsi1sk"),
-		ConditionExpression: aws.String("attribute_exists(PK)"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":name":        &types.AttributeValueMemberS{Value: c.Name},
-			":description": &types.AttributeValueMemberS{Value: c.Description},
-			":updatedAt":   &types.AttributeValueMemberS{Value: c.UpdatedAt.Format("2006-01-02T15:04:05.999999999Z07:00")},
-			":gsi1sk":      &types.AttributeValueMemberS{Value: persistence.MakeCollectionGSI1SK(c.Name)},
-		},
+		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: values,
 	})
 
 	if err != nil {
@@ -267,3 +255,122 @@ func (d *dynamo) IncrementCollectionItemCount(ownerId string, libraryId string,
 
 	return nil
 }
+
+// QueryCollectionVolumes returns the volume numbers of the items in a collection
+// Items without a volume number are skipped
+func (d *dynamo) QueryCollectionVolumes(ownerId string, libraryId string, collectionId string) ([]int, error) {
+	// Same access pattern as GetMaxOrderInCollection: no GSI on collectionId
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK,:library_item_prefix)"),
+		FilterExpression:       aws.String("#CollectionId = :collectionId"),
+		ProjectionExpression:   aws.String("#Volume"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK":       "GSI1PK",
+			"#GSI1SK":       "GSI1SK",
+			"#CollectionId": "CollectionId",
+			"#Volume":       "Volume",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI1PK(ownerId, libraryId),
+			},
+			":library_item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+			":collectionId": &types.AttributeValueMemberS{
+				Value: collectionId,
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	volumes := []int{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("collectionId", collectionId).Msgf("Failed to query collection volumes: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal collection item: %s", err.Error())
+				continue
+			}
+			if record.Volume != nil {
+				volumes = append(volumes, *record.Volume)
+			}
+		}
+	}
+
+	return volumes, nil
+}
+
+// mapRecordToCollection converts persistence.Collection to domain.Collection
+func mapRecordToCollection(record *persistence.Collection) *domain.Collection {
+	return &domain.Collection{
+		Id:           record.Id,
+		Name:         record.Name,
+		Description:  record.Description,
+		ItemCount:    record.ItemCount,
+		OwnerId:      record.OwnerId,
+		LibraryId:    record.LibraryId,
+		CreatedAt:    record.CreatedAt,
+		UpdatedAt:    record.UpdatedAt,
+		IsSeries:     record.IsSeries,
+		TotalVolumes: record.TotalVolumes,
+	}
+}
+
+// QueryCollectionItems returns the items of a collection, same access pattern as QueryCollectionVolumes
+func (d *dynamo) QueryCollectionItems(ownerId string, libraryId string, collectionId string) ([]*domain.LibraryItem, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK,:library_item_prefix)"),
+		FilterExpression:       aws.String("#CollectionId = :collectionId and attribute_not_exists(#DeletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK":       "GSI1PK",
+			"#GSI1SK":       "GSI1SK",
+			"#CollectionId": "CollectionId",
+			"#DeletedAt":    "DeletedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI1PK(ownerId, libraryId),
+			},
+			":library_item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+			":collectionId": &types.AttributeValueMemberS{
+				Value: collectionId,
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	items := []*domain.LibraryItem{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("collectionId", collectionId).Msgf("Failed to query collection items: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal collection item: %s", err.Error())
+				continue
+			}
+			items = append(items, mapRecordToLibraryItem(&record))
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,376 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// PutContact creates a new contact
+func (d *dynamo) PutContact(c *domain.Contact) error {
+	record := persistence.Contact{
+		PK:         persistence.MakeContactPK(c.OwnerId),
+		SK:         persistence.MakeContactSK(c.Id),
+		Id:         c.Id,
+		Name:       c.Name,
+		Email:      c.Email,
+		Phone:      c.Phone,
+		UserId:     c.UserId,
+		OwnerId:    c.OwnerId,
+		UpdatedAt:  c.UpdatedAt,
+		EntityType: persistence.TypeContact,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("name", c.Name).Msgf("Failed to marshal contact: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		log.Error().Str("name", c.Name).Msgf("Failed to put contact: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateContact updates the name and details of a contact, the name of the items lent to it is updated
+// by the consistency manager
+func (d *dynamo) UpdateContact(c *domain.Contact) error {
+	values := map[string]types.AttributeValue{
+		":name":      &types.AttributeValueMemberS{Value: c.Name},
+		":updatedAt": &types.AttributeValueMemberS{Value: c.UpdatedAt.Format(time.RFC3339Nano)},
+	}
+
+	// Unset details are removed
+	sets := []string{"ContactName = :name", "UpdatedAt = :updatedAt"}
+	removes := []string{}
+	if c.Email != nil {
+		sets = append(sets, "Email = :email")
+		values[":email"] = &types.AttributeValueMemberS{Value: *c.Email}
+	} else {
+		removes = append(removes, "Email")
+	}
+	if c.Phone != nil {
+		sets = append(sets, "Phone = :phone")
+		values[":phone"] = &types.AttributeValueMemberS{Value: *c.Phone}
+	} else {
+		removes = append(removes, "Phone")
+	}
+	// The app user a contact is linked to is kept
+	if c.UserId != nil {
+		sets = append(sets, "UserId = :userId")
+		values[":userId"] = &types.AttributeValueMemberS{Value: *c.UserId}
+	}
+
+	update := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		update += " REMOVE " + strings.Join(removes, ", ")
+	}
+
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeContactPK(c.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeContactSK(c.Id)},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		log.Error().Str("contactId", c.Id).Msgf("Failed to update contact: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteContact removes a contact and its lending history
+func (d *dynamo) DeleteContact(c *domain.Contact) error {
+	requests := []types.WriteRequest{
+		{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: persistence.MakeContactPK(c.OwnerId)},
+					"SK": &types.AttributeValueMemberS{Value: persistence.MakeContactSK(c.Id)},
+				},
+			},
+		},
+	}
+	for _, l := range c.Loans {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: persistence.MakeContactLoanPK(c.OwnerId)},
+					"SK": &types.AttributeValueMemberS{Value: persistence.MakeContactLoanSK(c.Id, *l.LentAt, l.ItemId, l.CopyId)},
+				},
+			},
+		})
+	}
+
+	for _, chunk := range slices.ChunkBy(requests, 25) {
+		_, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				tableName: chunk,
+			},
+		})
+		if err != nil {
+			log.Error().Str("contactId", c.Id).Msgf("Failed to delete contact: %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetContact retrieves a contact by ID with its loans, nil if it does not exist
+func (d *dynamo) GetContact(ownerId string, contactId string) (*domain.Contact, error) {
+	contacts, err := d.queryContacts(ownerId, persistence.MakeContactSK(contactId))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range contacts {
+		if c.Id == contactId {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// QueryContacts returns all the contacts of an owner with their loans
+func (d *dynamo) QueryContacts(ownerId string) ([]*domain.Contact, error) {
+	return d.queryContacts(ownerId, "contact#")
+}
+
+// queryContacts reads the contacts and loans whose sort key starts with the prefix.
+// Loans are stored right after their contact: contact#<contact id>#loan#...
+func (d *dynamo) queryContacts(ownerId string, prefix string) ([]*domain.Contact, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk AND begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeContactPK(ownerId),
+			},
+			":sk_prefix": &types.AttributeValueMemberS{
+				Value: prefix,
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	contacts := []*domain.Contact{}
+	loans := map[string][]domain.ContactLoan{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("prefix", prefix).Msgf("Failed to query contacts: %s", err.Error())
+			return nil, errors.New("unable to query contacts")
+		}
+
+		for _, item := range result.Items {
+			entityType := ""
+			_ = attributevalue.Unmarshal(item["EntityType"], &entityType)
+
+			switch persistence.EntityType(entityType) {
+			case persistence.TypeContact:
+				record := persistence.Contact{}
+				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+					log.Warn().Msgf("Failed to unmarshal contact: %s", err.Error())
+					continue
+				}
+				contacts = append(contacts, mapRecordToContact(&record))
+
+			case persistence.TypeContactLoan:
+				record := persistence.ContactLoan{}
+				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+					log.Warn().Msgf("Failed to unmarshal contact loan: %s", err.Error())
+					continue
+				}
+				loans[record.ContactId] = append(loans[record.ContactId], *mapRecordToContactLoan(&record))
+			}
+		}
+	}
+
+	for _, c := range contacts {
+		c.Loans = loans[c.Id]
+		if c.Loans == nil {
+			c.Loans = []domain.ContactLoan{}
+		}
+		sort.SliceStable(c.Loans, func(a, b int) bool {
+			return c.Loans[a].LentAt.After(*c.Loans[b].LentAt)
+		})
+	}
+
+	return contacts, nil
+}
+
+// PutContactLoans replaces loan records, to index the current loans of a contact linked to an app user
+func (d *dynamo) PutContactLoans(loans []domain.ContactLoan) error {
+	requests := []types.WriteRequest{}
+	for _, l := range loans {
+		put, err := contactLoanPut(&l)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: put.Put.Item},
+		})
+	}
+
+	if len(requests) == 0 {
+		return nil
+	}
+
+	for _, chunk := range slices.ChunkBy(requests, 25) {
+		_, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				tableName: chunk,
+			},
+		})
+		if err != nil {
+			log.Error().Msgf("Failed to put contact loans: %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QueryBorrowedLoans returns the current loans to an app user, whatever the owner
+func (d *dynamo) QueryBorrowedLoans(borrowerId string) ([]domain.ContactLoan, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk AND begins_with(#GSI1SK, :gsi1sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk":        &types.AttributeValueMemberS{Value: persistence.MakeContactLoanGSI1PK(borrowerId)},
+			":gsi1sk_prefix": &types.AttributeValueMemberS{Value: "loan#"},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	loans := []domain.ContactLoan{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("borrowerId", borrowerId).Msgf("Failed to query borrowed loans: %s", err.Error())
+			return nil, errors.New("unable to query borrowed loans")
+		}
+
+		for _, item := range result.Items {
+			record := persistence.ContactLoan{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal contact loan: %s", err.Error())
+				continue
+			}
+			loans = append(loans, *mapRecordToContactLoan(&record))
+		}
+	}
+
+	return loans, nil
+}
+
+// contactLoanPut returns the put of a loan record, added to the transaction of its lend or return event.
+// The current loans to app users are indexed by borrower, the return removes them from the index.
+func contactLoanPut(l *domain.ContactLoan) (types.TransactWriteItem, error) {
+	record := persistence.ContactLoan{
+		PK:          persistence.MakeContactLoanPK(l.OwnerId),
+		SK:          persistence.MakeContactLoanSK(l.ContactId, *l.LentAt, l.ItemId, l.CopyId),
+		ContactId:   l.ContactId,
+		OwnerId:     l.OwnerId,
+		OwnerName:   l.OwnerName,
+		BorrowerId:  l.BorrowerId,
+		LibraryId:   l.LibraryId,
+		LibraryName: l.LibraryName,
+		ItemId:      l.ItemId,
+		Type:        int(l.ItemType),
+		Title:       l.Title,
+		CopyId:      l.CopyId,
+		LentAt:      l.LentAt,
+		DueDate:     l.DueDate,
+		ReturnedAt:  l.ReturnedAt,
+		EntityType:  persistence.TypeContactLoan,
+	}
+	record.ReturnCondition = itemConditionToRecord(l.ReturnCondition)
+	record.ReturnNote = l.ReturnNote
+	if l.BorrowerId != nil && l.ReturnedAt == nil {
+		record.GSI1PK = persistence.MakeContactLoanGSI1PK(*l.BorrowerId)
+		record.GSI1SK = persistence.MakeContactLoanGSI1SK(*l.LentAt, l.ItemId, l.CopyId)
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("contactId", l.ContactId).Str("itemId", l.ItemId).Msgf("Failed to marshal contact loan: %s", err.Error())
+		return types.TransactWriteItem{}, err
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: aws.String(tableName),
+			Item:      item,
+		},
+	}, nil
+}
+
+func mapRecordToContact(record *persistence.Contact) *domain.Contact {
+	return &domain.Contact{
+		Id:        record.Id,
+		OwnerId:   record.OwnerId,
+		Name:      record.Name,
+		Email:     record.Email,
+		Phone:     record.Phone,
+		UserId:    record.UserId,
+		UpdatedAt: record.UpdatedAt,
+	}
+}
+
+func mapRecordToContactLoan(record *persistence.ContactLoan) *domain.ContactLoan {
+	return &domain.ContactLoan{
+		ContactId:   record.ContactId,
+		OwnerId:     record.OwnerId,
+		OwnerName:   record.OwnerName,
+		BorrowerId:  record.BorrowerId,
+		LibraryId:   record.LibraryId,
+		LibraryName: record.LibraryName,
+		ItemId:      record.ItemId,
+		ItemType:    domain.ItemType(record.Type),
+		Title:       record.Title,
+		CopyId:      record.CopyId,
+		LentAt:      record.LentAt,
+		DueDate:     record.DueDate,
+		ReturnedAt:  record.ReturnedAt,
+		// Condition assessed on return
+		ReturnCondition: itemConditionFromRecord(record.ReturnCondition),
+		ReturnNote:      record.ReturnNote,
+	}
+}
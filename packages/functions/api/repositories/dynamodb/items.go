@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"alexandria.isnan.eu/functions/internal/domain"
@@ -142,9 +143,18 @@ func (d *dynamo) QueryItemEvents(i *domain.LibraryItem, continuationToken string
 		}
 
 		entries = append(entries, domain.ItemEvent{
-			Date:  record.UpdatedAt,
-			Type:  domain.ItemEventType(record.Type),
-			Event: record.Event,
+			Date:      record.UpdatedAt,
+			Type:      domain.ItemEventType(record.Type),
+			Event:     record.Event,
+			CopyId:    record.CopyId,
+			DueDate:   record.DueDate,
+			ContactId: record.ContactId,
+			// Condition assessed on return
+			Condition:        itemConditionFromRecord(record.Condition),
+			ConditionNote:    record.ConditionNote,
+			ConditionPicture: record.ConditionPicture,
+			UserId:           record.UserId,
+			UserName:         record.UserName,
 		})
 	}
 
@@ -165,8 +175,81 @@ func (d *dynamo) QueryItemEvents(i *domain.LibraryItem, continuationToken string
 
 }
 
-func (d *dynamo) PutItemEvent(i *domain.LibraryItem, evtType domain.ItemEventType, evt string, date *time.Time) error {
+func (d *dynamo) PutItemEvent(i *domain.LibraryItem, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan) error {
+	transactItems, err := itemEventWrites(i, evtType, evt, date, loan, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutItemCopyEvent records a lend or return event of one copy of an item and updates the copy lending state
+func (d *dynamo) PutItemCopyEvent(i *domain.LibraryItem, copyId string, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan) error {
+	transactItems, err := itemCopyEventWrites(i, copyId, evtType, evt, date, loan, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+
+	if err != nil {
+		log.Error().Str("id", i.Id).Str("copyId", copyId).Msgf("Failed to put item copy event: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// PutLendingEvents records the lend or return events of one or several items in one transaction, all dated the same.
+// An item appears in one event at most: its event and its lending state are written once per transaction.
+func (d *dynamo) PutLendingEvents(events []domain.LendingEvent, date *time.Time) error {
+	transactItems := []types.TransactWriteItem{}
+	for _, e := range events {
+		var writes []types.TransactWriteItem
+		var err error
+		if e.CopyId != "" {
+			writes, err = itemCopyEventWrites(e.Item, e.CopyId, e.Type, e.Person, date, e.Loan, e.Assessment)
+		} else {
+			writes, err = itemEventWrites(e.Item, e.Type, e.Person, date, e.Loan, e.Assessment)
+		}
+		if err != nil {
+			return err
+		}
+		transactItems = append(transactItems, writes...)
+	}
+
+	// DynamoDB transactions are limited to 100 writes
+	if len(transactItems) > 100 {
+		msg := "too many lending events for one transaction"
+		log.Error().Int("events", len(events)).Msg(msg)
+		return errors.New(msg)
+	}
+
+	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		log.Error().Int("events", len(events)).Msgf("Failed to put lending events: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
 
+// itemEventWrites builds the writes of an item event: the event, the item state and the contact loan if any.
+// The condition assessed on a return becomes the condition of the item.
+func itemEventWrites(i *domain.LibraryItem, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan, assessment *domain.ReturnAssessment) ([]types.TransactWriteItem, error) {
 	record := persistence.ItemEvent{
 		PK:         persistence.MakeItemEventPK(i.OwnerId),
 		SK:         persistence.MakeItemEventSK(i.LibraryId, i.Id, *date),
@@ -177,67 +260,231 @@ func (d *dynamo) PutItemEvent(i *domain.LibraryItem, evtType domain.ItemEventTyp
 		UpdatedAt:  date,
 		EntityType: persistence.TypeEvent,
 	}
+	if evtType == domain.Lent {
+		record.DueDate = i.DueDate
+	}
+	if loan != nil {
+		record.ContactId = &loan.ContactId
+	}
+	setEventAssessment(&record, assessment)
 	item, err := attributevalue.MarshalMap(record)
 	if err != nil {
 		log.Error().Str("id", i.Id).Msgf("Failed to marshal item event: %s", err.Error())
-		return err
+		return nil, err
 	}
 
 	var updReq types.Update
 	if evtType == domain.Lent {
+		values := map[string]types.AttributeValue{
+			":person": &types.AttributeValueMemberS{
+				Value: evt,
+			},
+		}
 		updReq = types.Update{
 			TableName: aws.String(tableName),
 			Key: map[string]types.AttributeValue{
 				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
 				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
 			},
-			UpdateExpression: aws.String("SET LentTo = :person"),
-			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":person": &types.AttributeValueMemberS{
-					Value: evt,
-				},
-			},
+			UpdateExpression:          aws.String(loanUpdateExpression("", i.LentToId, i.DueDate, i.NextDueDate(), nil, values)),
+			ExpressionAttributeValues: values,
 		}
 	}
 
 	if evtType == domain.Returned {
+		values := map[string]types.AttributeValue{
+			":person": &types.AttributeValueMemberNULL{
+				Value: true,
+			},
+		}
 		updReq = types.Update{
 			TableName: aws.String(tableName),
 			Key: map[string]types.AttributeValue{
 				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
 				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
 			},
-			UpdateExpression: aws.String("SET LentTo = :person"),
-			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":person": &types.AttributeValueMemberNULL{
-					Value: true,
-				},
+			UpdateExpression:          aws.String(loanUpdateExpression("", nil, nil, i.NextDueDate(), returnCondition(assessment), values)),
+			ExpressionAttributeValues: values,
+		}
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(tableName),
+				Item:      item,
 			},
+		},
+		{
+			Update: &updReq,
+		},
+	}
+
+	if loan != nil {
+		loanPut, err := contactLoanPut(loan)
+		if err != nil {
+			return nil, err
 		}
+		transactItems = append(transactItems, loanPut)
 	}
 
-	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
-		TransactItems: []types.TransactWriteItem{
-			{
-				Put: &types.Put{
-					TableName: aws.String(tableName),
-					Item:      item,
-				},
+	return transactItems, nil
+}
+
+// itemCopyEventWrites builds the writes of a lend or return event of one copy of an item
+func itemCopyEventWrites(i *domain.LibraryItem, copyId string, evtType domain.ItemEventType, evt string, date *time.Time, loan *domain.ContactLoan, assessment *domain.ReturnAssessment) ([]types.TransactWriteItem, error) {
+	idx := i.FindCopy(copyId)
+	if idx == -1 {
+		log.Error().Str("id", i.Id).Str("copyId", copyId).Msg("Unknown copy")
+		return nil, errors.New("copy not found")
+	}
+
+	record := persistence.ItemEvent{
+		PK:         persistence.MakeItemEventPK(i.OwnerId),
+		SK:         persistence.MakeItemEventSK(i.LibraryId, i.Id, *date),
+		GSI1PK:     persistence.MakeItemEventGSI1PK(i.OwnerId, i.LibraryId, i.Id),
+		GSI1SK:     persistence.MakeItemEventGSI1SK(*date),
+		Type:       string(evtType),
+		Event:      evt,
+		CopyId:     &copyId,
+		UpdatedAt:  date,
+		EntityType: persistence.TypeEvent,
+	}
+	if evtType == domain.Lent {
+		record.DueDate = i.Copies[idx].DueDate
+	}
+	if loan != nil {
+		record.ContactId = &loan.ContactId
+	}
+	setEventAssessment(&record, assessment)
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to marshal item event: %s", err.Error())
+		return nil, err
+	}
+
+	var person types.AttributeValue = &types.AttributeValueMemberNULL{Value: true}
+	var contactId *string
+	var dueDate *time.Time
+	if evtType == domain.Lent {
+		person = &types.AttributeValueMemberS{Value: evt}
+		contactId = i.Copies[idx].LentToId
+		dueDate = i.Copies[idx].DueDate
+	}
+
+	values := map[string]types.AttributeValue{
+		":person": person,
+		":copyId": &types.AttributeValueMemberS{Value: copyId},
+	}
+	updateExpression := loanUpdateExpression(fmt.Sprintf("Copies[%d].", idx), contactId, dueDate, i.NextDueDate(), returnCondition(assessment), values)
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(tableName),
+				Item:      item,
 			},
-			{
-				Update: &updReq,
+		},
+		{
+			Update: &types.Update{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+					"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+				},
+				// Copies may have been edited since the item was read
+				UpdateExpression:          aws.String(updateExpression),
+				ConditionExpression:       aws.String(fmt.Sprintf("Copies[%d].CopyId = :copyId", idx)),
+				ExpressionAttributeValues: values,
 			},
 		},
-	})
+	}
 
-	if err != nil {
-		return err
+	if loan != nil {
+		loanPut, err := contactLoanPut(loan)
+		if err != nil {
+			return nil, err
+		}
+		transactItems = append(transactItems, loanPut)
 	}
 
-	return nil
+	return transactItems, nil
+}
+
+// loanUpdateExpression builds the update of the lending state of the item (prefix "") or of one of its copies
+// (prefix "Copies[<idx>]."): the person (:person), the contact and due date of the loan, and the next due date
+// of the item. Attributes not set are removed, the item must hold its lending state after the event.
+// The condition, assessed on return, is only set when given.
+func loanUpdateExpression(prefix string, contactId *string, dueDate *time.Time, nextDueDate *time.Time, condition *string, values map[string]types.AttributeValue) string {
+	sets := []string{prefix + "LentTo = :person"}
+	removes := []string{}
+
+	if condition != nil {
+		sets = append(sets, prefix+"Condition = :condition")
+		values[":condition"] = &types.AttributeValueMemberS{Value: *condition}
+	}
+
+	if contactId != nil {
+		sets = append(sets, prefix+"LentToId = :contactId")
+		values[":contactId"] = &types.AttributeValueMemberS{Value: *contactId}
+	} else {
+		removes = append(removes, prefix+"LentToId")
+	}
+
+	if dueDate != nil {
+		sets = append(sets, prefix+"DueDate = :dueDate")
+		values[":dueDate"] = &types.AttributeValueMemberS{Value: dueDate.Format(time.RFC3339Nano)}
+	} else {
+		removes = append(removes, prefix+"DueDate")
+	}
+
+	if nextDueDate != nil {
+		sets = append(sets, "NextDueDate = :nextDueDate")
+		values[":nextDueDate"] = &types.AttributeValueMemberS{Value: nextDueDate.Format(time.RFC3339Nano)}
+	} else {
+		removes = append(removes, "NextDueDate")
+	}
+
+	expression := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		expression += " REMOVE " + strings.Join(removes, ", ")
+	}
+	return expression
+}
+
+// setEventAssessment records on a return event the condition of the item
+func setEventAssessment(record *persistence.ItemEvent, assessment *domain.ReturnAssessment) {
+	if assessment == nil {
+		return
+	}
+	record.Condition = returnCondition(assessment)
+	record.ConditionNote = assessment.Note
+	record.ConditionPicture = len(assessment.Picture) > 0
+}
+
+func returnCondition(assessment *domain.ReturnAssessment) *string {
+	if assessment == nil {
+		return nil
+	}
+	return itemConditionToRecord(&assessment.Condition)
 }
 
 func (d *dynamo) GetLibraryItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error) {
+	record, err := d.getLibraryItemRecord(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A trashed item is only reachable through the trash
+	if record.DeletedAt != nil {
+		log.Error().Str("id", itemId).Msg("Item is in the trash")
+		return nil, errors.New("unknown item")
+	}
+
+	return mapRecordToLibraryItem(record), nil
+}
+
+func (d *dynamo) getLibraryItemRecord(ownerId string, libraryId string, itemId string) (*persistence.LibraryItem, error) {
 	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		TableName: aws.String(tableName),
 		Key: map[string]types.AttributeValue{
@@ -261,32 +508,7 @@ func (d *dynamo) GetLibraryItem(ownerId string, libraryId string, itemId string)
 		return nil, err
 	}
 
-	return &domain.LibraryItem{
-			Id:             record.Id,
-			Title:          record.Title,
-			LibraryId:      record.LibraryId,
-			LibraryName:    record.LibraryName,
-			OwnerName:      record.OwnerName,
-			OwnerId:        record.OwnerId,
-			UpdatedAt:      record.UpdatedAt,
-			Summary:        record.Summary,
-			Authors:        record.Authors,
-			Isbn:           record.Isbn,
-			Type:           domain.ItemType(record.Type),
-			PictureUrl:     record.PictureUrl,
-			LentTo:         record.LentTo,
-			CollectionId:   record.CollectionId,
-			CollectionName: record.CollectionName,
-			Order:          record.Order,
-			// Video-specific fields
-			Directors:   record.Directors,
-			Cast:        record.Cast,
-			ReleaseYear: record.ReleaseYear,
-			Duration:    record.Duration,
-			TmdbId:      record.TmdbId,
-		},
-		nil
-
+	return &record, nil
 }
 
 func (d *dynamo) GetMatchedItems(matchedKeys []domain.IndexItem) ([]*domain.LibraryItem, error) {
@@ -321,30 +543,12 @@ func (d *dynamo) GetMatchedItems(matchedKeys []domain.IndexItem) ([]*domain.Libr
 					log.Warn().Msgf("Failed to unmarshal matched library item: %s", err.Error())
 				}
 
-				result = append(result, &domain.LibraryItem{
-					Id:             record.Id,
-					Title:          record.Title,
-					OwnerId:        record.OwnerId,
-					OwnerName:      record.OwnerName,
-					LibraryId:      record.LibraryId,
-					LibraryName:    record.LibraryName,
-					Summary:        record.Summary,
-					Authors:        record.Authors,
-					Isbn:           record.Isbn,
-					UpdatedAt:      record.UpdatedAt,
-					Type:           domain.ItemType(record.Type),
-					PictureUrl:     record.PictureUrl,
-					LentTo:         record.LentTo,
-					CollectionId:   record.CollectionId,
-					CollectionName: record.CollectionName,
-					Order:          record.Order,
-					// Video-specific fields
-					Directors:   record.Directors,
-					Cast:        record.Cast,
-					ReleaseYear: record.ReleaseYear,
-					Duration:    record.Duration,
-					TmdbId:      record.TmdbId,
-				})
+				// The index may still reference an item moved to the trash
+				if record.DeletedAt != nil {
+					continue
+				}
+
+				result = append(result, mapRecordToLibraryItem(&record))
 			}
 
 		}
@@ -353,18 +557,148 @@ func (d *dynamo) GetMatchedItems(matchedKeys []domain.IndexItem) ([]*domain.Libr
 	return result, nil
 }
 
-func (d *dynamo) DeleteLibraryItem(i *domain.LibraryItem) error {
+func (d *dynamo) UpdateLibraryItem(i *domain.LibraryItem) error {
+	upd := libraryItemUpdate(i)
+
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+		},
+
+		ConditionExpression:       aws.String("attribute_exists(PK) and attribute_exists(SK) and attribute_not_exists(DeletedAt)"),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// libraryItemUpdate sets the editable attributes of an item, keys and denormalized library attributes are unchanged
+func libraryItemUpdate(i *domain.LibraryItem) expression.UpdateBuilder {
+	purchaseDate, purchasePrice, currency, purchasePlace := acquisitionToRecord(i.Acquisition)
+
+	return expression.Set(expression.Name("Title"), expression.Value(i.Title)).
+		Set(expression.Name("Summary"), expression.Value(i.Summary)).
+		Set(expression.Name("Authors"), expression.Value(i.Authors)).
+		Set(expression.Name("Isbn"), expression.Value(i.Isbn)).
+		Set(expression.Name("UpdatedAt"), expression.Value(i.UpdatedAt)).
+		Set(expression.Name("GSI1SK"), expression.Value(persistence.MakeLibraryItemGSI1SK(i.Title, i.CollectionName, i.Order))).
+		Set(expression.Name("GSI2SK"), expression.Value(persistence.MakeLibraryItemGSI2SK(i.Title))).
+		Set(expression.Name("PictureUrl"), expression.Value(i.PictureUrl)).
+		Set(expression.Name("CollectionId"), expression.Value(i.CollectionId)).
+		Set(expression.Name("CollectionName"), expression.Value(i.CollectionName)).
+		Set(expression.Name("Order"), expression.Value(i.Order)).
+		Set(expression.Name("Volume"), expression.Value(i.Volume)).
+		Set(expression.Name("Tags"), expression.Value(i.Tags)).
+		Set(expression.Name("LocationId"), expression.Value(i.LocationId)).
+		Set(expression.Name("LocationPath"), expression.Value(i.LocationPath)).
+		Set(expression.Name("Format"), expression.Value(itemFormatToRecord(i.Format))).
+		Set(expression.Name("Copies"), expression.Value(itemCopiesToRecord(i.Copies))).
+		Set(expression.Name("PurchaseDate"), expression.Value(purchaseDate)).
+		Set(expression.Name("PurchasePrice"), expression.Value(purchasePrice)).
+		Set(expression.Name("Currency"), expression.Value(currency)).
+		Set(expression.Name("PurchasePlace"), expression.Value(purchasePlace)).
+		Set(expression.Name("CustomValues"), expression.Value(customValuesToRecord(i.CustomValues))).
+		// Video-specific fields
+		Set(expression.Name("Directors"), expression.Value(i.Directors)).
+		Set(expression.Name("Cast"), expression.Value(i.Cast)).
+		Set(expression.Name("ReleaseYear"), expression.Value(i.ReleaseYear)).
+		Set(expression.Name("Duration"), expression.Value(i.Duration)).
+		Set(expression.Name("TmdbId"), expression.Value(i.TmdbId)).
+		Set(expression.Name("VideoKind"), expression.Value(videoKindToRecord(i.VideoKind))).
+		Set(expression.Name("Seasons"), expression.Value(i.Seasons)).
+		Set(expression.Name("SeasonCount"), expression.Value(i.SeasonCount)).
+		// Music-specific fields
+		Set(expression.Name("Artists"), expression.Value(i.Artists)).
+		Set(expression.Name("Tracklist"), expression.Value(i.Tracklist)).
+		Set(expression.Name("Label"), expression.Value(i.Label)).
+		Set(expression.Name("Barcode"), expression.Value(i.Barcode)).
+		// Board game-specific fields
+		Set(expression.Name("Designers"), expression.Value(i.Designers)).
+		Set(expression.Name("Publisher"), expression.Value(i.Publisher)).
+		Set(expression.Name("MinPlayers"), expression.Value(i.MinPlayers)).
+		Set(expression.Name("MaxPlayers"), expression.Value(i.MaxPlayers)).
+		Set(expression.Name("PlayTime"), expression.Value(i.PlayTime)).
+		Set(expression.Name("BggId"), expression.Value(i.BggId))
+}
+
+func (d *dynamo) PutLibraryItem(i *domain.LibraryItem) error {
+	record := libraryItemToRecord(i)
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("title", i.Title).Msgf("Failed to marshal item: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			// Insert the library item
+			{
+				Put: &types.Put{
+					TableName: aws.String(tableName),
+					Item:      item,
+				},
+			},
+			// Increment TotalItems attribute of the library by 1
+			{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(i.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(i.LibraryId)},
+					},
+					UpdateExpression: aws.String("SET TotalItems = TotalItems + :incr"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":incr": &types.AttributeValueMemberN{
+							Value: "1",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("title", i.Title).Msgf("Failed to put item: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// movedItemRecord holds the attributes of the item records rewritten by MoveLibraryItem
+type movedItemRecord struct {
+	EntityType persistence.EntityType `dynamodbav:"EntityType"`
+	UserId     string                 `dynamodbav:"UserId"` // Of a status
+	Status     string                 `dynamodbav:"Status"`
+}
 
-	// Get all events for this item with PK=owner#<owner id> and SK begins with library#<library id>#item#<item id>#event
+// MoveLibraryItem rewrites an item with its history, reviews and statuses under another library of the same owner.
+// These records are copied before the item is moved and the originals removed after, so that a failure
+// never loses them. Moving again after a failure overwrites the copies.
+func (d *dynamo) MoveLibraryItem(from *domain.LibraryItem, to *domain.LibraryItem) error {
+	prefix := fmt.Sprintf("library#%s#item#%s#", from.LibraryId, from.Id)
+
+	// Get all events, reviews and statuses for this item with PK=owner#<owner id> and SK begins with library#<library id>#item#<item id>#
 	query := dynamodb.QueryInput{
 		TableName:              aws.String(tableName),
 		KeyConditionExpression: aws.String("#PK = :ownerId and begins_with(#SK,:library_item_sorting_key)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":ownerId": &types.AttributeValueMemberS{
-				Value: persistence.MakeItemEventPK(i.OwnerId),
+				Value: persistence.MakeItemEventPK(from.OwnerId),
 			},
 			":library_item_sorting_key": &types.AttributeValueMemberS{
-				Value: fmt.Sprintf("library#%s#item#%s#event", i.LibraryId, i.Id),
+				Value: prefix,
 			},
 		},
 		ExpressionAttributeNames: map[string]string{
@@ -374,75 +708,109 @@ func (d *dynamo) DeleteLibraryItem(i *domain.LibraryItem) error {
 		Limit: aws.Int32(25),
 	}
 
-	type Record struct {
-		PK string `dynamodbav:"PK"`
-		SK string `dynamodbav:"SK"`
-	}
-
-	records := []types.WriteRequest{}
+	copies := []types.WriteRequest{}
+	originals := []types.WriteRequest{}
 	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
-	for j := 0; queryPaginator.HasMorePages(); j++ {
+	for queryPaginator.HasMorePages() {
 		result, err := queryPaginator.NextPage(context.TODO())
-
 		if err != nil {
-			log.Error().Str("id", i.Id).Msgf("Failed to query records to delete: %s", err.Error())
+			log.Error().Str("id", from.Id).Msgf("Failed to query records to move: %s", err.Error())
 			return err
 		}
 
-		if result.Count > 0 {
-			for _, item := range result.Items {
-				record := Record{}
-				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
-					log.Warn().Msgf("Failed to unmarshal record: %s", err.Error())
-					continue
-				}
+		for _, item := range result.Items {
+			sk, ok := item["SK"].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
 
-				records = append(records, types.WriteRequest{
-					DeleteRequest: &types.DeleteRequest{
-						Key: map[string]types.AttributeValue{
-							"PK": &types.AttributeValueMemberS{Value: record.PK},
-							"SK": &types.AttributeValueMemberS{Value: record.SK},
-						},
-					},
-				})
+			moved := map[string]types.AttributeValue{}
+			for k, v := range item {
+				moved[k] = v
+			}
+			moved["SK"] = &types.AttributeValueMemberS{
+				Value: fmt.Sprintf("library#%s#item#%s#%s", to.LibraryId, to.Id, strings.TrimPrefix(sk.Value, prefix)),
+			}
+			// Events and statuses are listed through GSI1, reviews and statuses reference their library
+			record := movedItemRecord{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Error().Str("id", from.Id).Msgf("Failed to unmarshal record to move: %s", err.Error())
+				return err
+			}
+			switch record.EntityType {
+			case persistence.TypeEvent:
+				moved["GSI1PK"] = &types.AttributeValueMemberS{Value: persistence.MakeItemEventGSI1PK(to.OwnerId, to.LibraryId, to.Id)}
+			case persistence.TypeItemStatus:
+				moved["GSI1PK"] = &types.AttributeValueMemberS{Value: persistence.MakeItemStatusGSI1PK(record.UserId, to.LibraryId)}
+				moved["GSI1SK"] = &types.AttributeValueMemberS{Value: persistence.MakeItemStatusGSI1SK(record.Status, to.Id)}
 			}
+			if _, ok := item["LibraryId"]; ok {
+				moved["LibraryId"] = &types.AttributeValueMemberS{Value: to.LibraryId}
+			}
+
+			copies = append(copies, types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: moved},
+			})
+			originals = append(originals, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{
+						"PK": item["PK"],
+						"SK": sk,
+					},
+				},
+			})
 		}
 	}
 
-	chunks := slices.ChunkBy(records, 25)
+	err := d.writeRecords(copies)
+	if err != nil {
+		log.Error().Str("id", from.Id).Msgf("Failed to batch copy records: %s", err.Error())
+		return err
+	}
 
-	for _, c := range chunks {
-		_, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				tableName: c,
-			},
-		})
-		if err != nil {
-			log.Warn().Str("id", i.Id).Msgf("Failed to batch delete records: %s", err.Error())
-			continue
-		}
+	record := libraryItemToRecord(to)
+	record.LentTo = to.LentTo
+	record.LentToId = to.LentToId
+	record.DueDate = to.DueDate
+	record.NextDueDate = to.NextDueDate()
+	record.Condition = itemConditionToRecord(to.Condition)
+	record.RatingTotal = to.RatingTotal
+	record.RatingCount = to.RatingCount
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("id", from.Id).Msgf("Failed to marshal item: %s", err.Error())
+		return err
 	}
 
-	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
-			// Remove the library item
+			// Remove the item from the source library
 			{
 				Delete: &types.Delete{
 					TableName: aws.String(tableName),
 					Key: map[string]types.AttributeValue{
-						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
-						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(from.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(from.LibraryId, from.Id)},
 					},
 					ConditionExpression: aws.String("attribute_exists(PK) and attribute_exists(SK)"),
 				},
 			},
-			// Decrement TotalItems attribute of the library by 1
+			// Insert it into the target library
+			{
+				Put: &types.Put{
+					TableName:           aws.String(tableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(PK) and attribute_not_exists(SK)"),
+				},
+			},
+			// Decrement TotalItems attribute of the source library by 1
 			{
 				Update: &types.Update{
 					TableName: aws.String(tableName),
 					Key: map[string]types.AttributeValue{
-						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(i.OwnerId)},
-						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(i.LibraryId)},
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(from.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(from.LibraryId)},
 					},
 					UpdateExpression: aws.String("SET TotalItems = TotalItems - :decr"),
 					ExpressionAttributeValues: map[string]types.AttributeValue{
@@ -452,67 +820,149 @@ func (d *dynamo) DeleteLibraryItem(i *domain.LibraryItem) error {
 					},
 				},
 			},
+			// Increment TotalItems attribute of the target library by 1
+			{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(to.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(to.LibraryId)},
+					},
+					UpdateExpression: aws.String("SET TotalItems = TotalItems + :incr"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":incr": &types.AttributeValueMemberN{
+							Value: "1",
+						},
+					},
+				},
+			},
 		},
 	})
 
 	if err != nil {
-		log.Error().Str("id", i.Id).Msgf("Failed to delete item: %s", err.Error())
+		log.Error().Str("id", from.Id).Msgf("Failed to move item: %s", err.Error())
 		return err
 	}
 
+	err = d.writeRecords(originals)
+	if err != nil {
+		log.Warn().Str("id", from.Id).Msgf("Failed to batch delete moved records: %s", err.Error())
+	}
+
 	return nil
 }
 
-func (d *dynamo) UpdateLibraryItem(i *domain.LibraryItem) error {
+// writeRecords writes the requests in batches, retrying the ones left unprocessed by DynamoDB
+func (d *dynamo) writeRecords(requests []types.WriteRequest) error {
+	for _, c := range slices.ChunkBy(requests, 25) {
+		if len(c) == 0 {
+			continue
+		}
 
-	upd := expression.Set(expression.Name("Title"), expression.Value(i.Title)).
-		Set(expression.Name("Summary"), expression.Value(i.Summary)).
-		Set(expression.Name("Authors"), expression.Value(i.Authors)).
-		Set(expression.Name("Isbn"), expression.Value(i.Isbn)).
-		Set(expression.Name("UpdatedAt"), expression.Value(i.UpdatedAt)).
-		Set(expression.Name("GSI1SK"), expression.Value(persistence.MakeLibraryItemGSI1SK(i.Title, i.CollectionName, i.Order))).
-		Set(expression.Name("GSI2SK"), expression.Value(persistence.MakeLibraryItemGSI2SK(i.Title))).
-		Set(expression.Name("PictureUrl"), expression.Value(i.PictureUrl)).
-		Set(expression.Name("CollectionId"), expression.Value(i.CollectionId)).
-		Set(expression.Name("CollectionName"), expression.Value(i.CollectionName)).
-		Set(expression.Name("Order"), expression.Value(i.Order)).
-		// Video-specific fields
-		Set(expression.Name("Directors"), expression.Value(i.Directors)).
-		Set(expression.Name("Cast"), expression.Value(i.Cast)).
-		Set(expression.Name("ReleaseYear"), expression.Value(i.ReleaseYear)).
-		Set(expression.Name("Duration"), expression.Value(i.Duration)).
-		Set(expression.Name("TmdbId"), expression.Value(i.TmdbId))
+		requestItems := map[string][]types.WriteRequest{
+			tableName: c,
+		}
+		for len(requestItems) > 0 {
+			res, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				return err
+			}
 
-	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+			requestItems = res.UnprocessedItems
+		}
+	}
 
-	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		TableName: aws.String(tableName),
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
-			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+	return nil
+}
+
+// DeleteLibraryItem removes an item for good, without going through the trash, and removes it from the library total.
+// Its history, reviews and statuses are removed after the item.
+func (d *dynamo) DeleteLibraryItem(i *domain.LibraryItem) error {
+	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			// Remove the library item
+			{
+				Delete: &types.Delete{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+					},
+					ConditionExpression: aws.String("attribute_exists(PK) and attribute_not_exists(DeletedAt)"),
+				},
+			},
+			// Decrement TotalItems attribute of the library by 1
+			libraryTotalItemsUpdate(i.OwnerId, i.LibraryId, -1),
 		},
-
-		ConditionExpression:       aws.String("attribute_exists(PK) and attribute_exists(SK)"),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		UpdateExpression:          expr.Update(),
 	})
 
 	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to delete item: %s", err.Error())
 		return err
 	}
 
+	// Get all events, reviews and statuses for this item with PK=owner#<owner id> and SK begins with library#<library id>#item#<item id>#
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :ownerId and begins_with(#SK,:library_item_sorting_key)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ownerId": &types.AttributeValueMemberS{
+				Value: persistence.MakeItemEventPK(i.OwnerId),
+			},
+			":library_item_sorting_key": &types.AttributeValueMemberS{
+				Value: fmt.Sprintf("library#%s#item#%s#", i.LibraryId, i.Id),
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ProjectionExpression: aws.String("PK, SK"),
+	}
+
+	records := []types.WriteRequest{}
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Warn().Str("id", i.Id).Msgf("Failed to query records to delete: %s", err.Error())
+			return nil
+		}
+
+		for _, item := range result.Items {
+			records = append(records, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{Key: item},
+			})
+		}
+	}
+
+	err = d.writeRecords(records)
+	if err != nil {
+		log.Warn().Str("id", i.Id).Msgf("Failed to batch delete item records: %s", err.Error())
+	}
+
 	return nil
 }
 
-func (d *dynamo) PutLibraryItem(i *domain.LibraryItem) error {
+// libraryItemToRecord builds the item record with its keys. Lending, status and rating attributes are
+// only written through item events and reviews.
+func libraryItemToRecord(i *domain.LibraryItem) persistence.LibraryItem {
 	// Determine entity type based on item type
 	entityType := persistence.TypeBook
-	if i.Type == domain.ItemVideo {
+	switch i.Type {
+	case domain.ItemVideo:
 		entityType = persistence.TypeVideo
+	case domain.ItemMusic:
+		entityType = persistence.TypeMusic
+	case domain.ItemBoardGame:
+		entityType = persistence.TypeBoardGame
 	}
 
-	record := persistence.LibraryItem{
+	purchaseDate, purchasePrice, currency, purchasePlace := acquisitionToRecord(i.Acquisition)
+
+	return persistence.LibraryItem{
 		PK:             persistence.MakeLibraryItemPK(i.OwnerId),
 		SK:             persistence.MakeLibraryItemSK(i.LibraryId, i.Id),
 		GSI1PK:         persistence.MakeLibraryItemGSI1PK(i.OwnerId, i.LibraryId),
@@ -535,54 +985,40 @@ func (d *dynamo) PutLibraryItem(i *domain.LibraryItem) error {
 		CollectionId:   i.CollectionId,
 		CollectionName: i.CollectionName,
 		Order:          i.Order,
+		Volume:         i.Volume,
+		Tags:           i.Tags,
+		Wanted:         i.Wanted,
+		LocationId:     i.LocationId,
+		LocationPath:   i.LocationPath,
+		Format:         itemFormatToRecord(i.Format),
+		Copies:         itemCopiesToRecord(i.Copies),
+		PurchaseDate:   purchaseDate,
+		PurchasePrice:  purchasePrice,
+		Currency:       currency,
+		PurchasePlace:  purchasePlace,
+		CustomValues:   customValuesToRecord(i.CustomValues),
 		// Video-specific fields
 		Directors:   i.Directors,
 		Cast:        i.Cast,
 		ReleaseYear: i.ReleaseYear,
 		Duration:    i.Duration,
 		TmdbId:      i.TmdbId,
+		VideoKind:   videoKindToRecord(i.VideoKind),
+		Seasons:     i.Seasons,
+		SeasonCount: i.SeasonCount,
+		// Music-specific fields
+		Artists:   i.Artists,
+		Tracklist: i.Tracklist,
+		Label:     i.Label,
+		Barcode:   i.Barcode,
+		// Board game-specific fields
+		Designers:  i.Designers,
+		Publisher:  i.Publisher,
+		MinPlayers: i.MinPlayers,
+		MaxPlayers: i.MaxPlayers,
+		PlayTime:   i.PlayTime,
+		BggId:      i.BggId,
 	}
-
-	item, err := attributevalue.MarshalMap(record)
-	if err != nil {
-		log.Error().Str("title", i.Title).Msgf("Failed to marshal item: %s", err.Error())
-		return err
-	}
-
-	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
-		TransactItems: []types.TransactWriteItem{
-			// Insert the library item
-			{
-				Put: &types.Put{
-					TableName: aws.String(tableName),
-					Item:      item,
-				},
-			},
-			// Increment TotalItems attribute of the library by 1
-			{
-				Update: &types.Update{
-					TableName: aws.String(tableName),
-					Key: map[string]types.AttributeValue{
-						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(i.OwnerId)},
-						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(i.LibraryId)},
-					},
-					UpdateExpression: aws.String("SET TotalItems = TotalItems + :incr"),
-					ExpressionAttributeValues: map[string]types.AttributeValue{
-						":incr": &types.AttributeValueMemberN{
-							Value: "1",
-						},
-					},
-				},
-			},
-		},
-	})
-
-	if err != nil {
-		log.Error().Str("title", i.Title).Msgf("Failed to put item: %s", err.Error())
-		return err
-	}
-
-	return nil
 }
 
 func (d *dynamo) QueryItemsByLibrary(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.LibraryContent, error) {
@@ -629,30 +1065,7 @@ func (d *dynamo) QueryItemsByLibrary(ownerId string, libraryId string, continuat
 			log.Warn().Str("id", libraryId).Msgf("Failed to unmarshal library item: %s", err.Error())
 		}
 
-		items = append(items, &domain.LibraryItem{
-			Id:             record.Id,
-			Title:          record.Title,
-			OwnerId:        record.OwnerId,
-			OwnerName:      record.OwnerName,
-			LibraryId:      record.LibraryId,
-			LibraryName:    record.LibraryName,
-			Summary:        record.Summary,
-			Authors:        record.Authors,
-			Isbn:           record.Isbn,
-			UpdatedAt:      record.UpdatedAt,
-			Type:           domain.ItemType(record.Type),
-			PictureUrl:     record.PictureUrl,
-			LentTo:         record.LentTo,
-			CollectionId:   record.CollectionId,
-			CollectionName: record.CollectionName,
-			Order:          record.Order,
-			// Video-specific fields
-			Directors:   record.Directors,
-			Cast:        record.Cast,
-			ReleaseYear: record.ReleaseYear,
-			Duration:    record.Duration,
-			TmdbId:      record.TmdbId,
-		})
+		items = append(items, mapRecordToLibraryItem(&record))
 	}
 
 	content := &domain.LibraryContent{
@@ -671,6 +1084,107 @@ func (d *dynamo) QueryItemsByLibrary(ownerId string, libraryId string, continuat
 	return content, nil
 }
 
+// QueryFilteredItems returns the library items matching all the filter criteria (status, tag, location).
+// Pages are filled up to pageSize since the filter is applied after the key condition.
+// The status filter matches the statuses of the requester, kept apart from the items.
+func (d *dynamo) QueryFilteredItems(ownerId string, libraryId string, filter *domain.ItemFilter, continuationToken string, pageSize int) (*domain.LibraryContent, error) {
+	keyCond := expression.Key("GSI1PK").Equal(expression.Value(persistence.MakeLibraryItemGSI1PK(ownerId, libraryId))).
+		And(expression.Key("GSI1SK").BeginsWith("item#"))
+
+	// Collections share the GSI1SK prefix, they never carry a status, tags nor location
+	cond := expression.Name("EntityType").NotEqual(expression.Value(persistence.TypeCollection))
+	var statusIds map[string]bool
+	if filter.Status != nil {
+		var err error
+		statusIds, err = d.queryItemIdsByStatus(filter.UserId, libraryId, *filter.Status)
+		if err != nil {
+			return nil, err
+		}
+		if len(statusIds) == 0 {
+			return &domain.LibraryContent{Items: []*domain.LibraryItem{}}, nil
+		}
+	}
+	if filter.Tag != nil {
+		cond = cond.And(expression.Contains(expression.Name("Tags"), *filter.Tag))
+	}
+	if len(filter.LocationIds) > 0 {
+		locationIds := slices.Map(filter.LocationIds, func(id string) expression.OperandBuilder { return expression.Value(id) })
+		if len(locationIds) == 1 {
+			cond = cond.And(expression.Name("LocationId").Equal(locationIds[0]))
+		} else {
+			cond = cond.And(expression.Name("LocationId").In(locationIds[0], locationIds[1:]...))
+		}
+	}
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithFilter(cond).Build()
+	if err != nil {
+		log.Error().Str("id", libraryId).Msgf("Failed to build filter expression: %s", err.Error())
+		return nil, err
+	}
+
+	query := dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		IndexName:                 aws.String("GSI1"),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	if continuationToken != "" {
+		lek, err := deserializeLek(continuationToken)
+		if err != nil {
+			log.Error().Str("id", libraryId).Msgf("Unable to deserialize continuation token: %s", err.Error())
+			return nil, errors.New("unable to deserialize continuation token")
+		}
+
+		query.ExclusiveStartKey = lek
+	}
+
+	items := []*domain.LibraryItem{}
+	for {
+		query.Limit = aws.Int32(int32(pageSize - len(items)))
+		result, err := d.client.Query(context.TODO(), &query)
+		if err != nil {
+			log.Error().Str("id", libraryId).Msgf("Failed to query filtered library items: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("id", libraryId).Msgf("Failed to unmarshal library item: %s", err.Error())
+			}
+
+			if statusIds != nil && !statusIds[record.Id] {
+				continue
+			}
+
+			items = append(items, mapRecordToLibraryItem(&record))
+		}
+
+		query.ExclusiveStartKey = result.LastEvaluatedKey
+		if result.LastEvaluatedKey == nil || len(items) >= pageSize {
+			break
+		}
+	}
+
+	content := &domain.LibraryContent{
+		Items: items,
+	}
+
+	if query.ExclusiveStartKey != nil {
+		nextToken, err := serializeLek(query.ExclusiveStartKey)
+		if err != nil {
+			log.Error().Str("id", libraryId).Msg("Unable to serialize continuation token")
+			return nil, errors.New("unable to serialize continuation token")
+		}
+		content.ContinuationToken = *nextToken
+	}
+
+	return content, nil
+}
+
 // PaginationState encodes collection context for resuming mid-collection pagination
 type PaginationState struct {
 	LastEvaluatedKey map[string]types.AttributeValue `json:"lek,omitempty"`
@@ -681,10 +1195,12 @@ type PaginationState struct {
 
 // CollectionContext holds the active collection when pagination splits a collection
 type CollectionContext struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"desc,omitempty"`
-	ItemCount   int    `json:"cnt"` // Total items in collection (for UI display)
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"desc,omitempty"`
+	ItemCount    int    `json:"cnt"` // Total items in collection (for UI display)
+	IsSeries     bool   `json:"series,omitempty"`
+	TotalVolumes *int   `json:"vols,omitempty"`
 }
 
 // QueryLibraryContentGrouped returns library content with collections nested with their items.
@@ -763,6 +1279,9 @@ func (d *dynamo) QueryLibraryContentGrouped(ownerId string, libraryId string, co
 				Items:     []*domain.LibraryItem{},
 				ItemCount: ctx.ItemCount,
 				Partial:   true,
+				// Series info
+				IsSeries:     ctx.IsSeries,
+				TotalVolumes: ctx.TotalVolumes,
 			}
 			collectionsMap[partialColl.Id] = partialColl
 			partialCollections = append(partialCollections, partialColl)
@@ -796,11 +1315,14 @@ func (d *dynamo) QueryLibraryContentGrouped(ownerId string, libraryId string, co
 				Items:     []*domain.LibraryItem{},
 				ItemCount: record.ItemCount,
 				Partial:   false,
+				// Series info
+				IsSeries:     record.IsSeries,
+				TotalVolumes: record.TotalVolumes,
 			}
 			collectionsMap[record.Id] = collection
 			orderedEntities = append(orderedEntities, entityRef{id: record.Id, isCollection: true})
 		} else {
-			// BOOK or VIDEO item
+			// BOOK, VIDEO, MUSIC or BOARDGAME item
 			record := persistence.LibraryItem{}
 			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
 				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
@@ -875,6 +1397,9 @@ func (d *dynamo) QueryLibraryContentGrouped(ownerId string, libraryId string, co
 					Name:        coll.Title,
 					Description: coll.Summary,
 					ItemCount:   coll.ItemCount,
+					// Series info
+					IsSeries:     coll.IsSeries,
+					TotalVolumes: coll.TotalVolumes,
 				})
 			}
 		}
@@ -906,15 +1431,177 @@ func mapRecordToLibraryItem(record *persistence.LibraryItem) *domain.LibraryItem
 		Type:           domain.ItemType(record.Type),
 		PictureUrl:     record.PictureUrl,
 		LentTo:         record.LentTo,
+		LentToId:       record.LentToId,
+		DueDate:        record.DueDate,
+		Condition:      itemConditionFromRecord(record.Condition),
 		CollectionId:   record.CollectionId,
 		CollectionName: record.CollectionName,
 		Order:          record.Order,
+		Volume:         record.Volume,
+		RatingTotal:    record.RatingTotal,
+		RatingCount:    record.RatingCount,
+		Tags:           record.Tags,
+		Wanted:         record.Wanted,
+		LocationId:     record.LocationId,
+		LocationPath:   record.LocationPath,
+		Format:         itemFormatFromRecord(record.Format),
+		Copies:         itemCopiesFromRecord(record.Copies),
+		Acquisition:    acquisitionFromRecord(record),
+		CustomValues:   record.CustomValues,
 		Directors:      record.Directors,
 		Cast:           record.Cast,
 		ReleaseYear:    record.ReleaseYear,
 		Duration:       record.Duration,
 		TmdbId:         record.TmdbId,
+		VideoKind:      videoKindFromRecord(record.VideoKind),
+		Seasons:        record.Seasons,
+		SeasonCount:    record.SeasonCount,
+		Artists:        record.Artists,
+		Tracklist:      record.Tracklist,
+		Label:          record.Label,
+		Barcode:        record.Barcode,
+		Designers:      record.Designers,
+		Publisher:      record.Publisher,
+		MinPlayers:     record.MinPlayers,
+		MaxPlayers:     record.MaxPlayers,
+		PlayTime:       record.PlayTime,
+		BggId:          record.BggId,
+		DeletedAt:      record.DeletedAt,
+		ExpiresAt:      expiresAtFromRecord(record.ExpiresAt),
+	}
+}
+
+// videoKindToRecord converts the optional domain video kind to its persisted form
+func videoKindToRecord(kind *domain.VideoKind) *string {
+	if kind == nil {
+		return nil
+	}
+	k := string(*kind)
+	return &k
+}
+
+// videoKindFromRecord converts the optional persisted video kind to its domain form
+func videoKindFromRecord(kind *string) *domain.VideoKind {
+	if kind == nil {
+		return nil
+	}
+	k := domain.VideoKind(*kind)
+	return &k
+}
+
+// itemFormatToRecord converts the optional domain format to its persisted form
+func itemFormatToRecord(format *domain.ItemFormat) *string {
+	if format == nil {
+		return nil
+	}
+	f := string(*format)
+	return &f
+}
+
+// itemFormatFromRecord converts the optional persisted format to its domain form
+func itemFormatFromRecord(format *string) *domain.ItemFormat {
+	if format == nil {
+		return nil
+	}
+	f := domain.ItemFormat(*format)
+	return &f
+}
+
+func itemConditionToRecord(condition *domain.ItemCondition) *string {
+	if condition == nil {
+		return nil
 	}
+	c := string(*condition)
+	return &c
+}
+
+func itemConditionFromRecord(condition *string) *domain.ItemCondition {
+	if condition == nil {
+		return nil
+	}
+	c := domain.ItemCondition(*condition)
+	return &c
+}
+
+// itemCopiesToRecord converts the domain copies to their persisted form, nil when the item has no copies
+func itemCopiesToRecord(copies []domain.ItemCopy) []persistence.ItemCopy {
+	if len(copies) == 0 {
+		return nil
+	}
+
+	records := []persistence.ItemCopy{}
+	for _, c := range copies {
+		records = append(records, persistence.ItemCopy{
+			Id:        c.Id,
+			Format:    itemFormatToRecord(c.Format),
+			Condition: itemConditionToRecord(c.Condition),
+			LentTo:    c.LentTo,
+			LentToId:  c.LentToId,
+			DueDate:   c.DueDate,
+		})
+	}
+	return records
+}
+
+// itemCopiesFromRecord converts the persisted copies to their domain form
+func itemCopiesFromRecord(records []persistence.ItemCopy) []domain.ItemCopy {
+	if len(records) == 0 {
+		return nil
+	}
+
+	copies := []domain.ItemCopy{}
+	for _, r := range records {
+		copies = append(copies, domain.ItemCopy{
+			Id:        r.Id,
+			Format:    itemFormatFromRecord(r.Format),
+			Condition: itemConditionFromRecord(r.Condition),
+			LentTo:    r.LentTo,
+			LentToId:  r.LentToId,
+			DueDate:   r.DueDate,
+		})
+	}
+	return copies
+}
+
+// customValuesToRecord returns nil when the item has no custom values, so that the attribute is not stored
+func customValuesToRecord(values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// acquisitionToRecord splits the optional acquisition into its persisted attributes
+func acquisitionToRecord(a *domain.Acquisition) (date *time.Time, price *float64, currency *string, place *string) {
+	if a == nil {
+		return nil, nil, nil, nil
+	}
+	if a.Currency != "" {
+		currency = &a.Currency
+	}
+	if a.Place != "" {
+		place = &a.Place
+	}
+	return a.Date, a.Price, currency, place
+}
+
+// acquisitionFromRecord gathers the persisted acquisition attributes, nil when none is set
+func acquisitionFromRecord(record *persistence.LibraryItem) *domain.Acquisition {
+	if record.PurchaseDate == nil && record.PurchasePrice == nil && record.Currency == nil && record.PurchasePlace == nil {
+		return nil
+	}
+
+	a := domain.Acquisition{
+		Date:  record.PurchaseDate,
+		Price: record.PurchasePrice,
+	}
+	if record.Currency != nil {
+		a.Currency = *record.Currency
+	}
+	if record.PurchasePlace != nil {
+		a.Place = *record.PurchasePlace
+	}
+	return &a
 }
 
 // paginationStateJSON is the JSON-serializable version of PaginationState
@@ -1082,3 +1769,137 @@ func (d *dynamo) GetMaxOrderInCollection(ownerId string, libraryId string, colle
 
 	return maxOrder, nil
 }
+
+// QueryItemsAcquisition returns all the items of a library with only the attributes needed to value them:
+// type, collection, wishlist flag and purchase price.
+func (d *dynamo) QueryItemsAcquisition(ownerId string, libraryId string) ([]*domain.LibraryItem, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK,:library_item_prefix)"),
+		ProjectionExpression:   aws.String("#ItemId, #Type, #CollectionId, #CollectionName, #Wanted, #PurchasePrice, #Currency"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK":         "GSI1PK",
+			"#GSI1SK":         "GSI1SK",
+			"#ItemId":         "ItemId",
+			"#Type":           "Type",
+			"#CollectionId":   "CollectionId",
+			"#CollectionName": "CollectionName",
+			"#Wanted":         "Wanted",
+			"#PurchasePrice":  "PurchasePrice",
+			"#Currency":       "Currency",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI1PK(ownerId, libraryId),
+			},
+			":library_item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	items := []*domain.LibraryItem{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("libraryId", libraryId).Msgf("Failed to query items acquisition: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+				continue
+			}
+
+			items = append(items, &domain.LibraryItem{
+				Id:             record.Id,
+				Type:           domain.ItemType(record.Type),
+				OwnerId:        ownerId,
+				LibraryId:      libraryId,
+				CollectionId:   record.CollectionId,
+				CollectionName: record.CollectionName,
+				Wanted:         record.Wanted,
+				Acquisition:    acquisitionFromRecord(&record),
+			})
+		}
+	}
+
+	return items, nil
+}
+
+// QueryItemIdentifiers returns the items of the given type with only their identifying attributes (ISBN, TMDB id).
+// Uses the owner-wide GSI2 when libraryId is empty, the library GSI1 otherwise.
+func (d *dynamo) QueryItemIdentifiers(ownerId string, libraryId string, itemType domain.ItemType) ([]*domain.LibraryItem, error) {
+	query := dynamodb.QueryInput{
+		TableName:            aws.String(tableName),
+		FilterExpression:     aws.String("#Type = :type"),
+		ProjectionExpression: aws.String("#ItemId, #Title, #Type, #OwnerId, #OwnerName, #LibraryId, #LibraryName, #Isbn, #TmdbId, #VideoKind, #Wanted"),
+		ExpressionAttributeNames: map[string]string{
+			"#ItemId":      "ItemId",
+			"#Title":       "Title",
+			"#Type":        "Type",
+			"#OwnerId":     "OwnerId",
+			"#OwnerName":   "OwnerName",
+			"#LibraryId":   "LibraryId",
+			"#LibraryName": "LibraryName",
+			"#Isbn":        "Isbn",
+			"#TmdbId":      "TmdbId",
+			"#VideoKind":   "VideoKind",
+			"#Wanted":      "Wanted",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":type": &types.AttributeValueMemberN{
+				Value: fmt.Sprintf("%d", int(itemType)),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+		},
+	}
+
+	if libraryId == "" {
+		query.IndexName = aws.String("GSI2")
+		query.KeyConditionExpression = aws.String("#GSI2PK = :pk and begins_with(#GSI2SK,:item_prefix)")
+		query.ExpressionAttributeNames["#GSI2PK"] = "GSI2PK"
+		query.ExpressionAttributeNames["#GSI2SK"] = "GSI2SK"
+		query.ExpressionAttributeValues[":pk"] = &types.AttributeValueMemberS{
+			Value: persistence.MakeLibraryItemGSI2PK(ownerId),
+		}
+	} else {
+		query.IndexName = aws.String("GSI1")
+		query.KeyConditionExpression = aws.String("#GSI1PK = :pk and begins_with(#GSI1SK,:item_prefix)")
+		query.ExpressionAttributeNames["#GSI1PK"] = "GSI1PK"
+		query.ExpressionAttributeNames["#GSI1SK"] = "GSI1SK"
+		query.ExpressionAttributeValues[":pk"] = &types.AttributeValueMemberS{
+			Value: persistence.MakeLibraryItemGSI1PK(ownerId, libraryId),
+		}
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	items := []*domain.LibraryItem{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("ownerId", ownerId).Msgf("Failed to query item identifiers: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+				continue
+			}
+
+			items = append(items, mapRecordToLibraryItem(&record))
+		}
+	}
+
+	return items, nil
+}
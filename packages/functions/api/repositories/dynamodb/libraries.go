@@ -7,7 +7,6 @@ import (
 
 	"alexandria.isnan.eu/functions/internal/domain"
 	"alexandria.isnan.eu/functions/internal/persistence"
-	"alexandria.isnan.eu/functions/internal/slices"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -16,6 +15,21 @@ import (
 )
 
 func (d *dynamo) GetLibrary(ownerId string, libraryId string) (*domain.Library, error) {
+	record, err := d.getLibraryRecord(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A trashed library is only reachable through the trash
+	if record.DeletedAt != nil {
+		log.Error().Str("id", libraryId).Msg("Library is in the trash")
+		return nil, errors.New("unknown library")
+	}
+
+	return mapRecordToLibrary(record), nil
+}
+
+func (d *dynamo) getLibraryRecord(ownerId string, libraryId string) (*persistence.Library, error) {
 
 	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		TableName: aws.String(tableName),
@@ -41,110 +55,51 @@ func (d *dynamo) GetLibrary(ownerId string, libraryId string) (*domain.Library,
 		return nil, err
 	}
 
-	return &domain.Library{
-			Id:          record.Id,
-			Name:        record.Name,
-			Description: record.Description,
-			TotalItems:  record.TotalItems,
-			UpdatedAt:   record.UpdatedAt,
-			OwnerId:     record.OwnerId,
-			OwnerName:   record.OwnerName,
-			SharedTo:    record.SharedTo,
-		},
-		nil
-
+	return &record, nil
 }
 
-func (d *dynamo) DeleteLibrary(l *domain.Library) error {
-
-	// Get all items with PK=owner#<owner id> and SK begins with library#<library id>
-	query := dynamodb.QueryInput{
-		TableName:              aws.String(tableName),
-		KeyConditionExpression: aws.String("#PK = :ownerId and begins_with(#SK,:library_sorting_key)"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":ownerId": &types.AttributeValueMemberS{
-				Value: persistence.MakeLibraryPK(l.OwnerId),
-			},
-			":library_sorting_key": &types.AttributeValueMemberS{
-				Value: persistence.MakeLibrarySK(l.Id),
-			},
-		},
-		ExpressionAttributeNames: map[string]string{
-			"#PK": "PK",
-			"#SK": "SK",
-		},
-		Limit: aws.Int32(25),
-	}
-
-	type Record struct {
-		PK string `dynamodbav:"PK"`
-		SK string `dynamodbav:"SK"`
-	}
-
-	records := []types.WriteRequest{}
-	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
-	for i := 0; queryPaginator.HasMorePages(); i++ {
-		result, err := queryPaginator.NextPage(context.TODO())
-
-		if err != nil {
-			log.Error().Str("id", l.Id).Msgf("Failed to query records to delete: %s", err.Error())
-			return err
-		}
-
-		if result.Count > 0 {
-			for _, item := range result.Items {
-				record := Record{}
-				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
-					log.Warn().Msgf("Failed to unmarshal record: %s", err.Error())
-					continue
-				}
-
-				records = append(records, types.WriteRequest{
-					DeleteRequest: &types.DeleteRequest{
-						Key: map[string]types.AttributeValue{
-							"PK": &types.AttributeValueMemberS{Value: record.PK},
-							"SK": &types.AttributeValueMemberS{Value: record.SK},
-						},
-					},
-				})
-			}
-		}
-	}
-
-	chunks := slices.ChunkBy(records, 25)
-
-	for _, c := range chunks {
-		_, err := d.client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				tableName: c,
-			},
-		})
-		if err != nil {
-			log.Warn().Str("id", l.Id).Msgf("Failed to batch delete records: %s", err.Error())
-			continue
-		}
+func mapRecordToLibrary(record *persistence.Library) *domain.Library {
+	return &domain.Library{
+		Id:           record.Id,
+		Name:         record.Name,
+		Description:  record.Description,
+		Kind:         libraryKindFromRecord(record.Kind),
+		TotalItems:   record.TotalItems,
+		UpdatedAt:    record.UpdatedAt,
+		OwnerId:      record.OwnerId,
+		OwnerName:    record.OwnerName,
+		SharedTo:     record.SharedTo,
+		ShareRoles:   shareRolesFromRecord(record.SharedTo, record.ShareRoles),
+		DeletedAt:    record.DeletedAt,
+		ExpiresAt:    expiresAtFromRecord(record.ExpiresAt),
+		CustomFields: customFieldsFromRecord(record.CustomFields),
 	}
-
-	return nil
 }
 
 func (d *dynamo) UpdateLibrary(l *domain.Library) error {
 
-	_, err := d.client.UpdateItem(context.TODO(),
+	customFields, err := attributevalue.Marshal(customFieldsToRecord(l.CustomFields))
+	if err != nil {
+		log.Error().Str("id", l.Id).Msgf("Failed to marshal library custom fields: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.UpdateItem(context.TODO(),
 		&dynamodb.UpdateItemInput{
 			TableName: aws.String(tableName),
 			Key: map[string]types.AttributeValue{
 				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(l.OwnerId)},
 				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(l.Id)},
 			},
-			UpdateExpression:    aws.String("set LibraryName = :name, Description = :description, UpdatedAt = :updatedAt, GSI1PK = :gsi1pk, GSI1SK = :gsi1sk"),
-			ConditionExpression: aws.String("attribute_exists(PK)"),
+			UpdateExpression:    aws.String("set LibraryName = :name, Description = :description, CustomFields = :customFields, UpdatedAt = :updatedAt, GSI1PK = :gsi1pk, GSI1SK = :gsi1sk"),
+			ConditionExpression: aws.String("attribute_exists(PK) and attribute_not_exists(DeletedAt)"),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":name":        &types.AttributeValueMemberS{Value: l.Name},
-				":description": &types.AttributeValueMemberS{Value: l.Description},
-				":updatedAt":   &types.AttributeValueMemberS{Value: l.UpdatedAt.Format(time.RFC3339Nano)},
-				":gsi1pk":      &types.AttributeValueMemberS{Value: persistence.MakeLibraryGSI1PK(l.OwnerId)},
-				":gsi1sk":      &types.AttributeValueMemberS{Value: persistence.MakeLibraryGSI1SK(l.Name)},
+				":name":         &types.AttributeValueMemberS{Value: l.Name},
+				":description":  &types.AttributeValueMemberS{Value: l.Description},
+				":customFields": customFields,
+				":updatedAt":    &types.AttributeValueMemberS{Value: l.UpdatedAt.Format(time.RFC3339Nano)},
+				":gsi1pk":       &types.AttributeValueMemberS{Value: persistence.MakeLibraryGSI1PK(l.OwnerId)},
+				":gsi1sk":       &types.AttributeValueMemberS{Value: persistence.MakeLibraryGSI1SK(l.Name)},
 			},
 		})
 
@@ -197,13 +152,16 @@ func (d *dynamo) QueryLibraries(ownerId string) ([]domain.Library, error) {
 				}
 
 				records = append(records, domain.Library{
-					Id:          record.Id,
-					Name:        record.Name,
-					Description: record.Description,
-					TotalItems:  record.TotalItems,
-					UpdatedAt:   record.UpdatedAt,
-					OwnerName:   record.OwnerName,
-					SharedTo:    record.SharedTo,
+					Id:           record.Id,
+					Name:         record.Name,
+					Description:  record.Description,
+					Kind:         libraryKindFromRecord(record.Kind),
+					TotalItems:   record.TotalItems,
+					UpdatedAt:    record.UpdatedAt,
+					OwnerName:    record.OwnerName,
+					SharedTo:     record.SharedTo,
+					ShareRoles:   shareRolesFromRecord(record.SharedTo, record.ShareRoles),
+					CustomFields: customFieldsFromRecord(record.CustomFields),
 				})
 			}
 		}
@@ -230,9 +188,10 @@ func (d *dynamo) QueryLibraries(ownerId string) ([]domain.Library, error) {
 	queryPaginatorSharedLibraries := dynamodb.NewQueryPaginator(d.client, &querySharedLibraries)
 
 	type sharedLibraryIdentifier struct {
-		LibraryId      string `dynamodbav:"LibraryId"`
-		SharedFromId   string `dynamodbav:"SharedFromId"`
-		SharedFromName string `dynamodbav:"SharedFromName"`
+		LibraryId      string  `dynamodbav:"LibraryId"`
+		SharedFromId   string  `dynamodbav:"SharedFromId"`
+		SharedFromName string  `dynamodbav:"SharedFromName"`
+		Role           *string `dynamodbav:"Role"`
 	}
 	sharedLibrariesIdentifiers := map[string]sharedLibraryIdentifier{}
 	for i := 0; queryPaginatorSharedLibraries.HasMorePages(); i++ {
@@ -284,13 +243,16 @@ func (d *dynamo) QueryLibraries(ownerId string) ([]domain.Library, error) {
 					continue
 				}
 				records = append(records, domain.Library{
-					Id:          record.Id,
-					Name:        record.Name,
-					Description: record.Description,
-					TotalItems:  record.TotalItems,
-					UpdatedAt:   record.UpdatedAt,
-					OwnerName:   record.OwnerName,
-					SharedFrom:  aws.String(sharedLibrariesIdentifiers[record.Id].SharedFromName),
+					Id:           record.Id,
+					Name:         record.Name,
+					Description:  record.Description,
+					Kind:         libraryKindFromRecord(record.Kind),
+					TotalItems:   record.TotalItems,
+					UpdatedAt:    record.UpdatedAt,
+					OwnerName:    record.OwnerName,
+					SharedFrom:   aws.String(sharedLibrariesIdentifiers[record.Id].SharedFromName),
+					ShareRole:    shareRoleFromRecord(sharedLibrariesIdentifiers[record.Id].Role),
+					CustomFields: customFieldsFromRecord(record.CustomFields),
 				})
 			}
 		}
@@ -323,6 +285,12 @@ func (d *dynamo) UnshareLibrary(s *domain.UnshareLibrary) error {
 		newSharedToAttr.Value = append(newSharedToAttr.Value, &types.AttributeValueMemberS{Value: userName})
 	}
 
+	shareRoles, err := attributevalue.Marshal(shareRolesToRecord(s.NewShareRoles))
+	if err != nil {
+		log.Error().Str("libraryId", s.LibraryId).Msgf("Failed to marshal share roles: %s", err.Error())
+		return err
+	}
+
 	// Update library with filtered sharedTo list
 	transactItems = append(transactItems, types.TransactWriteItem{
 		Update: &types.Update{
@@ -331,14 +299,15 @@ func (d *dynamo) UnshareLibrary(s *domain.UnshareLibrary) error {
 				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(s.SharedFromUserId)},
 				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(s.LibraryId)},
 			},
-			UpdateExpression: aws.String("SET SharedTo = :sharedTo"),
+			UpdateExpression: aws.String("SET SharedTo = :sharedTo, ShareRoles = :shareRoles"),
 			ExpressionAttributeValues: map[string]types.AttributeValue{
-				":sharedTo": newSharedToAttr,
+				":sharedTo":   newSharedToAttr,
+				":shareRoles": shareRoles,
 			},
 		},
 	})
 
-	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
 		TransactItems: transactItems,
 	})
 
@@ -358,6 +327,7 @@ func (d *dynamo) ShareLibrary(s *domain.ShareLibrary) error {
 		SharedToId:     s.SharedToUserId,
 		SharedFromId:   s.SharedFromUserId,
 		SharedFromName: s.SharedFromUserName,
+		Role:           aws.String(string(s.Role)),
 		UpdatedAt:      s.UpdatedAt,
 		EntityType:     persistence.TypeSharedLibrary,
 	}
@@ -367,6 +337,12 @@ func (d *dynamo) ShareLibrary(s *domain.ShareLibrary) error {
 		return err
 	}
 
+	shareRoles, err := attributevalue.Marshal(shareRolesToRecord(s.NewShareRoles))
+	if err != nil {
+		log.Error().Str("libraryId", s.LibraryId).Msgf("Failed to marshal share roles: %s", err.Error())
+		return err
+	}
+
 	sharedTo := types.AttributeValueMemberS{
 		Value: s.SharedToUserName,
 	}
@@ -391,7 +367,7 @@ func (d *dynamo) ShareLibrary(s *domain.ShareLibrary) error {
 						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(s.SharedFromUserId)},
 						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(s.LibraryId)},
 					},
-					UpdateExpression: aws.String("SET SharedTo = list_append(if_not_exists(SharedTo, :emptyList), :sharedTo)"),
+					UpdateExpression: aws.String("SET SharedTo = list_append(if_not_exists(SharedTo, :emptyList), :sharedTo), ShareRoles = :shareRoles"),
 					ExpressionAttributeValues: map[string]types.AttributeValue{
 						":sharedTo": &types.AttributeValueMemberL{
 							Value: sharedToList,
@@ -399,6 +375,7 @@ func (d *dynamo) ShareLibrary(s *domain.ShareLibrary) error {
 						":emptyList": &types.AttributeValueMemberL{
 							Value: []types.AttributeValue{},
 						},
+						":shareRoles": shareRoles,
 					},
 				},
 			},
@@ -413,22 +390,76 @@ func (d *dynamo) ShareLibrary(s *domain.ShareLibrary) error {
 	return nil
 }
 
+// UpdateLibraryShareRole changes the role of an existing share, on the shared library and in the library roles
+func (d *dynamo) UpdateLibraryShareRole(s *domain.ShareLibrary) error {
+	shareRoles, err := attributevalue.Marshal(shareRolesToRecord(s.NewShareRoles))
+	if err != nil {
+		log.Error().Str("libraryId", s.LibraryId).Msgf("Failed to marshal share roles: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeSharedLibraryPK(s.SharedToUserId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeSharedLibrarySK(s.LibraryId)},
+					},
+					UpdateExpression:    aws.String("SET #role = :role, UpdatedAt = :updatedAt"),
+					ConditionExpression: aws.String("attribute_exists(PK) and attribute_exists(SK)"),
+					ExpressionAttributeNames: map[string]string{
+						"#role": "Role",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":role":      &types.AttributeValueMemberS{Value: string(s.Role)},
+						":updatedAt": &types.AttributeValueMemberS{Value: s.UpdatedAt.Format(time.RFC3339Nano)},
+					},
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(s.SharedFromUserId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(s.LibraryId)},
+					},
+					UpdateExpression: aws.String("SET ShareRoles = :shareRoles"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":shareRoles": shareRoles,
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("libraryId", s.LibraryId).Msgf("Failed to update share role: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (d *dynamo) PutLibrary(l *domain.Library) error {
 
 	record := persistence.Library{
-		PK:          persistence.MakeLibraryPK(l.OwnerId),
-		SK:          persistence.MakeLibrarySK(l.Id),
-		GSI1PK:      persistence.MakeLibraryGSI1PK(l.OwnerId),
-		GSI1SK:      persistence.MakeLibraryGSI1SK(l.Name),
-		Id:          l.Id,
-		OwnerName:   l.OwnerName,
-		OwnerId:     l.OwnerId,
-		Name:        l.Name,
-		Description: l.Description,
-		TotalItems:  0,
-		UpdatedAt:   l.UpdatedAt,
-		SharedTo:    make([]string, 0),
-		EntityType:  persistence.TypeLibrary,
+		PK:           persistence.MakeLibraryPK(l.OwnerId),
+		SK:           persistence.MakeLibrarySK(l.Id),
+		GSI1PK:       persistence.MakeLibraryGSI1PK(l.OwnerId),
+		GSI1SK:       persistence.MakeLibraryGSI1SK(l.Name),
+		Id:           l.Id,
+		OwnerName:    l.OwnerName,
+		OwnerId:      l.OwnerId,
+		Name:         l.Name,
+		Description:  l.Description,
+		Kind:         libraryKindToRecord(l.Kind),
+		TotalItems:   0,
+		UpdatedAt:    l.UpdatedAt,
+		SharedTo:     make([]string, 0),
+		EntityType:   persistence.TypeLibrary,
+		CustomFields: customFieldsToRecord(l.CustomFields),
 	}
 
 	item, err := attributevalue.MarshalMap(record)
@@ -451,29 +482,125 @@ func (d *dynamo) PutLibrary(l *domain.Library) error {
 }
 
 func (d *dynamo) GetSharedLibrary(ownerId string, libraryId string) (string, error) {
+	share, err := d.GetLibraryShare(ownerId, libraryId)
+	if err != nil || share == nil {
+		return "", err
+	}
+
+	return share.OwnerId, nil
+}
+
+// GetLibraryShare returns the share of a library to a user, nil if the library is not shared to the user
+func (d *dynamo) GetLibraryShare(userId string, libraryId string) (*domain.LibraryShare, error) {
 	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		TableName: aws.String(tableName),
 		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: persistence.MakeSharedLibraryPK(ownerId)},
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeSharedLibraryPK(userId)},
 			"SK": &types.AttributeValueMemberS{Value: persistence.MakeSharedLibrarySK(libraryId)},
 		},
 	})
 
 	if err != nil {
 		log.Error().Str("id", libraryId).Msgf("Unable to get shared library: %s", err.Error())
-		return "", errors.New("unable to get shared library")
+		return nil, errors.New("unable to get shared library")
 	}
 
 	if output.Item == nil {
 		// Not a warning - caller will fall back to owned library lookup
-		return "", nil
+		return nil, nil
 	}
 
 	record := persistence.SharedLibrary{}
 	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
 		log.Error().Msgf("Failed to unmarshal library: %s", err.Error())
-		return "", err
+		return nil, err
+	}
+
+	return &domain.LibraryShare{
+		LibraryId: record.LibraryId,
+		OwnerId:   record.SharedFromId,
+		UserId:    record.SharedToId,
+		Role:      shareRoleFromRecord(record.Role),
+	}, nil
+}
+
+// shareRoleFromRecord converts the optional persisted share role, shares predating roles are read-only
+func shareRoleFromRecord(role *string) domain.ShareRole {
+	if role == nil || *role == "" {
+		return domain.ShareViewer
+	}
+	return domain.ShareRole(*role)
+}
+
+// shareRolesFromRecord returns the role of each user the library is shared to
+func shareRolesFromRecord(sharedTo []string, roles map[string]string) map[string]domain.ShareRole {
+	result := make(map[string]domain.ShareRole, len(sharedTo))
+	for _, userName := range sharedTo {
+		role, ok := roles[userName]
+		if !ok {
+			result[userName] = domain.ShareViewer
+			continue
+		}
+		result[userName] = shareRoleFromRecord(&role)
+	}
+	return result
+}
+
+func shareRolesToRecord(roles map[string]domain.ShareRole) map[string]string {
+	result := make(map[string]string, len(roles))
+	for userName, role := range roles {
+		result[userName] = string(role)
+	}
+	return result
+}
+
+// libraryKindToRecord converts the library kind to its persisted form, only wishlists are marked
+func libraryKindToRecord(kind domain.LibraryKind) *string {
+	if kind != domain.WishlistLibrary {
+		return nil
+	}
+	k := string(kind)
+	return &k
+}
+
+// libraryKindFromRecord converts the optional persisted library kind to its domain form
+func libraryKindFromRecord(kind *string) domain.LibraryKind {
+	if kind == nil {
+		return domain.OwnedLibrary
+	}
+	return domain.LibraryKind(*kind)
+}
+
+// customFieldsToRecord converts the library custom fields to their persisted form, nil when the library has none
+func customFieldsToRecord(fields []domain.CustomField) []persistence.CustomField {
+	if len(fields) == 0 {
+		return nil
 	}
 
-	return record.SharedFromId, nil
+	records := []persistence.CustomField{}
+	for _, f := range fields {
+		records = append(records, persistence.CustomField{
+			Name:    f.Name,
+			Type:    string(f.Type),
+			Options: f.Options,
+		})
+	}
+	return records
+}
+
+// customFieldsFromRecord converts the persisted custom fields to their domain form
+func customFieldsFromRecord(records []persistence.CustomField) []domain.CustomField {
+	if len(records) == 0 {
+		return nil
+	}
+
+	fields := []domain.CustomField{}
+	for _, r := range records {
+		fields = append(fields, domain.CustomField{
+			Name:    r.Name,
+			Type:    domain.CustomFieldType(r.Type),
+			Options: r.Options,
+		})
+	}
+	return fields
 }
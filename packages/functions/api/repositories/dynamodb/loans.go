@@ -0,0 +1,97 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// QueryOverdueItems returns the items of all the libraries of an owner whose next due date is before the given date.
+// Uses the owner-wide GSI2, trashed items are skipped.
+func (d *dynamo) QueryOverdueItems(ownerId string, before time.Time) ([]*domain.LibraryItem, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("#GSI2PK = :pk and begins_with(#GSI2SK,:item_prefix)"),
+		FilterExpression:       aws.String("#NextDueDate < :before and attribute_not_exists(#DeletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI2PK":      "GSI2PK",
+			"#GSI2SK":      "GSI2SK",
+			"#NextDueDate": "NextDueDate",
+			"#DeletedAt":   "DeletedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI2PK(ownerId),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+			":before": &types.AttributeValueMemberS{
+				Value: before.UTC().Format(time.RFC3339Nano),
+			},
+		},
+	}
+
+	return d.queryOwnerItems(ownerId, query)
+}
+
+// QueryLentItems returns the items of all the libraries of an owner lent, or having copies, whatever their due date.
+// Uses the owner-wide GSI2, trashed items are skipped.
+func (d *dynamo) QueryLentItems(ownerId string) ([]*domain.LibraryItem, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("#GSI2PK = :pk and begins_with(#GSI2SK,:item_prefix)"),
+		FilterExpression:       aws.String("(attribute_exists(#LentTo) or attribute_exists(#Copies)) and attribute_not_exists(#DeletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI2PK":    "GSI2PK",
+			"#GSI2SK":    "GSI2SK",
+			"#LentTo":    "LentTo",
+			"#Copies":    "Copies",
+			"#DeletedAt": "DeletedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI2PK(ownerId),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+		},
+	}
+
+	return d.queryOwnerItems(ownerId, query)
+}
+
+func (d *dynamo) queryOwnerItems(ownerId string, query dynamodb.QueryInput) ([]*domain.LibraryItem, error) {
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	items := []*domain.LibraryItem{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("ownerId", ownerId).Msgf("Failed to query items: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+				continue
+			}
+
+			items = append(items, mapRecordToLibraryItem(&record))
+		}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,223 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// GetLocation retrieves a location by ID, nil if it does not exist
+func (d *dynamo) GetLocation(ownerId string, libraryId string, locationId string) (*domain.Location, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLocationPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLocationSK(libraryId, locationId)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("locationId", locationId).Msgf("Unable to get location: %s", err.Error())
+		return nil, errors.New("unable to get location")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.Location{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal location: %s", err.Error())
+		return nil, err
+	}
+
+	return mapRecordToLocation(&record), nil
+}
+
+// QueryLocationsByLibrary returns all the locations of a library.
+// Query: PK = owner#<ownerId>, SK begins_with library#<libraryId>#location#
+func (d *dynamo) QueryLocationsByLibrary(ownerId string, libraryId string) ([]domain.Location, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk AND begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLocationPK(ownerId),
+			},
+			":sk_prefix": &types.AttributeValueMemberS{
+				Value: fmt.Sprintf("library#%s#location#", libraryId),
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	locations := []domain.Location{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("libraryId", libraryId).Msgf("Failed to query locations: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.Location{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal location: %s", err.Error())
+				continue
+			}
+
+			locations = append(locations, *mapRecordToLocation(&record))
+		}
+	}
+
+	return locations, nil
+}
+
+// PutLocation creates a new location
+func (d *dynamo) PutLocation(l *domain.Location) error {
+	record := persistence.Location{
+		PK:         persistence.MakeLocationPK(l.OwnerId),
+		SK:         persistence.MakeLocationSK(l.LibraryId, l.Id),
+		Id:         l.Id,
+		Name:       l.Name,
+		Kind:       string(l.Kind),
+		ParentId:   l.ParentId,
+		OwnerId:    l.OwnerId,
+		LibraryId:  l.LibraryId,
+		UpdatedAt:  l.UpdatedAt,
+		EntityType: persistence.TypeLocation,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("name", l.Name).Msgf("Failed to marshal location: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		log.Error().Str("name", l.Name).Msgf("Failed to put location: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateLocation renames a location, item paths are updated by the consistency manager
+func (d *dynamo) UpdateLocation(l *domain.Location) error {
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLocationPK(l.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLocationSK(l.LibraryId, l.Id)},
+		},
+		UpdateExpression:    aws.String("SET LocationName = :name, UpdatedAt = :updatedAt"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":name":      &types.AttributeValueMemberS{Value: l.Name},
+			":updatedAt": &types.AttributeValueMemberS{Value: l.UpdatedAt.Format(time.RFC3339Nano)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("locationId", l.Id).Msgf("Failed to update location: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteLocation removes a location, its items are cleared by the consistency manager
+func (d *dynamo) DeleteLocation(l *domain.Location) error {
+	_, err := d.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLocationPK(l.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLocationSK(l.LibraryId, l.Id)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("locationId", l.Id).Msgf("Failed to delete location: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateItemsLocation stores the given items into a location, or removes them from any location when l is nil.
+// Items are updated in transactions of 100, a missing item fails its whole chunk.
+func (d *dynamo) UpdateItemsLocation(ownerId string, libraryId string, itemIds []string, l *domain.Location) error {
+	var upd expression.UpdateBuilder
+	if l != nil {
+		upd = expression.Set(expression.Name("LocationId"), expression.Value(l.Id)).
+			Set(expression.Name("LocationPath"), expression.Value(l.Path))
+	} else {
+		upd = expression.Remove(expression.Name("LocationId")).
+			Remove(expression.Name("LocationPath"))
+	}
+
+	expr, err := expression.NewBuilder().WithUpdate(upd).Build()
+	if err != nil {
+		log.Error().Str("libraryId", libraryId).Msgf("Failed to build location update: %s", err.Error())
+		return err
+	}
+
+	for _, chunk := range slices.ChunkBy(itemIds, 100) {
+		transactItems := []types.TransactWriteItem{}
+		for _, itemId := range chunk {
+			transactItems = append(transactItems, types.TransactWriteItem{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(ownerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(libraryId, itemId)},
+					},
+					ConditionExpression:       aws.String("attribute_exists(PK) and attribute_exists(SK)"),
+					UpdateExpression:          expr.Update(),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+				},
+			})
+		}
+
+		_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		})
+		if err != nil {
+			log.Error().Str("libraryId", libraryId).Msgf("Failed to update items location: %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mapRecordToLocation(record *persistence.Location) *domain.Location {
+	return &domain.Location{
+		Id:        record.Id,
+		Name:      record.Name,
+		Kind:      domain.LocationKind(record.Kind),
+		ParentId:  record.ParentId,
+		OwnerId:   record.OwnerId,
+		LibraryId: record.LibraryId,
+		UpdatedAt: record.UpdatedAt,
+	}
+}
@@ -0,0 +1,239 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// GetItemReview returns the review of a user on an item, or nil if the user did not review it
+func (d *dynamo) GetItemReview(ownerId string, libraryId string, itemId string, userId string) (*domain.ItemReview, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewSK(libraryId, itemId, userId)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("id", itemId).Msgf("Unable to get item review: %s", err.Error())
+		return nil, errors.New("unable to get item review")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.ItemReview{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal item review: %s", err.Error())
+		return nil, err
+	}
+
+	return mapRecordToItemReview(&record), nil
+}
+
+// GetItemReviews returns the reviews of a user on the given items, indexed by item id
+func (d *dynamo) GetItemReviews(userId string, items []*domain.LibraryItem) (map[string]*domain.ItemReview, error) {
+	var keys []map[string]types.AttributeValue
+	for _, i := range items {
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewPK(i.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewSK(i.LibraryId, i.Id, userId)},
+		})
+	}
+
+	result := map[string]*domain.ItemReview{}
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	// BatchGetItem is limited to 100 keys per request
+	for _, chunk := range slices.ChunkBy(keys, 100) {
+		requestItems := map[string]types.KeysAndAttributes{
+			tableName: {
+				Keys: chunk,
+			},
+		}
+
+		for len(requestItems) > 0 {
+			res, err := d.client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				log.Error().Msgf("Failed to fetch item reviews: %s", err.Error())
+				return nil, err
+			}
+
+			for _, records := range res.Responses {
+				for _, r := range records {
+					record := persistence.ItemReview{}
+					if err := attributevalue.UnmarshalMap(r, &record); err != nil {
+						log.Warn().Msgf("Failed to unmarshal item review: %s", err.Error())
+						continue
+					}
+					result[record.ItemId] = mapRecordToItemReview(&record)
+				}
+			}
+
+			requestItems = res.UnprocessedKeys
+		}
+	}
+
+	return result, nil
+}
+
+// PutItemReview creates or replaces a user review, and keeps the item rating aggregates in sync.
+// previous is the review being replaced, nil on creation.
+func (d *dynamo) PutItemReview(r *domain.ItemReview, previous *domain.ItemReview) error {
+	record := persistence.ItemReview{
+		PK:         persistence.MakeItemReviewPK(r.OwnerId),
+		SK:         persistence.MakeItemReviewSK(r.LibraryId, r.ItemId, r.UserId),
+		OwnerId:    r.OwnerId,
+		LibraryId:  r.LibraryId,
+		ItemId:     r.ItemId,
+		UserId:     r.UserId,
+		Rating:     r.Rating,
+		Notes:      r.Notes,
+		UpdatedAt:  r.UpdatedAt,
+		EntityType: persistence.TypeReview,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("id", r.ItemId).Msgf("Failed to marshal item review: %s", err.Error())
+		return err
+	}
+
+	// Guard against concurrent changes, which would skew the aggregates
+	condition := "attribute_not_exists(PK)"
+	var values map[string]types.AttributeValue
+	if previous != nil {
+		condition, values = storedRatingCondition(previous.Rating)
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:                 aws.String(tableName),
+				Item:                      item,
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			},
+		},
+	}
+
+	var previousRating *int
+	if previous != nil {
+		previousRating = previous.Rating
+	}
+	if update := ratingAggregatesUpdate(r, previousRating, r.Rating); update != nil {
+		transactItems = append(transactItems, types.TransactWriteItem{Update: update})
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		log.Error().Str("id", r.ItemId).Msgf("Failed to put item review: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteItemReview removes a user review, and its rating from the item aggregates
+func (d *dynamo) DeleteItemReview(r *domain.ItemReview) error {
+	condition, values := storedRatingCondition(r.Rating)
+	transactItems := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewPK(r.OwnerId)},
+					"SK": &types.AttributeValueMemberS{Value: persistence.MakeItemReviewSK(r.LibraryId, r.ItemId, r.UserId)},
+				},
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			},
+		},
+	}
+
+	if update := ratingAggregatesUpdate(r, r.Rating, nil); update != nil {
+		transactItems = append(transactItems, types.TransactWriteItem{Update: update})
+	}
+
+	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		log.Error().Str("id", r.ItemId).Msgf("Failed to delete item review: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// storedRatingCondition builds the condition on the stored review still having the rating read before the change,
+// so that concurrent changes fail instead of applying the same rating twice to the aggregates
+func storedRatingCondition(rating *int) (string, map[string]types.AttributeValue) {
+	if rating == nil {
+		return "attribute_exists(PK) AND attribute_not_exists(Rating)", nil
+	}
+	return "attribute_exists(PK) AND Rating = :previousRating", map[string]types.AttributeValue{
+		":previousRating": &types.AttributeValueMemberN{Value: strconv.Itoa(*rating)},
+	}
+}
+
+// ratingAggregatesUpdate builds the item update replacing a rating by another one in the aggregates.
+// Returns nil when the aggregates are unchanged.
+func ratingAggregatesUpdate(r *domain.ItemReview, from *int, to *int) *types.Update {
+	total, count := 0, 0
+	if from != nil {
+		total -= *from
+		count--
+	}
+	if to != nil {
+		total += *to
+		count++
+	}
+
+	if total == 0 && count == 0 {
+		return nil
+	}
+
+	return &types.Update{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(r.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(r.LibraryId, r.ItemId)},
+		},
+		UpdateExpression:    aws.String("ADD RatingTotal :total, RatingCount :count"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":total": &types.AttributeValueMemberN{Value: strconv.Itoa(total)},
+			":count": &types.AttributeValueMemberN{Value: strconv.Itoa(count)},
+		},
+	}
+}
+
+func mapRecordToItemReview(record *persistence.ItemReview) *domain.ItemReview {
+	return &domain.ItemReview{
+		OwnerId:   record.OwnerId,
+		LibraryId: record.LibraryId,
+		ItemId:    record.ItemId,
+		UserId:    record.UserId,
+		Rating:    record.Rating,
+		Notes:     record.Notes,
+		UpdatedAt: record.UpdatedAt,
+	}
+}
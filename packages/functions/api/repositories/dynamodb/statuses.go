@@ -0,0 +1,206 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// GetItemStatus returns the status of a user on an item, or nil if the user never set it
+func (d *dynamo) GetItemStatus(ownerId string, libraryId string, itemId string, userId string) (*domain.UserItemStatus, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusSK(libraryId, itemId, userId)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("id", itemId).Msgf("Unable to get item status: %s", err.Error())
+		return nil, errors.New("unable to get item status")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.ItemStatus{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal item status: %s", err.Error())
+		return nil, err
+	}
+
+	return mapRecordToItemStatus(&record), nil
+}
+
+// GetItemStatuses returns the statuses of a user on the given items, indexed by item id
+func (d *dynamo) GetItemStatuses(userId string, items []*domain.LibraryItem) (map[string]*domain.UserItemStatus, error) {
+	var keys []map[string]types.AttributeValue
+	for _, i := range items {
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusPK(i.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusSK(i.LibraryId, i.Id, userId)},
+		})
+	}
+
+	result := map[string]*domain.UserItemStatus{}
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	// BatchGetItem is limited to 100 keys per request
+	for _, chunk := range slices.ChunkBy(keys, 100) {
+		requestItems := map[string]types.KeysAndAttributes{
+			tableName: {
+				Keys: chunk,
+			},
+		}
+
+		for len(requestItems) > 0 {
+			res, err := d.client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				log.Error().Msgf("Failed to fetch item statuses: %s", err.Error())
+				return nil, err
+			}
+
+			for _, records := range res.Responses {
+				for _, r := range records {
+					record := persistence.ItemStatus{}
+					if err := attributevalue.UnmarshalMap(r, &record); err != nil {
+						log.Warn().Msgf("Failed to unmarshal item status: %s", err.Error())
+						continue
+					}
+					result[record.ItemId] = mapRecordToItemStatus(&record)
+				}
+			}
+
+			requestItems = res.UnprocessedKeys
+		}
+	}
+
+	return result, nil
+}
+
+// PutItemStatus records a status change of a user in the item history, with the user, and replaces the user status on the item
+func (d *dynamo) PutItemStatus(i *domain.LibraryItem, s *domain.UserItemStatus, actor domain.Actor) error {
+	event := persistence.ItemEvent{
+		PK:         persistence.MakeItemEventPK(i.OwnerId),
+		SK:         persistence.MakeItemEventSK(i.LibraryId, i.Id, *s.Date),
+		GSI1PK:     persistence.MakeItemEventGSI1PK(i.OwnerId, i.LibraryId, i.Id),
+		GSI1SK:     persistence.MakeItemEventGSI1SK(*s.Date),
+		Type:       string(domain.StatusChanged),
+		Event:      string(s.Status),
+		UserId:     actor.UserId,
+		UserName:   actor.UserName,
+		UpdatedAt:  s.Date,
+		EntityType: persistence.TypeEvent,
+	}
+
+	eventItem, err := attributevalue.MarshalMap(event)
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to marshal item event: %s", err.Error())
+		return err
+	}
+
+	record := persistence.ItemStatus{
+		PK:         persistence.MakeItemStatusPK(i.OwnerId),
+		SK:         persistence.MakeItemStatusSK(i.LibraryId, i.Id, s.UserId),
+		GSI1PK:     persistence.MakeItemStatusGSI1PK(s.UserId, i.LibraryId),
+		GSI1SK:     persistence.MakeItemStatusGSI1SK(string(s.Status), i.Id),
+		OwnerId:    i.OwnerId,
+		LibraryId:  i.LibraryId,
+		ItemId:     i.Id,
+		UserId:     s.UserId,
+		Status:     string(s.Status),
+		StatusDate: s.Date,
+		EntityType: persistence.TypeItemStatus,
+	}
+
+	statusItem, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to marshal item status: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: aws.String(tableName),
+					Item:      eventItem,
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(tableName),
+					Item:      statusItem,
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to put item status: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// queryItemIdsByStatus returns the ids of the items of a library on which the user has the given status
+func (d *dynamo) queryItemIdsByStatus(userId string, libraryId string, status domain.ItemStatus) (map[string]bool, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK, :status)"),
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusGSI1PK(userId, libraryId)},
+			":status": &types.AttributeValueMemberS{Value: persistence.MakeItemStatusGSI1SK(string(status), "")},
+		},
+	}
+
+	ids := map[string]bool{}
+	paginator := dynamodb.NewQueryPaginator(d.client, &query)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("libraryId", libraryId).Msgf("Failed to query item statuses: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.ItemStatus{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("libraryId", libraryId).Msgf("Failed to unmarshal item status: %s", err.Error())
+				continue
+			}
+			ids[record.ItemId] = true
+		}
+	}
+
+	return ids, nil
+}
+
+func mapRecordToItemStatus(record *persistence.ItemStatus) *domain.UserItemStatus {
+	return &domain.UserItemStatus{
+		OwnerId:   record.OwnerId,
+		LibraryId: record.LibraryId,
+		ItemId:    record.ItemId,
+		UserId:    record.UserId,
+		Status:    domain.ItemStatus(record.Status),
+		Date:      record.StatusDate,
+	}
+}
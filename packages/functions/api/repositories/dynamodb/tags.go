@@ -0,0 +1,194 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// GetTag retrieves a tag by ID, nil if it does not exist
+func (d *dynamo) GetTag(ownerId string, libraryId string, tagId string) (*domain.Tag, error) {
+	output, err := d.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeTagPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeTagSK(libraryId, tagId)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("tagId", tagId).Msgf("Unable to get tag: %s", err.Error())
+		return nil, errors.New("unable to get tag")
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	record := persistence.Tag{}
+	if err := attributevalue.UnmarshalMap(output.Item, &record); err != nil {
+		log.Error().Msgf("Failed to unmarshal tag: %s", err.Error())
+		return nil, err
+	}
+
+	return mapRecordToTag(&record), nil
+}
+
+// QueryTagsByLibrary returns the tag index of a library.
+// Query: PK = owner#<ownerId>, SK begins_with library#<libraryId>#tag#
+func (d *dynamo) QueryTagsByLibrary(ownerId string, libraryId string) ([]domain.Tag, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk AND begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeTagPK(ownerId),
+			},
+			":sk_prefix": &types.AttributeValueMemberS{
+				Value: fmt.Sprintf("library#%s#tag#", libraryId),
+			},
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	tags := []domain.Tag{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("libraryId", libraryId).Msgf("Failed to query tags: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := persistence.Tag{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal tag: %s", err.Error())
+				continue
+			}
+
+			tags = append(tags, *mapRecordToTag(&record))
+		}
+	}
+
+	return tags, nil
+}
+
+// PutTag creates a new tag in the library tag index
+func (d *dynamo) PutTag(t *domain.Tag) error {
+	record := persistence.Tag{
+		PK:         persistence.MakeTagPK(t.OwnerId),
+		SK:         persistence.MakeTagSK(t.LibraryId, t.Id),
+		Id:         t.Id,
+		Name:       t.Name,
+		ItemCount:  t.ItemCount,
+		OwnerId:    t.OwnerId,
+		LibraryId:  t.LibraryId,
+		UpdatedAt:  t.UpdatedAt,
+		EntityType: persistence.TypeTag,
+	}
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		log.Error().Str("name", t.Name).Msgf("Failed to marshal tag: %s", err.Error())
+		return err
+	}
+
+	_, err = d.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		log.Error().Str("name", t.Name).Msgf("Failed to put tag: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// UpdateTag renames a tag, items are updated by the consistency manager
+func (d *dynamo) UpdateTag(t *domain.Tag) error {
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeTagPK(t.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeTagSK(t.LibraryId, t.Id)},
+		},
+		UpdateExpression:    aws.String("SET TagName = :name, UpdatedAt = :updatedAt"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":name":      &types.AttributeValueMemberS{Value: t.Name},
+			":updatedAt": &types.AttributeValueMemberS{Value: t.UpdatedAt.Format("2006-01-02T15:04:05.999999999Z07:00")},
+		},
+	})
+	if err != nil {
+		log.Error().Str("tagId", t.Id).Msgf("Failed to update tag: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTag removes a tag, items are untagged by the consistency manager
+func (d *dynamo) DeleteTag(t *domain.Tag) error {
+	_, err := d.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeTagPK(t.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeTagSK(t.LibraryId, t.Id)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("tagId", t.Id).Msgf("Failed to delete tag: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// IncrementTagItemCount atomically adjusts the number of items carrying a tag
+func (d *dynamo) IncrementTagItemCount(ownerId string, libraryId string, tagId string, delta int) error {
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeTagPK(ownerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeTagSK(libraryId, tagId)},
+		},
+		UpdateExpression: aws.String("ADD ItemCount :delta"),
+		// Do not resurrect a tag deleted in the meantime
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":delta": &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+		},
+	})
+	if err != nil {
+		log.Error().Str("tagId", tagId).Msgf("Failed to update tag item count: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func mapRecordToTag(record *persistence.Tag) *domain.Tag {
+	return &domain.Tag{
+		Id:        record.Id,
+		Name:      record.Name,
+		ItemCount: record.ItemCount,
+		OwnerId:   record.OwnerId,
+		LibraryId: record.LibraryId,
+		UpdatedAt: record.UpdatedAt,
+	}
+}
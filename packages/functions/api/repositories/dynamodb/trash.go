@@ -0,0 +1,292 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// TrashLibrary moves a library to the trash of its owner. The library records are kept until the TTL purges them.
+func (d *dynamo) TrashLibrary(l *domain.Library) error {
+	upd := expression.Set(expression.Name("DeletedAt"), expression.Value(l.DeletedAt)).
+		Set(expression.Name("ExpiresAt"), expression.Value(expiresAtToRecord(l.ExpiresAt))).
+		Set(expression.Name("GSI1PK"), expression.Value(persistence.MakeTrashGSI1PK(l.OwnerId))).
+		Set(expression.Name("GSI1SK"), expression.Value(persistence.MakeTrashGSI1SK(*l.DeletedAt, l.Id)))
+
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(l.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(l.Id)},
+		},
+		ConditionExpression:       aws.String("attribute_exists(PK) and attribute_not_exists(DeletedAt)"),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+
+	if err != nil {
+		log.Error().Str("id", l.Id).Msgf("Failed to trash library: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RestoreLibrary moves a trashed library back to the libraries of its owner
+func (d *dynamo) RestoreLibrary(l *domain.Library) error {
+	upd := expression.Set(expression.Name("GSI1PK"), expression.Value(persistence.MakeLibraryGSI1PK(l.OwnerId))).
+		Set(expression.Name("GSI1SK"), expression.Value(persistence.MakeLibraryGSI1SK(l.Name))).
+		Set(expression.Name("UpdatedAt"), expression.Value(l.UpdatedAt)).
+		Remove(expression.Name("DeletedAt")).
+		Remove(expression.Name("ExpiresAt"))
+
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+
+	_, err := d.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(l.OwnerId)},
+			"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(l.Id)},
+		},
+		ConditionExpression:       aws.String("attribute_exists(DeletedAt)"),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	})
+
+	if err != nil {
+		log.Error().Str("id", l.Id).Msgf("Failed to restore library: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetTrashedLibrary returns a library of the owner trash
+func (d *dynamo) GetTrashedLibrary(ownerId string, libraryId string) (*domain.Library, error) {
+	record, err := d.getLibraryRecord(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.DeletedAt == nil {
+		log.Error().Str("id", libraryId).Msg("Library is not in the trash")
+		return nil, errors.New("unknown library")
+	}
+
+	return mapRecordToLibrary(record), nil
+}
+
+// TrashLibraryItem moves an item to the trash of its owner and removes it from the library total.
+// The item keeps its history, reviews and picture until the TTL purges it.
+func (d *dynamo) TrashLibraryItem(i *domain.LibraryItem) error {
+	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			libraryItemTrashUpdate(i),
+			// Decrement TotalItems attribute of the library by 1
+			libraryTotalItemsUpdate(i.OwnerId, i.LibraryId, -1),
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to trash item: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RestoreLibraryItem moves a trashed item back to its library, with the given attributes, and adds it to the library total
+func (d *dynamo) RestoreLibraryItem(i *domain.LibraryItem) error {
+	upd := libraryItemUpdate(i).
+		Set(expression.Name("LibraryName"), expression.Value(i.LibraryName)).
+		Set(expression.Name("GSI1PK"), expression.Value(persistence.MakeLibraryItemGSI1PK(i.OwnerId, i.LibraryId))).
+		Set(expression.Name("GSI2PK"), expression.Value(persistence.MakeLibraryItemGSI2PK(i.OwnerId))).
+		Remove(expression.Name("DeletedAt")).
+		Remove(expression.Name("ExpiresAt"))
+
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+
+	_, err := d.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+						"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+					},
+					ConditionExpression:       aws.String("attribute_exists(DeletedAt)"),
+					ExpressionAttributeNames:  expr.Names(),
+					ExpressionAttributeValues: expr.Values(),
+					UpdateExpression:          expr.Update(),
+				},
+			},
+			// Increment TotalItems attribute of the library by 1
+			libraryTotalItemsUpdate(i.OwnerId, i.LibraryId, 1),
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("id", i.Id).Msgf("Failed to restore item: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetTrashedLibraryItem returns an item of the owner trash
+func (d *dynamo) GetTrashedLibraryItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error) {
+	record, err := d.getLibraryItemRecord(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.DeletedAt == nil {
+		log.Error().Str("id", itemId).Msg("Item is not in the trash")
+		return nil, errors.New("unknown item")
+	}
+
+	return mapRecordToLibraryItem(record), nil
+}
+
+// QueryTrash returns the trashed libraries and items of an owner, in deletion order
+func (d *dynamo) QueryTrash(ownerId string) (*domain.Trash, error) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :ownerId and begins_with(#GSI1SK,:trash_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ownerId": &types.AttributeValueMemberS{
+				Value: persistence.MakeTrashGSI1PK(ownerId),
+			},
+			":trash_prefix": &types.AttributeValueMemberS{
+				Value: "trash#",
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+		},
+	}
+
+	type Record struct {
+		EntityType persistence.EntityType `dynamodbav:"EntityType"`
+	}
+
+	trash := domain.Trash{
+		Libraries: []domain.Library{},
+		Items:     []*domain.LibraryItem{},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(d.client, &query)
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Msgf("Failed to query trash: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			record := Record{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal trash record: %s", err.Error())
+				continue
+			}
+
+			if record.EntityType == persistence.TypeLibrary {
+				library := persistence.Library{}
+				if err := attributevalue.UnmarshalMap(item, &library); err != nil {
+					log.Warn().Msgf("Failed to unmarshal library: %s", err.Error())
+					continue
+				}
+				trash.Libraries = append(trash.Libraries, *mapRecordToLibrary(&library))
+				continue
+			}
+
+			libraryItem := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &libraryItem); err != nil {
+				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+				continue
+			}
+			trash.Items = append(trash.Items, mapRecordToLibraryItem(&libraryItem))
+		}
+	}
+
+	return &trash, nil
+}
+
+// libraryItemTrashUpdate moves an item out of the library (GSI1) and owner-wide (GSI2) listings into the owner trash
+func libraryItemTrashUpdate(i *domain.LibraryItem) types.TransactWriteItem {
+	upd := expression.Set(expression.Name("DeletedAt"), expression.Value(i.DeletedAt)).
+		Set(expression.Name("ExpiresAt"), expression.Value(expiresAtToRecord(i.ExpiresAt))).
+		Set(expression.Name("GSI1PK"), expression.Value(persistence.MakeTrashGSI1PK(i.OwnerId))).
+		Set(expression.Name("GSI1SK"), expression.Value(persistence.MakeTrashGSI1SK(*i.DeletedAt, i.Id))).
+		Remove(expression.Name("GSI2PK")).
+		Remove(expression.Name("GSI2SK"))
+
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemPK(i.OwnerId)},
+				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryItemSK(i.LibraryId, i.Id)},
+			},
+			ConditionExpression:       aws.String("attribute_exists(PK) and attribute_not_exists(DeletedAt)"),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+		},
+	}
+}
+
+// libraryTotalItemsUpdate adds delta to the TotalItems attribute of a library, within a transaction
+func libraryTotalItemsUpdate(ownerId string, libraryId string, delta int) types.TransactWriteItem {
+	expr, _ := expression.NewBuilder().
+		WithUpdate(expression.Set(expression.Name("TotalItems"), expression.Name("TotalItems").Plus(expression.Value(delta)))).
+		Build()
+
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(tableName),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: persistence.MakeLibraryPK(ownerId)},
+				"SK": &types.AttributeValueMemberS{Value: persistence.MakeLibrarySK(libraryId)},
+			},
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			UpdateExpression:          expr.Update(),
+		},
+	}
+}
+
+// expiresAtToRecord converts a purge date to the epoch seconds expected by the DynamoDB TTL
+func expiresAtToRecord(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// expiresAtFromRecord converts the TTL epoch seconds to a purge date
+func expiresAtFromRecord(ts int64) *time.Time {
+	if ts == 0 {
+		return nil
+	}
+	t := time.Unix(ts, 0).UTC()
+	return &t
+}
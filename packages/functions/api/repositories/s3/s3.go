@@ -270,3 +270,66 @@ func (o *objectstorage) PutPicture(ownerId string, libraryId string, itemId stri
 	}
 	return nil
 }
+
+// PutReturnPicture uploads the photo of a returned item, resized like item pictures but larger to show its condition
+func (o *objectstorage) PutReturnPicture(ownerId string, libraryId string, itemId string, pictureId string, picture []byte) error {
+	key := fmt.Sprintf("incoming/%s-return-%s", itemId, pictureId)
+
+	_, err := o.client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:        aws.String(bucketName),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(picture),
+		ContentLength: aws.Int64(int64(len(picture))),
+		Metadata: map[string]string{
+			"TargetPrefix": fmt.Sprintf("user/%s/library/%s/item/%s/returns/%s", ownerId, libraryId, itemId, pictureId),
+			"TargetWidth":  fmt.Sprintf("%d", 600),
+			"TargetHeight": fmt.Sprintf("%d", 800),
+		},
+	})
+
+	if err != nil {
+		log.Error().Str("key", key).Msgf("Failed to upload return picture: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (o *objectstorage) MoveReturnPictures(ownerId string, fromLibraryId string, toLibraryId string, itemId string) error {
+	prefix := fmt.Sprintf("user/%s/library/%s/item/%s/returns/", ownerId, fromLibraryId, itemId)
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	p := s3.NewListObjectsV2Paginator(o.client, params)
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("prefix", prefix).Msgf("Failed to list return pictures: %s", err.Error())
+			return err
+		}
+
+		for _, obj := range page.Contents {
+			target := fmt.Sprintf("user/%s/library/%s/item/%s/returns/%s", ownerId, toLibraryId, itemId, (*obj.Key)[len(prefix):])
+			_, err = o.client.CopyObject(context.TODO(), &s3.CopyObjectInput{
+				Bucket:     aws.String(bucketName),
+				CopySource: aws.String(fmt.Sprintf("%s/%s", bucketName, *obj.Key)),
+				Key:        aws.String(target),
+			})
+			if err != nil {
+				log.Error().Str("key", *obj.Key).Msgf("Failed to copy return picture: %s", err.Error())
+				return err
+			}
+
+			_, err = o.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+				Bucket: aws.String(bucketName),
+				Key:    obj.Key,
+			})
+			if err != nil {
+				log.Warn().Str("key", *obj.Key).Msgf("Failed to delete moved return picture: %s", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
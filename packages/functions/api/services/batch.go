@@ -0,0 +1,467 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// Pictures of a batch are fetched and uploaded in parallel
+const batchPictureWorkers = 10
+
+// itemBatch holds the library data read once for all the operations of a batch
+type itemBatch struct {
+	library       *domain.Library
+	collections   map[string]domain.Collection
+	locationPaths map[string]string
+	tagIndex      map[string]domain.Tag
+	current       map[string]*domain.LibraryItem
+	orders        map[string]int // Last order given in each collection
+}
+
+// ApplyItemOperations applies a batch of create, update, delete (to the trash) and move to collection operations to the items of an
+// owned library, or of a library shared to the user as editor. Library data and current items are read once, items are written
+// in batches, and the library, collection and tag counts are updated once per entity. Returns the result of each operation, in order.
+func (s *services) ApplyItemOperations(userId string, libraryId string, ops []domain.ItemOperation, actor domain.Actor) ([]domain.ItemOperationResult, error) {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	library, err := s.db.GetLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := s.db.QueryCollectionsByLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	b := itemBatch{
+		library:     library,
+		collections: map[string]domain.Collection{},
+		orders:      map[string]int{},
+	}
+	for _, c := range collections {
+		b.collections[c.Id] = c
+	}
+
+	itemIds := []string{}
+	tagNames := []string{}
+	located := false
+	for _, op := range ops {
+		if op.Action != domain.CreateItemAction {
+			itemIds = append(itemIds, op.Item.Id)
+		}
+		if op.Action == domain.CreateItemAction || op.Action == domain.UpdateItemAction {
+			tagNames = append(tagNames, op.Item.Tags...)
+			located = located || (op.Item.LocationId != nil && *op.Item.LocationId != "")
+		}
+	}
+
+	b.current, err = s.db.GetLibraryItems(ownerId, libraryId, itemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// Kept locations are resolved as the requested ones
+	for _, i := range b.current {
+		located = located || i.LocationId != nil
+	}
+	if located {
+		locations, err := s.db.QueryLocationsByLibrary(ownerId, libraryId)
+		if err != nil {
+			return nil, err
+		}
+		b.locationPaths = domain.BuildLocationPaths(locations)
+	}
+
+	// Missing tags are created once for the whole batch, the index is also needed to count the removed tags
+	hasTags := len(tagNames) > 0
+	for _, i := range b.current {
+		hasTags = hasTags || len(i.Tags) > 0
+	}
+	if hasTags {
+		_, b.tagIndex, err = s.resolveTags(ownerId, libraryId, tagNames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]domain.ItemOperationResult, len(ops))
+	prepared := make([]*domain.LibraryItem, len(ops))
+	creates := []*domain.LibraryItem{}
+	updates := []*domain.LibraryItem{}
+	deletes := []*domain.LibraryItem{}
+	seen := map[string]bool{}
+	now := time.Now().UTC()
+
+	for idx, op := range ops {
+		var item *domain.LibraryItem
+
+		if op.Action != domain.CreateItemAction {
+			if seen[op.Item.Id] {
+				msg := "item already in batch"
+				log.Error().Str("id", op.Item.Id).Msg(msg)
+				results[idx].Err = errors.New(msg)
+				continue
+			}
+			seen[op.Item.Id] = true
+		}
+
+		switch op.Action {
+		case domain.CreateItemAction:
+			item, err = s.prepareItemCreation(&b, op.Item)
+			if err == nil {
+				creates = append(creates, item)
+			}
+		case domain.UpdateItemAction:
+			item, err = s.prepareItemUpdate(&b, op.Item)
+			if err == nil {
+				updates = append(updates, item)
+			}
+		case domain.MoveToCollectionAction:
+			item, err = s.prepareItemCollectionMove(&b, op.Item.Id, op.CollectionId)
+			if err == nil {
+				updates = append(updates, item)
+			}
+		case domain.DeleteItemAction:
+			item, err = b.currentItem(op.Item.Id)
+			if err == nil && item.IsLent() {
+				msg := "item is lent and not returned"
+				log.Error().Str("id", item.Id).Msg(msg)
+				err = errors.New(msg)
+			}
+			if err == nil {
+				expiresAt := now.Add(trashRetention)
+				item.DeletedAt = &now
+				item.ExpiresAt = &expiresAt
+				deletes = append(deletes, item)
+			}
+		default:
+			msg := "unknown action"
+			log.Error().Str("action", string(op.Action)).Msg(msg)
+			err = errors.New(msg)
+		}
+
+		if err != nil {
+			results[idx].Err = err
+			continue
+		}
+
+		item.UpdatedAt = &now
+		prepared[idx] = item
+	}
+
+	failed := s.db.PutLibraryItems(ownerId, libraryId, creates)
+	for id, err := range s.db.UpdateLibraryItems(updates) {
+		failed[id] = err
+	}
+	for id, err := range s.db.TrashLibraryItems(ownerId, libraryId, deletes) {
+		failed[id] = err
+	}
+
+	// Counts of the written items, once per collection and tag
+	collectionDeltas := map[string]int{}
+	previousTags := []string{}
+	currentTags := []string{}
+	pictures := []func(){}
+	changes := map[string]*domain.ChangeEntry{}
+
+	for idx, op := range ops {
+		item := prepared[idx]
+		if item == nil {
+			continue
+		}
+		if err, ok := failed[item.Id]; ok {
+			results[idx].Err = err
+			continue
+		}
+
+		if previous, ok := b.current[item.Id]; ok {
+			if previous.CollectionId != nil {
+				collectionDeltas[*previous.CollectionId]--
+			}
+			previousTags = append(previousTags, previous.Tags...)
+		}
+
+		// A trashed item keeps its picture until it is purged
+		if op.Action == domain.DeleteItemAction {
+			continue
+		}
+
+		if previous, ok := b.current[item.Id]; ok {
+			if e := newChangeEntry(actor, &now, diffItem(previous, item)); e != nil {
+				changes[item.Id] = e
+			}
+		}
+
+		if item.CollectionId != nil {
+			collectionDeltas[*item.CollectionId]++
+		}
+		currentTags = append(currentTags, item.Tags...)
+		results[idx].Item = item
+
+		fetch := op.Action == domain.CreateItemAction || (op.Action == domain.UpdateItemAction && op.FetchPicture)
+		if fetch && item.PictureUrl != nil && *item.PictureUrl != "" {
+			pictures = append(pictures, func() {
+				// Picture fetch is best-effort - the item is already saved
+				data, err := fetchPicture(*item.PictureUrl)
+				if err != nil {
+					log.Warn().Str("url", *item.PictureUrl).Err(err).Msg("Picture fetch failed, continuing without picture")
+					return
+				}
+				if err = s.storage.PutPicture(ownerId, libraryId, item.Id, data); err != nil {
+					log.Warn().Str("id", item.Id).Err(err).Msg("Picture upload failed, continuing without picture")
+				}
+			})
+		}
+	}
+
+	for collectionId, delta := range collectionDeltas {
+		if delta == 0 {
+			continue
+		}
+		err = s.db.IncrementCollectionItemCount(ownerId, libraryId, collectionId, delta)
+		if err != nil {
+			// Log but don't fail - the count may drift until check-consistency reports it
+			log.Warn().Str("collectionId", collectionId).Msgf("Failed to update collection item count: %s", err.Error())
+		}
+	}
+
+	s.updateTagCounts(ownerId, libraryId, b.tagIndex, previousTags, currentTags)
+
+	if len(changes) > 0 {
+		err = s.db.PutItemChanges(ownerId, libraryId, changes)
+		if err != nil {
+			// Log but don't fail - the items are already updated
+			log.Warn().Str("libraryId", libraryId).Msgf("Failed to record item changes: %s", err.Error())
+		}
+	}
+
+	runInParallel(pictures, batchPictureWorkers)
+
+	return results, nil
+}
+
+// prepareItemCreation completes a new item of the batch library, as CreateItem does
+func (s *services) prepareItemCreation(b *itemBatch, i *domain.LibraryItem) (*domain.LibraryItem, error) {
+	i.Id = identifier.NewId()
+	if i.OwnerId != b.library.OwnerId {
+		// Added by an editor of the shared library
+		i.OwnerId = b.library.OwnerId
+		i.OwnerName = b.library.OwnerName
+	}
+	i.LibraryName = b.library.Name
+	i.Wanted = b.library.Kind == domain.WishlistLibrary
+
+	err := checkCustomValues(b.library, i)
+	if err != nil {
+		return nil, err
+	}
+
+	// Suggest the series collection matching the series detected from the book metadata
+	if (i.CollectionId == nil || *i.CollectionId == "") && i.SeriesName != nil && *i.SeriesName != "" {
+		for _, c := range b.collections {
+			if c.IsSeries && strings.EqualFold(c.Name, *i.SeriesName) {
+				i.CollectionId = &c.Id
+				break
+			}
+		}
+	}
+
+	err = s.assignBatchCollection(b, i, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.assignLocation(i)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new item has no copy yet
+	i.Copies, err = mergeItemCopies(&domain.LibraryItem{Id: i.Id}, i.Copies)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Tags = b.canonicalTags(i.Tags)
+
+	return i, nil
+}
+
+// prepareItemUpdate completes an updated item of the batch library, as UpdateItem does
+func (s *services) prepareItemUpdate(b *itemBatch, i *domain.LibraryItem) (*domain.LibraryItem, error) {
+	current, err := b.currentItem(i.Id)
+	if err != nil {
+		return nil, err
+	}
+	keepOmittedFields(current, i)
+
+	i.OwnerId = b.library.OwnerId
+	i.LibraryName = b.library.Name
+
+	err = checkUpdatedCustomValues(b.library, current, i)
+	if err != nil {
+		return nil, err
+	}
+
+	previousCollectionId := ""
+	if current.CollectionId != nil {
+		previousCollectionId = *current.CollectionId
+	}
+
+	err = s.assignBatchCollection(b, i, previousCollectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.assignLocation(i)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Copies, err = mergeItemCopies(current, i.Copies)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Tags = b.canonicalTags(i.Tags)
+
+	return i, nil
+}
+
+// prepareItemCollectionMove files an item of the batch library into a collection, or out of any collection when nil
+func (s *services) prepareItemCollectionMove(b *itemBatch, itemId string, collectionId *string) (*domain.LibraryItem, error) {
+	current, err := b.currentItem(itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := *current
+
+	previousCollectionId := ""
+	if current.CollectionId != nil {
+		previousCollectionId = *current.CollectionId
+	}
+
+	// The order within the current collection is kept
+	if collectionId == nil || *collectionId != previousCollectionId {
+		moved.CollectionId = collectionId
+		moved.Order = nil
+	}
+
+	err = s.assignBatchCollection(b, &moved, previousCollectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &moved, nil
+}
+
+// assignBatchCollection sets the collection name and order of an item, the last orders of the collections are read once
+func (s *services) assignBatchCollection(b *itemBatch, i *domain.LibraryItem, previousCollectionId string) error {
+	if i.CollectionId == nil || *i.CollectionId == "" {
+		i.CollectionId = nil
+		i.CollectionName = nil
+		i.Order = nil
+		return nil
+	}
+
+	collection, ok := b.collections[*i.CollectionId]
+	if !ok {
+		msg := "collection not found"
+		log.Error().Str("collectionId", *i.CollectionId).Msg(msg)
+		return errors.New(msg)
+	}
+	i.CollectionName = &collection.Name
+
+	// Series are ordered by volume number
+	if i.Order == nil && collection.IsSeries && i.Volume != nil {
+		i.Order = i.Volume
+	}
+
+	if i.Order == nil && previousCollectionId != collection.Id {
+		if _, ok := b.orders[collection.Id]; !ok {
+			maxOrder, err := s.db.GetMaxOrderInCollection(i.OwnerId, i.LibraryId, collection.Id)
+			if err != nil {
+				return err
+			}
+			b.orders[collection.Id] = maxOrder
+		}
+		b.orders[collection.Id]++
+		order := b.orders[collection.Id]
+		i.Order = &order
+	}
+
+	return nil
+}
+
+// assignLocation sets the location path of an item, as resolveItemLocation does
+func (b *itemBatch) assignLocation(i *domain.LibraryItem) error {
+	if i.LocationId == nil || *i.LocationId == "" {
+		i.LocationId = nil
+		i.LocationPath = nil
+		return nil
+	}
+
+	path, ok := b.locationPaths[*i.LocationId]
+	if !ok {
+		msg := "location not found"
+		log.Error().Str("locationId", *i.LocationId).Msg(msg)
+		return errors.New(msg)
+	}
+	i.LocationPath = &path
+
+	return nil
+}
+
+// canonicalTags returns the tag names with the casing of the library tag index
+func (b *itemBatch) canonicalTags(names []string) []string {
+	var canonical []string
+	for _, name := range names {
+		if t, ok := b.tagIndex[strings.ToLower(name)]; ok {
+			canonical = append(canonical, t.Name)
+		}
+	}
+	return canonical
+}
+
+func (b *itemBatch) currentItem(itemId string) (*domain.LibraryItem, error) {
+	current, ok := b.current[itemId]
+	if !ok {
+		msg := "unknown item"
+		log.Error().Str("id", itemId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+	return current, nil
+}
+
+// runInParallel runs the tasks with at most the given number of concurrent workers
+func runInParallel(tasks []func(), workers int) {
+	ch := make(chan func())
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range ch {
+				task()
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		ch <- task
+	}
+	close(ch)
+	wg.Wait()
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestBorrow records the request of a user to borrow an item of a library shared to them.
+// Requests are allowed on lent items, the owner answers them once the item is returned.
+func (s *services) RequestBorrow(r *domain.BorrowRequest) (*domain.BorrowRequest, error) {
+	ownerId, err := s.db.GetSharedLibrary(r.RequesterId, r.LibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Owners lend their items directly
+	if ownerId == "" {
+		msg := "library not shared with the requester"
+		log.Error().Str("libraryId", r.LibraryId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	item, err := s.db.GetLibraryItem(ownerId, r.LibraryId, r.ItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Wanted {
+		msg := "cannot borrow a wishlist item"
+		log.Error().Str("id", r.ItemId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	if r.CopyId != nil && item.FindCopy(*r.CopyId) == -1 {
+		msg := "copy not found"
+		log.Error().Str("id", r.ItemId).Str("copyId", *r.CopyId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	requests, err := s.db.QuerySentBorrowRequests(r.RequesterId)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range requests {
+		if other.Status == domain.BorrowPending && other.OwnerId == ownerId && other.ItemId == r.ItemId {
+			msg := "borrow request already exists"
+			log.Error().Str("id", r.ItemId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	current := time.Now().UTC()
+	r.Id = identifier.NewId()
+	r.OwnerId = ownerId
+	r.OwnerName = item.OwnerName
+	r.LibraryName = item.LibraryName
+	r.ItemType = item.Type
+	r.Title = item.Title
+	r.Status = domain.BorrowPending
+	r.CreatedAt = &current
+	r.UpdatedAt = &current
+
+	err = s.db.PutBorrowRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *services) ListReceivedBorrowRequests(ownerId string) ([]*domain.BorrowRequest, error) {
+	requests, err := s.db.QueryReceivedBorrowRequests(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBorrowRequests(requests)
+	return requests, nil
+}
+
+func (s *services) ListSentBorrowRequests(requesterId string) ([]*domain.BorrowRequest, error) {
+	requests, err := s.db.QuerySentBorrowRequests(requesterId)
+	if err != nil {
+		return nil, err
+	}
+
+	sortBorrowRequests(requests)
+	return requests, nil
+}
+
+// AcceptBorrowRequest lends the item to the contact linked to the requester, created on the first accepted request
+func (s *services) AcceptBorrowRequest(ownerId string, requestId string, copyId string, dueDate *time.Time) error {
+	r, err := s.getPendingBorrowRequest(ownerId, requestId)
+	if err != nil {
+		return err
+	}
+
+	if copyId == "" && r.CopyId != nil {
+		copyId = *r.CopyId
+	}
+	if dueDate == nil {
+		dueDate = r.DueDate
+	}
+
+	contact, err := s.resolveUserContact(ownerId, r.RequesterId, r.RequesterName)
+	if err != nil {
+		return err
+	}
+
+	err = s.LendItem(ownerId, r.LibraryId, r.ItemId, copyId, contact.Id, dueDate)
+	if err != nil {
+		return err
+	}
+
+	current := time.Now().UTC()
+	r.Status = domain.BorrowAccepted
+	r.UpdatedAt = &current
+	return s.db.UpdateBorrowRequestStatus(r)
+}
+
+func (s *services) DeclineBorrowRequest(ownerId string, requestId string) error {
+	r, err := s.getPendingBorrowRequest(ownerId, requestId)
+	if err != nil {
+		return err
+	}
+
+	current := time.Now().UTC()
+	r.Status = domain.BorrowDeclined
+	r.UpdatedAt = &current
+	return s.db.UpdateBorrowRequestStatus(r)
+}
+
+func (s *services) CancelBorrowRequest(requesterId string, requestId string) error {
+	r, err := s.db.GetSentBorrowRequest(requesterId, requestId)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		msg := "borrow request not found"
+		log.Error().Str("requestId", requestId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.DeleteBorrowRequest(r)
+}
+
+func (s *services) getPendingBorrowRequest(ownerId string, requestId string) (*domain.BorrowRequest, error) {
+	r, err := s.db.GetBorrowRequest(ownerId, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		msg := "borrow request not found"
+		log.Error().Str("requestId", requestId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	if r.Status != domain.BorrowPending {
+		msg := "borrow request already answered"
+		log.Error().Str("requestId", requestId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return r, nil
+}
+
+// sortBorrowRequests sorts the requests most recent first
+func sortBorrowRequests(requests []*domain.BorrowRequest) {
+	sort.Slice(requests, func(a, b int) bool {
+		return requests[a].CreatedAt.After(*requests[b].CreatedAt)
+	})
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+const calendarEventsPageSize = 20
+
+// CreateCalendarFeed generates a new token for the loans calendar of the owner, the previous one stops working
+func (s *services) CreateCalendarFeed(ownerId string) (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		log.Error().Str("ownerId", ownerId).Msgf("Failed to generate calendar token: %s", err.Error())
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	current := time.Now().UTC()
+	err = s.db.PutCalendarFeed(&domain.CalendarFeed{
+		OwnerId:   ownerId,
+		TokenHash: hashCalendarToken(token),
+		CreatedAt: &current,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *services) GetCalendarFeed(ownerId string) (*domain.CalendarFeed, error) {
+	feed, err := s.db.GetCalendarFeed(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil {
+		msg := "calendar feed not found"
+		log.Error().Str("ownerId", ownerId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return feed, nil
+}
+
+func (s *services) DeleteCalendarFeed(ownerId string) error {
+	_, err := s.GetCalendarFeed(ownerId)
+	if err != nil {
+		return err
+	}
+
+	return s.db.DeleteCalendarFeed(ownerId)
+}
+
+// ListCalendarLoans returns the current loans of the owner, dated by their LENT event.
+// The token must be the one of the owner calendar feed.
+func (s *services) ListCalendarLoans(ownerId string, token string) ([]domain.CalendarLoan, error) {
+	feed, err := s.db.GetCalendarFeed(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	if feed == nil || subtle.ConstantTimeCompare([]byte(feed.TokenHash), []byte(hashCalendarToken(token))) != 1 {
+		msg := "invalid calendar token"
+		log.Error().Str("ownerId", ownerId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	items, err := s.db.QueryLentItems(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := []domain.CalendarLoan{}
+	for _, i := range items {
+		current := i.CurrentLoans()
+		if len(current) == 0 {
+			continue
+		}
+
+		lendingDates, err := s.findLendingDates(i, len(current))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range current {
+			loan := domain.CalendarLoan{Loan: l}
+			if date, ok := lendingDates[lendingKey(l.CopyId)]; ok {
+				loan.LentAt = date
+			}
+			loans = append(loans, loan)
+		}
+	}
+
+	return loans, nil
+}
+
+// findLendingDates returns the date of the latest LENT event of the item and of each of its copies,
+// keyed by copy id ("" for the item itself). The history is read newest first until count loans are dated.
+func (s *services) findLendingDates(i *domain.LibraryItem, count int) (map[string]*time.Time, error) {
+	dates := map[string]*time.Time{}
+	// A RETURNED event closes the loan of its copy, an older LENT event belongs to a previous loan
+	closed := map[string]bool{}
+
+	continuationToken := ""
+	for {
+		history, err := s.db.QueryItemEvents(i, continuationToken, calendarEventsPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range history.Entries {
+			if e.Type != domain.Lent && e.Type != domain.Returned {
+				continue
+			}
+
+			key := lendingKey(e.CopyId)
+			if closed[key] {
+				continue
+			}
+			closed[key] = true
+
+			if e.Type == domain.Lent {
+				dates[key] = e.Date
+			}
+		}
+
+		if len(dates) == count || history.ContinuationToken == "" {
+			return dates, nil
+		}
+		continuationToken = history.ContinuationToken
+	}
+}
+
+func lendingKey(copyId *string) string {
+	if copyId == nil {
+		return ""
+	}
+	return *copyId
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+// calendarDatabase holds the calendar feed, the lent items and their history, newest event first
+type calendarDatabase struct {
+	ports.Database
+	feed   *domain.CalendarFeed
+	items  []*domain.LibraryItem
+	events map[string][]domain.ItemEvent
+}
+
+func (d *calendarDatabase) PutCalendarFeed(f *domain.CalendarFeed) error {
+	d.feed = f
+	return nil
+}
+
+func (d *calendarDatabase) GetCalendarFeed(ownerId string) (*domain.CalendarFeed, error) {
+	return d.feed, nil
+}
+
+func (d *calendarDatabase) QueryLentItems(ownerId string) ([]*domain.LibraryItem, error) {
+	return d.items, nil
+}
+
+func (d *calendarDatabase) QueryItemEvents(i *domain.LibraryItem, continuationToken string, pageSize int) (*domain.ItemHistory, error) {
+	start, _ := strconv.Atoi(continuationToken)
+	events := d.events[i.Id]
+	end := min(start+pageSize, len(events))
+
+	history := &domain.ItemHistory{Entries: events[start:end]}
+	if end < len(events) {
+		history.ContinuationToken = strconv.Itoa(end)
+	}
+	return history, nil
+}
+
+func TestListCalendarLoansToken(t *testing.T) {
+	db := &calendarDatabase{}
+	s := NewServices(db, nil, nil, nil)
+
+	_, err := s.ListCalendarLoans("owner", "token")
+	if err == nil || err.Error() != "invalid calendar token" {
+		t.Errorf("expected the token to be rejected without feed, got %v", err)
+	}
+
+	token, err := s.CreateCalendarFeed("owner")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if db.feed.TokenHash == token {
+		t.Errorf("expected only a hash of the token to be stored")
+	}
+
+	for _, invalid := range []string{"", token[:len(token)-1], db.feed.TokenHash} {
+		_, err = s.ListCalendarLoans("owner", invalid)
+		if err == nil || err.Error() != "invalid calendar token" {
+			t.Errorf("expected token %q to be rejected, got %v", invalid, err)
+		}
+	}
+
+	loans, err := s.ListCalendarLoans("owner", token)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(loans) != 0 {
+		t.Errorf("expected no loans, got %d", len(loans))
+	}
+
+	// A new feed revokes the previous token
+	renewed, err := s.CreateCalendarFeed("owner")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if _, err = s.ListCalendarLoans("owner", token); err == nil {
+		t.Errorf("expected the previous token to be rejected")
+	}
+	if _, err = s.ListCalendarLoans("owner", renewed); err != nil {
+		t.Errorf("unexpected error: %s", err.Error())
+	}
+}
+
+func TestListCalendarLoansLendingDates(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2024, 2, d, 10, 0, 0, 0, time.UTC)
+		return &date
+	}
+	str := func(s string) *string { return &s }
+
+	// Older events of dune fill more than one page of history
+	duneEvents := []domain.ItemEvent{
+		{Date: day(28), Type: domain.StatusChanged, Event: string(domain.Reading)},
+		{Date: day(20), Type: domain.Lent},
+		{Date: day(15), Type: domain.Returned},
+	}
+	for i := 0; i < calendarEventsPageSize; i++ {
+		duneEvents = append(duneEvents, domain.ItemEvent{Date: day(10), Type: domain.Lent})
+	}
+
+	db := &calendarDatabase{
+		items: []*domain.LibraryItem{
+			{Id: "dune", Title: "Dune", LentTo: str("Alice")},
+			{Id: "box", Title: "Box set", Copies: []domain.ItemCopy{
+				{Id: "a", LentTo: str("Bob")},
+				{Id: "b", LentTo: str("Carol")},
+				{Id: "c"},
+			}},
+			// Lent before the history was recorded
+			{Id: "old", Title: "Old book", LentTo: str("Dave")},
+			{Id: "returned", Title: "Returned book"},
+		},
+		events: map[string][]domain.ItemEvent{
+			"dune": duneEvents,
+			"box": {
+				{Date: day(12), Type: domain.Lent, CopyId: str("b")},
+				{Date: day(8), Type: domain.Returned, CopyId: str("a")},
+				{Date: day(6), Type: domain.Returned, CopyId: str("b")},
+				{Date: day(5), Type: domain.Lent, CopyId: str("a")},
+				{Date: day(3), Type: domain.Lent, CopyId: str("b")},
+			},
+			"returned": {{Date: day(1), Type: domain.Returned}},
+		},
+	}
+	s := NewServices(db, nil, nil, nil)
+	token, err := s.CreateCalendarFeed("owner")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	loans, err := s.ListCalendarLoans("owner", token)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := map[string]*time.Time{
+		"Alice": day(20),
+		"Bob":   nil, // Returned since, lent again before the recorded history
+		"Carol": day(12),
+		"Dave":  nil,
+	}
+	if len(loans) != len(expected) {
+		t.Fatalf("expected %d loans, got %d", len(expected), len(loans))
+	}
+	for _, l := range loans {
+		date, ok := expected[l.LentTo]
+		if !ok {
+			t.Errorf("unexpected loan to %s", l.LentTo)
+			continue
+		}
+		if (date == nil) != (l.LentAt == nil) || (date != nil && !date.Equal(*l.LentAt)) {
+			t.Errorf("loan to %s: expected lent at %v, got %v", l.LentTo, date, l.LentAt)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+func (s *services) GetItemChanges(ownerId string, libraryId string, itemId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	_, err := s.db.GetLibraryItem(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.QueryItemChanges(ownerId, libraryId, itemId, continuationToken, pageSize)
+}
+
+func (s *services) GetCollectionChanges(ownerId string, libraryId string, collectionId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	collection, err := s.db.GetCollection(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		msg := "collection not found"
+		log.Error().Str("id", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return s.db.QueryCollectionChanges(ownerId, libraryId, collectionId, continuationToken, pageSize)
+}
+
+func (s *services) GetLibraryChanges(ownerId string, libraryId string, continuationToken string, pageSize int) (*domain.ChangeLog, error) {
+	_, err := s.db.GetLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.QueryLibraryChanges(ownerId, libraryId, continuationToken, pageSize)
+}
+
+// newChangeEntry returns the change log entry of an update, nil when no field was modified
+func newChangeEntry(actor domain.Actor, date *time.Time, changes fieldChanges) *domain.ChangeEntry {
+	if len(changes) == 0 {
+		return nil
+	}
+	return &domain.ChangeEntry{
+		Date:     date,
+		UserId:   actor.UserId,
+		UserName: actor.UserName,
+		Changes:  changes,
+	}
+}
+
+// fieldChanges collects the fields modified by an update, field names are the API ones
+type fieldChanges []domain.FieldChange
+
+func (c *fieldChanges) add(field string, from *string, to *string) {
+	if from == nil && to == nil {
+		return
+	}
+	if from != nil && to != nil && *from == *to {
+		return
+	}
+	*c = append(*c, domain.FieldChange{Field: field, From: from, To: to})
+}
+
+// diffItem returns the fields of an item modified by an update. Lending state, status, ratings and counts
+// have their own history and are not compared.
+func diffItem(previous *domain.LibraryItem, current *domain.LibraryItem) fieldChanges {
+	c := fieldChanges{}
+	c.add("title", textValue(previous.Title), textValue(current.Title))
+	c.add("summary", textValue(previous.Summary), textValue(current.Summary))
+	c.add("authors", listValue(previous.Authors), listValue(current.Authors))
+	c.add("isbn", textValue(previous.Isbn), textValue(current.Isbn))
+	c.add("pictureUrl", optionalValue(previous.PictureUrl), optionalValue(current.PictureUrl))
+	c.add("collection", optionalValue(previous.CollectionName), optionalValue(current.CollectionName))
+	c.add("order", intValue(previous.Order), intValue(current.Order))
+	c.add("volume", intValue(previous.Volume), intValue(current.Volume))
+	c.add("tags", listValue(previous.Tags), listValue(current.Tags))
+	c.add("location", optionalValue(previous.LocationPath), optionalValue(current.LocationPath))
+	c.add("format", formatValue(previous.Format), formatValue(current.Format))
+	c.add("copies", copiesValue(previous.Copies), copiesValue(current.Copies))
+
+	var previousAcquisition, currentAcquisition domain.Acquisition
+	if previous.Acquisition != nil {
+		previousAcquisition = *previous.Acquisition
+	}
+	if current.Acquisition != nil {
+		currentAcquisition = *current.Acquisition
+	}
+	c.add("purchaseDate", dateValue(previousAcquisition.Date), dateValue(currentAcquisition.Date))
+	c.add("purchasePrice", priceValue(previousAcquisition.Price, previousAcquisition.Currency), priceValue(currentAcquisition.Price, currentAcquisition.Currency))
+	c.add("purchasePlace", textValue(previousAcquisition.Place), textValue(currentAcquisition.Place))
+
+	// One change per custom field, e.g. "customValues.Signed"
+	names := []string{}
+	for name := range previous.CustomValues {
+		names = append(names, name)
+	}
+	for name := range current.CustomValues {
+		if _, ok := previous.CustomValues[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.add("customValues."+name, mapValue(previous.CustomValues, name), mapValue(current.CustomValues, name))
+	}
+
+	// Video-specific fields
+	c.add("directors", listValue(previous.Directors), listValue(current.Directors))
+	c.add("cast", listValue(previous.Cast), listValue(current.Cast))
+	c.add("releaseYear", intValue(previous.ReleaseYear), intValue(current.ReleaseYear))
+	c.add("duration", intValue(previous.Duration), intValue(current.Duration))
+	c.add("tmdbId", optionalValue(previous.TmdbId), optionalValue(current.TmdbId))
+	c.add("videoKind", videoKindValue(previous.VideoKind), videoKindValue(current.VideoKind))
+	c.add("seasons", intListValue(previous.Seasons), intListValue(current.Seasons))
+	c.add("seasonCount", intValue(previous.SeasonCount), intValue(current.SeasonCount))
+	// Music-specific fields
+	c.add("artists", listValue(previous.Artists), listValue(current.Artists))
+	c.add("tracklist", listValue(previous.Tracklist), listValue(current.Tracklist))
+	c.add("label", optionalValue(previous.Label), optionalValue(current.Label))
+	c.add("barcode", optionalValue(previous.Barcode), optionalValue(current.Barcode))
+	// Board game-specific fields
+	c.add("designers", listValue(previous.Designers), listValue(current.Designers))
+	c.add("publisher", optionalValue(previous.Publisher), optionalValue(current.Publisher))
+	c.add("minPlayers", intValue(previous.MinPlayers), intValue(current.MinPlayers))
+	c.add("maxPlayers", intValue(previous.MaxPlayers), intValue(current.MaxPlayers))
+	c.add("playTime", intValue(previous.PlayTime), intValue(current.PlayTime))
+	c.add("bggId", optionalValue(previous.BggId), optionalValue(current.BggId))
+
+	return c
+}
+
+func diffCollection(previous *domain.Collection, current *domain.Collection) fieldChanges {
+	c := fieldChanges{}
+	c.add("name", textValue(previous.Name), textValue(current.Name))
+	c.add("description", textValue(previous.Description), textValue(current.Description))
+	c.add("isSeries", boolValue(previous.IsSeries), boolValue(current.IsSeries))
+	c.add("totalVolumes", intValue(previous.TotalVolumes), intValue(current.TotalVolumes))
+	return c
+}
+
+func diffLibrary(previous *domain.Library, current *domain.Library) fieldChanges {
+	c := fieldChanges{}
+	c.add("name", textValue(previous.Name), textValue(current.Name))
+	c.add("description", textValue(previous.Description), textValue(current.Description))
+	c.add("customFields", customFieldsValue(previous.CustomFields), customFieldsValue(current.CustomFields))
+	return c
+}
+
+// Field values are rendered as text, unset and empty values are nil
+
+func textValue(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func optionalValue(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	return textValue(*v)
+}
+
+func listValue(v []string) *string {
+	return textValue(strings.Join(v, ", "))
+}
+
+func intValue(v *int) *string {
+	if v == nil {
+		return nil
+	}
+	return textValue(strconv.Itoa(*v))
+}
+
+func intListValue(v []int) *string {
+	values := []string{}
+	for _, n := range v {
+		values = append(values, strconv.Itoa(n))
+	}
+	return listValue(values)
+}
+
+func mapValue(v map[string]string, key string) *string {
+	return textValue(v[key])
+}
+
+func boolValue(v bool) *string {
+	return textValue(strconv.FormatBool(v))
+}
+
+func dateValue(v *time.Time) *string {
+	if v == nil {
+		return nil
+	}
+	return textValue(v.Format("2006-01-02"))
+}
+
+func priceValue(price *float64, currency string) *string {
+	if price == nil {
+		return nil
+	}
+	return textValue(strings.TrimSpace(fmt.Sprintf("%s %s", strconv.FormatFloat(*price, 'f', -1, 64), currency)))
+}
+
+func formatValue(v *domain.ItemFormat) *string {
+	if v == nil {
+		return nil
+	}
+	return textValue(string(*v))
+}
+
+func videoKindValue(v *domain.VideoKind) *string {
+	if v == nil {
+		return nil
+	}
+	return textValue(string(*v))
+}
+
+// customFieldsValue renders the name, type and options of each field, e.g. "Signed TEXT, Region ENUM (EU/US)"
+func customFieldsValue(fields []domain.CustomField) *string {
+	values := []string{}
+	for _, f := range fields {
+		v := fmt.Sprintf("%s %s", f.Name, f.Type)
+		if len(f.Options) > 0 {
+			v = fmt.Sprintf("%s (%s)", v, strings.Join(f.Options, "/"))
+		}
+		values = append(values, v)
+	}
+	return listValue(values)
+}
+
+// copiesValue renders the format and condition of each copy, e.g. "HARDCOVER GOOD, PAPERBACK"
+func copiesValue(copies []domain.ItemCopy) *string {
+	values := []string{}
+	for _, c := range copies {
+		parts := []string{}
+		if c.Format != nil {
+			parts = append(parts, string(*c.Format))
+		}
+		if c.Condition != nil {
+			parts = append(parts, string(*c.Condition))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "-")
+		}
+		values = append(values, strings.Join(parts, " "))
+	}
+	return listValue(values)
+}
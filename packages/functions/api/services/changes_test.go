@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+// itemsDatabase holds a library with its items and records the changes logged on them,
+// the other methods of the database are not expected to be called
+type itemsDatabase struct {
+	ports.Database
+	library        domain.Library
+	items          map[string]domain.LibraryItem
+	itemChanges    map[string]*domain.ChangeEntry
+	libraryChanges []*domain.ChangeEntry
+}
+
+func newItemsDatabase(items ...domain.LibraryItem) *itemsDatabase {
+	db := &itemsDatabase{
+		library:     domain.Library{Id: "library", Name: "Books", OwnerId: "owner"},
+		items:       map[string]domain.LibraryItem{},
+		itemChanges: map[string]*domain.ChangeEntry{},
+	}
+	for _, i := range items {
+		i.OwnerId = "owner"
+		i.LibraryId = "library"
+		db.items[i.Id] = i
+	}
+	return db
+}
+
+// GetLibraryShare finds no share, the library is edited by its owner
+func (d *itemsDatabase) GetLibraryShare(userId string, libraryId string) (*domain.LibraryShare, error) {
+	return nil, nil
+}
+
+func (d *itemsDatabase) GetLibrary(ownerId string, libraryId string) (*domain.Library, error) {
+	l := d.library
+	return &l, nil
+}
+
+func (d *itemsDatabase) UpdateLibrary(l *domain.Library) error {
+	d.library = *l
+	return nil
+}
+
+func (d *itemsDatabase) GetLibraryItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error) {
+	i, ok := d.items[itemId]
+	if !ok {
+		return nil, fmt.Errorf("unknown item")
+	}
+	return &i, nil
+}
+
+func (d *itemsDatabase) UpdateLibraryItem(i *domain.LibraryItem) error {
+	d.items[i.Id] = *i
+	return nil
+}
+
+func (d *itemsDatabase) PutItemChanges(ownerId string, libraryId string, changes map[string]*domain.ChangeEntry) error {
+	for id, e := range changes {
+		d.itemChanges[id] = e
+	}
+	return nil
+}
+
+func (d *itemsDatabase) PutLibraryChange(l *domain.Library, e *domain.ChangeEntry) error {
+	d.libraryChanges = append(d.libraryChanges, e)
+	return nil
+}
+
+// renderChanges formats the changes as "field: from -> to", unset values as "-"
+func renderChanges(e *domain.ChangeEntry) []string {
+	value := func(v *string) string {
+		if v == nil {
+			return "-"
+		}
+		return *v
+	}
+
+	rendered := []string{}
+	for _, c := range e.Changes {
+		rendered = append(rendered, fmt.Sprintf("%s: %s -> %s", c.Field, value(c.From), value(c.To)))
+	}
+	return rendered
+}
+
+func TestUpdateItemRecordsChanges(t *testing.T) {
+	actor := domain.Actor{UserId: "editor", UserName: "Editor"}
+	str := func(s string) *string { return &s }
+	price := func(p float64) *float64 { return &p }
+	purchased := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	paperback := domain.Paperback
+	good := domain.ConditionGood
+	reading := domain.Reading
+
+	tests := map[string]struct {
+		current  domain.LibraryItem
+		update   domain.LibraryItem
+		expected []string // nil when no change is recorded
+	}{
+		"modified, set and unset fields": {
+			current: domain.LibraryItem{Title: "Dune", Summary: "Spice", Isbn: "9780441013593", Authors: []string{"Frank Herbert"}},
+			update:  domain.LibraryItem{Title: "Dune Messiah", Summary: "Spice", Authors: []string{"Frank Herbert", "Brian Herbert"}, Volume: func() *int { n := 2; return &n }()},
+			expected: []string{
+				"title: Dune -> Dune Messiah",
+				"authors: Frank Herbert -> Frank Herbert, Brian Herbert",
+				"isbn: 9780441013593 -> -",
+				"volume: - -> 2",
+			},
+		},
+		"nothing modified": {
+			current: domain.LibraryItem{Title: "Dune", Authors: []string{"Frank Herbert"}, PictureUrl: str("")},
+			update:  domain.LibraryItem{Title: "Dune", Authors: []string{"Frank Herbert"}},
+		},
+		"lending and status have their own history": {
+			current: domain.LibraryItem{Title: "Dune", LentTo: str("Alice"), Status: &reading},
+			update:  domain.LibraryItem{Title: "Dune"},
+		},
+		"acquisition": {
+			current: domain.LibraryItem{Title: "Dune"},
+			update: domain.LibraryItem{Title: "Dune", Acquisition: &domain.Acquisition{
+				Date: &purchased, Price: price(12.5), Currency: "EUR", Place: "Bookshop",
+			}},
+			expected: []string{
+				"purchaseDate: - -> 2024-03-15",
+				"purchasePrice: - -> 12.5 EUR",
+				"purchasePlace: - -> Bookshop",
+			},
+		},
+		"copies": {
+			current: domain.LibraryItem{Title: "Dune", Copies: []domain.ItemCopy{{Id: "copy", Format: &paperback}}},
+			update: domain.LibraryItem{Title: "Dune", Copies: []domain.ItemCopy{
+				{Id: "copy", Format: &paperback, Condition: &good},
+				{},
+			}},
+			expected: []string{"copies: PAPERBACK -> PAPERBACK GOOD, -"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.current.Id = "item"
+			db := newItemsDatabase(tt.current)
+			s := NewServices(db, nil, nil, nil)
+
+			update := tt.update
+			update.Id = "item"
+			update.OwnerId = "owner"
+			update.LibraryId = "library"
+			err := s.UpdateItem(&update, false, actor)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			e, ok := db.itemChanges["item"]
+			if tt.expected == nil {
+				if ok {
+					t.Fatalf("expected no change recorded, got %v", renderChanges(e))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected changes %v, none recorded", tt.expected)
+			}
+			if e.UserId != actor.UserId || e.UserName != actor.UserName {
+				t.Errorf("expected changes made by %v, got %s (%s)", actor, e.UserId, e.UserName)
+			}
+			if e.Date == nil || !e.Date.Equal(*db.items["item"].UpdatedAt) {
+				t.Errorf("expected changes dated as the item update, got %v", e.Date)
+			}
+			if changes := renderChanges(e); !reflect.DeepEqual(changes, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, changes)
+			}
+		})
+	}
+}
+
+func TestUpdateLibraryRecordsChanges(t *testing.T) {
+	db := newItemsDatabase()
+	db.library.Description = "Novels"
+	s := NewServices(db, nil, nil, nil)
+	actor := domain.Actor{UserId: "owner", UserName: "Owner"}
+
+	err := s.UpdateLibrary(&domain.Library{Id: "library", OwnerId: "owner", Name: "Novels", Description: "Novels"}, actor)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(db.libraryChanges) != 1 {
+		t.Fatalf("expected one change entry, got %d", len(db.libraryChanges))
+	}
+	if changes := renderChanges(db.libraryChanges[0]); !reflect.DeepEqual(changes, []string{"name: Books -> Novels"}) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	// Saving the library as is records nothing
+	err = s.UpdateLibrary(&domain.Library{Id: "library", OwnerId: "owner", Name: "Novels", Description: "Novels"}, actor)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(db.libraryChanges) != 1 {
+		t.Errorf("expected no new change entry, got %d", len(db.libraryChanges))
+	}
+}
@@ -13,6 +13,12 @@ import (
 // CreateCollection creates a new collection in a library
 // Enforces unique collection names within a library
 func (s *services) CreateCollection(c *domain.Collection) (*domain.Collection, error) {
+	ownerId, err := s.ResolveLibraryOwner(c.OwnerId, c.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	c.OwnerId = ownerId
+
 	// Check for duplicate name
 	existing, err := s.db.GetCollectionByName(c.OwnerId, c.LibraryId, c.Name)
 	if err != nil {
@@ -40,7 +46,14 @@ func (s *services) CreateCollection(c *domain.Collection) (*domain.Collection, e
 
 // UpdateCollection updates an existing collection
 // Checks for name uniqueness if the name is being changed
-func (s *services) UpdateCollection(c *domain.Collection) error {
+// keepSeries keeps the series flag of the collection, and its total volumes unless set, for clients unaware of series
+func (s *services) UpdateCollection(c *domain.Collection, keepSeries bool, actor domain.Actor) error {
+	ownerId, err := s.ResolveLibraryOwner(c.OwnerId, c.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	c.OwnerId = ownerId
+
 	// Get current collection to check if name is changing
 	current, err := s.db.GetCollection(c.OwnerId, c.LibraryId, c.Id)
 	if err != nil {
@@ -52,6 +65,17 @@ func (s *services) UpdateCollection(c *domain.Collection) error {
 		return errors.New(msg)
 	}
 
+	if keepSeries {
+		c.IsSeries = current.IsSeries
+		if c.TotalVolumes == nil {
+			c.TotalVolumes = current.TotalVolumes
+		} else if !c.IsSeries {
+			msg := "total volumes can only be set on a series"
+			log.Error().Str("id", c.Id).Msg(msg)
+			return errors.New(msg)
+		}
+	}
+
 	// If name is changing, check for uniqueness
 	if current.Name != c.Name {
 		existing, err := s.db.GetCollectionByName(c.OwnerId, c.LibraryId, c.Name)
@@ -68,12 +92,31 @@ func (s *services) UpdateCollection(c *domain.Collection) error {
 	now := time.Now().UTC()
 	c.UpdatedAt = &now
 
-	return s.db.UpdateCollection(c)
+	err = s.db.UpdateCollection(c)
+	if err != nil {
+		return err
+	}
+
+	if e := newChangeEntry(actor, &now, diffCollection(current, c)); e != nil {
+		err = s.db.PutCollectionChange(c, e)
+		if err != nil {
+			// Log but don't fail - the collection is already updated
+			log.Warn().Str("id", c.Id).Msgf("Failed to record collection changes: %s", err.Error())
+		}
+	}
+
+	return nil
 }
 
 // DeleteCollection removes a collection
 // Items in the collection will be orphaned by the consistency-manager
 func (s *services) DeleteCollection(c *domain.Collection) error {
+	ownerId, err := s.ResolveLibraryOwner(c.OwnerId, c.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	c.OwnerId = ownerId
+
 	return s.db.DeleteCollection(c)
 }
 
@@ -82,6 +125,56 @@ func (s *services) GetCollection(ownerId string, libraryId string, collectionId
 	return s.db.GetCollection(ownerId, libraryId, collectionId)
 }
 
+// GetSeriesVolumes reports owned and missing volumes of a series collection
+// Expected volumes run from 1 to TotalVolumes, or to the highest owned volume when the total is unknown
+func (s *services) GetSeriesVolumes(ownerId string, libraryId string, collectionId string) (*domain.SeriesVolumes, error) {
+	collection, err := s.db.GetCollection(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		msg := "collection not found"
+		log.Error().Str("id", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+	if !collection.IsSeries {
+		msg := "collection is not a series"
+		log.Error().Str("id", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	volumes, err := s.db.QueryCollectionVolumes(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := map[int]bool{}
+	lastVolume := 0
+	for _, v := range volumes {
+		owned[v] = true
+		lastVolume = max(lastVolume, v)
+	}
+	if collection.TotalVolumes != nil {
+		lastVolume = max(lastVolume, *collection.TotalVolumes)
+	}
+
+	result := &domain.SeriesVolumes{
+		CollectionId:   collectionId,
+		TotalVolumes:   collection.TotalVolumes,
+		OwnedVolumes:   []int{},
+		MissingVolumes: []int{},
+	}
+	for v := 1; v <= lastVolume; v++ {
+		if owned[v] {
+			result.OwnedVolumes = append(result.OwnedVolumes, v)
+		} else {
+			result.MissingVolumes = append(result.MissingVolumes, v)
+		}
+	}
+
+	return result, nil
+}
+
 // ListCollectionsByLibrary returns all collections in a library
 func (s *services) ListCollectionsByLibrary(ownerId string, libraryId string) ([]domain.Collection, error) {
 	return s.db.QueryCollectionsByLibrary(ownerId, libraryId)
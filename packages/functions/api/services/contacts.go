@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// ListContacts returns the contacts of the user with their loans, sorted by name
+func (s *services) ListContacts(ownerId string) ([]*domain.Contact, error) {
+	contacts, err := s.db.QueryContacts(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(contacts, func(a, b int) bool {
+		return strings.ToLower(contacts[a].Name) < strings.ToLower(contacts[b].Name)
+	})
+
+	return contacts, nil
+}
+
+func (s *services) GetContact(ownerId string, contactId string) (*domain.Contact, error) {
+	contact, err := s.db.GetContact(ownerId, contactId)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		msg := "contact not found"
+		log.Error().Str("contactId", contactId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return contact, nil
+}
+
+// CreateContact creates a contact, names are unique per user regardless of their case
+func (s *services) CreateContact(c *domain.Contact) (*domain.Contact, error) {
+	contacts, err := s.db.QueryContacts(c.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if findContactByName(contacts, c.Name) != nil {
+		msg := "contact with this name already exists"
+		log.Error().Str("name", c.Name).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	c.Id = identifier.NewId()
+	c.UpdatedAt = &current
+	c.Loans = []domain.ContactLoan{}
+
+	err = s.db.PutContact(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// UpdateContact renames a contact and updates its details, the consistency manager updates the name
+// of the items lent to it
+func (s *services) UpdateContact(c *domain.Contact) error {
+	contacts, err := s.db.QueryContacts(c.OwnerId)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, other := range contacts {
+		if other.Id == c.Id {
+			found = true
+			continue
+		}
+		if strings.EqualFold(other.Name, c.Name) {
+			msg := "contact with this name already exists"
+			log.Error().Str("name", c.Name).Msg(msg)
+			return errors.New(msg)
+		}
+	}
+
+	if !found {
+		msg := "contact not found"
+		log.Error().Str("contactId", c.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	c.UpdatedAt = &current
+
+	return s.db.UpdateContact(c)
+}
+
+// DeleteContact removes a contact and its lending history, once all the items lent to it are returned
+func (s *services) DeleteContact(ownerId string, contactId string) error {
+	contact, err := s.GetContact(ownerId, contactId)
+	if err != nil {
+		return err
+	}
+
+	if contact.Stats(time.Now().UTC()).CurrentLoans > 0 {
+		msg := "contact has items not returned"
+		log.Error().Str("contactId", contactId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.DeleteContact(contact)
+}
+
+// ResolveContact returns the contact with the given name, created if none exists.
+// Lets lending by name reuse the same contact whatever the case of the name.
+func (s *services) ResolveContact(ownerId string, name string) (*domain.Contact, error) {
+	contacts, err := s.db.QueryContacts(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if contact := findContactByName(contacts, name); contact != nil {
+		return contact, nil
+	}
+
+	return s.CreateContact(&domain.Contact{
+		OwnerId: ownerId,
+		Name:    name,
+	})
+}
+
+// resolveUserContact returns the contact linked to an app user. The contact with the name of the user is linked
+// to it when not linked to another user yet, and a contact is created when none has this name.
+func (s *services) resolveUserContact(ownerId string, userId string, name string) (*domain.Contact, error) {
+	contacts, err := s.db.QueryContacts(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range contacts {
+		if c.UserId != nil && *c.UserId == userId {
+			return c, nil
+		}
+	}
+
+	contact := findContactByName(contacts, name)
+	if contact == nil {
+		return s.CreateContact(&domain.Contact{
+			OwnerId: ownerId,
+			Name:    name,
+			UserId:  &userId,
+		})
+	}
+
+	if contact.UserId != nil {
+		msg := "contact with this name already exists"
+		log.Error().Str("name", name).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	contact.UserId = &userId
+	contact.UpdatedAt = &current
+	err = s.db.UpdateContact(contact)
+	if err != nil {
+		return nil, err
+	}
+
+	// The items already lent to the contact are now borrowed by the user
+	loans := []domain.ContactLoan{}
+	for _, l := range contact.Loans {
+		if l.ReturnedAt == nil {
+			l.BorrowerId = &userId
+			loans = append(loans, l)
+		}
+	}
+	err = s.db.PutContactLoans(loans)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+func (s *services) ResolveUserContact(ownerId string, userName string) (*domain.Contact, error) {
+	userId, err := s.idp.GetUserIdFromUserName(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if userId == ownerId {
+		msg := "cannot lend an item to its owner"
+		log.Error().Str("userName", userName).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return s.resolveUserContact(ownerId, userId, userName)
+}
+
+func (s *services) ListBorrowedLoans(userId string) ([]domain.ContactLoan, error) {
+	loans, err := s.db.QueryBorrowedLoans(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(loans, func(a, b int) bool {
+		return loans[a].LentAt.After(*loans[b].LentAt)
+	})
+
+	return loans, nil
+}
+
+func findContactByName(contacts []*domain.Contact, name string) *domain.Contact {
+	for _, c := range contacts {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// returnContactLoan returns the current loan to a contact of an item (or of one of its copies), marked as returned.
+// Returns nil when the item was lent before contacts existed, or when the contact loan is not found.
+func (s *services) returnContactLoan(ownerId string, contactId *string, itemId string, copyId *string, date *time.Time) (*domain.ContactLoan, error) {
+	if contactId == nil {
+		return nil, nil
+	}
+
+	contact, err := s.db.GetContact(ownerId, *contactId)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		log.Warn().Str("contactId", *contactId).Str("itemId", itemId).Msg("Contact of lent item not found")
+		return nil, nil
+	}
+
+	idx := contact.FindCurrentLoan(itemId, copyId)
+	if idx == -1 {
+		log.Warn().Str("contactId", *contactId).Str("itemId", itemId).Msg("Contact loan of lent item not found")
+		return nil, nil
+	}
+
+	loan := contact.Loans[idx]
+	loan.ReturnedAt = date
+	return &loan, nil
+}
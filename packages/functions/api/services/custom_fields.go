@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// checkCustomValues validates the custom values of an item against the custom fields of its library,
+// and renames them after the fields, whose names are matched case-insensitively
+func checkCustomValues(library *domain.Library, i *domain.LibraryItem) error {
+	if len(i.CustomValues) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for name, v := range i.CustomValues {
+		idx := library.FindCustomField(name)
+		if idx == -1 {
+			msg := "custom field not found"
+			log.Error().Str("libraryId", library.Id).Str("field", name).Msg(msg)
+			return errors.New(msg)
+		}
+
+		field := library.CustomFields[idx]
+		if !field.Accepts(v) {
+			msg := "invalid custom field value"
+			log.Error().Str("libraryId", library.Id).Str("field", field.Name).Str("value", v).Msg(msg)
+			return errors.New(msg)
+		}
+		values[field.Name] = v
+	}
+	i.CustomValues = values
+
+	return nil
+}
+
+// checkUpdatedCustomValues validates the custom values of an updated item as checkCustomValues does, except for the
+// values left unchanged: those no longer valid since the library fields were changed are dropped instead of rejected
+func checkUpdatedCustomValues(library *domain.Library, current *domain.LibraryItem, i *domain.LibraryItem) error {
+	changed := map[string]string{}
+	unchanged := map[string]string{}
+	for name, v := range i.CustomValues {
+		if previous, ok := current.CustomValues[name]; ok && previous == v {
+			unchanged[name] = v
+		} else {
+			changed[name] = v
+		}
+	}
+
+	i.CustomValues = changed
+	err := checkCustomValues(library, i)
+	if err != nil {
+		return err
+	}
+
+	for name, v := range keepCustomValues(library, unchanged) {
+		if _, ok := i.CustomValues[name]; !ok {
+			i.CustomValues[name] = v
+		}
+	}
+	if len(i.CustomValues) == 0 {
+		i.CustomValues = nil
+	}
+
+	return nil
+}
+
+// keepCustomValues returns the custom values of an item that are valid in the target library, nil when none is.
+// Used when an item changes library, the other values are dropped.
+func keepCustomValues(target *domain.Library, values map[string]string) map[string]string {
+	kept := map[string]string{}
+	for name, v := range values {
+		idx := target.FindCustomField(name)
+		if idx == -1 || !target.CustomFields[idx].Accepts(v) {
+			continue
+		}
+		kept[target.CustomFields[idx].Name] = v
+	}
+
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
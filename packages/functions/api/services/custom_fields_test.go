@@ -0,0 +1,137 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+var customFields = []domain.CustomField{
+	{Name: "Signed", Type: domain.CustomText},
+	{Name: "Pages", Type: domain.CustomNumber},
+	{Name: "Region", Type: domain.CustomEnum, Options: []string{"EU", "US"}},
+}
+
+func TestUpdateItemCustomValues(t *testing.T) {
+	db := newItemsDatabase(domain.LibraryItem{Id: "item", Title: "Dune"})
+	db.library.CustomFields = customFields
+	s := NewServices(db, nil, nil, nil)
+	actor := domain.Actor{UserId: "owner", UserName: "Owner"}
+
+	update := func(values map[string]string) error {
+		return s.UpdateItem(&domain.LibraryItem{
+			Id: "item", OwnerId: "owner", LibraryId: "library", Title: "Dune", CustomValues: values,
+		}, false, actor)
+	}
+
+	// Values are stored under the name of their field
+	err := update(map[string]string{"signed": "yes", "PAGES": "412"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := map[string]string{"Signed": "yes", "Pages": "412"}
+	if values := db.items["item"].CustomValues; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+	changes := renderChanges(db.itemChanges["item"])
+	if !reflect.DeepEqual(changes, []string{"customValues.Pages: - -> 412", "customValues.Signed: - -> yes"}) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	rejected := map[string]map[string]string{
+		"custom field not found":     {"Edition": "First"},
+		"invalid custom field value": {"Pages": "many"},
+	}
+	for msg, values := range rejected {
+		err := update(values)
+		if err == nil || err.Error() != msg {
+			t.Errorf("values %v: expected error %q, got %v", values, msg, err)
+		}
+	}
+	err = update(map[string]string{"Region": "JP"})
+	if err == nil || err.Error() != "invalid custom field value" {
+		t.Errorf("expected an option outside the enum to be rejected, got %v", err)
+	}
+	if values := db.items["item"].CustomValues; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected rejected updates to keep %v, got %v", expected, values)
+	}
+}
+
+func TestUpdateItemCustomValuesAfterFieldsChange(t *testing.T) {
+	db := newItemsDatabase(domain.LibraryItem{
+		Id:           "item",
+		Title:        "Dune",
+		CustomValues: map[string]string{"Signed": "yes", "Pages": "412", "Region": "EU"},
+	})
+	// Signed was removed, Pages renamed and Region turned into a number since the values were set
+	db.library.CustomFields = []domain.CustomField{
+		{Name: "Page count", Type: domain.CustomNumber},
+		{Name: "Region", Type: domain.CustomNumber},
+	}
+	s := NewServices(db, nil, nil, nil)
+
+	update := func(values map[string]string) error {
+		return s.UpdateItem(&domain.LibraryItem{
+			Id: "item", OwnerId: "owner", LibraryId: "library", Title: "Dune", CustomValues: values,
+		}, false, domain.Actor{})
+	}
+
+	// Values of other fields are rejected once changed
+	err := update(map[string]string{"Signed": "no", "Pages": "412", "Region": "EU"})
+	if err == nil || err.Error() != "custom field not found" {
+		t.Errorf("expected the changed value to be rejected, got %v", err)
+	}
+	err = update(map[string]string{"Region": "US"})
+	if err == nil || err.Error() != "invalid custom field value" {
+		t.Errorf("expected the changed value to be rejected, got %v", err)
+	}
+
+	// The values read before are sent back as is, the ones no longer valid are dropped
+	err = update(map[string]string{"Signed": "yes", "Pages": "412", "Region": "EU", "page count": "412"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	expected := map[string]string{"Page count": "412"}
+	if values := db.items["item"].CustomValues; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	// Omitted values are kept
+	err = update(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if values := db.items["item"].CustomValues; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	// An empty object removes them
+	err = update(map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if values := db.items["item"].CustomValues; values != nil {
+		t.Errorf("expected no values, got %v", values)
+	}
+}
+
+func TestKeepCustomValues(t *testing.T) {
+	target := &domain.Library{CustomFields: customFields[1:]}
+
+	tests := map[string]struct {
+		values   map[string]string
+		expected map[string]string
+	}{
+		"renamed after the target fields":    {map[string]string{"pages": "412", "Region": "EU"}, map[string]string{"Pages": "412", "Region": "EU"}},
+		"unknown fields and invalid dropped": {map[string]string{"Signed": "yes", "Region": "JP", "Pages": "412"}, map[string]string{"Pages": "412"}},
+		"none kept":                          {map[string]string{"Signed": "yes"}, nil},
+		"no values":                          {nil, nil},
+	}
+
+	for name, tt := range tests {
+		if kept := keepCustomValues(target, tt.values); !reflect.DeepEqual(kept, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", name, tt.expected, kept)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+// FindDuplicates returns, for each candidate, the existing items with the same normalized ISBN (books)
+// or TMDB id (videos), in the libraries of the user and the ones shared to them.
+// The candidate itself is never reported, so an existing item can be checked as well.
+func (s *services) FindDuplicates(userId string, candidates []*domain.LibraryItem) ([][]*domain.LibraryItem, error) {
+	matches := make([][]*domain.LibraryItem, len(candidates))
+
+	keys := map[domain.ItemType]bool{}
+	for idx, c := range candidates {
+		matches[idx] = []*domain.LibraryItem{}
+		if duplicateKey(c) != "" {
+			keys[c.Type] = true
+		}
+	}
+
+	if len(keys) == 0 {
+		return matches, nil
+	}
+
+	libraries, err := s.db.QueryLibraries(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[string]bool{}
+	for _, l := range libraries {
+		visible[l.Id] = true
+	}
+
+	for itemType := range keys {
+		// Owned libraries in a single owner-wide query
+		existing, err := s.db.QueryItemIdentifiers(userId, "", itemType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range libraries {
+			if l.SharedFrom == nil {
+				continue
+			}
+
+			sharedLibraryOwnerId, err := s.db.GetSharedLibrary(userId, l.Id)
+			if err != nil {
+				return nil, err
+			}
+			if sharedLibraryOwnerId == "" {
+				continue
+			}
+
+			shared, err := s.db.QueryItemIdentifiers(sharedLibraryOwnerId, l.Id, itemType)
+			if err != nil {
+				return nil, err
+			}
+			existing = append(existing, shared...)
+		}
+
+		byKey := map[string][]*domain.LibraryItem{}
+		for _, e := range existing {
+			// Items of a trashed library are still returned by the owner-wide query
+			if !visible[e.LibraryId] {
+				continue
+			}
+			if key := duplicateKey(e); key != "" {
+				byKey[key] = append(byKey[key], e)
+			}
+		}
+
+		for idx, c := range candidates {
+			if c.Type != itemType {
+				continue
+			}
+			for _, e := range byKey[duplicateKey(c)] {
+				if e.Id == c.Id && e.LibraryId == c.LibraryId {
+					continue
+				}
+				matches[idx] = append(matches[idx], e)
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// duplicateKey identifies the work of an item: ISBN-13 for books, TMDB id for videos (movies and TV series
+// ids are distinct namespaces). Empty when the item cannot be identified.
+func duplicateKey(i *domain.LibraryItem) string {
+	switch i.Type {
+	case domain.ItemBook:
+		return normalizeIsbn(i.Isbn)
+	case domain.ItemVideo:
+		if i.TmdbId == nil || strings.TrimSpace(*i.TmdbId) == "" {
+			return ""
+		}
+		kind := domain.Movie
+		if i.VideoKind != nil && *i.VideoKind != "" {
+			kind = *i.VideoKind
+		}
+		return fmt.Sprintf("%s#%s", kind, strings.TrimSpace(*i.TmdbId))
+	default:
+		return ""
+	}
+}
+
+// normalizeIsbn converts ISBN-10 and ISBN-13, with or without hyphens, to ISBN-13 digits.
+// Other codes are only stripped of separators.
+func normalizeIsbn(code string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+
+	if len(digits) != 10 {
+		return digits
+	}
+
+	// ISBN-10 to ISBN-13: 978 prefix, first 9 digits, new check digit
+	body := "978" + digits[:9]
+	sum := 0
+	for idx, r := range body {
+		if r < '0' || r > '9' {
+			return digits
+		}
+		weight := 1
+		if idx%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+
+	return fmt.Sprintf("%s%d", body, (10-sum%10)%10)
+}
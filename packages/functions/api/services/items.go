@@ -3,6 +3,9 @@ package services
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"alexandria.isnan.eu/functions/internal/domain"
@@ -16,7 +19,7 @@ func (s *services) DeleteLibraryItemHistory(ownerId string, libraryId string, it
 		return err
 	}
 
-	if item.LentTo != nil && len(*item.LentTo) != 0 {
+	if item.IsLent() {
 		msg := "item already lent"
 		log.Error().Str("id", item.Id).Msg(msg)
 		return errors.New(msg)
@@ -44,22 +47,70 @@ func (s *services) GetLibraryItemHistory(ownerId string, libraryId string, itemI
 	return history, nil
 }
 
-func (s *services) LendItem(ownerId string, libraryId string, itemId string, lendTo string) error {
+// LendItem lends an item of a library owned by the user or shared to them as lender, to a contact of the library owner
+func (s *services) LendItem(userId string, libraryId string, itemId string, copyId string, contactId string, dueDate *time.Time) error {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareLender)
+	if err != nil {
+		return err
+	}
 
 	item, err := s.db.GetLibraryItem(ownerId, libraryId, itemId)
 	if err != nil {
 		return err
 	}
 
-	if item.LentTo != nil {
+	if item.Wanted {
+		msg := "cannot lend a wishlist item"
+		log.Error().Str("id", itemId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	lentTo, err := itemCopyLentTo(item, copyId)
+	if err != nil {
+		return err
+	}
+
+	if lentTo != nil {
 		msg := "item already lent"
 		log.Error().Str("id", itemId).Msg(msg)
 		return errors.New(msg)
 	}
 
+	contact, err := s.GetContact(ownerId, contactId)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 
-	err = s.db.PutItemEvent(item, domain.Lent, lendTo, &now)
+	loan := &domain.ContactLoan{
+		ContactId:   contact.Id,
+		OwnerId:     ownerId,
+		OwnerName:   item.OwnerName,
+		BorrowerId:  contact.UserId,
+		LibraryId:   item.LibraryId,
+		LibraryName: item.LibraryName,
+		ItemId:      item.Id,
+		ItemType:    item.Type,
+		Title:       item.Title,
+		LentAt:      &now,
+		DueDate:     dueDate,
+	}
+
+	// The item holds its lending state after the event, which gives its next due date
+	if copyId != "" {
+		idx := item.FindCopy(copyId)
+		item.Copies[idx].LentTo = &contact.Name
+		item.Copies[idx].LentToId = &contact.Id
+		item.Copies[idx].DueDate = dueDate
+		loan.CopyId = &copyId
+		return s.db.PutItemCopyEvent(item, copyId, domain.Lent, contact.Name, &now, loan)
+	}
+
+	item.LentTo = &contact.Name
+	item.LentToId = &contact.Id
+	item.DueDate = dueDate
+	err = s.db.PutItemEvent(item, domain.Lent, contact.Name, &now, loan)
 	if err != nil {
 		return err
 	}
@@ -67,66 +118,352 @@ func (s *services) LendItem(ownerId string, libraryId string, itemId string, len
 	return nil
 }
 
-func (s *services) ReturnItem(ownerId string, libraryId string, itemId string, from string) error {
+// ReturnItem returns an item of a library owned by the user or shared to them as lender
+func (s *services) ReturnItem(userId string, libraryId string, itemId string, copyId string, from string, assessment *domain.ReturnAssessment) error {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareLender)
+	if err != nil {
+		return err
+	}
+
 	item, err := s.db.GetLibraryItem(ownerId, libraryId, itemId)
 	if err != nil {
 		return err
 	}
 
-	if item.LentTo == nil {
+	lentTo, err := itemCopyLentTo(item, copyId)
+	if err != nil {
+		return err
+	}
+
+	if lentTo == nil {
 		msg := "item not lent"
 		log.Error().Str("id", itemId).Msg(msg)
 		return errors.New(msg)
 	}
 
 	now := time.Now().UTC()
+	event := domain.LendingEvent{
+		Item:       item,
+		CopyId:     copyId,
+		Type:       domain.Returned,
+		Assessment: assessment,
+	}
 
-	err = s.db.PutItemEvent(item, domain.Returned, from, &now)
+	// Items lent to a contact are returned from it
+	if copyId != "" {
+		idx := item.FindCopy(copyId)
+		event.Loan, err = s.returnContactLoan(ownerId, item.Copies[idx].LentToId, itemId, &copyId, &now)
+		if err != nil {
+			return err
+		}
+		if item.Copies[idx].LentToId != nil {
+			from = *lentTo
+		}
+
+		item.Copies[idx].LentTo = nil
+		item.Copies[idx].LentToId = nil
+		item.Copies[idx].DueDate = nil
+		if assessment != nil {
+			item.Copies[idx].Condition = &assessment.Condition
+		}
+	} else {
+		event.Loan, err = s.returnContactLoan(ownerId, item.LentToId, itemId, nil, &now)
+		if err != nil {
+			return err
+		}
+		if item.LentToId != nil {
+			from = *lentTo
+		}
+
+		item.LentTo = nil
+		item.LentToId = nil
+		item.DueDate = nil
+		if assessment != nil {
+			item.Condition = &assessment.Condition
+		}
+	}
+	event.Person = from
+
+	if assessment != nil {
+		if event.Loan != nil {
+			event.Loan.ReturnCondition = &assessment.Condition
+			event.Loan.ReturnNote = assessment.Note
+		}
+		s.putReturnPicture(item, assessment, now)
+	}
+
+	return s.db.PutLendingEvents([]domain.LendingEvent{event}, &now)
+}
+
+// putReturnPicture uploads the photo of a returned item next to its picture.
+// The return is recorded even if the upload fails, without its photo.
+func (s *services) putReturnPicture(item *domain.LibraryItem, assessment *domain.ReturnAssessment, date time.Time) {
+	if len(assessment.Picture) == 0 {
+		return
+	}
+
+	err := s.storage.PutReturnPicture(item.OwnerId, item.LibraryId, item.Id, domain.ReturnPictureId(date), assessment.Picture)
+	if err != nil {
+		log.Warn().Str("id", item.Id).Msgf("Return recorded without its picture: %s", err.Error())
+		assessment.Picture = nil
+	}
+}
+
+// itemCopyLentTo returns the lending state of the copy of an item having several copies, or of the item itself.
+// A copy must be given exactly when the item has copies.
+func itemCopyLentTo(item *domain.LibraryItem, copyId string) (*string, error) {
+	if len(item.Copies) == 0 {
+		if copyId != "" {
+			msg := "copy not found"
+			log.Error().Str("id", item.Id).Str("copyId", copyId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+		return item.LentTo, nil
+	}
+
+	if copyId == "" {
+		msg := "copy is mandatory for items with several copies"
+		log.Error().Str("id", item.Id).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	idx := item.FindCopy(copyId)
+	if idx == -1 {
+		msg := "copy not found"
+		log.Error().Str("id", item.Id).Str("copyId", copyId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	return item.Copies[idx].LentTo, nil
+}
+
+// keepOmittedFields keeps the current values of the fields an update left unset (nil), for clients unaware of them
+func keepOmittedFields(current *domain.LibraryItem, i *domain.LibraryItem) {
+	if i.Tags == nil {
+		i.Tags = current.Tags
+	}
+	// An empty location id removes the item from its location
+	if i.LocationId == nil {
+		i.LocationId = current.LocationId
+	}
+	if i.Copies == nil {
+		i.Copies = slices.Clone(current.Copies)
+	}
+	// An empty acquisition removes the purchase details
+	if i.Acquisition == nil {
+		i.Acquisition = current.Acquisition
+	} else if *i.Acquisition == (domain.Acquisition{}) {
+		i.Acquisition = nil
+	}
+	if i.CustomValues == nil {
+		i.CustomValues = maps.Clone(current.CustomValues)
+	}
+}
+
+// mergeItemCopies assigns ids to the new copies and keeps the lending state of the existing ones.
+// Lent copies cannot be removed, and a lent item must be returned before being split into copies.
+func mergeItemCopies(current *domain.LibraryItem, copies []domain.ItemCopy) ([]domain.ItemCopy, error) {
+	if len(copies) > 0 && len(current.Copies) == 0 && current.LentTo != nil {
+		msg := "lent item must be returned before adding copies"
+		log.Error().Str("id", current.Id).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	kept := map[string]bool{}
+	for idx := range copies {
+		if copies[idx].Id == "" {
+			copies[idx].Id = identifier.NewId()
+			copies[idx].LentTo = nil
+			copies[idx].LentToId = nil
+			copies[idx].DueDate = nil
+			continue
+		}
+
+		existing := current.FindCopy(copies[idx].Id)
+		if existing == -1 {
+			msg := "copy not found"
+			log.Error().Str("id", current.Id).Str("copyId", copies[idx].Id).Msg(msg)
+			return nil, errors.New(msg)
+		}
+		copies[idx].LentTo = current.Copies[existing].LentTo
+		copies[idx].LentToId = current.Copies[existing].LentToId
+		copies[idx].DueDate = current.Copies[existing].DueDate
+		kept[copies[idx].Id] = true
+	}
+
+	for _, c := range current.Copies {
+		if !kept[c.Id] && c.LentTo != nil {
+			msg := "cannot remove a lent copy"
+			log.Error().Str("id", current.Id).Str("copyId", c.Id).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	return copies, nil
+}
+
+// SetItemStatus records a reading/watching status change of the user in the item history, along with the user.
+// Each user has their own status, members of a shared library track their progress on its items as well.
+func (s *services) SetItemStatus(userId string, libraryId string, itemId string, status domain.ItemStatus, date *time.Time, actor domain.Actor) error {
+	item, err := s.getAccessibleItem(userId, libraryId, itemId)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if !status.IsValidFor(item.Type) {
+		msg := fmt.Sprintf("status %s not supported for item type %s", status, item.Type)
+		log.Error().Str("id", itemId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	now := time.Now().UTC()
+	if date == nil {
+		date = &now
+	}
+
+	if date.After(now) {
+		msg := "status date in the future"
+		log.Error().Str("id", itemId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	current, err := s.db.GetItemStatus(item.OwnerId, libraryId, itemId, userId)
+	if err != nil {
+		return err
+	}
+
+	// Keep the current status consistent with the most recent event
+	if current != nil && current.Date != nil && date.Before(*current.Date) {
+		msg := "status date before the current status date"
+		log.Error().Str("id", itemId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.PutItemStatus(item, &domain.UserItemStatus{
+		OwnerId:   item.OwnerId,
+		LibraryId: libraryId,
+		ItemId:    itemId,
+		UserId:    userId,
+		Status:    status,
+		Date:      date,
+	}, actor)
+}
+
+// ListFilteredItems returns the items of a library matching the filter (reading/watching status, tag, location)
+func (s *services) ListFilteredItems(ownerId string, libraryId string, filter *domain.ItemFilter, continuationToken string, pageSize int) (*domain.LibraryContent, error) {
+	// Find if it is a shared library to the current requester
+	sharedLibraryOwnerId, err := s.db.GetSharedLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryOwnerId := ownerId
+	if sharedLibraryOwnerId != "" {
+		libraryOwnerId = sharedLibraryOwnerId
+	}
+
+	// Items carry the tag index casing
+	if filter.Tag != nil {
+		tags, err := s.db.QueryTagsByLibrary(libraryOwnerId, libraryId)
+		if err != nil {
+			return nil, err
+		}
+
+		idx := slices.IndexFunc(tags, func(t domain.Tag) bool { return strings.EqualFold(t.Name, *filter.Tag) })
+		if idx == -1 {
+			return &domain.LibraryContent{Items: []*domain.LibraryItem{}}, nil
+		}
+		filter.Tag = &tags[idx].Name
+	}
+
+	// A room contains its bookcases and shelves
+	if len(filter.LocationIds) > 0 {
+		locations, err := s.db.QueryLocationsByLibrary(libraryOwnerId, libraryId)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.LocationIds = expandLocations(locations, filter.LocationIds)
+
+		// DynamoDB IN comparator accepts up to 100 values
+		if len(filter.LocationIds) > 100 {
+			msg := "too many nested locations"
+			log.Error().Str("libraryId", libraryId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	// Statuses are the requester own
+	filter.UserId = ownerId
+
+	content, err := s.db.QueryFilteredItems(libraryOwnerId, libraryId, filter, continuationToken, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	s.fillMyRatings(ownerId, content.Items)
+	s.fillMyStatuses(ownerId, content.Items)
+
+	return content, nil
 }
 
 func (s *services) DeleteItem(i *domain.LibraryItem) error {
+	// Editors of a shared library act on the items of its owner
+	ownerId, err := s.ResolveLibraryOwner(i.OwnerId, i.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	i.OwnerId = ownerId
+
 	// Get current item to check if it's in a collection
 	current, err := s.db.GetLibraryItem(i.OwnerId, i.LibraryId, i.Id)
 	if err != nil {
 		return err
 	}
 
-	err = s.db.DeleteLibraryItem(i)
+	// Loans of a trashed item would still be listed and reminded
+	if current.IsLent() {
+		msg := "item is lent and not returned"
+		log.Error().Str("id", i.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	// History, reviews and picture stay with the trashed item until it is purged
+	now := time.Now().UTC()
+	expiresAt := now.Add(trashRetention)
+	current.DeletedAt = &now
+	current.ExpiresAt = &expiresAt
+
+	err = s.db.TrashLibraryItem(current)
 	if err != nil {
 		return err
 	}
 
+	s.releaseTags(current)
+
 	// Decrement collection item count if item was in a collection
 	if current.CollectionId != nil && *current.CollectionId != "" {
 		err = s.db.IncrementCollectionItemCount(i.OwnerId, i.LibraryId, *current.CollectionId, -1)
 		if err != nil {
-			// Log but don't fail - eventual consistency via stream will fix this
+			// Log but don't fail - the count may drift until check-consistency reports it
 			log.Warn().Str("collectionId", *current.CollectionId).Msgf("Failed to decrement collection item count: %s", err.Error())
 		}
 	}
 
-	err = s.storage.DeletePicture(i.OwnerId, i.LibraryId, i.Id)
-	if err != nil {
-		return err
-	}
 	return nil
 }
 
-func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
-	library, err := s.db.GetLibrary(i.OwnerId, i.LibraryId)
+func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool, actor domain.Actor) error {
+	// Editors of a shared library act on the items of its owner
+	ownerId, err := s.ResolveLibraryOwner(i.OwnerId, i.LibraryId, domain.ShareEditor)
 	if err != nil {
 		return err
 	}
+	i.OwnerId = ownerId
 
-	if library.OwnerId != i.OwnerId {
-		msg := fmt.Sprintf("Library %s does not belong to user", i.LibraryId)
-		log.Error().Msg(msg)
-		return errors.New(msg)
+	library, err := s.db.GetLibrary(i.OwnerId, i.LibraryId)
+	if err != nil {
+		return err
 	}
 
 	// Get current item to track collection changes
@@ -134,6 +471,12 @@ func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
 	if err != nil {
 		return err
 	}
+	keepOmittedFields(currentItem, i)
+
+	err = checkUpdatedCustomValues(library, currentItem, i)
+	if err != nil {
+		return err
+	}
 
 	// Picture fetch is best-effort - don't fail item update if it fails
 	if fetchPic && i.PictureUrl != nil && *i.PictureUrl != "" {
@@ -163,6 +506,11 @@ func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
 		}
 		i.CollectionName = &collection.Name
 
+		// Series are ordered by volume number
+		if i.Order == nil && collection.IsSeries && i.Volume != nil {
+			i.Order = i.Volume
+		}
+
 		// Auto-calculate order if not provided and item is being added/moved to a collection
 		oldCollectionId := ""
 		if currentItem.CollectionId != nil {
@@ -183,6 +531,24 @@ func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
 		i.CollectionName = nil
 	}
 
+	err = s.resolveItemLocation(i)
+	if err != nil {
+		return err
+	}
+
+	i.Copies, err = mergeItemCopies(currentItem, i.Copies)
+	if err != nil {
+		return err
+	}
+
+	var tagIndex map[string]domain.Tag
+	if len(i.Tags) > 0 || len(currentItem.Tags) > 0 {
+		i.Tags, tagIndex, err = s.resolveTags(i.OwnerId, i.LibraryId, i.Tags)
+		if err != nil {
+			return err
+		}
+	}
+
 	current := time.Now().UTC()
 	i.UpdatedAt = &current
 
@@ -191,6 +557,16 @@ func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
 		return err
 	}
 
+	if e := newChangeEntry(actor, &current, diffItem(currentItem, i)); e != nil {
+		err = s.db.PutItemChanges(i.OwnerId, i.LibraryId, map[string]*domain.ChangeEntry{i.Id: e})
+		if err != nil {
+			// Log but don't fail - the item is already updated
+			log.Warn().Str("id", i.Id).Msgf("Failed to record item changes: %s", err.Error())
+		}
+	}
+
+	s.updateTagCounts(i.OwnerId, i.LibraryId, tagIndex, currentItem.Tags, i.Tags)
+
 	// Handle collection item count changes
 	oldCollectionId := ""
 	newCollectionId := ""
@@ -221,6 +597,13 @@ func (s *services) UpdateItem(i *domain.LibraryItem, fetchPic bool) error {
 }
 
 func (s *services) CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error) {
+	// Editors of a shared library add items to its owner
+	ownerId, err := s.ResolveLibraryOwner(i.OwnerId, i.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	shared := ownerId != i.OwnerId
+	i.OwnerId = ownerId
 	i.Id = identifier.NewId()
 
 	// Picture fetch is best-effort - don't fail item creation if it fails
@@ -242,6 +625,27 @@ func (s *services) CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error
 	}
 
 	i.LibraryName = library.Name
+	if shared {
+		i.OwnerName = library.OwnerName
+	}
+	i.Wanted = library.Kind == domain.WishlistLibrary
+
+	err = checkCustomValues(library, i)
+	if err != nil {
+		return nil, err
+	}
+
+	// Suggest the series collection matching the series detected from the book metadata
+	if (i.CollectionId == nil || *i.CollectionId == "") && i.SeriesName != nil && *i.SeriesName != "" {
+		series, err := s.db.GetCollectionByName(i.OwnerId, i.LibraryId, *i.SeriesName)
+		if err != nil {
+			return nil, err
+		}
+		if series != nil && series.IsSeries {
+			log.Debug().Str("collectionId", series.Id).Str("series", *i.SeriesName).Msg("Matched series collection for new item")
+			i.CollectionId = &series.Id
+		}
+	}
 
 	// If collectionId is provided, look up the collection to get the name
 	if i.CollectionId != nil && *i.CollectionId != "" {
@@ -256,6 +660,11 @@ func (s *services) CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error
 		}
 		i.CollectionName = &collection.Name
 
+		// Series are ordered by volume number
+		if i.Order == nil && collection.IsSeries && i.Volume != nil {
+			i.Order = i.Volume
+		}
+
 		// Auto-calculate order if not provided
 		if i.Order == nil {
 			maxOrder, err := s.db.GetMaxOrderInCollection(i.OwnerId, i.LibraryId, *i.CollectionId)
@@ -268,6 +677,25 @@ func (s *services) CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error
 		}
 	}
 
+	err = s.resolveItemLocation(i)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new item has no copy yet
+	i.Copies, err = mergeItemCopies(&domain.LibraryItem{Id: i.Id}, i.Copies)
+	if err != nil {
+		return nil, err
+	}
+
+	var tagIndex map[string]domain.Tag
+	if len(i.Tags) > 0 {
+		i.Tags, tagIndex, err = s.resolveTags(i.OwnerId, i.LibraryId, i.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	current := time.Now().UTC()
 	i.UpdatedAt = &current
 
@@ -276,11 +704,13 @@ func (s *services) CreateItem(i *domain.LibraryItem) (*domain.LibraryItem, error
 		return nil, err
 	}
 
+	s.updateTagCounts(i.OwnerId, i.LibraryId, tagIndex, nil, i.Tags)
+
 	// Increment collection item count if item is in a collection
 	if i.CollectionId != nil && *i.CollectionId != "" {
 		err = s.db.IncrementCollectionItemCount(i.OwnerId, i.LibraryId, *i.CollectionId, 1)
 		if err != nil {
-			// Log but don't fail - eventual consistency via stream will fix this
+			// Log but don't fail - the count may drift until check-consistency reports it
 			log.Warn().Str("collectionId", *i.CollectionId).Msgf("Failed to increment collection item count: %s", err.Error())
 		}
 	}
@@ -309,6 +739,9 @@ func (s *services) ListItemsByLibrary(ownerId string, libraryId string, continua
 		return nil, err
 	}
 
+	s.fillMyRatings(ownerId, content.Items)
+	s.fillMyStatuses(ownerId, content.Items)
+
 	// Pictures are now served via CloudFront URLs - no need to load bytes from S3
 
 	// On first page (no continuation token), include collections as items with type = ItemCollection
@@ -362,6 +795,9 @@ func (s *services) ListItemsByLibraryGrouped(ownerId string, libraryId string, c
 		return nil, err
 	}
 
+	s.fillMyRatings(ownerId, content.Items)
+	s.fillMyStatuses(ownerId, content.Items)
+
 	// Pictures are now served via CloudFront URLs - no need to load bytes from S3
 
 	return content, nil
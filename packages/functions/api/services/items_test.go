@@ -0,0 +1,60 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+func TestUpdateItemKeepsOmittedFields(t *testing.T) {
+	alice := "Alice"
+	paperback := domain.Paperback
+	price := 12.5
+	current := domain.LibraryItem{
+		Id:          "item",
+		Title:       "Dune",
+		Acquisition: &domain.Acquisition{Price: &price, Currency: "EUR"},
+		Copies: []domain.ItemCopy{
+			{Id: "lent", Format: &paperback, LentTo: &alice},
+			{Id: "other"},
+		},
+	}
+	db := newItemsDatabase(current)
+	s := NewServices(db, nil, nil, nil)
+
+	// A client unaware of the copies and acquisition leaves them out of the update
+	err := s.UpdateItem(&domain.LibraryItem{Id: "item", OwnerId: "owner", LibraryId: "library", Title: "Dune Messiah"}, false, domain.Actor{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	updated := db.items["item"]
+	if updated.Title != "Dune Messiah" {
+		t.Errorf("expected the title updated, got %s", updated.Title)
+	}
+	if !reflect.DeepEqual(updated.Copies, current.Copies) {
+		t.Errorf("expected copies %v kept, got %v", current.Copies, updated.Copies)
+	}
+	if !reflect.DeepEqual(updated.Acquisition, current.Acquisition) {
+		t.Errorf("expected acquisition %v kept, got %v", current.Acquisition, updated.Acquisition)
+	}
+	if changes := renderChanges(db.itemChanges["item"]); !reflect.DeepEqual(changes, []string{"title: Dune -> Dune Messiah"}) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	// An empty list removes the copies, but not the lent ones
+	err = s.UpdateItem(&domain.LibraryItem{Id: "item", OwnerId: "owner", LibraryId: "library", Title: "Dune", Copies: []domain.ItemCopy{}}, false, domain.Actor{})
+	if err == nil || err.Error() != "cannot remove a lent copy" {
+		t.Errorf("expected the lent copy to be kept, got %v", err)
+	}
+
+	// An empty acquisition removes the purchase details
+	err = s.UpdateItem(&domain.LibraryItem{Id: "item", OwnerId: "owner", LibraryId: "library", Title: "Dune", Acquisition: &domain.Acquisition{}}, false, domain.Actor{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if a := db.items["item"].Acquisition; a != nil {
+		t.Errorf("expected no acquisition, got %v", a)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"time"
 
@@ -23,30 +24,61 @@ func (s *services) DeleteLibrary(l *domain.Library) error {
 		return errors.New(msg)
 	}
 
-	err = s.db.DeleteLibrary(l)
-
+	// Loans of a trashed library would still be listed and reminded
+	lent, err := s.db.QueryLentItems(l.OwnerId)
 	if err != nil {
 		return err
 	}
+	for _, i := range lent {
+		if i.LibraryId == l.Id && i.IsLent() {
+			msg := "library has items not returned"
+			log.Error().Str("id", l.Id).Msg(msg)
+			return errors.New(msg)
+		}
+	}
+
+	// Items, collections and pictures stay with the trashed library until it is purged
+	now := time.Now().UTC()
+	expiresAt := now.Add(trashRetention)
+	libraryToDelete.DeletedAt = &now
+	libraryToDelete.ExpiresAt = &expiresAt
 
-	err = s.storage.DeletePictures(l.OwnerId, l.Id)
+	err = s.db.TrashLibrary(libraryToDelete)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (s *services) UpdateLibrary(l *domain.Library) error {
+func (s *services) UpdateLibrary(l *domain.Library, actor domain.Actor) error {
+	previous, err := s.db.GetLibrary(l.OwnerId, l.Id)
+	if err != nil {
+		return err
+	}
+
+	// Custom fields are kept when not part of the update
+	if l.CustomFields == nil {
+		l.CustomFields = previous.CustomFields
+	}
 
 	current := time.Now().UTC()
 	l.UpdatedAt = &current
 
-	err := s.db.UpdateLibrary(l)
+	err = s.db.UpdateLibrary(l)
 
 	if err != nil {
 		return err
 	}
 
+	if e := newChangeEntry(actor, &current, diffLibrary(previous, l)); e != nil {
+		err = s.db.PutLibraryChange(l, e)
+		if err != nil {
+			// Log but don't fail - the library is already updated
+			log.Warn().Str("id", l.Id).Msgf("Failed to record library changes: %s", err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -63,6 +95,9 @@ func (s *services) CreateLibrary(l *domain.Library) (*domain.Library, error) {
 
 	l.Id = identifier.NewId()
 	l.UpdatedAt = &current
+	if l.Kind == "" {
+		l.Kind = domain.OwnedLibrary
+	}
 
 	err := s.db.PutLibrary(l)
 	if err != nil {
@@ -103,9 +138,11 @@ func (s *services) UnshareLibrary(sh *domain.UnshareLibrary) error {
 
 	// Compute the new sharedTo list by filtering out removed users
 	sh.NewSharedToList = make([]string, 0)
+	sh.NewShareRoles = map[string]domain.ShareRole{}
 	for _, userName := range library.SharedTo {
 		if !toRemove[userName] {
 			sh.NewSharedToList = append(sh.NewSharedToList, userName)
+			sh.NewShareRoles[userName] = library.ShareRoles[userName]
 		}
 	}
 
@@ -116,6 +153,7 @@ func (s *services) UnshareLibrary(sh *domain.UnshareLibrary) error {
 	return nil
 }
 
+// ShareLibrary shares a library to a user with a role. Sharing it again to the user changes the role.
 func (s *services) ShareLibrary(sh *domain.ShareLibrary) error {
 	userIdTo, err := s.idp.GetUserIdFromUserName(sh.SharedToUserName)
 	if err != nil {
@@ -127,18 +165,29 @@ func (s *services) ShareLibrary(sh *domain.ShareLibrary) error {
 		return err
 	}
 
-	if libraryToShare.SharedTo != nil {
-		i := slices.Index(libraryToShare.SharedTo, sh.SharedToUserName)
-		if i != -1 {
-			msg := fmt.Sprintf("Library %s already shared with %s", sh.LibraryId, sh.SharedToUserName)
-			log.Error().Msg(msg)
-			return errors.New(msg)
-		}
+	if sh.Role == "" {
+		sh.Role = domain.ShareViewer
+	}
+
+	shared := slices.Contains(libraryToShare.SharedTo, sh.SharedToUserName)
+	if shared && libraryToShare.ShareRoles[sh.SharedToUserName] == sh.Role {
+		msg := fmt.Sprintf("Library %s already shared with %s", sh.LibraryId, sh.SharedToUserName)
+		log.Error().Msg(msg)
+		return errors.New(msg)
 	}
 
 	current := time.Now().UTC()
 	sh.SharedToUserId = userIdTo
 	sh.UpdatedAt = &current
+	sh.NewShareRoles = maps.Clone(libraryToShare.ShareRoles)
+	if sh.NewShareRoles == nil {
+		sh.NewShareRoles = map[string]domain.ShareRole{}
+	}
+	sh.NewShareRoles[sh.SharedToUserName] = sh.Role
+
+	if shared {
+		return s.db.UpdateLibraryShareRole(sh)
+	}
 
 	err = s.db.ShareLibrary(sh)
 	if err != nil {
@@ -146,3 +195,47 @@ func (s *services) ShareLibrary(sh *domain.ShareLibrary) error {
 	}
 	return nil
 }
+
+// ResolveLibraryOwner returns the owner of the library the user acts on: the user for its own libraries, the owner of
+// a library shared to the user with at least the role. Other libraries are left to the lookups of the caller, which
+// do not find them under the user.
+func (s *services) ResolveLibraryOwner(userId string, libraryId string, role domain.ShareRole) (string, error) {
+	share, err := s.db.GetLibraryShare(userId, libraryId)
+	if err != nil {
+		return "", err
+	}
+
+	if share == nil {
+		return userId, nil
+	}
+
+	if !share.Role.Allows(role) {
+		msg := fmt.Sprintf("share role %s does not allow this action, %s required", share.Role, role)
+		log.Error().Str("libraryId", libraryId).Str("userId", userId).Msg(msg)
+		return "", errors.New(msg)
+	}
+
+	return share.OwnerId, nil
+}
+
+// resolveLibrariesOwner returns the owner of the source and target libraries of a move, which the user must edit.
+// Items only move between the libraries of their owner.
+func (s *services) resolveLibrariesOwner(userId string, libraryId string, targetLibraryId string) (string, error) {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareEditor)
+	if err != nil {
+		return "", err
+	}
+
+	targetOwnerId, err := s.ResolveLibraryOwner(userId, targetLibraryId, domain.ShareEditor)
+	if err != nil {
+		return "", err
+	}
+
+	if targetOwnerId != ownerId {
+		msg := "target library belongs to another user"
+		log.Error().Str("libraryId", targetLibraryId).Msg(msg)
+		return "", errors.New(msg)
+	}
+
+	return ownerId, nil
+}
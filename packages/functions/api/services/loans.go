@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+const maxCollectionLendingEvents = 33
+
+// ListOverdueLoans returns the loans whose due date has passed, a loan due today is not overdue yet
+func (s *services) ListOverdueLoans(ownerId string) ([]domain.Loan, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	items, err := s.db.QueryOverdueItems(ownerId, today)
+	if err != nil {
+		return nil, err
+	}
+
+	loans := []domain.Loan{}
+	for _, i := range items {
+		loans = append(loans, i.OverdueLoans(today)...)
+	}
+
+	sort.SliceStable(loans, func(a, b int) bool {
+		return loans[a].DueDate.Before(*loans[b].DueDate)
+	})
+
+	return loans, nil
+}
+
+// LendCollection lends the items of a collection to a contact in one transaction, with one history entry per item.
+// Items having copies lend their first available copy. Items already lent are skipped and reported.
+func (s *services) LendCollection(userId string, libraryId string, collectionId string, contactId string, dueDate *time.Time) (*domain.CollectionLending, error) {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareLender)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.getCollectionItems(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	contact, err := s.GetContact(ownerId, contactId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result := &domain.CollectionLending{Loans: []domain.Loan{}, Skipped: []domain.Loan{}}
+	events := []domain.LendingEvent{}
+	for _, item := range items {
+		if item.Wanted {
+			msg := "cannot lend a wishlist item"
+			log.Error().Str("id", item.Id).Msg(msg)
+			return nil, errors.New(msg)
+		}
+
+		copyId := ""
+		if len(item.Copies) > 0 {
+			for idx := range item.Copies {
+				if item.Copies[idx].LentTo == nil {
+					copyId = item.Copies[idx].Id
+					break
+				}
+			}
+			if copyId == "" {
+				result.Skipped = append(result.Skipped, item.CurrentLoans()...)
+				continue
+			}
+		} else if item.LentTo != nil {
+			result.Skipped = append(result.Skipped, item.CurrentLoans()...)
+			continue
+		}
+
+		loan := &domain.ContactLoan{
+			ContactId:   contact.Id,
+			OwnerId:     ownerId,
+			OwnerName:   item.OwnerName,
+			BorrowerId:  contact.UserId,
+			LibraryId:   item.LibraryId,
+			LibraryName: item.LibraryName,
+			ItemId:      item.Id,
+			ItemType:    item.Type,
+			Title:       item.Title,
+			LentAt:      &now,
+			DueDate:     dueDate,
+		}
+
+		// Same lending state as LendItem
+		if copyId != "" {
+			idx := item.FindCopy(copyId)
+			item.Copies[idx].LentTo = &contact.Name
+			item.Copies[idx].LentToId = &contact.Id
+			item.Copies[idx].DueDate = dueDate
+			loan.CopyId = &item.Copies[idx].Id
+		} else {
+			item.LentTo = &contact.Name
+			item.LentToId = &contact.Id
+			item.DueDate = dueDate
+		}
+
+		events = append(events, domain.LendingEvent{Item: item, CopyId: copyId, Type: domain.Lent, Person: contact.Name, Loan: loan})
+		result.Loans = append(result.Loans, domain.Loan{Item: item, CopyId: loan.CopyId, LentTo: contact.Name, DueDate: dueDate})
+	}
+
+	if len(events) == 0 {
+		msg := "all the items of the collection are already lent"
+		log.Error().Str("collectionId", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	err = s.putCollectionLendingEvents(collectionId, events, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReturnCollection returns the items of a collection lent to a contact, or all its lent items when contactId is empty,
+// in one transaction with one history entry per item. Items not lent, or lent to someone else, are skipped and reported.
+// One copy per item is returned at once: the other lent copies of an item are reported and returned on their own.
+func (s *services) ReturnCollection(userId string, libraryId string, collectionId string, contactId string) (*domain.CollectionLending, error) {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareLender)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.getCollectionItems(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	result := &domain.CollectionLending{Loans: []domain.Loan{}, Skipped: []domain.Loan{}}
+	events := []domain.LendingEvent{}
+	for _, item := range items {
+		if !item.IsLent() {
+			result.Skipped = append(result.Skipped, domain.Loan{Item: item})
+			continue
+		}
+
+		returned := false
+		for _, current := range item.CurrentLoans() {
+			lentToId := item.LentToId
+			if current.CopyId != nil {
+				lentToId = item.Copies[item.FindCopy(*current.CopyId)].LentToId
+			}
+			if returned || (contactId != "" && (lentToId == nil || *lentToId != contactId)) {
+				result.Skipped = append(result.Skipped, current)
+				continue
+			}
+
+			loan, err := s.returnContactLoan(ownerId, lentToId, item.Id, current.CopyId, &now)
+			if err != nil {
+				return nil, err
+			}
+
+			// Same lending state as ReturnItem
+			copyId := ""
+			if current.CopyId != nil {
+				copyId = *current.CopyId
+				idx := item.FindCopy(copyId)
+				item.Copies[idx].LentTo = nil
+				item.Copies[idx].LentToId = nil
+				item.Copies[idx].DueDate = nil
+			} else {
+				item.LentTo = nil
+				item.LentToId = nil
+				item.DueDate = nil
+			}
+
+			events = append(events, domain.LendingEvent{Item: item, CopyId: copyId, Type: domain.Returned, Person: current.LentTo, Loan: loan})
+			result.Loans = append(result.Loans, current)
+			returned = true
+		}
+	}
+
+	if len(events) == 0 {
+		msg := "no lent item in the collection"
+		log.Error().Str("collectionId", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	err = s.putCollectionLendingEvents(collectionId, events, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getCollectionItems returns the items of an existing collection, in collection order
+func (s *services) getCollectionItems(ownerId string, libraryId string, collectionId string) ([]*domain.LibraryItem, error) {
+	collection, err := s.db.GetCollection(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		msg := "collection not found"
+		log.Error().Str("id", collectionId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	items, err := s.db.QueryCollectionItems(ownerId, libraryId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(a, b int) bool {
+		if items[a].Order == nil || items[b].Order == nil {
+			return items[b].Order == nil && items[a].Order != nil
+		}
+		return *items[a].Order < *items[b].Order
+	})
+
+	return items, nil
+}
+
+// putCollectionLendingEvents records the events of a collection in one transaction,
+// each event takes 3 of the 100 writes of a transaction (event, item and contact loan)
+func (s *services) putCollectionLendingEvents(collectionId string, events []domain.LendingEvent, date *time.Time) error {
+	if len(events) > maxCollectionLendingEvents {
+		msg := fmt.Sprintf("too many items to lend or return at once (max. %d)", maxCollectionLendingEvents)
+		log.Error().Str("collectionId", collectionId).Int("items", len(events)).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.PutLendingEvents(events, date)
+}
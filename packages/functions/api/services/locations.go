@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// ListLocations returns the locations of an owned or shared library with their full path, sorted by path
+func (s *services) ListLocations(userId string, libraryId string) ([]domain.Location, error) {
+	// Find if it is a shared library to the current requester
+	sharedLibraryOwnerId, err := s.db.GetSharedLibrary(userId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryOwnerId := userId
+	if sharedLibraryOwnerId != "" {
+		libraryOwnerId = sharedLibraryOwnerId
+	}
+
+	locations, err := s.db.QueryLocationsByLibrary(libraryOwnerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := domain.BuildLocationPaths(locations)
+	for idx := range locations {
+		locations[idx].Path = paths[locations[idx].Id]
+	}
+
+	sort.Slice(locations, func(a, b int) bool {
+		return strings.ToLower(locations[a].Path) < strings.ToLower(locations[b].Path)
+	})
+
+	return locations, nil
+}
+
+// CreateLocation creates a room, or a bookcase in a room, or a shelf in a bookcase
+// Enforces unique names among the locations sharing the same parent
+func (s *services) CreateLocation(l *domain.Location) (*domain.Location, error) {
+	ownerId, err := s.ResolveLibraryOwner(l.OwnerId, l.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+	l.OwnerId = ownerId
+
+	locations, err := s.db.QueryLocationsByLibrary(l.OwnerId, l.LibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	parentKind := l.Kind.ParentKind()
+	if parentKind == "" && l.ParentId != nil {
+		msg := "a room cannot be nested into another location"
+		log.Error().Str("name", l.Name).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	if parentKind != "" {
+		if l.ParentId == nil {
+			msg := "parent location is mandatory"
+			log.Error().Str("name", l.Name).Msg(msg)
+			return nil, errors.New(msg)
+		}
+
+		idx := findLocation(locations, *l.ParentId)
+		if idx == -1 {
+			msg := "parent location not found"
+			log.Error().Str("parentId", *l.ParentId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+		if locations[idx].Kind != parentKind {
+			msg := "invalid parent location kind"
+			log.Error().Str("parentId", *l.ParentId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	if hasSiblingNamed(locations, l) {
+		msg := "location with this name already exists"
+		log.Error().Str("name", l.Name).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	l.Id = identifier.NewId()
+	l.UpdatedAt = &current
+
+	err = s.db.PutLocation(l)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Path = domain.BuildLocationPaths(append(locations, *l))[l.Id]
+
+	return l, nil
+}
+
+// RenameLocation renames a location, the consistency manager updates the path of the items stored in it
+func (s *services) RenameLocation(l *domain.Location) error {
+	ownerId, err := s.ResolveLibraryOwner(l.OwnerId, l.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	l.OwnerId = ownerId
+
+	locations, err := s.db.QueryLocationsByLibrary(l.OwnerId, l.LibraryId)
+	if err != nil {
+		return err
+	}
+
+	idx := findLocation(locations, l.Id)
+	if idx == -1 {
+		msg := "location not found"
+		log.Error().Str("locationId", l.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	l.ParentId = locations[idx].ParentId
+	if hasSiblingNamed(locations, l) {
+		msg := "location with this name already exists"
+		log.Error().Str("name", l.Name).Msg(msg)
+		return errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	l.UpdatedAt = &current
+
+	return s.db.UpdateLocation(l)
+}
+
+// DeleteLocation removes an empty location (without nested locations)
+// Items stored in it are cleared by the consistency manager
+func (s *services) DeleteLocation(l *domain.Location) error {
+	ownerId, err := s.ResolveLibraryOwner(l.OwnerId, l.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	l.OwnerId = ownerId
+
+	locations, err := s.db.QueryLocationsByLibrary(l.OwnerId, l.LibraryId)
+	if err != nil {
+		return err
+	}
+
+	if findLocation(locations, l.Id) == -1 {
+		msg := "location not found"
+		log.Error().Str("locationId", l.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	for _, other := range locations {
+		if other.ParentId != nil && *other.ParentId == l.Id {
+			msg := "location has nested locations"
+			log.Error().Str("locationId", l.Id).Msg(msg)
+			return errors.New(msg)
+		}
+	}
+
+	return s.db.DeleteLocation(l)
+}
+
+// MoveItemsToLocation stores items of an owned (or edited) library into a location, or removes them from any location when locationId is nil
+func (s *services) MoveItemsToLocation(userId string, libraryId string, itemIds []string, locationId *string) error {
+	ownerId, err := s.ResolveLibraryOwner(userId, libraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+
+	var location *domain.Location
+	if locationId != nil {
+		locations, err := s.db.QueryLocationsByLibrary(ownerId, libraryId)
+		if err != nil {
+			return err
+		}
+
+		idx := findLocation(locations, *locationId)
+		if idx == -1 {
+			msg := "location not found"
+			log.Error().Str("locationId", *locationId).Msg(msg)
+			return errors.New(msg)
+		}
+
+		location = &locations[idx]
+		location.Path = domain.BuildLocationPaths(locations)[location.Id]
+	}
+
+	// The items are updated in transactions, which fail on a key appearing twice
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, itemId := range itemIds {
+		if !seen[itemId] {
+			seen[itemId] = true
+			unique = append(unique, itemId)
+		}
+	}
+
+	return s.db.UpdateItemsLocation(ownerId, libraryId, unique, location)
+}
+
+// resolveItemLocation checks the item location exists and sets its denormalized path
+func (s *services) resolveItemLocation(i *domain.LibraryItem) error {
+	if i.LocationId == nil || *i.LocationId == "" {
+		i.LocationId = nil
+		i.LocationPath = nil
+		return nil
+	}
+
+	locations, err := s.db.QueryLocationsByLibrary(i.OwnerId, i.LibraryId)
+	if err != nil {
+		return err
+	}
+
+	if findLocation(locations, *i.LocationId) == -1 {
+		msg := "location not found"
+		log.Error().Str("locationId", *i.LocationId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	path := domain.BuildLocationPaths(locations)[*i.LocationId]
+	i.LocationPath = &path
+
+	return nil
+}
+
+// expandLocations returns the given locations with all their nested locations (a room contains its bookcases and shelves)
+func expandLocations(locations []domain.Location, locationIds []string) []string {
+	expanded := append([]string{}, locationIds...)
+	for idx := 0; idx < len(expanded); idx++ {
+		for _, l := range locations {
+			if l.ParentId != nil && *l.ParentId == expanded[idx] {
+				expanded = append(expanded, l.Id)
+			}
+		}
+	}
+	return expanded
+}
+
+func findLocation(locations []domain.Location, locationId string) int {
+	for idx, l := range locations {
+		if l.Id == locationId {
+			return idx
+		}
+	}
+	return -1
+}
+
+// hasSiblingNamed reports whether another location with the same parent has the same name (case-insensitive)
+func hasSiblingNamed(locations []domain.Location, l *domain.Location) bool {
+	for _, other := range locations {
+		if other.Id == l.Id || !strings.EqualFold(other.Name, l.Name) {
+			continue
+		}
+		if (other.ParentId == nil && l.ParentId == nil) ||
+			(other.ParentId != nil && l.ParentId != nil && *other.ParentId == *l.ParentId) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+// locationsDatabase holds the locations of a library, the other methods of the database are not expected to be called
+type locationsDatabase struct {
+	ports.Database
+	ownerId   string
+	shares    map[string]domain.ShareRole
+	locations []domain.Location
+	created   []domain.Location
+	located   []string
+}
+
+func (d *locationsDatabase) GetSharedLibrary(userId string, libraryId string) (string, error) {
+	if userId == d.ownerId {
+		return "", nil
+	}
+	return d.ownerId, nil
+}
+
+func (d *locationsDatabase) GetLibraryShare(userId string, libraryId string) (*domain.LibraryShare, error) {
+	role, ok := d.shares[userId]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.LibraryShare{LibraryId: libraryId, OwnerId: d.ownerId, UserId: userId, Role: role}, nil
+}
+
+func (d *locationsDatabase) QueryLocationsByLibrary(ownerId string, libraryId string) ([]domain.Location, error) {
+	if ownerId != d.ownerId {
+		return []domain.Location{}, nil
+	}
+	return append([]domain.Location{}, d.locations...), nil
+}
+
+func (d *locationsDatabase) PutLocation(l *domain.Location) error {
+	d.created = append(d.created, *l)
+	return nil
+}
+
+func (d *locationsDatabase) UpdateItemsLocation(ownerId string, libraryId string, itemIds []string, l *domain.Location) error {
+	d.located = itemIds
+	return nil
+}
+
+func newLocationsDatabase() *locationsDatabase {
+	ptr := func(s string) *string { return &s }
+	return &locationsDatabase{
+		ownerId: "owner",
+		shares:  map[string]domain.ShareRole{"editor": domain.ShareEditor, "viewer": domain.ShareViewer},
+		locations: []domain.Location{
+			{Id: "shelf", Name: "Shelf 2", Kind: domain.Shelf, ParentId: ptr("bookcase")},
+			{Id: "office", Name: "office", Kind: domain.Room},
+			{Id: "bookcase", Name: "Bookcase A", Kind: domain.Bookcase, ParentId: ptr("room")},
+			{Id: "room", Name: "Living room", Kind: domain.Room},
+		},
+	}
+}
+
+func TestListLocations(t *testing.T) {
+	db := newLocationsDatabase()
+	s := NewServices(db, nil, nil, nil)
+
+	// The locations of a shared library are the ones of its owner
+	for _, userId := range []string{"owner", "sharee"} {
+		locations, err := s.ListLocations(userId, "library")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+
+		// Sorted by path, case-insensitively
+		expected := []string{"Living room", "Living room / Bookcase A", "Living room / Bookcase A / Shelf 2", "office"}
+		if len(locations) != len(expected) {
+			t.Fatalf("expected %d locations, got %d", len(expected), len(locations))
+		}
+		for idx, path := range expected {
+			if locations[idx].Path != path {
+				t.Errorf("location %d of %s: expected %q, got %q", idx, userId, path, locations[idx].Path)
+			}
+		}
+	}
+}
+
+func TestCreateLocation(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		location domain.Location
+		path     string
+		err      string
+	}{
+		"room":                      {location: domain.Location{Name: "Attic", Kind: domain.Room}, path: "Attic"},
+		"shelf in a bookcase":       {location: domain.Location{Name: "Shelf 3", Kind: domain.Shelf, ParentId: ptr("bookcase")}, path: "Living room / Bookcase A / Shelf 3"},
+		"same name in another room": {location: domain.Location{Name: "Bookcase A", Kind: domain.Bookcase, ParentId: ptr("office")}, path: "office / Bookcase A"},
+		"nested room":               {location: domain.Location{Name: "Attic", Kind: domain.Room, ParentId: ptr("room")}, err: "a room cannot be nested into another location"},
+		"bookcase without room":     {location: domain.Location{Name: "Bookcase B", Kind: domain.Bookcase}, err: "parent location is mandatory"},
+		"unknown parent":            {location: domain.Location{Name: "Bookcase B", Kind: domain.Bookcase, ParentId: ptr("garage")}, err: "parent location not found"},
+		"shelf in a room":           {location: domain.Location{Name: "Shelf 3", Kind: domain.Shelf, ParentId: ptr("room")}, err: "invalid parent location kind"},
+		"duplicate sibling name":    {location: domain.Location{Name: "bookcase a", Kind: domain.Bookcase, ParentId: ptr("room")}, err: "location with this name already exists"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := newLocationsDatabase()
+			s := NewServices(db, nil, nil, nil)
+
+			l := tt.location
+			l.OwnerId = "owner"
+			l.LibraryId = "library"
+			created, err := s.CreateLocation(&l)
+
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				if len(db.created) != 0 {
+					t.Errorf("expected no location written, got %v", db.created)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if created.Id == "" || created.Path != tt.path {
+				t.Errorf("expected an id and path %q, got %q (%q)", tt.path, created.Path, created.Id)
+			}
+			if len(db.created) != 1 {
+				t.Errorf("expected the location written, got %v", db.created)
+			}
+		})
+	}
+}
+
+func TestCreateLocationInSharedLibrary(t *testing.T) {
+	db := newLocationsDatabase()
+	s := NewServices(db, nil, nil, nil)
+
+	// Editors create the locations of the owner
+	created, err := s.CreateLocation(&domain.Location{OwnerId: "editor", LibraryId: "library", Name: "Attic", Kind: domain.Room})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if created.OwnerId != "owner" {
+		t.Errorf("expected the location created for the owner, got %s", created.OwnerId)
+	}
+
+	_, err = s.CreateLocation(&domain.Location{OwnerId: "viewer", LibraryId: "library", Name: "Garage", Kind: domain.Room})
+	if err == nil || err.Error() != "share role VIEWER does not allow this action, EDITOR required" {
+		t.Errorf("expected viewers to be rejected, got %v", err)
+	}
+	if len(db.created) != 1 {
+		t.Errorf("expected one location written, got %d", len(db.created))
+	}
+}
+
+func TestMoveItemsToLocation(t *testing.T) {
+	db := newLocationsDatabase()
+	s := NewServices(db, nil, nil, nil)
+
+	locationId := "shelf"
+	err := s.MoveItemsToLocation("owner", "library", []string{"a", "b", "a", "c", "b"}, &locationId)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(db.located, expected) {
+		t.Errorf("expected items %v, got %v", expected, db.located)
+	}
+
+	locationId = "garage"
+	err = s.MoveItemsToLocation("owner", "library", []string{"a"}, &locationId)
+	if err == nil || err.Error() != "location not found" {
+		t.Errorf("expected an unknown location to be rejected, got %v", err)
+	}
+}
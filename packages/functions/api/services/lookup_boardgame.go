@@ -0,0 +1,62 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/api/services/resolvers"
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Overall timeout for board game resolution - return partial results if exceeded
+const boardGameResolverTimeout = 25 * time.Second
+
+var boardGameResolversRegistry []ports.BoardGameResolver
+
+// ResolveBoardGame searches for board games by title or EAN/UPC barcode using registered resolvers
+func (s *services) ResolveBoardGame(query string, isBarcode bool) []domain.ResolvedBoardGame {
+	result := []domain.ResolvedBoardGame{}
+
+	ch := make(chan []domain.ResolvedBoardGame, len(boardGameResolversRegistry))
+
+	for _, r := range boardGameResolversRegistry {
+		if isBarcode {
+			go r.ResolveByBarcode(query, ch)
+		} else {
+			go r.ResolveByTitle(query, ch)
+		}
+	}
+
+	// Collect results with timeout - return partial results if some resolvers hang
+	timeout := time.After(boardGameResolverTimeout)
+	received := 0
+	for received < len(boardGameResolversRegistry) {
+		select {
+		case resolvedGames := <-ch:
+			received++
+			if resolvedGames != nil {
+				result = append(result, resolvedGames...)
+			}
+		case <-timeout:
+			log.Warn().Int("received", received).Int("expected", len(boardGameResolversRegistry)).Msg("ResolveBoardGame: timeout, returning partial results")
+			goto done
+		}
+	}
+done:
+
+	// Sort by source name for consistent ordering
+	slices.SortFunc(result, func(a, b domain.ResolvedBoardGame) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	return result
+}
+
+func init() {
+	boardGameResolversRegistry = []ports.BoardGameResolver{
+		resolvers.NewBoardGameGeekResolver(),
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/api/services/resolvers"
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Overall timeout for music resolution - return partial results if exceeded
+const musicResolverTimeout = 25 * time.Second
+
+var musicResolversRegistry []ports.MusicResolver
+
+// ResolveMusic looks up music albums by EAN/UPC barcode using registered resolvers
+func (s *services) ResolveMusic(code string) []domain.ResolvedMusic {
+	result := []domain.ResolvedMusic{}
+
+	ch := make(chan []domain.ResolvedMusic, len(musicResolversRegistry))
+
+	for _, r := range musicResolversRegistry {
+		go r.Resolve(code, ch)
+	}
+
+	// Collect results with timeout - return partial results if some resolvers hang
+	timeout := time.After(musicResolverTimeout)
+	received := 0
+	for received < len(musicResolversRegistry) {
+		select {
+		case resolvedMusic := <-ch:
+			received++
+			if resolvedMusic != nil {
+				result = append(result, resolvedMusic...)
+			}
+		case <-timeout:
+			log.Warn().Int("received", received).Int("expected", len(musicResolversRegistry)).Msg("ResolveMusic: timeout, returning partial results")
+			goto done
+		}
+	}
+done:
+
+	// Sort by source name for consistent ordering
+	slices.SortFunc(result, func(a, b domain.ResolvedMusic) int {
+		return strings.Compare(a.Source, b.Source)
+	})
+
+	return result
+}
+
+func init() {
+	musicResolversRegistry = []ports.MusicResolver{
+		resolvers.NewMusicBrainzResolver(),
+	}
+}
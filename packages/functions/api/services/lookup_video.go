@@ -18,19 +18,31 @@ const videoResolverTimeout = 25 * time.Second
 var videoResolversRegistry []ports.VideoResolver
 
 // ResolveVideo searches for videos by title using registered resolvers
-func (s *services) ResolveVideo(title string) []domain.ResolvedVideo {
+// Movies and TV series are searched concurrently unless a kind is given
+func (s *services) ResolveVideo(title string, kind *domain.VideoKind) []domain.ResolvedVideo {
 	result := []domain.ResolvedVideo{}
 
-	ch := make(chan []domain.ResolvedVideo, len(videoResolversRegistry))
+	searchMovies := kind == nil || *kind == domain.Movie
+	searchTvSeries := kind == nil || *kind == domain.TvSeries
 
+	ch := make(chan []domain.ResolvedVideo, 2*len(videoResolversRegistry))
+
+	expected := 0
 	for _, r := range videoResolversRegistry {
-		go r.ResolveByTitle(title, ch)
+		if searchMovies {
+			go r.ResolveByTitle(title, ch)
+			expected++
+		}
+		if searchTvSeries {
+			go r.ResolveTvSeriesByTitle(title, ch)
+			expected++
+		}
 	}
 
 	// Collect results with timeout - return partial results if some resolvers hang
 	timeout := time.After(videoResolverTimeout)
 	received := 0
-	for received < len(videoResolversRegistry) {
+	for received < expected {
 		select {
 		case resolvedVideos := <-ch:
 			received++
@@ -38,12 +50,23 @@ func (s *services) ResolveVideo(title string) []domain.ResolvedVideo {
 				result = append(result, resolvedVideos...)
 			}
 		case <-timeout:
-			log.Warn().Int("received", received).Int("expected", len(videoResolversRegistry)).Msg("ResolveVideo: timeout, returning partial results")
+			log.Warn().Int("received", received).Int("expected", expected).Msg("ResolveVideo: timeout, returning partial results")
 			goto done
 		}
 	}
 done:
 
+	// A source may find movies but no TV series (or the opposite), only keep its "not found" entry if it found nothing
+	found := map[string]bool{}
+	for _, v := range result {
+		if v.Error == nil {
+			found[v.Source] = true
+		}
+	}
+	result = slices.DeleteFunc(result, func(v domain.ResolvedVideo) bool {
+		return v.Error != nil && found[v.Source]
+	})
+
 	// Sort by source name for consistent ordering
 	slices.SortFunc(result, func(a, b domain.ResolvedVideo) int {
 		return strings.Compare(a.Source, b.Source)
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// MoveItem moves an item to another library of its owner. The item keeps its id, history, reviews, copies and picture.
+// Collections, tags and locations belong to a library: the item joins the requested collection of the target library,
+// or the one having the name of its current collection, its tags are created there and its location is cleared.
+// The search index follows through the table stream (removal from the source library, insertion into the target).
+// Editors of shared libraries move items between the libraries of the same owner they edit.
+// Lent items and items with pending borrow requests cannot be moved.
+func (s *services) MoveItem(userId string, libraryId string, itemId string, targetLibraryId string, collectionId *string) (*domain.LibraryItem, error) {
+	if targetLibraryId == libraryId {
+		msg := "item already in target library"
+		log.Error().Str("id", itemId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	ownerId, err := s.resolveLibrariesOwner(userId, libraryId, targetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.db.GetLibraryItem(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Current loans and pending borrow requests keep referencing the item in its library
+	if item.IsLent() {
+		msg := "item is lent and not returned"
+		log.Error().Str("id", itemId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	requests, err := s.db.QueryReceivedBorrowRequests(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range requests {
+		if r.Status == domain.BorrowPending && r.LibraryId == libraryId && r.ItemId == itemId {
+			msg := "item has pending borrow requests"
+			log.Error().Str("id", itemId).Str("requestId", r.Id).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	target, err := s.db.GetLibrary(ownerId, targetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := *item
+	moved.LibraryId = target.Id
+	moved.LibraryName = target.Name
+	moved.Wanted = target.Kind == domain.WishlistLibrary
+	moved.CollectionId = nil
+	moved.CollectionName = nil
+	moved.Order = nil
+	moved.LocationId = nil
+	moved.LocationPath = nil
+	moved.CustomValues = keepCustomValues(target, item.CustomValues)
+
+	var collection *domain.Collection
+	if collectionId != nil && *collectionId != "" {
+		collection, err = s.db.GetCollection(ownerId, target.Id, *collectionId)
+		if err != nil {
+			return nil, err
+		}
+		if collection == nil {
+			msg := "collection not found"
+			log.Error().Str("collectionId", *collectionId).Msg(msg)
+			return nil, errors.New(msg)
+		}
+	} else if item.CollectionName != nil && *item.CollectionName != "" {
+		collection, err = s.db.GetCollectionByName(ownerId, target.Id, *item.CollectionName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if collection != nil {
+		moved.CollectionId = &collection.Id
+		moved.CollectionName = &collection.Name
+
+		// Series are ordered by volume number, other collections get the item last
+		if collection.IsSeries && moved.Volume != nil {
+			moved.Order = moved.Volume
+		} else {
+			maxOrder, err := s.db.GetMaxOrderInCollection(ownerId, target.Id, collection.Id)
+			if err != nil {
+				return nil, err
+			}
+			newOrder := maxOrder + 1
+			moved.Order = &newOrder
+		}
+	}
+
+	var tagIndex map[string]domain.Tag
+	if len(moved.Tags) > 0 {
+		moved.Tags, tagIndex, err = s.resolveTags(ownerId, target.Id, moved.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	current := time.Now().UTC()
+	moved.UpdatedAt = &current
+
+	// Picture is read before the move, its key depends on the library
+	picture, err := s.storage.GetPicture(ownerId, libraryId, itemId)
+	if err != nil {
+		log.Warn().Str("id", itemId).Err(err).Msg("Picture fetch failed, continuing without picture")
+	}
+
+	err = s.db.MoveLibraryItem(item, &moved)
+	if err != nil {
+		return nil, err
+	}
+
+	s.releaseTags(item)
+	s.updateTagCounts(ownerId, target.Id, tagIndex, nil, moved.Tags)
+
+	// Log but don't fail - the count may drift until check-consistency reports it
+	if item.CollectionId != nil && *item.CollectionId != "" {
+		err = s.db.IncrementCollectionItemCount(ownerId, libraryId, *item.CollectionId, -1)
+		if err != nil {
+			log.Warn().Str("collectionId", *item.CollectionId).Msgf("Failed to decrement collection item count: %s", err.Error())
+		}
+	}
+	if moved.CollectionId != nil {
+		err = s.db.IncrementCollectionItemCount(ownerId, target.Id, *moved.CollectionId, 1)
+		if err != nil {
+			log.Warn().Str("collectionId", *moved.CollectionId).Msgf("Failed to increment collection item count: %s", err.Error())
+		}
+	}
+
+	// Picture move is best-effort - the item is already moved
+	if picture != nil {
+		err = s.storage.PutPicture(ownerId, target.Id, itemId, picture)
+		if err != nil {
+			log.Warn().Str("id", itemId).Err(err).Msg("Picture upload failed, continuing without picture")
+		} else if err = s.storage.DeletePicture(ownerId, libraryId, itemId); err != nil {
+			log.Warn().Str("id", itemId).Err(err).Msg("Picture removal failed")
+		}
+	}
+	if err = s.storage.MoveReturnPictures(ownerId, libraryId, target.Id, itemId); err != nil {
+		log.Warn().Str("id", itemId).Err(err).Msg("Return pictures move failed")
+	}
+
+	return &moved, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+)
+
+// moveDatabase adds the borrow requests received by the owner to the items database
+type moveDatabase struct {
+	*itemsDatabase
+	requests []*domain.BorrowRequest
+}
+
+func (d *moveDatabase) QueryReceivedBorrowRequests(ownerId string) ([]*domain.BorrowRequest, error) {
+	return d.requests, nil
+}
+
+func TestMoveItemReferencedByLoans(t *testing.T) {
+	alice := "Alice"
+	db := &moveDatabase{
+		itemsDatabase: newItemsDatabase(
+			domain.LibraryItem{Id: "lent", Title: "Dune", Copies: []domain.ItemCopy{{Id: "copy", LentTo: &alice}}},
+			domain.LibraryItem{Id: "requested", Title: "Dune Messiah"},
+		),
+		requests: []*domain.BorrowRequest{
+			{Id: "declined", LibraryId: "library", ItemId: "lent", Status: domain.BorrowDeclined},
+			{Id: "pending", LibraryId: "library", ItemId: "requested", Status: domain.BorrowPending},
+		},
+	}
+	s := NewServices(db, nil, nil, nil)
+
+	expected := map[string]string{
+		"lent":      "item is lent and not returned",
+		"requested": "item has pending borrow requests",
+	}
+	for itemId, msg := range expected {
+		_, err := s.MoveItem("owner", "library", itemId, "target", nil)
+		if err == nil || err.Error() != msg {
+			t.Errorf("moving %s: expected error %q, got %v", itemId, msg, err)
+		}
+	}
+}
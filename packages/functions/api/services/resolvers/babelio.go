@@ -339,6 +339,21 @@ func (r *babelioResolver) Resolve(code string, ch chan []domain.ResolvedBook) {
 		resolvedBook.Title = strings.TrimSpace(e.ChildText("a"))
 	})
 
+	// Series link (format: /serie/Name/ID), the volume number is in the surrounding text ("Tome 3")
+	c.OnHTML("a[href*='/serie/']", func(e *colly.HTMLElement) {
+		if resolvedBook.SeriesName != nil {
+			return
+		}
+		seriesName := strings.TrimSpace(e.Text)
+		if seriesName == "" {
+			return
+		}
+		resolvedBook.SeriesName = &seriesName
+		if volume, ok := parseVolume(e.DOM.Parent().Text()); ok {
+			resolvedBook.Volume = &volume
+		}
+	})
+
 	err := c.Visit(isbnUrl)
 	if err != nil {
 		if isTimeout(err) {
@@ -365,6 +380,16 @@ func (r *babelioResolver) Resolve(code string, ch chan []domain.ResolvedBook) {
 		return
 	}
 
+	// Fallback: infer series and volume from the title
+	if resolvedBook.Volume == nil {
+		if name, volume, ok := parseSeries(resolvedBook.Title); ok {
+			if resolvedBook.SeriesName == nil {
+				resolvedBook.SeriesName = &name
+			}
+			resolvedBook.Volume = &volume
+		}
+	}
+
 	log.Debug().
 		Str("source", "Babelio").
 		Str("isbn", code).
@@ -0,0 +1,324 @@
+package resolvers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+// SSM parameter names for the BoardGameGeek access token and the GameUPC API key
+var (
+	bggAccessTokenParam = &ssmParameter{name: os.Getenv("BGG_ACCESS_TOKEN")}
+	gameUpcApiKeyParam  = &ssmParameter{name: os.Getenv("GAMEUPC_API_KEY")}
+)
+
+// ssmParameter caches a secret fetched once from SSM
+type ssmParameter struct {
+	name  string
+	once  sync.Once
+	value string
+	err   error
+}
+
+// get fetches the parameter from SSM once, caching the result
+func (p *ssmParameter) get() (string, error) {
+	p.once.Do(func() {
+		region := os.Getenv("REGION")
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+		if err != nil {
+			p.err = err
+			return
+		}
+
+		ssmClient := ssm.NewFromConfig(cfg)
+		withDecryption := true
+		output, err := ssmClient.GetParameter(context.TODO(), &ssm.GetParameterInput{
+			Name:           &p.name,
+			WithDecryption: &withDecryption,
+		})
+		if err != nil {
+			p.err = err
+			return
+		}
+
+		p.value = *output.Parameter.Value
+	})
+
+	return p.value, p.err
+}
+
+const (
+	bggBaseURL     = "https://boardgamegeek.com/xmlapi2"
+	gameUpcBaseURL = "https://api.gameupc.com/v1/upc" // Maps EAN/UPC barcodes to BoardGameGeek ids
+	bggMaxResults  = 5
+)
+
+type boardGameGeekResolver struct {
+	client *http.Client
+}
+
+// BoardGameGeek XML API2 response structures
+type bggValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type bggName struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type bggLink struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type bggSearchResult struct {
+	Items []struct {
+		Id string `xml:"id,attr"`
+	} `xml:"item"`
+}
+
+type bggThing struct {
+	Id            string    `xml:"id,attr"`
+	Names         []bggName `xml:"name"`
+	Description   string    `xml:"description"`
+	Image         string    `xml:"image"`
+	YearPublished bggValue  `xml:"yearpublished"`
+	MinPlayers    bggValue  `xml:"minplayers"`
+	MaxPlayers    bggValue  `xml:"maxplayers"`
+	PlayingTime   bggValue  `xml:"playingtime"`
+	Links         []bggLink `xml:"link"`
+}
+
+type bggThingResult struct {
+	Items []bggThing `xml:"item"`
+}
+
+// GameUPC API response structure
+type gameUpcResult struct {
+	Upc     string `json:"upc"`
+	BggInfo []struct {
+		Id int `json:"id"`
+	} `json:"bgg_info"`
+}
+
+func (r *boardGameGeekResolver) Name() string {
+	return "BoardGameGeek"
+}
+
+// ResolveByTitle searches BoardGameGeek by title and returns the matching games details
+func (r *boardGameGeekResolver) ResolveByTitle(title string, ch chan []domain.ResolvedBoardGame) {
+	accessToken, ok := r.accessToken()
+	if !ok {
+		ch <- nil
+		return
+	}
+
+	searchURL := fmt.Sprintf("%s/search?query=%s&type=boardgame", bggBaseURL, url.QueryEscape(title))
+	body, err := r.get(searchURL, "Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	if err != nil {
+		r.logError(err)
+		ch <- nil
+		return
+	}
+
+	var searchResult bggSearchResult
+	if err := xml.Unmarshal(body, &searchResult); err != nil {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to unmarshal search response: %s", err.Error())
+		ch <- nil
+		return
+	}
+
+	var ids []string
+	for _, item := range searchResult.Items {
+		ids = append(ids, item.Id)
+	}
+
+	ch <- r.resolveIds(accessToken, ids, fmt.Sprintf("No board games found for title: %s", title))
+}
+
+// ResolveByBarcode maps an EAN/UPC barcode to BoardGameGeek ids through GameUPC, then fetches the games details
+func (r *boardGameGeekResolver) ResolveByBarcode(code string, ch chan []domain.ResolvedBoardGame) {
+	accessToken, ok := r.accessToken()
+	if !ok {
+		ch <- nil
+		return
+	}
+
+	apiKey, err := gameUpcApiKeyParam.get()
+	if err != nil {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to get GameUPC API key from SSM: %s", err.Error())
+		ch <- nil
+		return
+	}
+
+	body, err := r.get(fmt.Sprintf("%s/%s", gameUpcBaseURL, url.PathEscape(code)), "x-api-key", apiKey)
+	if err != nil {
+		r.logError(err)
+		ch <- nil
+		return
+	}
+
+	var upcResult gameUpcResult
+	if err := json.Unmarshal(body, &upcResult); err != nil {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to unmarshal GameUPC response: %s", err.Error())
+		ch <- nil
+		return
+	}
+
+	var ids []string
+	for _, info := range upcResult.BggInfo {
+		ids = append(ids, strconv.Itoa(info.Id))
+	}
+
+	ch <- r.resolveIds(accessToken, ids, fmt.Sprintf("No item found for code: %s", code))
+}
+
+// resolveIds fetches the details of the first matching games in a single request
+func (r *boardGameGeekResolver) resolveIds(accessToken string, ids []string, notFoundMsg string) []domain.ResolvedBoardGame {
+	if len(ids) == 0 {
+		log.Info().Str("source", "BoardGameGeek").Msg(notFoundMsg)
+		return []domain.ResolvedBoardGame{{
+			Source: r.Name(),
+			Error:  &notFoundMsg,
+		}}
+	}
+
+	ids = ids[:min(len(ids), bggMaxResults)]
+
+	thingURL := fmt.Sprintf("%s/thing?id=%s", bggBaseURL, strings.Join(ids, ","))
+	body, err := r.get(thingURL, "Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	if err != nil {
+		r.logError(err)
+		return nil
+	}
+
+	var thingResult bggThingResult
+	if err := xml.Unmarshal(body, &thingResult); err != nil {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to unmarshal thing response: %s", err.Error())
+		return nil
+	}
+
+	var result []domain.ResolvedBoardGame
+	for _, thing := range thingResult.Items {
+		result = append(result, r.toResolvedBoardGame(&thing))
+	}
+
+	return result
+}
+
+func (r *boardGameGeekResolver) toResolvedBoardGame(thing *bggThing) domain.ResolvedBoardGame {
+	var title string
+	for _, n := range thing.Names {
+		if n.Type == "primary" {
+			title = n.Value
+			break
+		}
+	}
+
+	var designers []string
+	var publisher string
+	for _, l := range thing.Links {
+		switch l.Type {
+		case "boardgamedesigner":
+			designers = append(designers, l.Value)
+		case "boardgamepublisher":
+			// First listed publisher is the original one
+			if publisher == "" {
+				publisher = l.Value
+			}
+		}
+	}
+
+	var pictureUrl *string
+	if thing.Image != "" {
+		image := thing.Image
+		pictureUrl = &image
+	}
+
+	minPlayers, _ := strconv.Atoi(thing.MinPlayers.Value)
+	maxPlayers, _ := strconv.Atoi(thing.MaxPlayers.Value)
+	playTime, _ := strconv.Atoi(thing.PlayingTime.Value)
+	releaseYear, _ := strconv.Atoi(thing.YearPublished.Value)
+
+	// Descriptions are double-escaped by BoardGameGeek
+	summary := strings.TrimSpace(html.UnescapeString(thing.Description))
+
+	return domain.ResolvedBoardGame{
+		Id:          fmt.Sprintf("%s#%s", r.Name(), thing.Id),
+		Title:       title,
+		Summary:     summary,
+		PictureUrl:  pictureUrl,
+		Designers:   designers,
+		Publisher:   publisher,
+		MinPlayers:  minPlayers,
+		MaxPlayers:  maxPlayers,
+		PlayTime:    playTime,
+		ReleaseYear: releaseYear,
+		BggId:       thing.Id,
+		Source:      r.Name(),
+	}
+}
+
+func (r *boardGameGeekResolver) accessToken() (string, bool) {
+	accessToken, err := bggAccessTokenParam.get()
+	if err != nil {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to get access token from SSM: %s", err.Error())
+		return "", false
+	}
+	if accessToken == "" {
+		log.Warn().Str("source", "BoardGameGeek").Msg("No access token configured")
+		return "", false
+	}
+	return accessToken, true
+}
+
+func (r *boardGameGeekResolver) logError(err error) {
+	if isTimeout(err) {
+		log.Warn().Str("source", "BoardGameGeek").Msg("Detection request timed out")
+	} else {
+		log.Error().Str("source", "BoardGameGeek").Msgf("Failed to detect: %s", err.Error())
+	}
+}
+
+func (r *boardGameGeekResolver) get(targetURL string, authHeader string, authValue string) ([]byte, error) {
+	req, _ := http.NewRequest(http.MethodGet, targetURL, nil)
+	req.Header.Set(authHeader, authValue)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// NewBoardGameGeekResolver creates a new BoardGameGeek resolver
+// Secrets are fetched from SSM on first use (parameter names from BGG_ACCESS_TOKEN and GAMEUPC_API_KEY env vars)
+func NewBoardGameGeekResolver() ports.BoardGameResolver {
+	return &boardGameGeekResolver{
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
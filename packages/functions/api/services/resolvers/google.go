@@ -6,6 +6,7 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"alexandria.isnan.eu/functions/api/ports"
@@ -25,11 +26,16 @@ type googleSearchImageLinks struct {
 	SmallThumbnail string `json:"smallThumbnail"`
 }
 
+type googleSearchSeriesInfo struct {
+	BookDisplayNumber string `json:"bookDisplayNumber"`
+}
+
 type googleSearchVolumeInfo struct {
-	Title       string                 `json:"title"`
-	Authors     []string               `json:"authors"`
-	Description string                 `json:"description"`
-	ImageLinks  googleSearchImageLinks `json:"imageLinks"`
+	Title       string                  `json:"title"`
+	Authors     []string                `json:"authors"`
+	Description string                  `json:"description"`
+	ImageLinks  googleSearchImageLinks  `json:"imageLinks"`
+	SeriesInfo  *googleSearchSeriesInfo `json:"seriesInfo"`
 }
 
 type googleSearchResultItem struct {
@@ -103,6 +109,16 @@ func (r *googleResolver) Resolve(code string, ch chan []domain.ResolvedBook) {
 			resolvedBook.Authors = []string{}
 		}
 
+		if name, volume, ok := parseSeries(b.VolumeInfo.Title); ok {
+			resolvedBook.SeriesName = &name
+			resolvedBook.Volume = &volume
+		} else if b.VolumeInfo.SeriesInfo != nil {
+			// Google only exposes the volume number, the series name is left to the other resolvers or the user
+			if volume, err := strconv.Atoi(b.VolumeInfo.SeriesInfo.BookDisplayNumber); err == nil && volume > 0 {
+				resolvedBook.Volume = &volume
+			}
+		}
+
 		result = append(result, resolvedBook)
 	}
 
@@ -0,0 +1,182 @@
+package resolvers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"alexandria.isnan.eu/functions/api/ports"
+	"alexandria.isnan.eu/functions/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	musicBrainzBaseURL   = "https://musicbrainz.org/ws/2"
+	coverArtArchiveURL   = "https://coverartarchive.org/release"
+	musicBrainzUserAgent = "Alexandria/1.0 ( https://alexandria.isnan.eu )" // MusicBrainz rejects anonymous user agents
+	musicBrainzMaxResult = 3                                                // MusicBrainz allows ~1 req/s, keep lookups small
+)
+
+type musicBrainzResolver struct {
+	client *http.Client
+}
+
+// MusicBrainz API response structures
+type musicBrainzSearchResult struct {
+	Count    int                  `json:"count"`
+	Releases []musicBrainzRelease `json:"releases"`
+}
+
+type musicBrainzArtistCredit struct {
+	Name string `json:"name"`
+}
+
+type musicBrainzLabelInfo struct {
+	Label *struct {
+		Name string `json:"name"`
+	} `json:"label"`
+}
+
+type musicBrainzTrack struct {
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+}
+
+type musicBrainzMedium struct {
+	Position int                `json:"position"`
+	Tracks   []musicBrainzTrack `json:"tracks"`
+}
+
+type musicBrainzRelease struct {
+	Id           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	Date         string                    `json:"date"`
+	ArtistCredit []musicBrainzArtistCredit `json:"artist-credit"`
+	LabelInfo    []musicBrainzLabelInfo    `json:"label-info"`
+	Media        []musicBrainzMedium       `json:"media"`
+	CoverArt     struct {
+		Front bool `json:"front"`
+	} `json:"cover-art-archive"`
+}
+
+func (r *musicBrainzResolver) Name() string {
+	return "MusicBrainz"
+}
+
+// Resolve looks up releases by EAN/UPC barcode, then fetches their tracklists
+func (r *musicBrainzResolver) Resolve(code string, ch chan []domain.ResolvedMusic) {
+	searchURL := fmt.Sprintf("%s/release/?query=%s&fmt=json", musicBrainzBaseURL, url.QueryEscape(fmt.Sprintf("barcode:%s", code)))
+
+	var searchResult musicBrainzSearchResult
+	if err := r.get(searchURL, &searchResult); err != nil {
+		if isTimeout(err) {
+			log.Warn().Str("source", "MusicBrainz").Msg("Detection request timed out")
+		} else {
+			log.Error().Str("source", "MusicBrainz").Msgf("Failed to detect: %s", err.Error())
+		}
+		ch <- nil
+		return
+	}
+
+	if len(searchResult.Releases) == 0 {
+		log.Info().Str("source", "MusicBrainz").Msgf("No item found for code: %s", code)
+		msg := fmt.Sprintf("No item found for code: %s", code)
+		ch <- []domain.ResolvedMusic{{
+			Source: r.Name(),
+			Error:  &msg}}
+		return
+	}
+
+	maxResults := min(len(searchResult.Releases), musicBrainzMaxResult)
+
+	var result []domain.ResolvedMusic
+	for _, release := range searchResult.Releases[:maxResults] {
+		// Search results do not carry tracks, fetch the full release
+		details := release
+		detailsURL := fmt.Sprintf("%s/release/%s?inc=recordings+artist-credits+labels&fmt=json", musicBrainzBaseURL, release.Id)
+		if err := r.get(detailsURL, &details); err != nil {
+			log.Warn().Str("source", "MusicBrainz").Msgf("Failed to fetch release details for %s: %s", release.Id, err.Error())
+		}
+
+		result = append(result, r.toResolvedMusic(&details))
+	}
+
+	ch <- result
+}
+
+func (r *musicBrainzResolver) toResolvedMusic(release *musicBrainzRelease) domain.ResolvedMusic {
+	artists := []string{}
+	for _, a := range release.ArtistCredit {
+		artists = append(artists, a.Name)
+	}
+
+	tracklist := []string{}
+	for _, m := range release.Media {
+		for _, t := range m.Tracks {
+			tracklist = append(tracklist, t.Title)
+		}
+	}
+
+	var label string
+	for _, l := range release.LabelInfo {
+		if l.Label != nil && l.Label.Name != "" {
+			label = l.Label.Name
+			break
+		}
+	}
+
+	var releaseYear int
+	if len(release.Date) >= 4 {
+		_, _ = fmt.Sscanf(release.Date[:4], "%d", &releaseYear)
+	}
+
+	var pictureUrl *string
+	if release.CoverArt.Front {
+		coverURL := fmt.Sprintf("%s/%s/front-250", coverArtArchiveURL, release.Id)
+		pictureUrl = &coverURL
+	}
+
+	return domain.ResolvedMusic{
+		Id:          fmt.Sprintf("%s#%s", r.Name(), release.Id),
+		Title:       release.Title,
+		Artists:     artists,
+		Tracklist:   tracklist,
+		Label:       label,
+		ReleaseYear: releaseYear,
+		PictureUrl:  pictureUrl,
+		Source:      r.Name(),
+	}
+}
+
+func (r *musicBrainzResolver) get(targetURL string, out any) error {
+	req, _ := http.NewRequest(http.MethodGet, targetURL, nil)
+	req.Header.Set("User-Agent", musicBrainzUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+func NewMusicBrainzResolver() ports.MusicResolver {
+	return &musicBrainzResolver{
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
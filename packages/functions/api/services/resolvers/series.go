@@ -0,0 +1,48 @@
+package resolvers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matches titles such as "One Piece - Tome 12", "Astérix, T.3", "Dune Vol. 2" or "Blacksad #4"
+var seriesTitleRegex = regexp.MustCompile(`(?i)^(.+?)[\s,:\-–]+(?:tome|vol(?:ume)?\.?|t\.|n°|#)\s*0*(\d{1,4})(?:\D|$)`)
+
+// Matches the volume number in texts such as "Tome 3" or "tome 12 sur 20"
+var seriesVolumeRegex = regexp.MustCompile(`(?i)tome\s*0*(\d{1,4})`)
+
+// parseSeries extracts the series name and the volume number from a book title
+func parseSeries(title string) (string, int, bool) {
+	matches := seriesTitleRegex.FindStringSubmatch(strings.TrimSpace(title))
+	if len(matches) < 3 {
+		return "", 0, false
+	}
+
+	volume, err := strconv.Atoi(matches[2])
+	if err != nil || volume == 0 {
+		return "", 0, false
+	}
+
+	name := strings.TrimSpace(strings.TrimRight(matches[1], " ,:-–"))
+	if name == "" {
+		return "", 0, false
+	}
+
+	return name, volume, true
+}
+
+// parseVolume extracts a volume number from a free text mentioning "tome N"
+func parseVolume(text string) (int, bool) {
+	matches := seriesVolumeRegex.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return 0, false
+	}
+
+	volume, err := strconv.Atoi(matches[1])
+	if err != nil || volume == 0 {
+		return 0, false
+	}
+
+	return volume, true
+}
@@ -95,6 +95,40 @@ type tmdbMovieDetails struct {
 	Runtime     int     `json:"runtime"`
 }
 
+type tmdbTvSearchResult struct {
+	Page         int      `json:"page"`
+	Results      []tmdbTv `json:"results"`
+	TotalResults int      `json:"total_results"`
+	TotalPages   int      `json:"total_pages"`
+}
+
+type tmdbTv struct {
+	Id           int     `json:"id"`
+	Name         string  `json:"name"`
+	Overview     string  `json:"overview"`
+	PosterPath   *string `json:"poster_path"`
+	FirstAirDate string  `json:"first_air_date"`
+}
+
+type tmdbTvDetails struct {
+	Id               int     `json:"id"`
+	Name             string  `json:"name"`
+	Overview         string  `json:"overview"`
+	PosterPath       *string `json:"poster_path"`
+	FirstAirDate     string  `json:"first_air_date"`
+	NumberOfSeasons  int     `json:"number_of_seasons"`
+	NumberOfEpisodes int     `json:"number_of_episodes"`
+	EpisodeRunTime   []int   `json:"episode_run_time"`
+	CreatedBy        []struct {
+		Name string `json:"name"`
+	} `json:"created_by"`
+	Seasons []struct {
+		SeasonNumber int    `json:"season_number"`
+		Name         string `json:"name"`
+		EpisodeCount int    `json:"episode_count"`
+	} `json:"seasons"`
+}
+
 type tmdbCredits struct {
 	Cast []tmdbCastMember `json:"cast"`
 	Crew []tmdbCrewMember `json:"crew"`
@@ -273,6 +307,7 @@ func (r *tmdbResolver) fetchMovieDetails(accessToken string, movieId int) *domai
 
 	return &domain.ResolvedVideo{
 		Id:          fmt.Sprintf("%s#%d", r.Name(), details.Id),
+		Kind:        domain.Movie,
 		Title:       details.Title,
 		Summary:     details.Overview,
 		PictureUrl:  pictureUrl,
@@ -285,6 +320,190 @@ func (r *tmdbResolver) fetchMovieDetails(accessToken string, movieId int) *domai
 	}
 }
 
+// ResolveTvSeriesByTitle searches for TV series by title and returns resolved video metadata
+// Searches in both French and English to maximize results
+func (r *tmdbResolver) ResolveTvSeriesByTitle(title string, ch chan []domain.ResolvedVideo) {
+	accessToken, err := getTmdbAccessToken()
+	if err != nil {
+		log.Error().Str("source", "TMDB").Msgf("Failed to get access token from SSM: %s", err.Error())
+		ch <- nil
+		return
+	}
+	if accessToken == "" {
+		log.Warn().Str("source", "TMDB").Msg("No access token configured")
+		ch <- nil
+		return
+	}
+
+	// Search in both languages and merge results (French first, then English)
+	seenIds := make(map[int]bool)
+	var allShows []tmdbTv
+
+	for _, lang := range []string{tmdbLangPrimary, tmdbLangFallback} {
+		shows := r.searchTvSeries(accessToken, title, lang)
+		for _, show := range shows {
+			if !seenIds[show.Id] {
+				seenIds[show.Id] = true
+				allShows = append(allShows, show)
+			}
+		}
+	}
+
+	if len(allShows) == 0 {
+		log.Info().Str("source", "TMDB").Msgf("No TV series found for title: %s", title)
+		msg := fmt.Sprintf("No TV series found for title: %s", title)
+		ch <- []domain.ResolvedVideo{{
+			Source: r.Name(),
+			Error:  &msg,
+		}}
+		return
+	}
+
+	// Limit results to first 5 shows
+	maxResults := min(len(allShows), 5)
+
+	var results []domain.ResolvedVideo
+	for _, show := range allShows[:maxResults] {
+		resolved := r.fetchTvDetails(accessToken, show.Id)
+		if resolved != nil {
+			results = append(results, *resolved)
+		}
+	}
+
+	ch <- results
+}
+
+// searchTvSeries performs a TV search in the specified language
+func (r *tmdbResolver) searchTvSeries(accessToken, title, language string) []tmdbTv {
+	searchURL := fmt.Sprintf("%s/search/tv?query=%s&language=%s&page=1", tmdbBaseURL, url.QueryEscape(title), language)
+	searchReq, _ := http.NewRequest(http.MethodGet, searchURL, nil)
+	searchReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	searchReq.Header.Set("Accept", "application/json")
+
+	searchResp, err := r.client.Do(searchReq)
+	if err != nil {
+		log.Error().Str("source", "TMDB").Msgf("Failed to search TV (%s): %s", language, err.Error())
+		return nil
+	}
+	defer func() { _ = searchResp.Body.Close() }()
+
+	if searchResp.StatusCode != http.StatusOK {
+		log.Error().Str("source", "TMDB").Msgf("TV search returned status: %d (%s)", searchResp.StatusCode, language)
+		return nil
+	}
+
+	searchBody, err := io.ReadAll(searchResp.Body)
+	if err != nil {
+		log.Error().Str("source", "TMDB").Msgf("Failed to read TV search response (%s): %s", language, err.Error())
+		return nil
+	}
+
+	var searchResult tmdbTvSearchResult
+	if err := json.Unmarshal(searchBody, &searchResult); err != nil {
+		log.Error().Str("source", "TMDB").Msgf("Failed to unmarshal TV search response (%s): %s", language, err.Error())
+		return nil
+	}
+
+	return searchResult.Results
+}
+
+// fetchTvDetails fetches detailed TV series info including seasons and credits
+// Returns metadata in French (primary language)
+func (r *tmdbResolver) fetchTvDetails(accessToken string, tvId int) *domain.ResolvedVideo {
+	detailsURL := fmt.Sprintf("%s/tv/%d?append_to_response=credits&language=%s", tmdbBaseURL, tvId, tmdbLangPrimary)
+	req, _ := http.NewRequest(http.MethodGet, detailsURL, nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		log.Warn().Str("source", "TMDB").Msgf("Failed to fetch TV details for %d: %s", tvId, err.Error())
+		return nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Warn().Str("source", "TMDB").Msgf("TV details returned status %d for %d", resp.StatusCode, tvId)
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	// Combined response structure
+	var details struct {
+		tmdbTvDetails
+		Credits tmdbCredits `json:"credits"`
+	}
+	if err := json.Unmarshal(body, &details); err != nil {
+		log.Warn().Str("source", "TMDB").Msgf("Failed to unmarshal TV details: %s", err.Error())
+		return nil
+	}
+
+	// Creators stand in for directors, which vary per episode
+	var creators []string
+	for _, c := range details.CreatedBy {
+		creators = append(creators, c.Name)
+	}
+
+	// Extract top cast members (limited by tmdbCastLimit)
+	var cast []string
+	for i, actor := range details.Credits.Cast {
+		if i >= tmdbCastLimit {
+			break
+		}
+		cast = append(cast, actor.Name)
+	}
+
+	// Season 0 holds specials, which are not part of the numbered seasons
+	var seasons []domain.VideoSeason
+	for _, s := range details.Seasons {
+		if s.SeasonNumber < 1 {
+			continue
+		}
+		seasons = append(seasons, domain.VideoSeason{
+			Number:       s.SeasonNumber,
+			Name:         s.Name,
+			EpisodeCount: s.EpisodeCount,
+		})
+	}
+
+	// Extract first air year
+	var releaseYear int
+	if len(details.FirstAirDate) >= 4 {
+		_, _ = fmt.Sscanf(details.FirstAirDate[:4], "%d", &releaseYear)
+	}
+
+	var episodeRuntime int
+	if len(details.EpisodeRunTime) > 0 {
+		episodeRuntime = details.EpisodeRunTime[0]
+	}
+
+	// Build poster URL
+	var pictureUrl *string
+	if details.PosterPath != nil && *details.PosterPath != "" {
+		posterURL := fmt.Sprintf("%s%s", tmdbImageURL, *details.PosterPath)
+		pictureUrl = &posterURL
+	}
+
+	return &domain.ResolvedVideo{
+		// Movie and TV ids share the same numbering, keep them apart
+		Id:           fmt.Sprintf("%s#tv#%d", r.Name(), details.Id),
+		Kind:         domain.TvSeries,
+		Title:        details.Name,
+		Summary:      details.Overview,
+		PictureUrl:   pictureUrl,
+		Directors:    creators,
+		Cast:         cast,
+		ReleaseYear:  releaseYear,
+		Duration:     episodeRuntime,
+		TmdbId:       fmt.Sprintf("%d", details.Id),
+		SeasonCount:  details.NumberOfSeasons,
+		EpisodeCount: details.NumberOfEpisodes,
+		Seasons:      seasons,
+		Source:       r.Name(),
+	}
+}
+
 // NewTmdbResolver creates a new TMDB resolver
 // Access token is fetched from SSM on first use (parameter name from TMDB_ACCESS_TOKEN env var)
 func NewTmdbResolver() ports.VideoResolver {
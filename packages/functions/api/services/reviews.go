@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// GetItemReview returns the requester review on an item, nil if not reviewed yet
+func (s *services) GetItemReview(userId string, libraryId string, itemId string) (*domain.ItemReview, error) {
+	item, err := s.getAccessibleItem(userId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetItemReview(item.OwnerId, libraryId, itemId, userId)
+}
+
+// SaveItemReview creates or replaces the requester review on an item of an owned or shared library
+func (s *services) SaveItemReview(r *domain.ItemReview) error {
+	item, err := s.getAccessibleItem(r.UserId, r.LibraryId, r.ItemId)
+	if err != nil {
+		return err
+	}
+
+	previous, err := s.db.GetItemReview(item.OwnerId, r.LibraryId, r.ItemId, r.UserId)
+	if err != nil {
+		return err
+	}
+
+	current := time.Now().UTC()
+	r.OwnerId = item.OwnerId
+	r.UpdatedAt = &current
+
+	return s.db.PutItemReview(r, previous)
+}
+
+// DeleteItemReview removes the requester review on an item
+func (s *services) DeleteItemReview(userId string, libraryId string, itemId string) error {
+	item, err := s.getAccessibleItem(userId, libraryId, itemId)
+	if err != nil {
+		return err
+	}
+
+	review, err := s.db.GetItemReview(item.OwnerId, libraryId, itemId, userId)
+	if err != nil {
+		return err
+	}
+
+	if review == nil {
+		msg := "review not found"
+		log.Error().Str("id", itemId).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.DeleteItemReview(review)
+}
+
+// getAccessibleItem fetches an item from a library owned by or shared to the requester
+func (s *services) getAccessibleItem(userId string, libraryId string, itemId string) (*domain.LibraryItem, error) {
+	// Find if it is a shared library to the current requester
+	sharedLibraryOwnerId, err := s.db.GetSharedLibrary(userId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryOwnerId := userId
+	if sharedLibraryOwnerId != "" {
+		libraryOwnerId = sharedLibraryOwnerId
+	}
+
+	return s.db.GetLibraryItem(libraryOwnerId, libraryId, itemId)
+}
+
+// fillMyRatings sets the requester own rating on the items (including items nested in collections)
+func (s *services) fillMyRatings(userId string, items []*domain.LibraryItem) {
+	var rateable []*domain.LibraryItem
+	for _, i := range items {
+		if i.Type == domain.ItemCollection {
+			rateable = append(rateable, i.Items...)
+		} else {
+			rateable = append(rateable, i)
+		}
+	}
+
+	reviews, err := s.db.GetItemReviews(userId, rateable)
+	if err != nil {
+		// Log but don't fail - ratings are optional in listings
+		log.Warn().Msgf("Failed to fetch item reviews: %s", err.Error())
+		return
+	}
+
+	for _, i := range rateable {
+		if r, ok := reviews[i.Id]; ok {
+			i.MyRating = r.Rating
+		}
+	}
+}
+
+// fillMyStatuses sets the requester own status on the items (including items nested in collections)
+func (s *services) fillMyStatuses(userId string, items []*domain.LibraryItem) {
+	var trackable []*domain.LibraryItem
+	for _, i := range items {
+		if i.Type == domain.ItemCollection {
+			trackable = append(trackable, i.Items...)
+		} else {
+			trackable = append(trackable, i)
+		}
+	}
+
+	statuses, err := s.db.GetItemStatuses(userId, trackable)
+	if err != nil {
+		// Log but don't fail - statuses are optional in listings
+		log.Warn().Msgf("Failed to fetch item statuses: %s", err.Error())
+		return
+	}
+
+	for _, i := range trackable {
+		if st, ok := statuses[i.Id]; ok {
+			i.Status = &st.Status
+			i.StatusDate = st.Date
+		}
+	}
+}
@@ -11,7 +11,11 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (s *services) SearchItems(ownerId string, terms []string) ([]*domain.LibraryItem, error) {
+func (s *services) SearchItems(ownerId string, terms []string, tags []string, customValues map[string]string) ([]*domain.LibraryItem, error) {
+	if len(terms) == 0 && len(tags) == 0 && len(customValues) == 0 {
+		return []*domain.LibraryItem{}, nil
+	}
+
 	// Get pre-built Bluge index from S3
 	indexDir, cleanup, err := s.storage.GetBlugeIndex()
 	if err != nil {
@@ -40,7 +44,7 @@ func (s *services) SearchItems(ownerId string, terms []string) ([]*domain.Librar
 	defer func() { _ = reader.Close() }()
 
 	// Build text query with prefix matching (wildcard) and fuzzy fallback
-	// Searches: title, authors (books), directors (videos), cast (videos), collection
+	// Searches: title, authors (books), directors (videos), cast (videos), artists (music), designers and publisher (board games), collection, location, custom values
 	textQuery := bluge.NewBooleanQuery()
 	for _, term := range terms {
 		termLower := strings.ToLower(term)
@@ -51,14 +55,24 @@ func (s *services) SearchItems(ownerId string, terms []string) ([]*domain.Librar
 		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("authors"))
 		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("directors"))
 		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("cast"))
+		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("artists"))
+		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("designers"))
+		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("publisher"))
 		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("collection"))
+		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("location"))
+		termQuery.AddShould(bluge.NewWildcardQuery(termLower + "*").SetField("custom"))
 
 		// Fuzzy matching for typos (e.g., "dragns" matches "dragons")
 		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("title"))
 		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("authors"))
 		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("directors"))
 		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("cast"))
+		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("artists"))
+		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("designers"))
+		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("publisher"))
 		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("collection"))
+		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("location"))
+		termQuery.AddShould(bluge.NewFuzzyQuery(termLower).SetField("custom"))
 
 		textQuery.AddMust(termQuery)
 	}
@@ -80,9 +94,19 @@ func (s *services) SearchItems(ownerId string, terms []string) ([]*domain.Librar
 		}
 	}
 
-	// Combine: (text match) AND (access filter)
+	// Combine: (text match) AND (tags) AND (custom values) AND (access filter)
 	finalQuery := bluge.NewBooleanQuery()
-	finalQuery.AddMust(textQuery)
+	if len(terms) > 0 {
+		finalQuery.AddMust(textQuery)
+	}
+	// Tags are indexed lowercase as keywords: exact match on every tag
+	for _, tag := range tags {
+		finalQuery.AddMust(bluge.NewTermQuery(strings.ToLower(tag)).SetField("tags"))
+	}
+	// Custom values are indexed lowercase as keywords, one field per custom field: exact match on every value
+	for name, v := range customValues {
+		finalQuery.AddMust(bluge.NewTermQuery(strings.ToLower(v)).SetField("custom." + strings.ToLower(name)))
+	}
 	finalQuery.AddMust(accessQuery)
 
 	// Execute search
@@ -115,11 +139,31 @@ func (s *services) SearchItems(ownerId string, terms []string) ([]*domain.Librar
 	}
 
 	// Fetch full items from DynamoDB
-	result, err := s.db.GetMatchedItems(matchedItemsId)
+	matched, err := s.db.GetMatchedItems(matchedItemsId)
 	if err != nil {
 		return nil, err
 	}
 
+	// Items of a trashed library stay indexed until the library is purged
+	libraries, err := s.db.QueryLibraries(ownerId)
+	if err != nil {
+		return nil, err
+	}
+	visible := map[string]bool{}
+	for _, l := range libraries {
+		visible[l.Id] = true
+	}
+
+	result := []*domain.LibraryItem{}
+	for _, i := range matched {
+		if visible[i.LibraryId] {
+			result = append(result, i)
+		}
+	}
+
+	s.fillMyRatings(ownerId, result)
+	s.fillMyStatuses(ownerId, result)
+
 	// Pictures are now served via CloudFront URLs - no need to load bytes from S3
 
 	return result, nil
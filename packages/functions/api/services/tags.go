@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// ListTags returns the tag index of an owned or shared library, sorted by name
+func (s *services) ListTags(userId string, libraryId string) ([]domain.Tag, error) {
+	// Find if it is a shared library to the current requester
+	sharedLibraryOwnerId, err := s.db.GetSharedLibrary(userId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryOwnerId := userId
+	if sharedLibraryOwnerId != "" {
+		libraryOwnerId = sharedLibraryOwnerId
+	}
+
+	tags, err := s.db.QueryTagsByLibrary(libraryOwnerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tags, func(a, b int) bool {
+		return strings.ToLower(tags[a].Name) < strings.ToLower(tags[b].Name)
+	})
+
+	return tags, nil
+}
+
+// RenameTag renames a tag of the library index, the consistency manager propagates the new name to the items
+func (s *services) RenameTag(t *domain.Tag) error {
+	ownerId, err := s.ResolveLibraryOwner(t.OwnerId, t.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	t.OwnerId = ownerId
+
+	tags, err := s.db.QueryTagsByLibrary(t.OwnerId, t.LibraryId)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, existing := range tags {
+		if existing.Id == t.Id {
+			found = true
+			continue
+		}
+		if strings.EqualFold(existing.Name, t.Name) {
+			msg := fmt.Sprintf("tag '%s' already exists in this library", t.Name)
+			log.Error().Str("libraryId", t.LibraryId).Msg(msg)
+			return errors.New(msg)
+		}
+	}
+
+	if !found {
+		msg := "tag not found"
+		log.Error().Str("tagId", t.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	current := time.Now().UTC()
+	t.UpdatedAt = &current
+
+	return s.db.UpdateTag(t)
+}
+
+// DeleteTag removes a tag from the library index, the consistency manager removes it from the items
+func (s *services) DeleteTag(t *domain.Tag) error {
+	ownerId, err := s.ResolveLibraryOwner(t.OwnerId, t.LibraryId, domain.ShareEditor)
+	if err != nil {
+		return err
+	}
+	t.OwnerId = ownerId
+
+	existing, err := s.db.GetTag(t.OwnerId, t.LibraryId, t.Id)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		msg := "tag not found"
+		log.Error().Str("tagId", t.Id).Msg(msg)
+		return errors.New(msg)
+	}
+
+	return s.db.DeleteTag(existing)
+}
+
+// resolveTags maps the requested tag names to the library tag index (case-insensitive),
+// creating the missing tags. Returns the canonical names to store on the item, and the index by lowercase name.
+func (s *services) resolveTags(ownerId string, libraryId string, names []string) ([]string, map[string]domain.Tag, error) {
+	tags, err := s.db.QueryTagsByLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := map[string]domain.Tag{}
+	for _, t := range tags {
+		index[strings.ToLower(t.Name)] = t
+	}
+
+	var canonical []string
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if t, ok := index[key]; ok {
+			canonical = append(canonical, t.Name)
+			continue
+		}
+
+		current := time.Now().UTC()
+		t := domain.Tag{
+			Id:        identifier.NewId(),
+			Name:      name,
+			OwnerId:   ownerId,
+			LibraryId: libraryId,
+			UpdatedAt: &current,
+		}
+		err = s.db.PutTag(&t)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		log.Debug().Str("tagId", t.Id).Str("name", name).Msg("Created tag")
+		index[key] = t
+		canonical = append(canonical, t.Name)
+	}
+
+	return canonical, index, nil
+}
+
+// releaseTags decrements the item count of the tags of an item removed from its library
+func (s *services) releaseTags(i *domain.LibraryItem) {
+	if len(i.Tags) == 0 {
+		return
+	}
+
+	tags, err := s.db.QueryTagsByLibrary(i.OwnerId, i.LibraryId)
+	if err != nil {
+		log.Warn().Str("id", i.Id).Msgf("Failed to query tags: %s", err.Error())
+		return
+	}
+
+	index := map[string]domain.Tag{}
+	for _, t := range tags {
+		index[strings.ToLower(t.Name)] = t
+	}
+	s.updateTagCounts(i.OwnerId, i.LibraryId, index, i.Tags, nil)
+}
+
+// updateTagCounts adjusts the item count of the tags added to or removed from an item
+func (s *services) updateTagCounts(ownerId string, libraryId string, index map[string]domain.Tag, previous []string, current []string) {
+	deltas := map[string]int{}
+	for _, name := range previous {
+		deltas[strings.ToLower(name)]--
+	}
+	for _, name := range current {
+		deltas[strings.ToLower(name)]++
+	}
+
+	for key, delta := range deltas {
+		t, ok := index[key]
+		if delta == 0 || !ok {
+			continue
+		}
+		err := s.db.IncrementTagItemCount(ownerId, libraryId, t.Id, delta)
+		if err != nil {
+			// Log but don't fail - counts are informative only
+			log.Warn().Str("tagId", t.Id).Msgf("Failed to update tag item count: %s", err.Error())
+		}
+	}
+}
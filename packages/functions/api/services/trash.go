@@ -0,0 +1,114 @@
+package services
+
+import (
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// Deleted libraries and items are kept in the trash for this period, then purged by the DynamoDB TTL
+const trashRetention = 30 * 24 * time.Hour
+
+// ListTrash returns the libraries and items deleted by the user and not purged yet
+func (s *services) ListTrash(ownerId string) (*domain.Trash, error) {
+	return s.db.QueryTrash(ownerId)
+}
+
+// RestoreLibrary moves a library back from the trash, with its items, collections, tags and locations
+func (s *services) RestoreLibrary(ownerId string, libraryId string) (*domain.Library, error) {
+	library, err := s.db.GetTrashedLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	library.UpdatedAt = &now
+	library.DeletedAt = nil
+	library.ExpiresAt = nil
+
+	err = s.db.RestoreLibrary(library)
+	if err != nil {
+		return nil, err
+	}
+
+	return library, nil
+}
+
+// RestoreItem moves an item back from the trash into its library, with its history, reviews and picture.
+// The library must not be in the trash. Its collection and location are dropped if they were deleted in the meantime,
+// and its tags are created again when needed.
+func (s *services) RestoreItem(ownerId string, libraryId string, itemId string) (*domain.LibraryItem, error) {
+	library, err := s.db.GetLibrary(ownerId, libraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.db.GetTrashedLibraryItem(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The library may have been renamed while the item was in the trash
+	item.LibraryName = library.Name
+
+	if item.CollectionId != nil && *item.CollectionId != "" {
+		collection, err := s.db.GetCollection(ownerId, libraryId, *item.CollectionId)
+		if err != nil {
+			return nil, err
+		}
+		if collection == nil {
+			log.Info().Str("id", itemId).Str("collectionId", *item.CollectionId).Msg("Collection deleted, restoring item without collection")
+			item.CollectionId = nil
+			item.CollectionName = nil
+			item.Order = nil
+		} else {
+			item.CollectionName = &collection.Name
+		}
+	}
+
+	if item.LocationId != nil && *item.LocationId != "" {
+		locations, err := s.db.QueryLocationsByLibrary(ownerId, libraryId)
+		if err != nil {
+			return nil, err
+		}
+		if findLocation(locations, *item.LocationId) == -1 {
+			log.Info().Str("id", itemId).Str("locationId", *item.LocationId).Msg("Location deleted, restoring item without location")
+			item.LocationId = nil
+			item.LocationPath = nil
+		} else {
+			path := domain.BuildLocationPaths(locations)[*item.LocationId]
+			item.LocationPath = &path
+		}
+	}
+
+	var tagIndex map[string]domain.Tag
+	if len(item.Tags) > 0 {
+		item.Tags, tagIndex, err = s.resolveTags(ownerId, libraryId, item.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	item.UpdatedAt = &now
+	item.DeletedAt = nil
+	item.ExpiresAt = nil
+
+	err = s.db.RestoreLibraryItem(item)
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateTagCounts(ownerId, libraryId, tagIndex, nil, item.Tags)
+
+	if item.CollectionId != nil {
+		err = s.db.IncrementCollectionItemCount(ownerId, libraryId, *item.CollectionId, 1)
+		if err != nil {
+			// Log but don't fail - the count may drift until check-consistency reports it
+			log.Warn().Str("collectionId", *item.CollectionId).Msgf("Failed to increment collection item count: %s", err.Error())
+		}
+	}
+
+	return item, nil
+}
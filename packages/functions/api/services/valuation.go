@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"github.com/rs/zerolog/log"
+)
+
+// GetValuation totals the purchase prices by library, collection and item type.
+// Covers the owned libraries and the ones shared to the user. Wishlist items are not owned and have no value.
+func (s *services) GetValuation(userId string, libraryId string) (*domain.Valuation, error) {
+	libraries, err := s.db.QueryLibraries(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := domain.Valuation{
+		Libraries:   []domain.ValuationEntry{},
+		Collections: []domain.ValuationEntry{},
+		Types:       []domain.ValuationEntry{},
+	}
+	byType := map[domain.ItemType]*domain.ValuationEntry{}
+	found := false
+
+	for _, l := range libraries {
+		if libraryId != "" && l.Id != libraryId {
+			continue
+		}
+		if libraryId == "" && l.Kind == domain.WishlistLibrary {
+			continue
+		}
+		found = true
+
+		// Same access rules as the library listing: shared libraries are read from their owner
+		sharedLibraryOwnerId, err := s.db.GetSharedLibrary(userId, l.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		libraryOwnerId := userId
+		if sharedLibraryOwnerId != "" {
+			libraryOwnerId = sharedLibraryOwnerId
+		}
+
+		items, err := s.db.QueryItemsAcquisition(libraryOwnerId, l.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		library := newValuationEntry(l.Id, l.Name, "")
+		byCollection := map[string]*domain.ValuationEntry{}
+
+		for _, i := range items {
+			addToValuation(&library, i)
+
+			t, ok := byType[i.Type]
+			if !ok {
+				entry := newValuationEntry(i.Type.String(), i.Type.String(), "")
+				t = &entry
+				byType[i.Type] = t
+			}
+			addToValuation(t, i)
+
+			if i.CollectionId != nil && *i.CollectionId != "" {
+				c, ok := byCollection[*i.CollectionId]
+				if !ok {
+					name := ""
+					if i.CollectionName != nil {
+						name = *i.CollectionName
+					}
+					entry := newValuationEntry(*i.CollectionId, name, l.Id)
+					c = &entry
+					byCollection[*i.CollectionId] = c
+				}
+				addToValuation(c, i)
+			}
+		}
+
+		valuation.Libraries = append(valuation.Libraries, roundValuation(library))
+
+		collections := []domain.ValuationEntry{}
+		for _, c := range byCollection {
+			collections = append(collections, roundValuation(*c))
+		}
+		sort.Slice(collections, func(a, b int) bool {
+			return strings.ToLower(collections[a].Name) < strings.ToLower(collections[b].Name)
+		})
+		valuation.Collections = append(valuation.Collections, collections...)
+	}
+
+	if libraryId != "" && !found {
+		msg := "library not found"
+		log.Error().Str("libraryId", libraryId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	for _, t := range []domain.ItemType{domain.ItemBook, domain.ItemVideo, domain.ItemMusic, domain.ItemBoardGame} {
+		if entry, ok := byType[t]; ok {
+			valuation.Types = append(valuation.Types, roundValuation(*entry))
+		}
+	}
+
+	return &valuation, nil
+}
+
+func newValuationEntry(id string, name string, libraryId string) domain.ValuationEntry {
+	return domain.ValuationEntry{
+		Id:        id,
+		Name:      name,
+		LibraryId: libraryId,
+		Totals:    map[string]float64{},
+	}
+}
+
+// addToValuation counts the item, wanted items are not owned and have no value
+func addToValuation(entry *domain.ValuationEntry, i *domain.LibraryItem) {
+	if i.Wanted {
+		return
+	}
+
+	entry.ItemCount++
+	if i.Acquisition != nil && i.Acquisition.Price != nil && i.Acquisition.Currency != "" {
+		entry.ValuedItemCount++
+		entry.Totals[i.Acquisition.Currency] += *i.Acquisition.Price
+	}
+}
+
+func roundValuation(entry domain.ValuationEntry) domain.ValuationEntry {
+	for currency, total := range entry.Totals {
+		entry.Totals[currency] = math.Round(total*100) / 100
+	}
+	return entry
+}
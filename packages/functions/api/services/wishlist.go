@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+// AcquireWishlistItem turns a wishlist item into an owned item of another library of the requester,
+// or of the owner of the wishlist for its editors. Metadata, tags and picture are kept, the wishlist entry is removed.
+func (s *services) AcquireWishlistItem(userId string, libraryId string, itemId string, targetLibraryId string) (*domain.LibraryItem, error) {
+	ownerId, err := s.resolveLibrariesOwner(userId, libraryId, targetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.db.GetLibraryItem(ownerId, libraryId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !item.Wanted {
+		msg := "item is not a wishlist item"
+		log.Error().Str("id", itemId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	target, err := s.db.GetLibrary(ownerId, targetLibraryId)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Kind == domain.WishlistLibrary {
+		msg := "target library is a wishlist"
+		log.Error().Str("libraryId", targetLibraryId).Msg(msg)
+		return nil, errors.New(msg)
+	}
+
+	acquired := *item
+	acquired.Id = identifier.NewId()
+	acquired.LibraryId = target.Id
+	acquired.LibraryName = target.Name
+	acquired.Wanted = false
+	// Collections, locations and review aggregates belong to the wishlist
+	acquired.CollectionId = nil
+	acquired.CollectionName = nil
+	acquired.Order = nil
+	acquired.LocationId = nil
+	acquired.LocationPath = nil
+	acquired.RatingTotal = 0
+	acquired.RatingCount = 0
+	acquired.CustomValues = keepCustomValues(target, item.CustomValues)
+
+	var tagIndex map[string]domain.Tag
+	if len(acquired.Tags) > 0 {
+		acquired.Tags, tagIndex, err = s.resolveTags(ownerId, target.Id, acquired.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	current := time.Now().UTC()
+	acquired.UpdatedAt = &current
+
+	err = s.db.PutLibraryItem(&acquired)
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateTagCounts(ownerId, target.Id, tagIndex, nil, acquired.Tags)
+
+	// Picture copy is best-effort - the item is already owned
+	picture, err := s.storage.GetPicture(ownerId, libraryId, itemId)
+	if err != nil {
+		log.Warn().Str("id", itemId).Err(err).Msg("Picture fetch failed, continuing without picture")
+	} else if picture != nil {
+		err = s.storage.PutPicture(ownerId, target.Id, acquired.Id, picture)
+		if err != nil {
+			log.Warn().Str("id", acquired.Id).Err(err).Msg("Picture upload failed, continuing without picture")
+		}
+	}
+
+	// The wanted item is replaced by the acquired one, it does not go through the trash.
+	// The item is already owned, a wishlist entry left behind can still be deleted by the user.
+	err = s.db.DeleteLibraryItem(item)
+	if err != nil {
+		log.Warn().Str("id", itemId).Msgf("Failed to remove the acquired wishlist item: %s", err.Error())
+		return &acquired, nil
+	}
+
+	s.releaseTags(item)
+
+	if item.CollectionId != nil && *item.CollectionId != "" {
+		err = s.db.IncrementCollectionItemCount(ownerId, libraryId, *item.CollectionId, -1)
+		if err != nil {
+			// Log but don't fail - the count may drift until check-consistency reports it
+			log.Warn().Str("collectionId", *item.CollectionId).Msgf("Failed to decrement collection item count: %s", err.Error())
+		}
+	}
+
+	err = s.storage.DeletePicture(ownerId, libraryId, itemId)
+	if err != nil {
+		log.Warn().Str("id", itemId).Err(err).Msg("Picture removal failed")
+	}
+
+	return &acquired, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/rs/zerolog/log"
 )
 
@@ -21,14 +22,25 @@ var region string = os.Getenv("REGION")
 func init() {
 	config, _ := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
 	client = dynamodb.NewFromConfig(config)
+	s3Client := s3.NewFromConfig(config)
 
 	handlers = map[string]map[persistence.EntityType]processing.Handler{
 		"MODIFY": {
 			persistence.TypeLibrary:    processing.UpdateLibraryHandler,
 			persistence.TypeCollection: processing.UpdateCollectionHandler,
+			persistence.TypeTag:        processing.UpdateTagHandler,
+			persistence.TypeLocation:   processing.UpdateLocationHandler,
+			persistence.TypeContact:    processing.UpdateContactHandler,
 		},
 		"REMOVE": {
 			persistence.TypeCollection: processing.DeleteCollectionHandler,
+			persistence.TypeTag:        processing.DeleteTagHandler,
+			persistence.TypeLocation:   processing.DeleteLocationHandler,
+			persistence.TypeLibrary:    processing.PurgeLibraryHandler(s3Client),
+			persistence.TypeBook:       processing.PurgeItemHandler(s3Client),
+			persistence.TypeVideo:      processing.PurgeItemHandler(s3Client),
+			persistence.TypeMusic:      processing.PurgeItemHandler(s3Client),
+			persistence.TypeBoardGame:  processing.PurgeItemHandler(s3Client),
 		},
 	}
 }
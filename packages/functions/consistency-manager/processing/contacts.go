@@ -0,0 +1,114 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	ddbconversions "github.com/aereal/go-dynamodb-attribute-conversions/v2"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateContactHandler handles MODIFY events for CONTACT entities
+// When a contact is renamed, updates LentTo on the items and copies lent to it, in all the owner libraries
+func UpdateContactHandler(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+	atv_new := ddbconversions.AttributeValueMapFrom(evt.Change.NewImage)
+	var contact_new persistence.Contact
+	_ = attributevalue.UnmarshalMap(atv_new, &contact_new)
+
+	atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+	var contact_old persistence.Contact
+	_ = attributevalue.UnmarshalMap(atv_old, &contact_old)
+
+	// Only proceed if name changed
+	if contact_new.Name == contact_old.Name {
+		return
+	}
+
+	log.Info().Str("contactId", contact_new.Id).Msgf("Contact renamed from '%s' to '%s'", contact_old.Name, contact_new.Name)
+
+	// Items have GSI2PK = owner#<ownerId> whatever their library, only lent items are read
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("#GSI2PK = :gsi2pk and begins_with(#GSI2SK, :item_prefix)"),
+		FilterExpression:       aws.String("attribute_exists(#LentToId) or attribute_exists(#Copies)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi2pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI2PK(contact_new.OwnerId),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#GSI2PK":   "GSI2PK",
+			"#GSI2SK":   "GSI2SK",
+			"#LentToId": "LentToId",
+			"#Copies":   "Copies",
+		},
+	}
+
+	requests := []types.BatchStatementRequest{}
+	queryPaginator := dynamodb.NewQueryPaginator(client, &query)
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("contactId", contact_new.Id).Msgf("Failed to query lent items: %s", err.Error())
+			return
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("contactId", contact_new.Id).Msgf("Failed to unmarshal item: %s", err.Error())
+				continue
+			}
+
+			if record.LentToId != nil && *record.LentToId == contact_new.Id {
+				requests = append(requests, lentToStatement(&record, "LentTo", contact_new.Name))
+			}
+			for idx, c := range record.Copies {
+				if c.LentToId != nil && *c.LentToId == contact_new.Id {
+					requests = append(requests, lentToStatement(&record, fmt.Sprintf("Copies[%d].LentTo", idx), contact_new.Name))
+				}
+			}
+		}
+	}
+
+	// Execute batch updates in chunks of 25
+	for _, chunk := range slices.ChunkBy(requests, 25) {
+		if len(chunk) == 0 {
+			continue
+		}
+		_, err := client.BatchExecuteStatement(context.TODO(), &dynamodb.BatchExecuteStatementInput{
+			Statements: chunk,
+		})
+		if err != nil {
+			log.Warn().Str("contactId", contact_new.Id).Msgf("Failed to batch update items: %s", err.Error())
+			continue
+		}
+	}
+
+	log.Info().Str("contactId", contact_new.Id).Msgf("Updated %d loans with new contact name", len(requests))
+}
+
+func lentToStatement(record *persistence.LibraryItem, path string, name string) types.BatchStatementRequest {
+	params, _ := attributevalue.MarshalList([]interface{}{
+		name,
+		persistence.MakeLibraryItemPK(record.OwnerId),
+		persistence.MakeLibraryItemSK(record.LibraryId, record.Id),
+	})
+
+	return types.BatchStatementRequest{
+		Statement:  aws.String(fmt.Sprintf("UPDATE \"%s\" SET %s=? WHERE PK=? AND SK=?", tableName, path)),
+		Parameters: params,
+	}
+}
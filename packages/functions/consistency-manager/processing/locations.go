@@ -0,0 +1,206 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	ddbconversions "github.com/aereal/go-dynamodb-attribute-conversions/v2"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateLocationHandler handles MODIFY events for LOCATION entities
+// When a location is renamed, updates LocationPath on all items stored in it or in its nested locations
+func UpdateLocationHandler(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+	atv_new := ddbconversions.AttributeValueMapFrom(evt.Change.NewImage)
+	var location_new persistence.Location
+	_ = attributevalue.UnmarshalMap(atv_new, &location_new)
+
+	atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+	var location_old persistence.Location
+	_ = attributevalue.UnmarshalMap(atv_old, &location_old)
+
+	// Only proceed if name changed
+	if location_new.Name == location_old.Name {
+		return
+	}
+
+	log.Info().Str("locationId", location_new.Id).Msgf("Location renamed from '%s' to '%s'", location_old.Name, location_new.Name)
+
+	// Query all the locations of the library to rebuild the paths
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk and begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLocationPK(location_new.OwnerId),
+			},
+			":sk_prefix": &types.AttributeValueMemberS{
+				Value: fmt.Sprintf("library#%s#location#", location_new.LibraryId),
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(client, &query)
+	locations := []domain.Location{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("locationId", location_new.Id).Msgf("Failed to query locations: %s", err.Error())
+			return
+		}
+
+		for _, item := range result.Items {
+			record := persistence.Location{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("locationId", location_new.Id).Msgf("Failed to unmarshal location: %s", err.Error())
+				continue
+			}
+
+			locations = append(locations, domain.Location{
+				Id:       record.Id,
+				Name:     record.Name,
+				ParentId: record.ParentId,
+			})
+		}
+	}
+
+	paths := domain.BuildLocationPaths(locations)
+
+	// The renamed location and its nested locations (bookcases of a room, shelves of a bookcase)
+	affected := map[string]bool{location_new.Id: true}
+	for changed := true; changed; {
+		changed = false
+		for _, l := range locations {
+			if !affected[l.Id] && l.ParentId != nil && affected[*l.ParentId] {
+				affected[l.Id] = true
+				changed = true
+			}
+		}
+	}
+
+	requests := []types.BatchStatementRequest{}
+	forEachLocatedItem(client, &location_new, func(record *persistence.LibraryItem) {
+		if !affected[*record.LocationId] {
+			return
+		}
+
+		params, _ := attributevalue.MarshalList([]interface{}{
+			paths[*record.LocationId],
+			persistence.MakeLibraryItemPK(record.OwnerId),
+			persistence.MakeLibraryItemSK(record.LibraryId, record.Id),
+		})
+
+		requests = append(requests, types.BatchStatementRequest{
+			Statement:  aws.String(fmt.Sprintf("UPDATE \"%s\" SET LocationPath=? WHERE PK=? AND SK=?", tableName)),
+			Parameters: params,
+		})
+	})
+
+	executeLocationRequests(client, &location_new, requests)
+
+	log.Info().Str("locationId", location_new.Id).Msgf("Updated %d items with new location path", len(requests))
+}
+
+// DeleteLocationHandler handles REMOVE events for LOCATION entities
+// When a location is deleted, clears LocationId and LocationPath on the items stored in it
+func DeleteLocationHandler(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+	atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+	var location persistence.Location
+	_ = attributevalue.UnmarshalMap(atv_old, &location)
+
+	log.Info().Str("locationId", location.Id).Msg("Location deleted, clearing items location")
+
+	requests := []types.BatchStatementRequest{}
+	forEachLocatedItem(client, &location, func(record *persistence.LibraryItem) {
+		if *record.LocationId != location.Id {
+			return
+		}
+
+		params, _ := attributevalue.MarshalList([]interface{}{
+			persistence.MakeLibraryItemPK(record.OwnerId),
+			persistence.MakeLibraryItemSK(record.LibraryId, record.Id),
+		})
+
+		requests = append(requests, types.BatchStatementRequest{
+			Statement:  aws.String(fmt.Sprintf("UPDATE \"%s\" REMOVE LocationId REMOVE LocationPath WHERE PK=? AND SK=?", tableName)),
+			Parameters: params,
+		})
+	})
+
+	executeLocationRequests(client, &location, requests)
+
+	log.Info().Str("locationId", location.Id).Msgf("Cleared location of %d items", len(requests))
+}
+
+// forEachLocatedItem calls fn on every item of the location library stored in any location
+func forEachLocatedItem(client *dynamodb.Client, location *persistence.Location, fn func(record *persistence.LibraryItem)) {
+	// Items have GSI1PK = owner#<ownerId>#library#<libraryId>
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK, :item_prefix)"),
+		FilterExpression:       aws.String("attribute_exists(#LocationId)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI1PK(location.OwnerId, location.LibraryId),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK":     "GSI1PK",
+			"#GSI1SK":     "GSI1SK",
+			"#LocationId": "LocationId",
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(client, &query)
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("locationId", location.Id).Msgf("Failed to query located items: %s", err.Error())
+			return
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("locationId", location.Id).Msgf("Failed to unmarshal item: %s", err.Error())
+				continue
+			}
+
+			if record.LocationId != nil {
+				fn(&record)
+			}
+		}
+	}
+}
+
+func executeLocationRequests(client *dynamodb.Client, location *persistence.Location, requests []types.BatchStatementRequest) {
+	// Execute batch updates in chunks of 25
+	chunks := slices.ChunkBy(requests, 25)
+	for _, chunk := range chunks {
+		_, err := client.BatchExecuteStatement(context.TODO(), &dynamodb.BatchExecuteStatementInput{
+			Statements: chunk,
+		})
+		if err != nil {
+			log.Warn().Str("locationId", location.Id).Msgf("Failed to batch update items: %s", err.Error())
+			continue
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	ddbconversions "github.com/aereal/go-dynamodb-attribute-conversions/v2"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateTagHandler handles MODIFY events for TAG entities
+// When a tag is renamed, replaces the old name by the new one on all the items carrying it
+func UpdateTagHandler(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+	atv_new := ddbconversions.AttributeValueMapFrom(evt.Change.NewImage)
+	var tag_new persistence.Tag
+	_ = attributevalue.UnmarshalMap(atv_new, &tag_new)
+
+	atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+	var tag_old persistence.Tag
+	_ = attributevalue.UnmarshalMap(atv_old, &tag_old)
+
+	// Only proceed if name changed (item count updates also trigger MODIFY events)
+	if tag_new.Name == tag_old.Name {
+		return
+	}
+
+	log.Info().Str("tagId", tag_new.Id).Msgf("Tag renamed from '%s' to '%s'", tag_old.Name, tag_new.Name)
+
+	updated := retagItems(client, &tag_old, func(tags []string) []string {
+		return slices.Map(tags, func(t string) string {
+			if t == tag_old.Name {
+				return tag_new.Name
+			}
+			return t
+		})
+	})
+
+	log.Info().Str("tagId", tag_new.Id).Msgf("Updated %d items with new tag name", updated)
+}
+
+// DeleteTagHandler handles REMOVE events for TAG entities
+// When a tag is deleted, removes it from all the items carrying it
+func DeleteTagHandler(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+	atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+	var tag persistence.Tag
+	_ = attributevalue.UnmarshalMap(atv_old, &tag)
+
+	log.Info().Str("tagId", tag.Id).Msg("Tag deleted, untagging items")
+
+	updated := retagItems(client, &tag, func(tags []string) []string {
+		return slices.Filter(tags, func(t string) bool { return t != tag.Name })
+	})
+
+	log.Info().Str("tagId", tag.Id).Msgf("Untagged %d items from deleted tag", updated)
+}
+
+// retagItems rewrites the tag list of the library items carrying the given tag. Returns the number of items updated.
+func retagItems(client *dynamodb.Client, tag *persistence.Tag, rewrite func([]string) []string) int {
+	// Items have GSI1PK = owner#<ownerId>#library#<libraryId> and Tags containing the tag name
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#GSI1PK = :gsi1pk and begins_with(#GSI1SK, :item_prefix)"),
+		FilterExpression:       aws.String("contains(#Tags, :tag)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{
+				Value: persistence.MakeLibraryItemGSI1PK(tag.OwnerId, tag.LibraryId),
+			},
+			":item_prefix": &types.AttributeValueMemberS{
+				Value: "item#",
+			},
+			":tag": &types.AttributeValueMemberS{
+				Value: tag.Name,
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#GSI1PK": "GSI1PK",
+			"#GSI1SK": "GSI1SK",
+			"#Tags":   "Tags",
+		},
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(client, &query)
+	requests := []types.BatchStatementRequest{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("tagId", tag.Id).Msgf("Failed to query tagged items: %s", err.Error())
+			return 0
+		}
+
+		for _, item := range result.Items {
+			record := persistence.LibraryItem{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("tagId", tag.Id).Msgf("Failed to unmarshal item: %s", err.Error())
+				continue
+			}
+
+			tags := rewrite(record.Tags)
+			if len(tags) == 0 {
+				params, _ := attributevalue.MarshalList([]interface{}{
+					persistence.MakeLibraryItemPK(record.OwnerId),
+					persistence.MakeLibraryItemSK(record.LibraryId, record.Id),
+				})
+
+				requests = append(requests, types.BatchStatementRequest{
+					Statement:  aws.String(fmt.Sprintf("UPDATE \"%s\" REMOVE Tags WHERE PK=? AND SK=?", tableName)),
+					Parameters: params,
+				})
+				continue
+			}
+
+			params, _ := attributevalue.MarshalList([]interface{}{
+				tags,
+				persistence.MakeLibraryItemPK(record.OwnerId),
+				persistence.MakeLibraryItemSK(record.LibraryId, record.Id),
+			})
+
+			requests = append(requests, types.BatchStatementRequest{
+				Statement:  aws.String(fmt.Sprintf("UPDATE \"%s\" SET Tags=? WHERE PK=? AND SK=?", tableName)),
+				Parameters: params,
+			})
+		}
+	}
+
+	// Execute batch updates in chunks of 25
+	chunks := slices.ChunkBy(requests, 25)
+	for _, chunk := range chunks {
+		_, err := client.BatchExecuteStatement(context.TODO(), &dynamodb.BatchExecuteStatementInput{
+			Statements: chunk,
+		})
+		if err != nil {
+			log.Warn().Str("tagId", tag.Id).Msgf("Failed to batch update tagged items: %s", err.Error())
+			continue
+		}
+	}
+
+	return len(requests)
+}
@@ -0,0 +1,177 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"alexandria.isnan.eu/functions/internal/slices"
+	ddbconversions "github.com/aereal/go-dynamodb-attribute-conversions/v2"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+// PurgeLibraryHandler handles REMOVE events for LIBRARY entities
+// When a trashed library expires, deletes its items, collections, tags, locations, history events, reviews and pictures
+func PurgeLibraryHandler(s3Client *s3.Client) Handler {
+	return func(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+		atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+		var library persistence.Library
+		_ = attributevalue.UnmarshalMap(atv_old, &library)
+
+		// Only trashed libraries are purged, the others are deleted with their records
+		if library.DeletedAt == nil {
+			return
+		}
+
+		log.Info().Str("libraryId", library.Id).Msg("Trashed library expired, purging its records and pictures")
+
+		deleteRecords(client, library.PK, fmt.Sprintf("library#%s#", library.Id))
+		deletePictures(s3Client, fmt.Sprintf("user/%s/library/%s/", library.OwnerId, library.Id))
+	}
+}
+
+// PurgeItemHandler handles REMOVE events for BOOK, VIDEO, MUSIC and BOARDGAME entities
+// When a trashed item expires, deletes its history events, reviews and picture
+func PurgeItemHandler(s3Client *s3.Client) Handler {
+	return func(client *dynamodb.Client, evt *events.DynamoDBEventRecord) {
+		atv_old := ddbconversions.AttributeValueMapFrom(evt.Change.OldImage)
+		var item persistence.LibraryItem
+		_ = attributevalue.UnmarshalMap(atv_old, &item)
+
+		// Only trashed items are purged, moved items keep their events, reviews and picture
+		if item.DeletedAt == nil {
+			return
+		}
+
+		log.Info().Str("id", item.Id).Msg("Trashed item expired, purging its records and picture")
+
+		deleteRecords(client, item.PK, fmt.Sprintf("library#%s#item#%s#", item.LibraryId, item.Id))
+		deletePictures(s3Client, fmt.Sprintf("user/%s/library/%s/item/%s", item.OwnerId, item.LibraryId, item.Id))
+	}
+}
+
+// deleteRecords deletes the records of a partition whose sort key starts with the prefix
+func deleteRecords(client *dynamodb.Client, pk string, skPrefix string) {
+	query := dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("#PK = :pk and begins_with(#SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: pk,
+			},
+			":sk_prefix": &types.AttributeValueMemberS{
+				Value: skPrefix,
+			},
+		},
+		ExpressionAttributeNames: map[string]string{
+			"#PK": "PK",
+			"#SK": "SK",
+		},
+		ProjectionExpression: aws.String("#PK, #SK"),
+	}
+
+	type Record struct {
+		PK string `dynamodbav:"PK"`
+		SK string `dynamodbav:"SK"`
+	}
+
+	queryPaginator := dynamodb.NewQueryPaginator(client, &query)
+	requests := []types.WriteRequest{}
+
+	for queryPaginator.HasMorePages() {
+		result, err := queryPaginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("prefix", skPrefix).Msgf("Failed to query records to purge: %s", err.Error())
+			return
+		}
+
+		for _, item := range result.Items {
+			record := Record{}
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Str("prefix", skPrefix).Msgf("Failed to unmarshal record: %s", err.Error())
+				continue
+			}
+
+			requests = append(requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: record.PK},
+						"SK": &types.AttributeValueMemberS{Value: record.SK},
+					},
+				},
+			})
+		}
+	}
+
+	if len(requests) == 0 {
+		return
+	}
+
+	// Execute batch deletes in chunks of 25, sending again the requests left unprocessed (throttling)
+	chunks := slices.ChunkBy(requests, 25)
+	for _, chunk := range chunks {
+		requestItems := map[string][]types.WriteRequest{
+			tableName: chunk,
+		}
+		for len(requestItems) > 0 {
+			res, err := client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+				RequestItems: requestItems,
+			})
+			if err != nil {
+				log.Warn().Str("prefix", skPrefix).Msgf("Failed to batch purge records: %s", err.Error())
+				break
+			}
+
+			requestItems = res.UnprocessedItems
+		}
+	}
+
+	log.Info().Str("prefix", skPrefix).Msgf("Purged %d records", len(requests))
+}
+
+// deletePictures deletes the pictures whose key starts with the prefix
+func deletePictures(s3Client *s3.Client, prefix string) {
+	p := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(picturesBucket),
+		Prefix: aws.String(prefix),
+	})
+
+	identifiers := []s3types.ObjectIdentifier{}
+	for p.HasMorePages() {
+		page, err := p.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Str("prefix", prefix).Msgf("Failed to list pictures to purge: %s", err.Error())
+			return
+		}
+
+		for _, obj := range page.Contents {
+			identifiers = append(identifiers, s3types.ObjectIdentifier{Key: obj.Key})
+		}
+	}
+
+	if len(identifiers) == 0 {
+		return
+	}
+
+	chunks := slices.ChunkBy(identifiers, 1000)
+	for _, chunk := range chunks {
+		_, err := s3Client.DeleteObjects(context.TODO(), &s3.DeleteObjectsInput{
+			Bucket: aws.String(picturesBucket),
+			Delete: &s3types.Delete{
+				Objects: chunk,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			log.Warn().Str("prefix", prefix).Msgf("Failed to purge pictures: %s", err.Error())
+			continue
+		}
+	}
+}
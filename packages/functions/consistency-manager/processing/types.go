@@ -8,5 +8,6 @@ import (
 )
 
 var tableName string = os.Getenv("DYNAMODB_TABLE_NAME")
+var picturesBucket string = os.Getenv("S3_PICTURES_BUCKET")
 
 type Handler func(client *dynamodb.Client, evt *events.DynamoDBEventRecord)
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,7 +50,7 @@ type SharedLibraryEntry struct {
 	LibraryId string `json:"libraryId"`
 }
 
-// createBlugeDocument creates a Bluge document from a library item (book or video)
+// createBlugeDocument creates a Bluge document from a library item (book, video, music or board game)
 func createBlugeDocument(item *persistence.LibraryItem) *bluge.Document {
 	docId := item.PK + "|" + item.SK
 	doc := bluge.NewDocument(docId)
@@ -67,6 +68,34 @@ func createBlugeDocument(item *persistence.LibraryItem) *bluge.Document {
 	if len(item.Cast) > 0 {
 		doc.AddField(bluge.NewTextField("cast", strings.Join(item.Cast, " ")).StoreValue())
 	}
+	if len(item.Artists) > 0 {
+		doc.AddField(bluge.NewTextField("artists", strings.Join(item.Artists, " ")).StoreValue())
+	}
+	if len(item.Designers) > 0 {
+		doc.AddField(bluge.NewTextField("designers", strings.Join(item.Designers, " ")).StoreValue())
+	}
+	if item.Publisher != nil && *item.Publisher != "" {
+		doc.AddField(bluge.NewTextField("publisher", *item.Publisher).StoreValue())
+	}
+	if item.LocationPath != nil && *item.LocationPath != "" {
+		doc.AddField(bluge.NewTextField("location", *item.LocationPath).StoreValue())
+	}
+
+	// Keyword fields for tag filtering, lowercased as tags are matched case-insensitively
+	for _, t := range item.Tags {
+		doc.AddField(bluge.NewKeywordField("tags", strings.ToLower(t)).StoreValue())
+	}
+
+	// Custom values: one keyword field per custom field for filtering, e.g. "custom.region",
+	// and all the values in a text field for fuzzy search
+	if len(item.CustomValues) > 0 {
+		values := []string{}
+		for name, v := range item.CustomValues {
+			doc.AddField(bluge.NewKeywordField("custom."+strings.ToLower(name), strings.ToLower(v)).StoreValue())
+			values = append(values, v)
+		}
+		doc.AddField(bluge.NewTextField("custom", strings.Join(values, " ")).StoreValue())
+	}
 
 	// Keyword fields for access filtering
 	doc.AddField(bluge.NewKeywordField("ownerId", item.OwnerId).StoreValue())
@@ -211,7 +240,7 @@ func fullResync() error {
 
 	// Scan all items from DynamoDB
 	var lastEvaluatedKey map[string]ddbtypes.AttributeValue
-	totalBooks := 0
+	totalItems := 0
 	totalSharedLibraries := 0
 
 	batch := bluge.NewBatch()
@@ -244,17 +273,22 @@ func fullResync() error {
 			entityType := persistence.EntityType(entityTypeStr.Value)
 
 			switch entityType {
-			case persistence.TypeBook, persistence.TypeVideo:
+			case persistence.TypeBook, persistence.TypeVideo, persistence.TypeMusic, persistence.TypeBoardGame:
 				var libraryItem persistence.LibraryItem
 				if err := attributevalue.UnmarshalMap(item, &libraryItem); err != nil {
 					log.Warn().Msgf("Failed to unmarshal item: %s", err.Error())
 					continue
 				}
 
+				// Trashed items are not searchable
+				if libraryItem.DeletedAt != nil {
+					continue
+				}
+
 				doc := createBlugeDocument(&libraryItem)
 				batch.Insert(doc)
 				batchSize++
-				totalBooks++ // Counts both books and videos
+				totalItems++ // Counts all item types
 
 				// Flush batch periodically
 				if batchSize >= maxBatchSize {
@@ -303,7 +337,7 @@ func fullResync() error {
 		return err
 	}
 
-	log.Info().Msgf("Indexed %d books, %d shared libraries", totalBooks, totalSharedLibraries)
+	log.Info().Msgf("Indexed %d items, %d shared libraries", totalItems, totalSharedLibraries)
 
 	// Create tar.gz of index directory
 	indexArchive, err := tarDirectory(indexDir)
@@ -417,7 +451,7 @@ func streamHandler(event events.DynamoDBEvent) error {
 		}
 
 		switch entityType {
-		case persistence.TypeBook, persistence.TypeVideo:
+		case persistence.TypeBook, persistence.TypeVideo, persistence.TypeMusic, persistence.TypeBoardGame:
 			switch record.EventName {
 			case "INSERT":
 				var item persistence.LibraryItem
@@ -444,13 +478,47 @@ func streamHandler(event events.DynamoDBEvent) error {
 					continue
 				}
 
+				// Trashed items are removed from the index, and indexed again when restored
+				if itemNew.DeletedAt != nil {
+					if itemOld.DeletedAt != nil {
+						continue
+					}
+					docId := itemOld.PK + "|" + itemOld.SK
+					if err := writer.Delete(bluge.Identifier(docId)); err != nil {
+						log.Warn().Msgf("Failed to delete trashed document: %s", err.Error())
+						continue
+					}
+					indexModified = true
+					log.Info().Str("itemId", itemNew.Id).Str("type", string(entityType)).Msg("Trashed item removed from index")
+					continue
+				}
+				if itemOld.DeletedAt != nil {
+					doc := createBlugeDocument(&itemNew)
+					if err := writer.Insert(doc); err != nil {
+						log.Warn().Msgf("Failed to insert restored document: %s", err.Error())
+						continue
+					}
+					indexModified = true
+					log.Info().Str("itemId", itemNew.Id).Str("type", string(entityType)).Msg("Restored item indexed")
+					continue
+				}
+
 				// Only reindex if searchable fields changed
 				// For books: title, authors
 				// For videos: title, directors, cast
+				// For music: title, artists
+				// For board games: title, designers, publisher
+				// For all items: tags, location, custom values
 				if itemNew.Title == itemOld.Title &&
 					strings.Join(itemNew.Authors, " ") == strings.Join(itemOld.Authors, " ") &&
 					strings.Join(itemNew.Directors, " ") == strings.Join(itemOld.Directors, " ") &&
-					strings.Join(itemNew.Cast, " ") == strings.Join(itemOld.Cast, " ") {
+					strings.Join(itemNew.Cast, " ") == strings.Join(itemOld.Cast, " ") &&
+					strings.Join(itemNew.Artists, " ") == strings.Join(itemOld.Artists, " ") &&
+					strings.Join(itemNew.Designers, " ") == strings.Join(itemOld.Designers, " ") &&
+					aws.ToString(itemNew.Publisher) == aws.ToString(itemOld.Publisher) &&
+					strings.Join(itemNew.Tags, "|") == strings.Join(itemOld.Tags, "|") &&
+					aws.ToString(itemNew.LocationPath) == aws.ToString(itemOld.LocationPath) &&
+					maps.Equal(itemNew.CustomValues, itemOld.CustomValues) {
 					continue
 				}
 
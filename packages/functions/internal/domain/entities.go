@@ -2,6 +2,9 @@ package domain
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +14,8 @@ const (
 	ItemBook ItemType = iota
 	ItemVideo
 	ItemCollection
+	ItemMusic
+	ItemBoardGame
 )
 
 func (e ItemType) String() string {
@@ -21,22 +26,128 @@ func (e ItemType) String() string {
 		return "Video"
 	case ItemCollection:
 		return "Collection"
+	case ItemMusic:
+		return "Music"
+	case ItemBoardGame:
+		return "BoardGame"
 	default:
 		return fmt.Sprintf("%d", int(e))
 	}
 }
 
+// VideoKind distinguishes movies from TV series within video items
+type VideoKind string
+
+const (
+	Movie    VideoKind = "MOVIE"
+	TvSeries VideoKind = "TV_SERIES"
+)
+
 type ItemEventType string
 
 const (
-	Lent     ItemEventType = "LENT"
-	Returned ItemEventType = "RETURNED"
+	Lent          ItemEventType = "LENT"
+	Returned      ItemEventType = "RETURNED"
+	StatusChanged ItemEventType = "STATUS_CHANGED" // Event holds the new ItemStatus
+)
+
+// ItemStatus tracks reading (books) or watching (videos) progress
+type ItemStatus string
+
+const (
+	ToRead   ItemStatus = "TO_READ"
+	Reading  ItemStatus = "READING"
+	Finished ItemStatus = "FINISHED"
+	ToWatch  ItemStatus = "TO_WATCH"
+	Watching ItemStatus = "WATCHING"
+	Watched  ItemStatus = "WATCHED"
+)
+
+// IsValidFor reports whether the status applies to the given item type
+func (s ItemStatus) IsValidFor(t ItemType) bool {
+	switch t {
+	case ItemBook:
+		return s == ToRead || s == Reading || s == Finished
+	case ItemVideo:
+		return s == ToWatch || s == Watching || s == Watched
+	default:
+		return false
+	}
+}
+
+// ItemFormat is the edition of a physical or digital copy
+type ItemFormat string
+
+const (
+	Hardcover ItemFormat = "HARDCOVER"
+	Paperback ItemFormat = "PAPERBACK"
+	Ebook     ItemFormat = "EBOOK"
+	Dvd       ItemFormat = "DVD"
+	BluRay    ItemFormat = "BLU_RAY"
+	Uhd4K     ItemFormat = "UHD_4K"
+	Digital   ItemFormat = "DIGITAL"
 )
 
+// IsValidFor reports whether the format applies to the given item type
+func (f ItemFormat) IsValidFor(t ItemType) bool {
+	switch t {
+	case ItemBook:
+		return f == Hardcover || f == Paperback || f == Ebook
+	case ItemVideo:
+		return f == Dvd || f == BluRay || f == Uhd4K || f == Digital
+	case ItemMusic:
+		return f == Digital
+	default:
+		return false
+	}
+}
+
+// ItemCondition is the physical state of a copy
+type ItemCondition string
+
+const (
+	ConditionNew     ItemCondition = "NEW"
+	ConditionGood    ItemCondition = "GOOD"
+	ConditionWorn    ItemCondition = "WORN"
+	ConditionDamaged ItemCondition = "DAMAGED"
+)
+
+// ItemCopy is one physical copy of an item, lent and returned independently of the other copies
+type ItemCopy struct {
+	Id        string
+	Format    *ItemFormat
+	Condition *ItemCondition
+	LentTo    *string
+	LentToId  *string    // Contact the copy is lent to
+	DueDate   *time.Time // Return date of the current loan, if any
+}
+
 type ItemEvent struct {
-	Date  *time.Time
-	Type  ItemEventType
-	Event string
+	Date      *time.Time
+	Type      ItemEventType
+	Event     string
+	CopyId    *string    // Copy lent or returned, nil for items without copies
+	DueDate   *time.Time // Return date given when lending
+	ContactId *string    // Contact the item is lent to or returned from, nil for events predating contacts
+	// Condition assessed on return, nil when not assessed
+	Condition        *ItemCondition
+	ConditionNote    *string
+	ConditionPicture bool // A photo was taken on return
+	// User who changed their status, empty for other events and status changes predating it
+	UserId   string
+	UserName string
+}
+
+// ReturnAssessment is the condition of an item, or of one of its copies, checked when it is returned
+type ReturnAssessment struct {
+	Condition ItemCondition
+	Note      *string
+	Picture   []byte // Photo of the returned item, optional
+}
+
+// ReturnPictureId identifies the photo of a return by the date of its event
+func ReturnPictureId(date time.Time) string {
+	return date.UTC().Format("20060102T150405Z")
 }
 
 type ItemHistory struct {
@@ -44,12 +155,40 @@ type ItemHistory struct {
 	ContinuationToken string
 }
 
+// Actor is the user performing a change
+type Actor struct {
+	UserId   string
+	UserName string
+}
+
+// FieldChange is one field modified by an update, values are rendered as text and nil when unset
+type FieldChange struct {
+	Field string
+	From  *string
+	To    *string
+}
+
+// ChangeEntry records who modified which fields of an item, collection or library, and when
+type ChangeEntry struct {
+	Date     *time.Time
+	UserId   string
+	UserName string
+	Changes  []FieldChange
+}
+
+type ChangeLog struct {
+	Entries           []ChangeEntry
+	ContinuationToken string
+}
+
 type ResolvedBook struct {
 	Id         string
 	Authors    []string
 	Title      string
 	Summary    string
 	PictureUrl *string
+	SeriesName *string // Comics/manga series the book belongs to, when known
+	Volume     *int    // Volume number within the series
 	Source     string
 	Error      *string
 }
@@ -57,28 +196,155 @@ type ResolvedBook struct {
 // ResolvedVideo represents a video resolved from TMDB
 type ResolvedVideo struct {
 	Id          string
+	Kind        VideoKind
 	Title       string
 	Summary     string
 	PictureUrl  *string
-	Directors   []string
+	Directors   []string // Creators for TV series
 	Cast        []string
 	ReleaseYear int
-	Duration    int
+	Duration    int // Movie runtime, or episode runtime for TV series
 	TmdbId      string
+	// TV series-specific fields
+	SeasonCount  int
+	EpisodeCount int
+	Seasons      []VideoSeason
+	Source       string
+	Error        *string
+}
+
+// VideoSeason describes one season of a TV series
+type VideoSeason struct {
+	Number       int
+	Name         string
+	EpisodeCount int
+}
+
+// ResolvedMusic represents a music album resolved from its EAN/UPC barcode
+type ResolvedMusic struct {
+	Id          string
+	Title       string
+	Artists     []string
+	Tracklist   []string
+	Label       string
+	ReleaseYear int
+	PictureUrl  *string
 	Source      string
 	Error       *string
 }
 
-type Library struct {
+// ResolvedBoardGame represents a board game resolved from BoardGameGeek
+type ResolvedBoardGame struct {
 	Id          string
-	Name        string
-	Description string
-	OwnerName   string
-	OwnerId     string
-	TotalItems  int
-	UpdatedAt   *time.Time
-	SharedTo    []string
-	SharedFrom  *string
+	Title       string
+	Summary     string
+	PictureUrl  *string
+	Designers   []string
+	Publisher   string
+	MinPlayers  int
+	MaxPlayers  int
+	PlayTime    int // Minutes
+	ReleaseYear int
+	BggId       string
+	Source      string
+	Error       *string
+}
+
+// LibraryKind distinguishes libraries of owned items from wishlists of wanted items
+type LibraryKind string
+
+const (
+	OwnedLibrary    LibraryKind = "OWNED"
+	WishlistLibrary LibraryKind = "WISHLIST"
+)
+
+// ShareRole is what the users a library is shared to can do with it, each role including the previous ones
+type ShareRole string
+
+const (
+	ShareViewer ShareRole = "VIEWER" // Browses the library
+	ShareLender ShareRole = "LENDER" // Records lend and return events
+	ShareEditor ShareRole = "EDITOR" // Adds, edits and organizes items
+)
+
+var shareRoleRanks = map[ShareRole]int{ShareViewer: 1, ShareLender: 2, ShareEditor: 3}
+
+func (r ShareRole) IsValid() bool {
+	return shareRoleRanks[r] != 0
+}
+
+// Allows tells whether the role includes the permissions of the other one
+func (r ShareRole) Allows(other ShareRole) bool {
+	return r.IsValid() && shareRoleRanks[r] >= shareRoleRanks[other]
+}
+
+// CustomFieldType is the type of the values of a custom field
+type CustomFieldType string
+
+const (
+	CustomText   CustomFieldType = "TEXT"
+	CustomNumber CustomFieldType = "NUMBER"
+	CustomDate   CustomFieldType = "DATE"
+	CustomEnum   CustomFieldType = "ENUM"
+)
+
+// IsValid reports whether the type is a known custom field type
+func (t CustomFieldType) IsValid() bool {
+	return t == CustomText || t == CustomNumber || t == CustomDate || t == CustomEnum
+}
+
+// CustomField is a metadata field defined by a library owner, e.g. "Signed by author" or "Region code"
+type CustomField struct {
+	Name    string
+	Type    CustomFieldType
+	Options []string // Allowed values of an ENUM field
+}
+
+// Accepts reports whether the value is valid for the field. Numbers are decimal, dates are YYYY-MM-DD.
+func (f CustomField) Accepts(value string) bool {
+	switch f.Type {
+	case CustomText:
+		return true
+	case CustomNumber:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case CustomDate:
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case CustomEnum:
+		return slices.Contains(f.Options, value)
+	default:
+		return false
+	}
+}
+
+type Library struct {
+	Id           string
+	Name         string
+	Description  string
+	Kind         LibraryKind // Set on creation, cannot be changed
+	OwnerName    string
+	OwnerId      string
+	TotalItems   int
+	UpdatedAt    *time.Time
+	SharedTo     []string
+	ShareRoles   map[string]ShareRole // Role of the users the library is shared to, by user name
+	SharedFrom   *string
+	ShareRole    ShareRole     // Role of the requester on a library shared to them
+	DeletedAt    *time.Time    // Set while in the trash
+	ExpiresAt    *time.Time    // Purge date of a trashed library
+	CustomFields []CustomField // Schema of the custom values of the library items
+}
+
+// FindCustomField returns the index of the field in the library custom fields, matching the name case-insensitively,
+// -1 if not found
+func (l *Library) FindCustomField(name string) int {
+	for idx, f := range l.CustomFields {
+		if strings.EqualFold(f.Name, name) {
+			return idx
+		}
+	}
+	return -1
 }
 
 type ShareLibrary struct {
@@ -88,7 +354,18 @@ type ShareLibrary struct {
 	SharedToUserName   string
 	LibraryId          string
 	SharedToUserIndex  int
+	Role               ShareRole
 	UpdatedAt          *time.Time
+	// NewShareRoles are the roles of all the shares of the library, with this one
+	NewShareRoles map[string]ShareRole
+}
+
+// LibraryShare is a library shared to a user, with the role the user has on it
+type LibraryShare struct {
+	LibraryId string
+	OwnerId   string
+	UserId    string
+	Role      ShareRole
 }
 
 // UnshareLibrary supports removing multiple users at once
@@ -100,6 +377,7 @@ type UnshareLibrary struct {
 	LibraryId          string
 	// NewSharedToList is the filtered list after removing users
 	NewSharedToList []string
+	NewShareRoles   map[string]ShareRole
 }
 
 type LibraryContent struct {
@@ -122,31 +400,481 @@ type LibraryItem struct {
 	Type           ItemType
 	PictureUrl     *string
 	LentTo         *string
-	CollectionId   *string // FK to Collection entity
-	CollectionName *string // Denormalized for display and GSI1SK sorting
+	LentToId       *string    // Contact the item is lent to, LentTo holds the contact name
+	DueDate        *time.Time // Return date of the current loan of an item without copies, if any
+	CollectionId   *string    // FK to Collection entity
+	CollectionName *string    // Denormalized for display and GSI1SK sorting
 	Order          *int
+	Volume         *int    // Volume number within a series collection
+	SeriesName     *string // Series hint used to pick a series collection on creation (not persisted)
+	Status         *ItemStatus
+	StatusDate     *time.Time // Status and its date are the requester own (not persisted on the item)
+	RatingTotal    int        // Sum of the users ratings
+	RatingCount    int        // Number of users who rated the item
+	MyRating       *int       // Rating of the requester (not persisted on the item)
+	Tags           []string   // Tag names, matching the library tag index
+	Wanted         bool       // True for items of a wishlist library, which cannot be lent
+	LocationId     *string    // FK to Location entity
+	LocationPath   *string    // Denormalized for display and search, e.g. "Living room / Bookcase A / Shelf 2"
+	Format         *ItemFormat
+	Copies         []ItemCopy     // Set when several copies are owned, LentTo is then tracked per copy
+	Condition      *ItemCondition // Of an item without copies, assessed on its returns. Copies have their own
+	Acquisition    *Acquisition
+	DeletedAt      *time.Time        // Set while in the trash
+	ExpiresAt      *time.Time        // Purge date of a trashed item
+	CustomValues   map[string]string // Values of the library custom fields, keyed by field name
 	// Video-specific fields
 	Directors   []string
 	Cast        []string
 	ReleaseYear *int
 	Duration    *int
 	TmdbId      *string
+	VideoKind   *VideoKind // nil means movie (items created before TV series support)
+	Seasons     []int      // Seasons physically contained (TV series only)
+	SeasonCount *int       // Total seasons of the show (TV series only)
+	// Music-specific fields (ReleaseYear is shared with videos)
+	Artists   []string
+	Tracklist []string
+	Label     *string
+	Barcode   *string
+	// Board game-specific fields (ReleaseYear and Barcode are shared)
+	Designers  []string
+	Publisher  *string
+	MinPlayers *int
+	MaxPlayers *int
+	PlayTime   *int // Minutes
+	BggId      *string
 	// Collection-specific fields (only set when Type == ItemCollection)
-	Items     []*LibraryItem // Nested items within collection
-	ItemCount int            // Total items in collection (denormalized from Collection entity)
-	Partial   bool           // True if collection continues from previous page
+	Items        []*LibraryItem // Nested items within collection
+	ItemCount    int            // Total items in collection (denormalized from Collection entity)
+	Partial      bool           // True if collection continues from previous page
+	IsSeries     bool           // True if collection is a numbered series
+	TotalVolumes *int           // Expected number of volumes in the series
 }
 
-// Collection represents a grouping of items within a library
-type Collection struct {
-	Id          string
-	Name        string
-	Description string
-	ItemCount   int
+// Acquisition records how an item was bought
+type Acquisition struct {
+	Date     *time.Time
+	Price    *float64
+	Currency string // ISO 4217 code, mandatory with a price
+	Place    string // Where the item was bought
+}
+
+// FindCopy returns the index of the copy in the item copies, -1 if not found
+func (i *LibraryItem) FindCopy(copyId string) int {
+	for idx, c := range i.Copies {
+		if c.Id == copyId {
+			return idx
+		}
+	}
+	return -1
+}
+
+// IsLent reports whether the item, or any of its copies, is currently lent
+func (i *LibraryItem) IsLent() bool {
+	if i.LentTo != nil && len(*i.LentTo) != 0 {
+		return true
+	}
+	for _, c := range i.Copies {
+		if c.LentTo != nil && len(*c.LentTo) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NextDueDate returns the earliest due date of the current loans of the item and its copies, nil when none is set
+func (i *LibraryItem) NextDueDate() *time.Time {
+	var next *time.Time
+	for _, l := range i.CurrentLoans() {
+		if l.DueDate != nil && (next == nil || l.DueDate.Before(*next)) {
+			next = l.DueDate
+		}
+	}
+	return next
+}
+
+// OverdueLoans returns the current loans of the item and its copies due before the given date
+func (i *LibraryItem) OverdueLoans(before time.Time) []Loan {
+	overdue := []Loan{}
+	for _, l := range i.CurrentLoans() {
+		if l.DueDate != nil && l.DueDate.Before(before) {
+			overdue = append(overdue, l)
+		}
+	}
+	return overdue
+}
+
+// CurrentLoans returns the current loans of the item, one per lent copy for items having several copies
+func (i *LibraryItem) CurrentLoans() []Loan {
+	loans := []Loan{}
+	if i.LentTo != nil && len(*i.LentTo) != 0 {
+		loans = append(loans, Loan{Item: i, LentTo: *i.LentTo, DueDate: i.DueDate})
+	}
+	for _, c := range i.Copies {
+		if c.LentTo != nil && len(*c.LentTo) != 0 {
+			loans = append(loans, Loan{Item: i, CopyId: &c.Id, LentTo: *c.LentTo, DueDate: c.DueDate})
+		}
+	}
+	return loans
+}
+
+// Loan is a current loan of an item, or of one of its copies
+type Loan struct {
+	Item    *LibraryItem
+	CopyId  *string // Copy lent, nil for items without copies
+	LentTo  string
+	DueDate *time.Time
+}
+
+// LendingEvent is the lend or return of an item, or of one of its copies, recorded with the other events of a batch.
+// The item holds its lending state after the event.
+type LendingEvent struct {
+	Item   *LibraryItem
+	CopyId string        // Copy lent or returned, "" for items without copies
+	Type   ItemEventType // Lent or Returned
+	Person string        // Name of the contact
+	Loan   *ContactLoan  // Loan started or ended, nil for returns of loans predating contacts
+	// Condition assessed on return, the item (or copy) holds it as its current condition
+	Assessment *ReturnAssessment
+}
+
+// CollectionLending reports a lend or return applied to all the items of a collection
+type CollectionLending struct {
+	Loans   []Loan // Loans started or ended, one per item
+	Skipped []Loan // Loans of the items already lent when lending, items not returned (LentTo empty if not lent) when returning
+}
+
+// CalendarLoan is a current loan dated by the LENT event that started it
+type CalendarLoan struct {
+	Loan
+	LentAt *time.Time // nil when the loan predates the item history
+}
+
+// CalendarFeed gives calendar applications access to the loans of an owner, outside the authenticated API.
+// Only a hash of the token is stored, the token itself is returned once to the owner.
+type CalendarFeed struct {
+	OwnerId   string
+	TokenHash string
+	CreatedAt *time.Time
+}
+
+// Contact is a person the owner lends items to
+type Contact struct {
+	Id        string
+	OwnerId   string
+	Name      string
+	Email     *string
+	Phone     *string
+	UserId    *string // App user the contact is, linked when accepting a borrow request of the user
+	UpdatedAt *time.Time
+	Loans     []ContactLoan // Current and past loans, most recent first
+}
+
+// FindCurrentLoan returns the index of the current loan of an item (or of one of its copies), -1 if not found
+func (c *Contact) FindCurrentLoan(itemId string, copyId *string) int {
+	for idx, l := range c.Loans {
+		if l.ReturnedAt != nil || l.ItemId != itemId {
+			continue
+		}
+		if (l.CopyId == nil && copyId == nil) || (l.CopyId != nil && copyId != nil && *l.CopyId == *copyId) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Stats returns the lending statistics of the contact at the given date
+func (c *Contact) Stats(now time.Time) LendingStats {
+	stats := LendingStats{TotalLoans: len(c.Loans)}
+	daysLate := 0
+	for _, l := range c.Loans {
+		if l.ReturnCondition != nil && *l.ReturnCondition == ConditionDamaged {
+			stats.DamagedReturns++
+		}
+
+		if l.ReturnedAt == nil {
+			stats.CurrentLoans++
+			if l.DaysLate(now) > 0 {
+				stats.OverdueLoans++
+			}
+			continue
+		}
+
+		// Loans without due date cannot be late
+		if l.DueDate == nil {
+			continue
+		}
+		if days := l.DaysLate(now); days > 0 {
+			stats.ReturnedLate++
+			daysLate += days
+		} else {
+			stats.ReturnedOnTime++
+		}
+	}
+
+	if stats.ReturnedLate > 0 {
+		stats.AverageDaysLate = float64(daysLate) / float64(stats.ReturnedLate)
+	}
+	if returned := stats.ReturnedOnTime + stats.ReturnedLate; returned > 0 {
+		rate := float64(stats.ReturnedOnTime) / float64(returned)
+		stats.PunctualityRate = &rate
+	}
+
+	return stats
+}
+
+// ContactLoan is a loan of an item, or of one of its copies, to a contact. It is kept after the return
+// for the lending history, the library and title are the ones of the item when it was lent.
+type ContactLoan struct {
+	ContactId   string
 	OwnerId     string
+	OwnerName   string
+	BorrowerId  *string // App user the contact is, the current loans of app users are listed to them
 	LibraryId   string
-	CreatedAt   *time.Time
-	UpdatedAt   *time.Time
+	LibraryName string
+	ItemId      string
+	ItemType    ItemType
+	Title       string
+	CopyId      *string // Copy lent, nil for items without copies
+	LentAt      *time.Time
+	DueDate     *time.Time
+	ReturnedAt  *time.Time // nil while the loan is current
+	// Condition assessed on return, nil while current or when not assessed
+	ReturnCondition *ItemCondition
+	ReturnNote      *string
+}
+
+// DaysLate returns the number of days past the due date of the loan when it was returned,
+// or at the given date while it is current. Returns 0 when the loan has no due date or was not late.
+func (l *ContactLoan) DaysLate(now time.Time) int {
+	if l.DueDate == nil {
+		return 0
+	}
+
+	end := now.UTC()
+	if l.ReturnedAt != nil {
+		end = l.ReturnedAt.UTC()
+	}
+	day := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	days := int(day.Sub(*l.DueDate).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// LendingStats summarizes the loans of a contact. Punctuality only considers returned loans having a due date.
+type LendingStats struct {
+	TotalLoans      int
+	CurrentLoans    int
+	OverdueLoans    int
+	ReturnedOnTime  int
+	ReturnedLate    int
+	AverageDaysLate float64  // Over the late returns
+	PunctualityRate *float64 // Share of the returns on time, nil when no loan with a due date was returned
+	DamagedReturns  int      // Returns assessed as damaged
+}
+
+// BorrowRequestStatus is the state of a borrow request, only pending requests are answered
+type BorrowRequestStatus string
+
+const (
+	BorrowPending  BorrowRequestStatus = "PENDING"
+	BorrowAccepted BorrowRequestStatus = "ACCEPTED"
+	BorrowDeclined BorrowRequestStatus = "DECLINED"
+)
+
+// BorrowRequest is a request of a user to borrow an item of a library shared to them.
+// Accepting it lends the item to the contact of the owner linked to the requester.
+type BorrowRequest struct {
+	Id            string
+	OwnerId       string
+	OwnerName     string
+	LibraryId     string
+	LibraryName   string
+	ItemId        string
+	ItemType      ItemType
+	Title         string
+	CopyId        *string // Copy requested, the owner picks one when accepting otherwise
+	RequesterId   string
+	RequesterName string
+	Message       *string
+	DueDate       *time.Time // Return date proposed by the requester
+	Status        BorrowRequestStatus
+	CreatedAt     *time.Time
+	UpdatedAt     *time.Time
+}
+
+// Trash holds the deleted libraries and items of a user until they are purged
+type Trash struct {
+	Libraries []Library
+	Items     []*LibraryItem // Items deleted on their own, the items of a trashed library stay in the library
+}
+
+// ItemAction is the kind of an operation of a batch on the items of a library
+type ItemAction string
+
+const (
+	CreateItemAction       ItemAction = "CREATE"
+	UpdateItemAction       ItemAction = "UPDATE"
+	DeleteItemAction       ItemAction = "DELETE"
+	MoveToCollectionAction ItemAction = "MOVE_TO_COLLECTION"
+)
+
+// ItemOperation is one operation of a batch on the items of a library
+type ItemOperation struct {
+	Action       ItemAction
+	Item         *LibraryItem // Item to create or update, only the id is used by the other actions
+	CollectionId *string      // Target of MOVE_TO_COLLECTION, nil removes the item from its collection
+	FetchPicture bool         // UPDATE downloads the picture again
+}
+
+// ItemOperationResult is the outcome of one operation of a batch, Item is the created or updated item
+type ItemOperationResult struct {
+	Item *LibraryItem
+	Err  error
+}
+
+// Tag is an entry of the per-library tag index, items reference tags by name
+type Tag struct {
+	Id        string
+	Name      string
+	ItemCount int
+	OwnerId   string
+	LibraryId string
+	UpdatedAt *time.Time
+}
+
+// LocationKind is the level of a location in the room > bookcase > shelf hierarchy
+type LocationKind string
+
+const (
+	Room     LocationKind = "ROOM"
+	Bookcase LocationKind = "BOOKCASE"
+	Shelf    LocationKind = "SHELF"
+)
+
+// ParentKind returns the kind of location a location of this kind is nested into, empty for top-level rooms
+func (k LocationKind) ParentKind() LocationKind {
+	switch k {
+	case Bookcase:
+		return Room
+	case Shelf:
+		return Bookcase
+	default:
+		return ""
+	}
+}
+
+// Location is a physical place of a library where items are stored
+type Location struct {
+	Id        string
+	Name      string
+	Kind      LocationKind
+	ParentId  *string // nil for rooms
+	Path      string  // Names from the room down to this location, not persisted
+	OwnerId   string
+	LibraryId string
+	UpdatedAt *time.Time
+}
+
+// LocationPathSeparator separates the location names in a location path
+const LocationPathSeparator = " / "
+
+// BuildLocationPaths computes the full path ("Living room / Bookcase A / Shelf 2") of each location, by location id
+func BuildLocationPaths(locations []Location) map[string]string {
+	byId := map[string]Location{}
+	for _, l := range locations {
+		byId[l.Id] = l
+	}
+
+	paths := map[string]string{}
+	for _, l := range locations {
+		path := l.Name
+		current := l
+		// Depth is bounded by the hierarchy, guard against dangling or cyclic parents anyway
+		for depth := 0; current.ParentId != nil && depth < 3; depth++ {
+			parent, ok := byId[*current.ParentId]
+			if !ok {
+				break
+			}
+			path = parent.Name + LocationPathSeparator + path
+			current = parent
+		}
+		paths[l.Id] = path
+	}
+
+	return paths
+}
+
+// ItemFilter restricts a library listing, unset criteria are ignored
+type ItemFilter struct {
+	Status      *ItemStatus // Own status of the requester
+	UserId      string      // Requester, set with Status
+	Tag         *string
+	LocationIds []string // Items stored in any of these locations
+}
+
+// ValuationEntry totals the purchase prices of a group of items (library, collection or item type)
+type ValuationEntry struct {
+	Id              string // Library id, collection id or item type name
+	Name            string
+	LibraryId       string             // Set for collections
+	ItemCount       int                // Items of the group
+	ValuedItemCount int                // Items having a purchase price
+	Totals          map[string]float64 // Purchase prices by currency, rounded to cents
+}
+
+// Valuation is the inventory value report of the libraries accessible to a user
+type Valuation struct {
+	Libraries   []ValuationEntry
+	Collections []ValuationEntry
+	Types       []ValuationEntry
+}
+
+// ItemReview is the personal rating and notes of a user on an item (owned or shared)
+type ItemReview struct {
+	OwnerId   string // Item owner
+	LibraryId string
+	ItemId    string
+	UserId    string // Reviewer
+	Rating    *int
+	Notes     string
+	UpdatedAt *time.Time
+}
+
+// UserItemStatus is the reading/watching status of a user on an item (owned or shared), each user has their own
+type UserItemStatus struct {
+	OwnerId   string // Item owner
+	LibraryId string
+	ItemId    string
+	UserId    string
+	Status    ItemStatus
+	Date      *time.Time // Date of the status change
+}
+
+// Collection represents a grouping of items within a library
+type Collection struct {
+	Id           string
+	Name         string
+	Description  string
+	ItemCount    int
+	OwnerId      string
+	LibraryId    string
+	CreatedAt    *time.Time
+	UpdatedAt    *time.Time
+	IsSeries     bool // Numbered series (comics, manga): items carry a volume number
+	TotalVolumes *int // Expected number of volumes, nil if unknown or ongoing
+}
+
+// SeriesVolumes reports which volumes of a series collection are owned or missing
+type SeriesVolumes struct {
+	CollectionId   string
+	TotalVolumes   *int
+	OwnedVolumes   []int
+	MissingVolumes []int
 }
 
 // GroupedLibraryContent represents library content with collections nested with their items
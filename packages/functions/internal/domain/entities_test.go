@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestBuildLocationPaths(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+
+	// Children listed before their parents, as the locations are not sorted when queried
+	paths := BuildLocationPaths([]Location{
+		{Id: "shelf", Name: "Shelf 2", Kind: Shelf, ParentId: ptr("bookcase")},
+		{Id: "room", Name: "Living room", Kind: Room},
+		{Id: "bookcase", Name: "Bookcase A", Kind: Bookcase, ParentId: ptr("room")},
+		{Id: "orphan", Name: "Shelf 9", Kind: Shelf, ParentId: ptr("deleted")},
+	})
+
+	expected := map[string]string{
+		"room":     "Living room",
+		"bookcase": "Living room / Bookcase A",
+		"shelf":    "Living room / Bookcase A / Shelf 2",
+		// A dangling parent ends the path
+		"orphan": "Shelf 9",
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %d paths, got %v", len(expected), paths)
+	}
+	for id, path := range expected {
+		if paths[id] != path {
+			t.Errorf("path of %s: expected %q, got %q", id, path, paths[id])
+		}
+	}
+
+	if paths := BuildLocationPaths(nil); len(paths) != 0 {
+		t.Errorf("expected no paths, got %v", paths)
+	}
+}
+
+func TestCustomFieldAccepts(t *testing.T) {
+	region := CustomField{Name: "Region", Type: CustomEnum, Options: []string{"EU", "US"}}
+
+	fields := []struct {
+		field    CustomField
+		accepted []string
+		rejected []string
+	}{
+		{CustomField{Type: CustomText}, []string{"Signed by the author", ""}, nil},
+		{CustomField{Type: CustomNumber}, []string{"42", "-3.5"}, []string{"forty", ""}},
+		{CustomField{Type: CustomDate}, []string{"2024-02-29"}, []string{"2023-02-29", "2024-02-29T10:00:00Z"}},
+		// Options are case sensitive
+		{region, []string{"EU", "US"}, []string{"eu", "JP"}},
+		{CustomField{Type: CustomEnum}, nil, []string{"EU"}},
+		{CustomField{Type: "BOOLEAN"}, nil, []string{"true"}},
+	}
+
+	for _, f := range fields {
+		for _, v := range f.accepted {
+			if !f.field.Accepts(v) {
+				t.Errorf("%s field with options %v: expected %q to be accepted", f.field.Type, f.field.Options, v)
+			}
+		}
+		for _, v := range f.rejected {
+			if f.field.Accepts(v) {
+				t.Errorf("%s field with options %v: expected %q to be rejected", f.field.Type, f.field.Options, v)
+			}
+		}
+	}
+}
@@ -12,24 +12,47 @@ const (
 	TypeSharedLibrary EntityType = "SHARED_LIBRARY"
 	TypeBook          EntityType = "BOOK"
 	TypeVideo         EntityType = "VIDEO"
+	TypeMusic         EntityType = "MUSIC"
+	TypeBoardGame     EntityType = "BOARDGAME"
 	TypeEvent         EntityType = "EVENT"
 	TypeCollection    EntityType = "COLLECTION"
+	TypeReview        EntityType = "REVIEW"
+	TypeItemStatus    EntityType = "ITEM_STATUS"
+	TypeTag           EntityType = "TAG"
+	TypeLocation      EntityType = "LOCATION"
+	TypeChange        EntityType = "CHANGE"
+	TypeContact       EntityType = "CONTACT"
+	TypeContactLoan   EntityType = "CONTACT_LOAN"
+	TypeBorrowRequest EntityType = "BORROW_REQUEST"
+	TypeCalendarFeed  EntityType = "CALENDAR_FEED"
 )
 
 type Library struct {
-	PK          string     `dynamodbav:"PK"`     // owner#<owner id>
-	SK          string     `dynamodbav:"SK"`     // library#<library id>
-	GSI1PK      string     `dynamodbav:"GSI1PK"` // owner#<owner id>
-	GSI1SK      string     `dynamodbav:"GSI1SK"` // library#<library name>
-	Id          string     `dynamodbav:"LibraryId"`
-	Name        string     `dynamodbav:"LibraryName"`
-	Description string     `dynamodbav:"Description"`
-	OwnerName   string     `dynamodbav:"OwnerName"`
-	OwnerId     string     `dynamodbav:"OwnerId"`
-	TotalItems  int        `dynamodbav:"TotalItems"`
-	UpdatedAt   *time.Time `dynamodbav:"UpdatedAt"`
-	SharedTo    []string   `dynamodbav:"SharedTo"`
-	EntityType  EntityType `dynamodbav:"EntityType"`
+	PK           string            `dynamodbav:"PK"`     // owner#<owner id>
+	SK           string            `dynamodbav:"SK"`     // library#<library id>
+	GSI1PK       string            `dynamodbav:"GSI1PK"` // owner#<owner id>
+	GSI1SK       string            `dynamodbav:"GSI1SK"` // library#<library name>
+	Id           string            `dynamodbav:"LibraryId"`
+	Name         string            `dynamodbav:"LibraryName"`
+	Description  string            `dynamodbav:"Description"`
+	Kind         *string           `dynamodbav:"Kind,omitempty"` // WISHLIST for wishlists, not set for owned items libraries
+	OwnerName    string            `dynamodbav:"OwnerName"`
+	OwnerId      string            `dynamodbav:"OwnerId"`
+	TotalItems   int               `dynamodbav:"TotalItems"`
+	UpdatedAt    *time.Time        `dynamodbav:"UpdatedAt"`
+	SharedTo     []string          `dynamodbav:"SharedTo"`
+	ShareRoles   map[string]string `dynamodbav:"ShareRoles,omitempty"` // Role of each user of SharedTo, VIEWER when not set
+	EntityType   EntityType        `dynamodbav:"EntityType"`
+	DeletedAt    *time.Time        `dynamodbav:"DeletedAt,omitempty"` // Set while in the trash
+	ExpiresAt    int64             `dynamodbav:"ExpiresAt,omitempty"` // TTL (epoch seconds) purging a trashed library
+	CustomFields []CustomField     `dynamodbav:"CustomFields,omitempty"`
+}
+
+// CustomField is stored as an element of the Library CustomFields list
+type CustomField struct {
+	Name    string   `dynamodbav:"Name"`
+	Type    string   `dynamodbav:"Type"`
+	Options []string `dynamodbav:"Options,omitempty"`
 }
 
 func MakeLibraryPK(ownerId string) string {
@@ -68,17 +91,64 @@ type LibraryItem struct {
 	Isbn           string     `dynamodbav:"Isbn"`
 	Type           int        `dynamodbav:"Type"`
 	PictureUrl     *string    `dynamodbav:"PictureUrl,omitempty"`
-	LentTo         *string    `dynamodbav:"LentTo,omitempty"`
+	LentTo         *string    `dynamodbav:"LentTo,omitempty"`      // Contact name, renames are propagated by the consistency manager
+	LentToId       *string    `dynamodbav:"LentToId,omitempty"`    // FK to Contact entity, only written through item events
+	DueDate        *time.Time `dynamodbav:"DueDate,omitempty"`     // Only written through item events
+	NextDueDate    *time.Time `dynamodbav:"NextDueDate,omitempty"` // Earliest due date of the item and copies loans, for overdue queries
+	Condition      *string    `dynamodbav:"Condition,omitempty"`   // Assessed on return for items without copies, only written through item events
 	EntityType     EntityType `dynamodbav:"EntityType"`
 	CollectionId   *string    `dynamodbav:"CollectionId,omitempty"`   // FK to Collection entity
 	CollectionName *string    `dynamodbav:"CollectionName,omitempty"` // Denormalized for GSI1SK sorting
 	Order          *int       `dynamodbav:"Order,omitempty"`
+	Volume         *int       `dynamodbav:"Volume,omitempty"`       // Volume number within a series collection
+	RatingTotal    int        `dynamodbav:"RatingTotal,omitempty"`  // Sum of the users ratings, maintained with reviews
+	RatingCount    int        `dynamodbav:"RatingCount,omitempty"`  // Number of users who rated the item
+	Tags           []string   `dynamodbav:"Tags,omitempty"`         // Tag names, renames are propagated by the consistency manager
+	Wanted         bool       `dynamodbav:"Wanted,omitempty"`       // Item of a wishlist library (denormalized)
+	LocationId     *string    `dynamodbav:"LocationId,omitempty"`   // FK to Location entity
+	LocationPath   *string    `dynamodbav:"LocationPath,omitempty"` // Denormalized, renames are propagated by the consistency manager
+	Format         *string    `dynamodbav:"Format,omitempty"`
+	Copies         []ItemCopy `dynamodbav:"Copies,omitempty"` // LentTo of each copy is only written through item events
+	PurchaseDate   *time.Time `dynamodbav:"PurchaseDate,omitempty"`
+	PurchasePrice  *float64   `dynamodbav:"PurchasePrice,omitempty"`
+	Currency       *string    `dynamodbav:"Currency,omitempty"` // ISO 4217 code of the purchase price
+	PurchasePlace  *string    `dynamodbav:"PurchasePlace,omitempty"`
 	// Video-specific fields
 	Directors   []string `dynamodbav:"Directors,omitempty"`
 	Cast        []string `dynamodbav:"Cast,omitempty"`
 	ReleaseYear *int     `dynamodbav:"ReleaseYear,omitempty"`
 	Duration    *int     `dynamodbav:"Duration,omitempty"`
 	TmdbId      *string  `dynamodbav:"TmdbId,omitempty"`
+	VideoKind   *string  `dynamodbav:"VideoKind,omitempty"`
+	Seasons     []int    `dynamodbav:"Seasons,omitempty"`
+	SeasonCount *int     `dynamodbav:"SeasonCount,omitempty"`
+	// Music-specific fields (ReleaseYear is shared with videos)
+	Artists   []string `dynamodbav:"Artists,omitempty"`
+	Tracklist []string `dynamodbav:"Tracklist,omitempty"`
+	Label     *string  `dynamodbav:"Label,omitempty"`
+	Barcode   *string  `dynamodbav:"Barcode,omitempty"`
+	// Board game-specific fields (ReleaseYear and Barcode are shared)
+	Designers  []string `dynamodbav:"Designers,omitempty"`
+	Publisher  *string  `dynamodbav:"Publisher,omitempty"`
+	MinPlayers *int     `dynamodbav:"MinPlayers,omitempty"`
+	MaxPlayers *int     `dynamodbav:"MaxPlayers,omitempty"`
+	PlayTime   *int     `dynamodbav:"PlayTime,omitempty"`
+	BggId      *string  `dynamodbav:"BggId,omitempty"`
+	// Library custom fields
+	CustomValues map[string]string `dynamodbav:"CustomValues,omitempty"` // Keyed by custom field name
+	// Trash
+	DeletedAt *time.Time `dynamodbav:"DeletedAt,omitempty"` // Set while in the trash
+	ExpiresAt int64      `dynamodbav:"ExpiresAt,omitempty"` // TTL (epoch seconds) purging a trashed item
+}
+
+// ItemCopy is stored as an element of the LibraryItem Copies list
+type ItemCopy struct {
+	Id        string     `dynamodbav:"CopyId"`
+	Format    *string    `dynamodbav:"Format,omitempty"`
+	Condition *string    `dynamodbav:"Condition,omitempty"`
+	LentTo    *string    `dynamodbav:"LentTo,omitempty"`
+	LentToId  *string    `dynamodbav:"LentToId,omitempty"`
+	DueDate   *time.Time `dynamodbav:"DueDate,omitempty"`
 }
 
 func MakeLibraryItemPK(ownerId string) string {
@@ -118,6 +188,16 @@ func MakeLibraryItemGSI2SK(itemTitle string) string {
 	return fmt.Sprintf("item#%s", NormalizeForSort(itemTitle))
 }
 
+// MakeTrashGSI1PK returns GSI1PK of a trashed library or item: trashed entities leave their listing partitions
+// and are listed in the owner trash, most recently deleted last
+func MakeTrashGSI1PK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeTrashGSI1SK(deletedAt time.Time, id string) string {
+	return fmt.Sprintf("trash#%s#%s", deletedAt.Format("2006/01/02.15:04:05"), id)
+}
+
 // Collection represents a grouping of items within a library
 type Collection struct {
 	PK          string     `dynamodbav:"PK"`     // owner#<owner id>
@@ -133,6 +213,9 @@ type Collection struct {
 	CreatedAt   *time.Time `dynamodbav:"CreatedAt"`
 	UpdatedAt   *time.Time `dynamodbav:"UpdatedAt"`
 	EntityType  EntityType `dynamodbav:"EntityType"`
+	// Series-specific fields
+	IsSeries     bool `dynamodbav:"IsSeries,omitempty"`
+	TotalVolumes *int `dynamodbav:"TotalVolumes,omitempty"`
 }
 
 func MakeCollectionPK(ownerId string) string {
@@ -156,6 +239,104 @@ func MakeCollectionGSI1SK(collectionName string) string {
 	return fmt.Sprintf("item#%s", NormalizeForSort(collectionName))
 }
 
+// Tag is an entry of the per-library tag index
+type Tag struct {
+	PK         string     `dynamodbav:"PK"` // owner#<owner id>
+	SK         string     `dynamodbav:"SK"` // library#<library id>#tag#<tag id>
+	Id         string     `dynamodbav:"TagId"`
+	Name       string     `dynamodbav:"TagName"`
+	ItemCount  int        `dynamodbav:"ItemCount"`
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	LibraryId  string     `dynamodbav:"LibraryId"`
+	UpdatedAt  *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeTagPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeTagSK(libraryId string, tagId string) string {
+	return fmt.Sprintf("library#%s#tag#%s", libraryId, tagId)
+}
+
+// Location is a room, bookcase or shelf of a library
+type Location struct {
+	PK         string     `dynamodbav:"PK"` // owner#<owner id>
+	SK         string     `dynamodbav:"SK"` // library#<library id>#location#<location id>
+	Id         string     `dynamodbav:"LocationId"`
+	Name       string     `dynamodbav:"LocationName"`
+	Kind       string     `dynamodbav:"LocationKind"`
+	ParentId   *string    `dynamodbav:"ParentLocationId,omitempty"`
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	LibraryId  string     `dynamodbav:"LibraryId"`
+	UpdatedAt  *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeLocationPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeLocationSK(libraryId string, locationId string) string {
+	return fmt.Sprintf("library#%s#location#%s", libraryId, locationId)
+}
+
+// ItemReview holds the personal rating and notes of a user on an item.
+// Stored in the item owner partition so that reviews of shared library members sit next to the item.
+type ItemReview struct {
+	PK         string     `dynamodbav:"PK"` // owner#<item owner id>
+	SK         string     `dynamodbav:"SK"` // library#<library id>#item#<item id>#review#<user id>
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	LibraryId  string     `dynamodbav:"LibraryId"`
+	ItemId     string     `dynamodbav:"ItemId"`
+	UserId     string     `dynamodbav:"UserId"`
+	Rating     *int       `dynamodbav:"Rating,omitempty"`
+	Notes      string     `dynamodbav:"Notes"`
+	UpdatedAt  *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeItemReviewPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeItemReviewSK(libraryId string, itemId string, userId string) string {
+	return fmt.Sprintf("library#%s#item#%s#review#%s", libraryId, itemId, userId)
+}
+
+// ItemStatus holds the reading/watching status of a user on an item.
+// Stored in the item owner partition like reviews, GSI1 lists the statuses of a user in a library.
+type ItemStatus struct {
+	PK         string     `dynamodbav:"PK"`     // owner#<item owner id>
+	SK         string     `dynamodbav:"SK"`     // library#<library id>#item#<item id>#status#<user id>
+	GSI1PK     string     `dynamodbav:"GSI1PK"` // user#<user id>#library#<library id>#status
+	GSI1SK     string     `dynamodbav:"GSI1SK"` // status#<status>#item#<item id>
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	LibraryId  string     `dynamodbav:"LibraryId"`
+	ItemId     string     `dynamodbav:"ItemId"`
+	UserId     string     `dynamodbav:"UserId"`
+	Status     string     `dynamodbav:"Status"`
+	StatusDate *time.Time `dynamodbav:"StatusDate"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeItemStatusPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeItemStatusSK(libraryId string, itemId string, userId string) string {
+	return fmt.Sprintf("library#%s#item#%s#status#%s", libraryId, itemId, userId)
+}
+
+func MakeItemStatusGSI1PK(userId string, libraryId string) string {
+	return fmt.Sprintf("user#%s#library#%s#status", userId, libraryId)
+}
+
+func MakeItemStatusGSI1SK(status string, itemId string) string {
+	return fmt.Sprintf("status#%s#item#%s", status, itemId)
+}
+
 type SharedLibrary struct {
 	PK             string     `dynamodbav:"PK"` // owner#<owner id>
 	SK             string     `dynamodbav:"SK"` // shared-library#<library id>
@@ -163,6 +344,7 @@ type SharedLibrary struct {
 	SharedToId     string     `dynamodbav:"SharedToId"`     // user the library is shared to
 	SharedFromId   string     `dynamodbav:"SharedFromId"`   // original owner of the library
 	SharedFromName string     `dynamodbav:"SharedFromName"` // original library owner
+	Role           *string    `dynamodbav:"Role,omitempty"` // VIEWER when not set, for shares predating roles
 	UpdatedAt      *time.Time `dynamodbav:"UpdatedAt"`
 	EntityType     EntityType `dynamodbav:"EntityType"`
 }
@@ -176,14 +358,22 @@ func MakeSharedLibrarySK(libraryId string) string {
 }
 
 type ItemEvent struct {
-	PK         string     `dynamodbav:"PK"`     // owner#<owner id>
-	SK         string     `dynamodbav:"SK"`     // library#<library id>#item#<item id>#event#<event date>
-	GSI1PK     string     `dynamodbav:"GSI1PK"` // owner#<owner id>#library#<library id>#item#<item id>
-	GSI1SK     string     `dynamodbav:"GSI1SK"` // event#<event date>
-	Type       string     `dynamodbav:"Type"`
-	Event      string     `dynamodbav:"Event"`
-	UpdatedAt  *time.Time `dynamodbav:"UpdatedAt"`
-	EntityType EntityType `dynamodbav:"EntityType"`
+	PK               string     `dynamodbav:"PK"`     // owner#<owner id>
+	SK               string     `dynamodbav:"SK"`     // library#<library id>#item#<item id>#event#<event date>
+	GSI1PK           string     `dynamodbav:"GSI1PK"` // owner#<owner id>#library#<library id>#item#<item id>
+	GSI1SK           string     `dynamodbav:"GSI1SK"` // event#<event date>
+	Type             string     `dynamodbav:"Type"`
+	Event            string     `dynamodbav:"Event"`
+	CopyId           *string    `dynamodbav:"CopyId,omitempty"`  // Copy lent or returned
+	DueDate          *time.Time `dynamodbav:"DueDate,omitempty"` // Return date given when lending
+	ContactId        *string    `dynamodbav:"ContactId,omitempty"`
+	Condition        *string    `dynamodbav:"Condition,omitempty"` // Assessed on return
+	ConditionNote    *string    `dynamodbav:"ConditionNote,omitempty"`
+	ConditionPicture bool       `dynamodbav:"ConditionPicture,omitempty"` // Photo taken on return, stored with the item picture
+	UserId           string     `dynamodbav:"UserId,omitempty"`           // User who changed their status
+	UserName         string     `dynamodbav:"UserName,omitempty"`
+	UpdatedAt        *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType       EntityType `dynamodbav:"EntityType"`
 }
 
 func MakeItemEventPK(ownerId string) string {
@@ -200,3 +390,169 @@ func MakeItemEventGSI1PK(ownerId string, libraryId string, itemId string) string
 func MakeItemEventGSI1SK(date time.Time) string {
 	return fmt.Sprintf("event#%s", date.Format("2006/01/02.15:04:05"))
 }
+
+// Contact is a person the owner lends items to
+type Contact struct {
+	PK         string     `dynamodbav:"PK"` // owner#<owner id>
+	SK         string     `dynamodbav:"SK"` // contact#<contact id>
+	Id         string     `dynamodbav:"ContactId"`
+	Name       string     `dynamodbav:"ContactName"`
+	Email      *string    `dynamodbav:"Email,omitempty"`
+	Phone      *string    `dynamodbav:"Phone,omitempty"`
+	UserId     *string    `dynamodbav:"UserId,omitempty"` // App user the contact is
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	UpdatedAt  *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeContactPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeContactSK(contactId string) string {
+	return fmt.Sprintf("contact#%s", contactId)
+}
+
+// ContactLoan is a current or past loan to a contact.
+// Stored under the sort key of its contact, so that a contact is read with its loans in a single query.
+type ContactLoan struct {
+	PK              string     `dynamodbav:"PK"`               // owner#<owner id>
+	SK              string     `dynamodbav:"SK"`               // contact#<contact id>#loan#<lent date>#<item id>[#<copy id>]
+	GSI1PK          string     `dynamodbav:"GSI1PK,omitempty"` // borrower#<borrower id>, only set on the current loans to app users
+	GSI1SK          string     `dynamodbav:"GSI1SK,omitempty"` // loan#<lent date>#<item id>[#<copy id>]
+	ContactId       string     `dynamodbav:"ContactId"`
+	OwnerId         string     `dynamodbav:"OwnerId"`
+	OwnerName       string     `dynamodbav:"OwnerName"`
+	BorrowerId      *string    `dynamodbav:"BorrowerId,omitempty"` // App user the contact is
+	LibraryId       string     `dynamodbav:"LibraryId"`
+	LibraryName     string     `dynamodbav:"LibraryName"`
+	ItemId          string     `dynamodbav:"ItemId"`
+	Type            int        `dynamodbav:"Type"`
+	Title           string     `dynamodbav:"Title"`
+	CopyId          *string    `dynamodbav:"CopyId,omitempty"`
+	LentAt          *time.Time `dynamodbav:"LentAt"`
+	DueDate         *time.Time `dynamodbav:"DueDate,omitempty"`
+	ReturnedAt      *time.Time `dynamodbav:"ReturnedAt,omitempty"`
+	ReturnCondition *string    `dynamodbav:"ReturnCondition,omitempty"`
+	ReturnNote      *string    `dynamodbav:"ReturnNote,omitempty"`
+	EntityType      EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeContactLoanPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeContactLoanSK(contactId string, lentAt time.Time, itemId string, copyId *string) string {
+	sk := fmt.Sprintf("contact#%s#loan#%s#%s", contactId, lentAt.Format("2006/01/02.15:04:05"), itemId)
+	if copyId != nil {
+		sk = fmt.Sprintf("%s#%s", sk, *copyId)
+	}
+	return sk
+}
+
+func MakeContactLoanGSI1PK(borrowerId string) string {
+	return fmt.Sprintf("borrower#%s", borrowerId)
+}
+
+func MakeContactLoanGSI1SK(lentAt time.Time, itemId string, copyId *string) string {
+	sk := fmt.Sprintf("loan#%s#%s", lentAt.Format("2006/01/02.15:04:05"), itemId)
+	if copyId != nil {
+		sk = fmt.Sprintf("%s#%s", sk, *copyId)
+	}
+	return sk
+}
+
+// ChangeEntry records the fields modified by one update of an item, collection or library.
+// Entries are stored under the sort key of their entity, so they follow an item when it is moved or purged.
+type ChangeEntry struct {
+	PK         string        `dynamodbav:"PK"` // owner#<owner id>
+	SK         string        `dynamodbav:"SK"` // See MakeItemChangeSK, MakeCollectionChangeSK and MakeLibraryChangeSK
+	UserId     string        `dynamodbav:"UserId"`
+	UserName   string        `dynamodbav:"UserName"`
+	Changes    []FieldChange `dynamodbav:"Changes"`
+	UpdatedAt  *time.Time    `dynamodbav:"UpdatedAt"`
+	EntityType EntityType    `dynamodbav:"EntityType"`
+}
+
+type FieldChange struct {
+	Field string  `dynamodbav:"Field"`
+	From  *string `dynamodbav:"From,omitempty"`
+	To    *string `dynamodbav:"To,omitempty"`
+}
+
+func MakeChangePK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+// Changes dates have a millisecond precision, as several updates may happen within a second
+
+func MakeItemChangeSK(libraryId string, itemId string, date time.Time) string {
+	return fmt.Sprintf("library#%s#item#%s#change#%s", libraryId, itemId, date.Format("2006/01/02.15:04:05.000"))
+}
+
+func MakeCollectionChangeSK(libraryId string, collectionId string, date time.Time) string {
+	return fmt.Sprintf("library#%s#change#collection#%s#%s", libraryId, collectionId, date.Format("2006/01/02.15:04:05.000"))
+}
+
+func MakeLibraryChangeSK(libraryId string, date time.Time) string {
+	return fmt.Sprintf("library#%s#change#library#%s", libraryId, date.Format("2006/01/02.15:04:05.000"))
+}
+
+// BorrowRequest is stored with the library owner, and indexed by requester for the listing of the requests sent
+type BorrowRequest struct {
+	PK            string     `dynamodbav:"PK"`     // owner#<owner id>
+	SK            string     `dynamodbav:"SK"`     // borrow-request#<request id>
+	GSI1PK        string     `dynamodbav:"GSI1PK"` // requester#<requester id>
+	GSI1SK        string     `dynamodbav:"GSI1SK"` // borrow-request#<request id>
+	Id            string     `dynamodbav:"RequestId"`
+	OwnerId       string     `dynamodbav:"OwnerId"`
+	OwnerName     string     `dynamodbav:"OwnerName"`
+	LibraryId     string     `dynamodbav:"LibraryId"`
+	LibraryName   string     `dynamodbav:"LibraryName"`
+	ItemId        string     `dynamodbav:"ItemId"`
+	Type          int        `dynamodbav:"Type"`
+	Title         string     `dynamodbav:"Title"`
+	CopyId        *string    `dynamodbav:"CopyId,omitempty"`
+	RequesterId   string     `dynamodbav:"RequesterId"`
+	RequesterName string     `dynamodbav:"RequesterName"`
+	Message       *string    `dynamodbav:"Message,omitempty"`
+	DueDate       *time.Time `dynamodbav:"DueDate,omitempty"`
+	Status        string     `dynamodbav:"Status"`
+	CreatedAt     *time.Time `dynamodbav:"CreatedAt"`
+	UpdatedAt     *time.Time `dynamodbav:"UpdatedAt"`
+	EntityType    EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeBorrowRequestPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeBorrowRequestSK(requestId string) string {
+	return fmt.Sprintf("borrow-request#%s", requestId)
+}
+
+func MakeBorrowRequestGSI1PK(requesterId string) string {
+	return fmt.Sprintf("requester#%s", requesterId)
+}
+
+func MakeBorrowRequestGSI1SK(requestId string) string {
+	return fmt.Sprintf("borrow-request#%s", requestId)
+}
+
+// CalendarFeed is stored once per owner, the token is rotated by overwriting it
+type CalendarFeed struct {
+	PK         string     `dynamodbav:"PK"` // owner#<owner id>
+	SK         string     `dynamodbav:"SK"` // calendar-feed
+	OwnerId    string     `dynamodbav:"OwnerId"`
+	TokenHash  string     `dynamodbav:"TokenHash"` // SHA-256 of the token, hex encoded
+	CreatedAt  *time.Time `dynamodbav:"CreatedAt"`
+	EntityType EntityType `dynamodbav:"EntityType"`
+}
+
+func MakeCalendarFeedPK(ownerId string) string {
+	return fmt.Sprintf("owner#%s", ownerId)
+}
+
+func MakeCalendarFeedSK() string {
+	return "calendar-feed"
+}
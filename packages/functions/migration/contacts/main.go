@@ -0,0 +1,464 @@
+// Migration tool to turn the free-text LentTo values into contacts.
+// Borrower names differing only by case or surrounding spaces are merged into the same contact.
+//
+// Usage:
+//
+//	go run ./migration/contacts --table alexandria [--dry-run] [--verbose]
+//
+// The tool will:
+// 1. Scan all CONTACT, BOOK, VIDEO, MUSIC, BOARDGAME and LENT/RETURNED EVENT entities
+// 2. Create a contact per owner and borrower name, existing contacts with the same name are reused
+// 3. Rebuild the lending history of the contacts by pairing the LENT and RETURNED events of each item and copy
+// 4. Set LentToId on the lent items and copies
+// 5. Write the records in batch (25 per request), contacts first so that items and loans never reference a missing one
+//
+// Only the events written before contacts existed (without ContactId) are migrated, so the tool can be run again.
+// Events of deleted items are skipped.
+//
+// Use --dry-run to preview changes without writing to DynamoDB.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/domain"
+	"alexandria.isnan.eu/functions/internal/identifier"
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	tableName string
+	dryRun    bool
+	verbose   bool
+)
+
+// lendEvent is a LENT or RETURNED event of an item or copy
+type lendEvent struct {
+	ownerId string
+	itemId  string
+	copyId  *string
+	kind    domain.ItemEventType
+	name    string
+	dueDate *time.Time
+	date    time.Time
+}
+
+// owner contacts, indexed by folded name
+type ownerContacts map[string]*persistence.Contact
+
+func main() {
+	flag.StringVar(&tableName, "table", "", "DynamoDB table name (required)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Preview changes without writing to DynamoDB")
+	flag.BoolVar(&verbose, "verbose", false, "Show detailed output for each record")
+	flag.Parse()
+
+	if tableName == "" {
+		fmt.Fprintln(os.Stderr, "Error: --table is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading AWS config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE - No changes will be written ===")
+	}
+	fmt.Printf("Migrating table: %s\n\n", tableName)
+
+	// Track statistics
+	stats := struct {
+		items           int
+		events          int
+		contactsFound   int
+		contactsCreated int
+		loans           int
+		itemsUpdated    int
+		skipped         int
+		errors          int
+	}{}
+
+	contacts := map[string]ownerContacts{}
+	items := map[string]map[string]types.AttributeValue{} // Raw items, indexed by item id
+	events := []lendEvent{}
+
+	// Scan all items in the table
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName: aws.String(tableName),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning table: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, item := range page.Items {
+			switch getStringAttr(item, "EntityType") {
+			case "CONTACT":
+				record := persistence.Contact{}
+				if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+					fmt.Fprintf(os.Stderr, "Error unmarshalling contact %s: %v\n", getStringAttr(item, "SK"), err)
+					stats.errors++
+					continue
+				}
+				ownerContactsOf(contacts, record.OwnerId)[foldName(record.Name)] = &record
+				stats.contactsFound++
+
+			case "BOOK", "VIDEO", "MUSIC", "BOARDGAME":
+				stats.items++
+				items[getStringAttr(item, "ItemId")] = item
+
+			case "EVENT":
+				kind := domain.ItemEventType(getStringAttr(item, "Type"))
+				if kind != domain.Lent && kind != domain.Returned {
+					continue
+				}
+				if getStringPtrAttr(item, "ContactId") != nil {
+					// Written by the API, the loan already exists
+					continue
+				}
+
+				record := persistence.ItemEvent{}
+				if err := attributevalue.UnmarshalMap(item, &record); err != nil || record.UpdatedAt == nil {
+					fmt.Fprintf(os.Stderr, "Error unmarshalling event %s\n", record.SK)
+					stats.errors++
+					continue
+				}
+
+				// SK: library#<library id>#item#<item id>#event#<event date>
+				parts := strings.Split(record.SK, "#")
+				if len(parts) < 4 {
+					stats.skipped++
+					continue
+				}
+
+				stats.events++
+				events = append(events, lendEvent{
+					ownerId: strings.TrimPrefix(record.PK, "owner#"),
+					itemId:  parts[3],
+					copyId:  record.CopyId,
+					kind:    kind,
+					name:    strings.TrimSpace(record.Event),
+					dueDate: record.DueDate,
+					date:    *record.UpdatedAt,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(a, b int) bool {
+		return events[a].date.Before(events[b].date)
+	})
+
+	// Pair the events of each item and copy, a loan not returned before the next lending is closed by it
+	loans := []*persistence.ContactLoan{}
+	current := map[string]*persistence.ContactLoan{} // Indexed by item id and copy id
+	for _, e := range events {
+		record, ok := items[e.itemId]
+		if !ok {
+			stats.skipped++
+			if verbose {
+				fmt.Printf("  [SKIP] %s event of deleted item %s\n", e.kind, e.itemId)
+			}
+			continue
+		}
+
+		key := e.itemId
+		if e.copyId != nil {
+			key = fmt.Sprintf("%s#%s", e.itemId, *e.copyId)
+		}
+
+		if l, ok := current[key]; ok {
+			date := e.date
+			l.ReturnedAt = &date
+			delete(current, key)
+		}
+
+		if e.kind == domain.Returned || e.name == "" {
+			continue
+		}
+
+		contact := resolveContact(contacts, e.ownerId, e.name, &stats.contactsCreated)
+		date := e.date
+		loan := &persistence.ContactLoan{
+			PK:          persistence.MakeContactLoanPK(e.ownerId),
+			SK:          persistence.MakeContactLoanSK(contact.Id, date, e.itemId, e.copyId),
+			ContactId:   contact.Id,
+			OwnerId:     e.ownerId,
+			LibraryId:   getStringAttr(record, "LibraryId"),
+			LibraryName: getStringAttr(record, "LibraryName"),
+			ItemId:      e.itemId,
+			Title:       getStringAttr(record, "Title"),
+			CopyId:      e.copyId,
+			LentAt:      &date,
+			DueDate:     e.dueDate,
+			EntityType:  persistence.TypeContactLoan,
+		}
+		if t := getIntPtrAttr(record, "Type"); t != nil {
+			loan.Type = *t
+		}
+		loans = append(loans, loan)
+		current[key] = loan
+	}
+
+	var contactWrites, itemWrites, loanWrites []types.WriteRequest
+
+	// Link the lent items and copies to their contact
+	for itemId, item := range items {
+		ownerId := getStringAttr(item, "OwnerId")
+		title := getStringAttr(item, "Title")
+		updatedItem := make(map[string]types.AttributeValue)
+		for k, v := range item {
+			updatedItem[k] = v
+		}
+		changed := false
+
+		if name := getStringPtrAttr(item, "LentTo"); name != nil && getStringPtrAttr(item, "LentToId") == nil {
+			contact := resolveContact(contacts, ownerId, *name, &stats.contactsCreated)
+			updatedItem["LentTo"] = &types.AttributeValueMemberS{Value: contact.Name}
+			updatedItem["LentToId"] = &types.AttributeValueMemberS{Value: contact.Id}
+			closeStaleLoan(current, itemId, contact.Id)
+			changed = true
+			if verbose {
+				fmt.Printf("  [UPDATE] %s: lent to %s\n", title, contact.Name)
+			}
+		} else if name == nil {
+			// Not lent anymore, a remaining loan has no known return date
+			delete(current, itemId)
+		}
+
+		if copies, ok := item["Copies"].(*types.AttributeValueMemberL); ok {
+			updatedCopies := make([]types.AttributeValue, len(copies.Value))
+			for idx, c := range copies.Value {
+				updatedCopies[idx] = c
+				m, ok := c.(*types.AttributeValueMemberM)
+				if !ok {
+					continue
+				}
+				key := fmt.Sprintf("%s#%s", itemId, getStringAttr(m.Value, "CopyId"))
+
+				name := getStringPtrAttr(m.Value, "LentTo")
+				if name == nil {
+					delete(current, key)
+					continue
+				}
+				if getStringPtrAttr(m.Value, "LentToId") != nil {
+					continue
+				}
+
+				contact := resolveContact(contacts, ownerId, *name, &stats.contactsCreated)
+				updatedCopy := make(map[string]types.AttributeValue)
+				for k, v := range m.Value {
+					updatedCopy[k] = v
+				}
+				updatedCopy["LentTo"] = &types.AttributeValueMemberS{Value: contact.Name}
+				updatedCopy["LentToId"] = &types.AttributeValueMemberS{Value: contact.Id}
+				updatedCopies[idx] = &types.AttributeValueMemberM{Value: updatedCopy}
+				closeStaleLoan(current, key, contact.Id)
+				changed = true
+				if verbose {
+					fmt.Printf("  [UPDATE] %s: copy lent to %s\n", title, contact.Name)
+				}
+			}
+			updatedItem["Copies"] = &types.AttributeValueMemberL{Value: updatedCopies}
+		}
+
+		if !changed {
+			continue
+		}
+		stats.itemsUpdated++
+		itemWrites = append(itemWrites, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: updatedItem},
+		})
+	}
+
+	// Loans left open do not match the item state, they are dropped rather than reported as current
+	for _, l := range loans {
+		if l.ReturnedAt == nil {
+			if _, ok := current[loanKey(l)]; !ok {
+				stats.skipped++
+				if verbose {
+					fmt.Printf("  [SKIP] Loan of %s to %s, item not lent anymore\n", l.Title, l.ContactId)
+				}
+				continue
+			}
+		}
+
+		item, err := attributevalue.MarshalMap(l)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshalling loan %s: %v\n", l.SK, err)
+			stats.errors++
+			continue
+		}
+		stats.loans++
+		if verbose {
+			fmt.Printf("  [LOAN] %s lent on %s\n", l.Title, l.LentAt.Format(time.DateOnly))
+		}
+		loanWrites = append(loanWrites, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	// New contacts, existing ones are left untouched
+	for _, owner := range contacts {
+		for _, c := range owner {
+			if c.SK != "" {
+				continue
+			}
+			c.PK = persistence.MakeContactPK(c.OwnerId)
+			c.SK = persistence.MakeContactSK(c.Id)
+
+			item, err := attributevalue.MarshalMap(c)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling contact %s: %v\n", c.Name, err)
+				stats.errors++
+				continue
+			}
+			if verbose {
+				fmt.Printf("  [CONTACT] %s\n", c.Name)
+			}
+			contactWrites = append(contactWrites, types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: item},
+			})
+		}
+	}
+
+	writes := append(append(contactWrites, itemWrites...), loanWrites...)
+	if !dryRun {
+		for start := 0; start < len(writes); start += 25 {
+			end := min(start+25, len(writes))
+			if err := executeBatch(ctx, client, writes[start:end]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error executing batch: %v\n", err)
+				stats.errors++
+			}
+		}
+	}
+
+	// Print summary
+	fmt.Println()
+	fmt.Println("=== Migration Summary ===")
+	fmt.Printf("Items scanned:       %d\n", stats.items)
+	fmt.Printf("Events scanned:      %d\n", stats.events)
+	fmt.Printf("Contacts found:      %d\n", stats.contactsFound)
+	fmt.Printf("Contacts created:    %d\n", stats.contactsCreated)
+	fmt.Printf("Loans written:       %d\n", stats.loans)
+	fmt.Printf("Items updated:       %d\n", stats.itemsUpdated)
+	fmt.Printf("Records skipped:     %d\n", stats.skipped)
+	if stats.errors > 0 {
+		fmt.Printf("Errors:              %d\n", stats.errors)
+	}
+
+	if dryRun {
+		fmt.Println("\n=== DRY RUN - No changes were written ===")
+	}
+}
+
+func ownerContactsOf(contacts map[string]ownerContacts, ownerId string) ownerContacts {
+	if _, ok := contacts[ownerId]; !ok {
+		contacts[ownerId] = ownerContacts{}
+	}
+	return contacts[ownerId]
+}
+
+// resolveContact returns the contact of the owner with the given name, created (in memory) if none exists.
+// The name of a created contact is the first spelling met.
+func resolveContact(contacts map[string]ownerContacts, ownerId string, name string, created *int) *persistence.Contact {
+	owner := ownerContactsOf(contacts, ownerId)
+	name = strings.TrimSpace(name)
+	if c, ok := owner[foldName(name)]; ok {
+		return c
+	}
+
+	now := time.Now().UTC()
+	c := &persistence.Contact{
+		Id:         identifier.NewId(),
+		Name:       name,
+		OwnerId:    ownerId,
+		UpdatedAt:  &now,
+		EntityType: persistence.TypeContact,
+	}
+	owner[foldName(name)] = c
+	*created++
+	return c
+}
+
+// closeStaleLoan drops the current loan of an item or copy when it is not to the contact it is lent to
+func closeStaleLoan(current map[string]*persistence.ContactLoan, key string, contactId string) {
+	if l, ok := current[key]; ok && l.ContactId != contactId {
+		delete(current, key)
+	}
+}
+
+func loanKey(l *persistence.ContactLoan) string {
+	if l.CopyId != nil {
+		return fmt.Sprintf("%s#%s", l.ItemId, *l.CopyId)
+	}
+	return l.ItemId
+}
+
+func foldName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func executeBatch(ctx context.Context, client *dynamodb.Client, writes []types.WriteRequest) error {
+	requestItems := map[string][]types.WriteRequest{
+		tableName: writes,
+	}
+	for len(requestItems) > 0 {
+		output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return err
+		}
+		requestItems = output.UnprocessedItems
+	}
+	return nil
+}
+
+func getStringAttr(item map[string]types.AttributeValue, key string) string {
+	if v, ok := item[key]; ok {
+		if sv, ok := v.(*types.AttributeValueMemberS); ok {
+			return sv.Value
+		}
+	}
+	return ""
+}
+
+func getStringPtrAttr(item map[string]types.AttributeValue, key string) *string {
+	if v, ok := item[key]; ok {
+		if sv, ok := v.(*types.AttributeValueMemberS); ok {
+			return &sv.Value
+		}
+	}
+	return nil
+}
+
+func getIntPtrAttr(item map[string]types.AttributeValue, key string) *int {
+	if v, ok := item[key]; ok {
+		var val int
+		if err := attributevalue.Unmarshal(v, &val); err == nil {
+			return &val
+		}
+	}
+	return nil
+}
@@ -7,8 +7,8 @@
 //	go run main.go --table alexandria [--dry-run] [--verbose]
 //
 // The tool will:
-// 1. Scan all LIBRARY, COLLECTION, BOOK, and VIDEO entities
-// 2. Recompute GSI1SK (and GSI2SK for items) using normalized sort values
+// 1. Scan all LIBRARY, COLLECTION, BOOK, VIDEO, MUSIC and BOARDGAME entities
+// 2. Recompute GSI1SK (and GSI2SK for items) using normalized sort values, trashed entities excepted
 // 3. Update items in batch (25 per request)
 //
 // Use --dry-run to preview changes without writing to DynamoDB.
@@ -107,7 +107,7 @@ func main() {
 				newGSI1SK = persistence.MakeCollectionGSI1SK(name)
 				description = fmt.Sprintf("COLLECTION: %s", name)
 
-			case "BOOK", "VIDEO":
+			case "BOOK", "VIDEO", "MUSIC", "BOARDGAME":
 				stats.items++
 				title := getStringAttr(item, "Title")
 				collectionName := getStringPtrAttr(item, "CollectionName")
@@ -121,10 +121,19 @@ func main() {
 				continue
 			}
 
+			// Trashed libraries and items are listed through the trash keys of GSI1, which must be kept
+			if _, ok := item["DeletedAt"]; ok {
+				stats.skipped++
+				if verbose {
+					fmt.Printf("  [SKIP] %s (in the trash)\n", description)
+				}
+				continue
+			}
+
 			// Check if GSI1SK needs updating
 			gsi1Changed := currentGSI1SK != newGSI1SK
 			gsi2Changed := false
-			if entityType == "BOOK" || entityType == "VIDEO" {
+			if newGSI2SK != "" {
 				currentGSI2SK := getStringAttr(item, "GSI2SK")
 				gsi2Changed = currentGSI2SK != newGSI2SK
 			}
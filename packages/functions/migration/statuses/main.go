@@ -0,0 +1,197 @@
+// Migration tool to turn the reading/watching status stored on the items into statuses of their owner.
+// Statuses are kept per user since each member of a shared library tracks their own progress.
+//
+// Usage:
+//
+//	go run ./migration/statuses --table alexandria [--dry-run] [--verbose]
+//
+// The tool will:
+// 1. Scan all BOOK, VIDEO, MUSIC and BOARDGAME entities carrying a Status
+// 2. Create the ITEM_STATUS record of the item owner with this status and its date
+// 3. Remove Status and StatusDate from the item
+// 4. Write the records in batch (25 per request)
+//
+// Migrated items do not carry a status anymore, so the tool can be run again.
+//
+// Use --dry-run to preview changes without writing to DynamoDB.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	tableName string
+	dryRun    bool
+	verbose   bool
+)
+
+func main() {
+	flag.StringVar(&tableName, "table", "", "DynamoDB table name (required)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Preview changes without writing to DynamoDB")
+	flag.BoolVar(&verbose, "verbose", false, "Show detailed output for each record")
+	flag.Parse()
+
+	if tableName == "" {
+		fmt.Fprintln(os.Stderr, "Error: --table is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading AWS config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+
+	if dryRun {
+		fmt.Println("=== DRY RUN MODE - No changes will be written ===")
+	}
+	fmt.Printf("Migrating table: %s\n\n", tableName)
+
+	// Track statistics
+	stats := struct {
+		items    int
+		statuses int
+		errors   int
+	}{}
+
+	var writes []types.WriteRequest
+
+	// Scan all items in the table
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName: aws.String(tableName),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning table: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, item := range page.Items {
+			switch getStringAttr(item, "EntityType") {
+			case "BOOK", "VIDEO", "MUSIC", "BOARDGAME":
+			default:
+				continue
+			}
+			stats.items++
+
+			status := getStringAttr(item, "Status")
+			if status == "" {
+				continue
+			}
+
+			ownerId := getStringAttr(item, "OwnerId")
+			libraryId := getStringAttr(item, "LibraryId")
+			itemId := getStringAttr(item, "ItemId")
+			var date *time.Time
+			if v, ok := item["StatusDate"]; ok {
+				var d time.Time
+				if err := attributevalue.Unmarshal(v, &d); err == nil {
+					date = &d
+				}
+			}
+
+			record, err := attributevalue.MarshalMap(persistence.ItemStatus{
+				PK:         persistence.MakeItemStatusPK(ownerId),
+				SK:         persistence.MakeItemStatusSK(libraryId, itemId, ownerId),
+				GSI1PK:     persistence.MakeItemStatusGSI1PK(ownerId, libraryId),
+				GSI1SK:     persistence.MakeItemStatusGSI1SK(status, itemId),
+				OwnerId:    ownerId,
+				LibraryId:  libraryId,
+				ItemId:     itemId,
+				UserId:     ownerId,
+				Status:     status,
+				StatusDate: date,
+				EntityType: persistence.TypeItemStatus,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling status of %s: %v\n", itemId, err)
+				stats.errors++
+				continue
+			}
+
+			updatedItem := make(map[string]types.AttributeValue)
+			for k, v := range item {
+				updatedItem[k] = v
+			}
+			delete(updatedItem, "Status")
+			delete(updatedItem, "StatusDate")
+
+			stats.statuses++
+			if verbose {
+				fmt.Printf("  [STATUS] %s: %s\n", getStringAttr(item, "Title"), status)
+			}
+
+			writes = append(writes,
+				types.WriteRequest{PutRequest: &types.PutRequest{Item: record}},
+				types.WriteRequest{PutRequest: &types.PutRequest{Item: updatedItem}},
+			)
+		}
+	}
+
+	if !dryRun {
+		for start := 0; start < len(writes); start += 25 {
+			end := min(start+25, len(writes))
+			if err := executeBatch(ctx, client, writes[start:end]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error executing batch: %v\n", err)
+				stats.errors++
+			}
+		}
+	}
+
+	// Print summary
+	fmt.Println()
+	fmt.Println("=== Migration Summary ===")
+	fmt.Printf("Items scanned:       %d\n", stats.items)
+	fmt.Printf("Statuses migrated:   %d\n", stats.statuses)
+	if stats.errors > 0 {
+		fmt.Printf("Errors:              %d\n", stats.errors)
+	}
+
+	if dryRun {
+		fmt.Println("\n=== DRY RUN - No changes were written ===")
+	}
+}
+
+// executeBatch writes the records, retrying the ones left unprocessed by DynamoDB
+func executeBatch(ctx context.Context, client *dynamodb.Client, writes []types.WriteRequest) error {
+	requestItems := map[string][]types.WriteRequest{
+		tableName: writes,
+	}
+	for len(requestItems) > 0 {
+		output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return err
+		}
+		requestItems = output.UnprocessedItems
+	}
+	return nil
+}
+
+func getStringAttr(item map[string]types.AttributeValue, key string) string {
+	if v, ok := item[key]; ok {
+		if sv, ok := v.(*types.AttributeValueMemberS); ok {
+			return sv.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"alexandria.isnan.eu/functions/reminders/processing"
+	"github.com/Maev4l/platform/notifications"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/rs/zerolog/log"
+)
+
+var client *dynamodb.Client
+var snsClient *sns.Client
+
+var region string = os.Getenv("REGION")
+var topicArn string = os.Getenv("SNS_TOPIC_ARN")
+
+func init() {
+	config, _ := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	client = dynamodb.NewFromConfig(config)
+	snsClient = sns.NewFromConfig(config)
+}
+
+// handler runs daily, finds the overdue loans and sends a single reminder listing them
+func handler(event events.CloudWatchEvent) error {
+	loans, err := processing.FindOverdueLoans(client, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if len(loans) == 0 {
+		log.Info().Msg("No overdue loan to remind")
+		return nil
+	}
+
+	// Same channel as the sign up notifications of user-management
+	message := notifications.Message{
+		Source:            "alexandria-loan-reminders",
+		SourceDescription: "Alexandria overdue loans",
+		Target:            "slack",
+		Content:           processing.FormatReminder(loans),
+		// Titles and borrower names are literal text
+		Format: "plain",
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		log.Error().Msgf("Failed to marshal reminder: %s", err.Error())
+		return err
+	}
+
+	_, err = snsClient.Publish(context.TODO(), &sns.PublishInput{
+		TopicArn: aws.String(topicArn),
+		Message:  aws.String(string(payload)),
+	})
+	if err != nil {
+		log.Error().Msgf("Failed to publish reminder: %s", err.Error())
+		return err
+	}
+
+	log.Info().Int("loans", len(loans)).Msg("Overdue loans reminder sent")
+	return nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
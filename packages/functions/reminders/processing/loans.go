@@ -0,0 +1,133 @@
+package processing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"alexandria.isnan.eu/functions/internal/persistence"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+var tableName string = os.Getenv("DYNAMODB_TABLE_NAME")
+
+// reminderInterval is the number of days between two reminders of the same loan.
+// A loan is reminded the day after its due date, then every week until it is returned.
+const reminderInterval = 7
+
+// OverdueLoan is a loan of an item, or of one of its copies, past its due date
+type OverdueLoan struct {
+	OwnerId     string
+	OwnerName   string
+	LibraryName string
+	Title       string
+	LentTo      string
+	DueDate     time.Time
+	DaysOverdue int
+}
+
+// FindOverdueLoans returns the overdue loans of all the owners to be reminded on the given day.
+// Scans the sparse GSI3, which only holds the items lent with a due date.
+func FindOverdueLoans(client *dynamodb.Client, now time.Time) ([]OverdueLoan, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:        aws.String(tableName),
+		IndexName:        aws.String("GSI3"),
+		FilterExpression: aws.String("#NextDueDate < :today and attribute_not_exists(#DeletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#NextDueDate": "NextDueDate",
+			"#DeletedAt":   "DeletedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":today": &types.AttributeValueMemberS{
+				Value: today.Format(time.RFC3339Nano),
+			},
+		},
+	})
+
+	loans := []OverdueLoan{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			log.Error().Msgf("Failed to scan overdue items: %s", err.Error())
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			var record persistence.LibraryItem
+			if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+				log.Warn().Msgf("Failed to unmarshal library item: %s", err.Error())
+				continue
+			}
+
+			if record.LentTo != nil && record.DueDate != nil {
+				loans = appendOverdueLoan(loans, &record, *record.LentTo, *record.DueDate, today)
+			}
+			for _, c := range record.Copies {
+				if c.LentTo != nil && c.DueDate != nil {
+					loans = appendOverdueLoan(loans, &record, *c.LentTo, *c.DueDate, today)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(loans, func(a, b int) bool {
+		if loans[a].OwnerName != loans[b].OwnerName {
+			return loans[a].OwnerName < loans[b].OwnerName
+		}
+		if loans[a].OwnerId != loans[b].OwnerId {
+			return loans[a].OwnerId < loans[b].OwnerId
+		}
+		return loans[a].DueDate.Before(loans[b].DueDate)
+	})
+
+	return loans, nil
+}
+
+// appendOverdueLoan appends the loan when it is due for a reminder today
+func appendOverdueLoan(loans []OverdueLoan, record *persistence.LibraryItem, lentTo string, dueDate time.Time, today time.Time) []OverdueLoan {
+	if !dueDate.Before(today) {
+		return loans
+	}
+
+	daysOverdue := int(today.Sub(dueDate).Hours() / 24)
+	if daysOverdue%reminderInterval != 1 {
+		return loans
+	}
+
+	return append(loans, OverdueLoan{
+		OwnerId:     record.OwnerId,
+		OwnerName:   record.OwnerName,
+		LibraryName: record.LibraryName,
+		Title:       record.Title,
+		LentTo:      lentTo,
+		DueDate:     dueDate,
+		DaysOverdue: daysOverdue,
+	})
+}
+
+// FormatReminder renders the overdue loans as a plain text digest, grouped by owner
+func FormatReminder(loans []OverdueLoan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d overdue loan(s)", len(loans))
+
+	owner := ""
+	for _, l := range loans {
+		if l.OwnerId != owner {
+			owner = l.OwnerId
+			fmt.Fprintf(&b, "\n%s:", l.OwnerName)
+		}
+		fmt.Fprintf(&b, "\n- %s (%s) lent to %s, due %s, %d day(s) overdue",
+			l.Title, l.LibraryName, l.LentTo, l.DueDate.Format("2006-01-02"), l.DaysOverdue)
+	}
+
+	return b.String()
+}
@@ -137,6 +137,19 @@ resource "aws_cloudfront_distribution" "main" {
     }
   }
 
+  # API Gateway Origin (calendar feeds, no authorizer)
+  origin {
+    domain_name = replace(aws_apigatewayv2_api.feeds.api_endpoint, "https://", "")
+    origin_id   = "api-gateway-feeds"
+
+    custom_origin_config {
+      http_port              = 80
+      https_port             = 443
+      origin_protocol_policy = "https-only"
+      origin_ssl_protocols   = ["TLSv1.2"]
+    }
+  }
+
   # S3 Origin (thumbnails)
   origin {
     domain_name              = aws_s3_bucket.alexandria.bucket_regional_domain_name
@@ -186,6 +199,18 @@ resource "aws_cloudfront_distribution" "main" {
     origin_request_policy_id = data.aws_cloudfront_origin_request_policy.all_viewer_except_host.id
   }
 
+  # /feeds/* → calendar feeds, read-only. The token is in the query string, never cached.
+  ordered_cache_behavior {
+    path_pattern             = "/feeds/*"
+    allowed_methods          = ["GET", "HEAD", "OPTIONS"]
+    cached_methods           = ["GET", "HEAD"]
+    target_origin_id         = "api-gateway-feeds"
+    viewer_protocol_policy   = "redirect-to-https"
+    compress                 = true
+    cache_policy_id          = data.aws_cloudfront_cache_policy.caching_disabled.id
+    origin_request_policy_id = data.aws_cloudfront_origin_request_policy.all_viewer_except_host.id
+  }
+
   # /assets/* → S3 (frontend). Content-hashed bundles: immutable, long-lived cache.
   # Patterns don't overlap, so precedence order vs the behaviors above is irrelevant.
   ordered_cache_behavior {
@@ -1,5 +1,5 @@
 # DynamoDB table for Alexandria
-# Single-table design with 2 GSIs for flexible access patterns
+# Single-table design with 3 GSIs for flexible access patterns
 
 resource "aws_dynamodb_table" "alexandria" {
   name         = var.dynamodb_table_name
@@ -14,6 +14,12 @@ resource "aws_dynamodb_table" "alexandria" {
   stream_enabled   = true
   stream_view_type = "NEW_AND_OLD_IMAGES"
 
+  # Trashed libraries and items are purged once expired
+  ttl {
+    attribute_name = "ExpiresAt"
+    enabled        = true
+  }
+
   # Key attributes
   attribute {
     name = "PK"
@@ -47,6 +53,12 @@ resource "aws_dynamodb_table" "alexandria" {
     type = "S"
   }
 
+  # GSI3 attribute - earliest due date of the current loans of an item
+  attribute {
+    name = "NextDueDate"
+    type = "S"
+  }
+
   # GSI1: Query-focused access pattern
   global_secondary_index {
     name            = "GSI1"
@@ -79,4 +91,15 @@ resource "aws_dynamodb_table" "alexandria" {
     }
   }
 
+  # GSI3: Sparse index of the items lent with a due date, for the reminders
+  global_secondary_index {
+    name            = "GSI3"
+    projection_type = "ALL"
+
+    key_schema {
+      attribute_name = "NextDueDate"
+      key_type       = "HASH"
+    }
+  }
+
 }
@@ -4,6 +4,7 @@ locals {
   indexerFilename        = "../functions/index-items/dist/indexer.zip"
   consistencyMgrFilename = "../functions/consistency-manager/dist/consistency-mgr.zip"
   userManagementFilename = "../functions/user-management/dist/user-management.zip"
+  remindersFilename      = "../functions/reminders/dist/reminders.zip"
 
   globalIndexFilename     = "global-index.tar.gz"
   sharedLibrariesFilename = "shared-libraries.json"
@@ -46,6 +47,8 @@ module "api" {
     SHARE_LIBRARIES_FILE_NAME = local.sharedLibrariesFilename
     LEK_SECRET_KEY            = "alexandria.lastevaluatedkey.secret"
     TMDB_ACCESS_TOKEN         = "alexandria.tmdb.access.token"
+    BGG_ACCESS_TOKEN          = "alexandria.bgg.access.token"
+    GAMEUPC_API_KEY           = "alexandria.gameupc.api.key"
     SCRAPER_PROXY_API_KEY     = "alexandria.scraper.proxy.api.key"
     OCR_MODEL                 = var.ocr_model
 
@@ -85,11 +88,58 @@ module "api_trigger" {
         "ANY /api/v1/libraries/{proxy+}",
         "POST /api/v1/detections",
         "POST /api/v1/search",
+        "GET /api/v1/valuation",
+        "GET /api/v1/trash",
+        "GET /api/v1/loans/overdue",
+        "GET /api/v1/loans/borrowed",
+        "GET /api/v1/contacts",
+        "POST /api/v1/contacts",
+        "ANY /api/v1/contacts/{proxy+}",
+        "ANY /api/v1/borrow-requests/{proxy+}",
+        "GET /api/v1/calendar-feed",
+        "POST /api/v1/calendar-feed",
+        "DELETE /api/v1/calendar-feed",
       ]
     }
   }
 }
 
+# Calendar feeds (loans.ics) are polled by calendar applications, which cannot send a Cognito
+# token. They get their own HTTP API without authorizer, exposing only the /feeds routes: the
+# lambda checks the token carried by the feed address, /api/v1 stays behind the JWT authorizer.
+resource "aws_apigatewayv2_api" "feeds" {
+  name          = "alexandria-feeds-http-api"
+  protocol_type = "HTTP"
+}
+
+resource "aws_apigatewayv2_integration" "feeds" {
+  api_id                 = aws_apigatewayv2_api.feeds.id
+  integration_type       = "AWS_PROXY"
+  integration_uri        = module.api.invoke_arn
+  payload_format_version = "2.0"
+}
+
+resource "aws_apigatewayv2_route" "feeds" {
+  api_id             = aws_apigatewayv2_api.feeds.id
+  route_key          = "GET /feeds/{proxy+}"
+  target             = "integrations/${aws_apigatewayv2_integration.feeds.id}"
+  authorization_type = "NONE"
+}
+
+resource "aws_apigatewayv2_stage" "feeds" {
+  api_id      = aws_apigatewayv2_api.feeds.id
+  name        = "$default"
+  auto_deploy = true
+}
+
+resource "aws_lambda_permission" "feeds" {
+  statement_id  = "AllowExecutionFromFeedsApi"
+  action        = "lambda:InvokeFunction"
+  function_name = module.api.function_name
+  principal     = "apigateway.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.feeds.execution_arn}/*/*"
+}
+
 module "indexer" {
   source = "github.com/Maev4l/terraform-modules//modules/lambda-function?ref=v1.7.1"
 
@@ -129,35 +179,35 @@ module "indexer_trigger" {
   maximum_batching_window_in_seconds = 10
 
   filter_criteria = [
-    # INSERT: LIBRARY, BOOK, VIDEO, SHARED_LIBRARY
+    # INSERT: LIBRARY, BOOK, VIDEO, MUSIC, BOARDGAME, SHARED_LIBRARY
     {
       pattern = jsonencode({
         eventName = ["INSERT"]
         dynamodb = {
           NewImage = {
-            EntityType = { S = ["LIBRARY", "BOOK", "VIDEO", "SHARED_LIBRARY"] }
+            EntityType = { S = ["LIBRARY", "BOOK", "VIDEO", "MUSIC", "BOARDGAME", "SHARED_LIBRARY"] }
           }
         }
       })
     },
-    # MODIFY: BOOK, VIDEO only
+    # MODIFY: BOOK, VIDEO, MUSIC, BOARDGAME only
     {
       pattern = jsonencode({
         eventName = ["MODIFY"]
         dynamodb = {
           NewImage = {
-            EntityType = { S = ["BOOK", "VIDEO"] }
+            EntityType = { S = ["BOOK", "VIDEO", "MUSIC", "BOARDGAME"] }
           }
         }
       })
     },
-    # REMOVE: LIBRARY, BOOK, VIDEO, SHARED_LIBRARY
+    # REMOVE: LIBRARY, BOOK, VIDEO, MUSIC, BOARDGAME, SHARED_LIBRARY
     {
       pattern = jsonencode({
         eventName = ["REMOVE"]
         dynamodb = {
           OldImage = {
-            EntityType = { S = ["LIBRARY", "BOOK", "VIDEO", "SHARED_LIBRARY"] }
+            EntityType = { S = ["LIBRARY", "BOOK", "VIDEO", "MUSIC", "BOARDGAME", "SHARED_LIBRARY"] }
           }
         }
       })
@@ -184,6 +234,7 @@ module "consistency_manager" {
   environment_variables = {
     REGION              = var.region
     DYNAMODB_TABLE_NAME = aws_dynamodb_table.alexandria.name
+    S3_PICTURES_BUCKET  = aws_s3_bucket.alexandria.id
   }
 }
 
@@ -199,14 +250,15 @@ module "consistency_manager_trigger" {
   starting_position                  = "LATEST"
   maximum_batching_window_in_seconds = 10
 
-  # Filter: MODIFY events for LIBRARY and COLLECTION entities, REMOVE for COLLECTION
+  # Filter: MODIFY events for LIBRARY, COLLECTION, TAG, LOCATION and CONTACT entities, REMOVE for COLLECTION, TAG and LOCATION,
+  # and REMOVE for trashed LIBRARY, BOOK, VIDEO, MUSIC and BOARDGAME entities once expired
   filter_criteria = [
     {
       pattern = jsonencode({
         eventName = ["MODIFY"]
         dynamodb = {
           NewImage = {
-            EntityType = { S = ["LIBRARY", "COLLECTION"] }
+            EntityType = { S = ["LIBRARY", "COLLECTION", "TAG", "LOCATION", "CONTACT"] }
           }
         }
       })
@@ -216,7 +268,18 @@ module "consistency_manager_trigger" {
         eventName = ["REMOVE"]
         dynamodb = {
           OldImage = {
-            EntityType = { S = ["COLLECTION"] }
+            EntityType = { S = ["COLLECTION", "TAG", "LOCATION"] }
+          }
+        }
+      })
+    },
+    {
+      pattern = jsonencode({
+        eventName = ["REMOVE"]
+        dynamodb = {
+          OldImage = {
+            EntityType = { S = ["LIBRARY", "BOOK", "VIDEO", "MUSIC", "BOARDGAME"] }
+            DeletedAt  = { S = [{ exists = true }] }
           }
         }
       })
@@ -255,6 +318,47 @@ module "user_management_trigger" {
   user_pool_id = aws_cognito_user_pool.alexandria_user_pool.id
 }
 
+module "reminders" {
+  source = "github.com/Maev4l/terraform-modules//modules/lambda-function?ref=v1.7.1"
+
+  function_name = "alexandria-reminders"
+  architecture  = "arm64"
+  memory_size   = 128
+
+  additional_policy_arns = [aws_iam_policy.reminders.arn]
+
+  zip = {
+    filename = local.remindersFilename
+    runtime  = "provided.al2023"
+    handler  = "bootstrap"
+    hash     = filebase64sha256("../functions/reminders/bin/bootstrap")
+  }
+
+  environment_variables = {
+    REGION              = var.region
+    DYNAMODB_TABLE_NAME = aws_dynamodb_table.alexandria.name
+    SNS_TOPIC_ARN       = data.aws_sns_topic.alerting.arn
+  }
+}
+
+# Overdue loans reminders, every day at 8:00 UTC
+resource "aws_cloudwatch_event_rule" "reminders" {
+  name                = "alexandria-reminders"
+  schedule_expression = "cron(0 8 * * ? *)"
+}
+
+resource "aws_cloudwatch_event_target" "reminders" {
+  rule = aws_cloudwatch_event_rule.reminders.name
+  arn  = module.reminders.function_arn
+}
+
+resource "aws_lambda_permission" "reminders" {
+  statement_id  = "AllowExecutionFromEventBridge"
+  action        = "lambda:InvokeFunction"
+  function_name = module.reminders.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.reminders.arn
+}
 
 module "image_processor" {
   source = "github.com/Maev4l/terraform-modules//modules/lambda-function?ref=v1.7.1"
@@ -81,6 +81,8 @@ data "aws_iam_policy_document" "api" {
     resources = [
       "arn:aws:ssm:${local.region}:${local.account_id}:parameter/alexandria.lastevaluatedkey.secret",
       "arn:aws:ssm:${local.region}:${local.account_id}:parameter/alexandria.tmdb.access.token",
+      "arn:aws:ssm:${local.region}:${local.account_id}:parameter/alexandria.bgg.access.token",
+      "arn:aws:ssm:${local.region}:${local.account_id}:parameter/alexandria.gameupc.api.key",
       "arn:aws:ssm:${local.region}:${local.account_id}:parameter/alexandria.scraper.proxy.api.key"
     ]
   }
@@ -114,6 +116,19 @@ data "aws_iam_policy_document" "consistency_manager" {
       "${aws_dynamodb_table.alexandria.arn}/index/*",
     ]
   }
+
+  # Purge of the pictures of expired trashed libraries and items
+  statement {
+    effect = "Allow"
+    actions = [
+      "s3:ListBucket",
+      "s3:DeleteObject",
+    ]
+    resources = [
+      aws_s3_bucket.alexandria.arn,
+      "${aws_s3_bucket.alexandria.arn}/*",
+    ]
+  }
 }
 
 resource "aws_iam_policy" "consistency_manager" {
@@ -177,6 +192,28 @@ resource "aws_iam_policy" "user_management" {
   policy = data.aws_iam_policy_document.user_management.json
 }
 
+#
+# Reminders Policy (role managed by lambda-function module)
+#
+data "aws_iam_policy_document" "reminders" {
+  statement {
+    effect    = "Allow"
+    actions   = ["dynamodb:Scan"]
+    resources = ["${aws_dynamodb_table.alexandria.arn}/index/GSI3"]
+  }
+
+  statement {
+    effect    = "Allow"
+    actions   = ["sns:Publish"]
+    resources = [data.aws_sns_topic.alerting.arn]
+  }
+}
+
+resource "aws_iam_policy" "reminders" {
+  name   = "alexandria-reminders"
+  policy = data.aws_iam_policy_document.reminders.json
+}
+
 #
 # Images Processor Policy (role managed by lambda-function module)
 #